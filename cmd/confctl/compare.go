@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	config "github.com/omeyang/practices/pkg/conf"
+
+	"github.com/spf13/afero"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// runCompare 拉取另一个实例经过脱敏的生效配置 与本地配置文件比较
+func runCompare(args []string) error {
+	fs := flag.NewFlagSet("compare", flag.ContinueOnError)
+	url := fs.String("url", "", "admin endpoint serving the remote instance's effective config")
+	file := fs.String("file", "config.yaml", "local config file to compare against")
+	redacted := fs.String("redacted", "******", "placeholder the remote uses for redacted values, which are not compared")
+	timeout := fs.Duration("timeout", 10*time.Second, "timeout for fetching the remote config")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *url == "" {
+		return errors.New("-url is required")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	loader, err := config.NewFileLoader[map[string]any](afero.NewOsFs(), *file, zap.NewNop())
+	if err != nil {
+		return err
+	}
+	local, err := loader.LoadConfig(ctx)
+	if err != nil {
+		return err
+	}
+	remote, err := fetchRemote(ctx, http.DefaultClient, *url)
+	if err != nil {
+		return err
+	}
+
+	changes, err := compareConfigs(*local, remote, *redacted)
+	if err != nil {
+		return err
+	}
+	printChanges(os.Stdout, *file, *url, changes)
+	if len(changes) > 0 {
+		return fmt.Errorf("%d keys differ", len(changes))
+	}
+	return nil
+}
+
+// fetchRemote 拉取远程实例的生效配置 按 Content-Type 选择 JSON 或 YAML 解码
+func fetchRemote(ctx context.Context, client *http.Client, url string) (map[string]any, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json, application/yaml")
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch %s: unexpected status %s", url, resp.Status)
+	}
+
+	body, err := config.DecodeContentEncoding(resp.Body, resp.Header.Get("Content-Encoding"))
+	if err != nil {
+		return nil, err
+	}
+	tree := map[string]any{}
+	if strings.Contains(resp.Header.Get("Content-Type"), "yaml") {
+		err = yaml.NewDecoder(body).Decode(&tree)
+	} else {
+		err = json.NewDecoder(body).Decode(&tree)
+	}
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("decode %s: %w", url, err)
+	}
+	return tree, nil
+}
+
+// compareConfigs 比较本地与远程配置 Old 为本地取值 New 为远程取值 远程脱敏的取值不参与比较
+func compareConfigs(local, remote map[string]any, redacted string) ([]config.Change, error) {
+	changes, err := config.Diff(&local, &remote)
+	if err != nil {
+		return nil, err
+	}
+	compared := changes[:0]
+	for _, change := range changes {
+		if s, ok := change.New.(string); ok && redacted != "" && s == redacted {
+			continue
+		}
+		compared = append(compared, change)
+	}
+	return compared, nil
+}
+
+// printChanges 逐行输出差异 - 表示仅本地存在 + 表示仅远程存在 ~ 表示取值不同
+func printChanges(w io.Writer, localName, remoteName string, changes []config.Change) {
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", localName, remoteName)
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "no differences")
+		return
+	}
+	for _, change := range changes {
+		switch {
+		case change.New == nil:
+			fmt.Fprintf(w, "- %s: %s\n", change.Path, formatValue(change.Old))
+		case change.Old == nil:
+			fmt.Fprintf(w, "+ %s: %s\n", change.Path, formatValue(change.New))
+		default:
+			fmt.Fprintf(w, "~ %s: %s -> %s\n", change.Path, formatValue(change.Old), formatValue(change.New))
+		}
+	}
+}
+
+// formatValue 以 JSON 形式输出取值 便于区分字符串与数值
+func formatValue(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
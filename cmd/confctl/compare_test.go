@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCompare 测试拉取远程配置并与本地配置比较 脱敏的取值不参与比较
+func TestCompare(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"prometheusCfg": {"enable": true, "port": 9091, "token": "******"}, "region": "cn"}`))
+	}))
+	defer server.Close()
+
+	remote, err := fetchRemote(context.Background(), server.Client(), server.URL)
+	assert.NoError(t, err)
+
+	local := map[string]any{
+		"prometheusCfg": map[string]any{"enable": true, "port": 9090, "token": "s3cret", "address": "0.0.0.0"},
+	}
+	changes, err := compareConfigs(local, remote, "******")
+	assert.NoError(t, err)
+
+	var out bytes.Buffer
+	printChanges(&out, "config.yaml", server.URL, changes)
+	assert.Equal(t, "--- config.yaml\n+++ "+server.URL+"\n"+
+		"- prometheusCfg.address: \"0.0.0.0\"\n"+
+		"~ prometheusCfg.port: 9090 -> 9091\n"+
+		"+ region: \"cn\"\n", out.String())
+
+	changes, err = compareConfigs(remote, remote, "******")
+	assert.NoError(t, err)
+	out.Reset()
+	printChanges(&out, "a", "b", changes)
+	assert.Contains(t, out.String(), "no differences")
+}
+
+// TestFetchRemote_Status 测试远程返回非 200 状态时报错
+func TestFetchRemote_Status(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	_, err := fetchRemote(context.Background(), server.Client(), server.URL)
+	assert.ErrorContains(t, err, "403")
+}
@@ -0,0 +1,451 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	config "github.com/omeyang/practices/pkg/conf"
+	"github.com/omeyang/practices/pkg/conf/awskms"
+	"github.com/omeyang/practices/pkg/conf/gcpkms"
+
+	gcpkmsapi "cloud.google.com/go/kms/apiv1"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+)
+
+// 取值的两种内联格式 与 config.DecryptValues 使用同一数据密钥
+const (
+	formatBracketed = "ENC" // ENC[...]
+	formatPrefix    = "enc" // enc:...
+)
+
+// kmsProviders 按 -kms 的取值创建 KMS 客户端 返回的 close 释放客户端
+var kmsProviders = map[string]func(ctx context.Context, keyName string) (config.KMSClient, func() error, error){
+	"aws": newAWSKMS,
+	"gcp": newGCPKMS,
+}
+
+// cryptFlags encrypt 与 decrypt 共用的参数
+type cryptFlags struct {
+	keyFile *string
+	keyEnv  *string
+	kms     *string
+	kmsKey  *string
+	file    *string
+	write   *bool
+}
+
+// bind 注册共用参数
+func (f *cryptFlags) bind(fs *flag.FlagSet) {
+	f.keyFile = fs.String("key-file", "", "file containing the base64 encoded data key, or the KMS-encrypted data key with -kms")
+	f.keyEnv = fs.String("key-env", "CONFIG_DATA_KEY", "environment variable holding the base64 encoded data key, used when -key-file is not set")
+	f.kms = fs.String("kms", "", "decrypt the data key with a KMS: aws or gcp")
+	f.kmsKey = fs.String("kms-key", "", "KMS key that encrypted the data key: an AWS key id or ARN (optional), or a GCP CryptoKey resource name")
+	f.file = fs.String("file", "", "YAML config file to process instead of a single value")
+	f.write = fs.Bool("w", false, "write the result back to -file instead of stdout")
+}
+
+// dataKey 读取数据密钥 设置 -kms 时读取的是 KMS 加密的数据密钥 经 KMS 解密后使用
+func (f *cryptFlags) dataKey(ctx context.Context) ([]byte, error) {
+	if *f.kms == "" {
+		return loadDataKey(*f.keyFile, *f.keyEnv)
+	}
+	newClient, ok := kmsProviders[*f.kms]
+	if !ok {
+		return nil, fmt.Errorf("unsupported -kms %q", *f.kms)
+	}
+	wrapped, err := readKey(*f.keyFile, *f.keyEnv)
+	if err != nil {
+		return nil, err
+	}
+	client, closeClient, err := newClient(ctx, *f.kmsKey)
+	if err != nil {
+		return nil, err
+	}
+	defer closeClient()
+	key, err := config.NewKMSKeySource(client, wrapped).DataKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return checkKeyLength(key)
+}
+
+// keySource 首次使用时读取数据密钥 只解密 SOPS 或 age 文件时不要求提供数据密钥
+type keySource struct {
+	flags *cryptFlags
+	key   []byte
+}
+
+// DataKey 实现 config.KeySource
+func (k *keySource) DataKey(ctx context.Context) ([]byte, error) {
+	if k.key != nil {
+		return k.key, nil
+	}
+	key, err := k.flags.dataKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	k.key = key
+	return key, nil
+}
+
+// newAWSKMS 使用默认的凭证链与区域创建 AWS KMS 客户端
+func newAWSKMS(ctx context.Context, keyName string) (config.KMSClient, func() error, error) {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return awskms.New(kms.NewFromConfig(cfg), keyName, nil), func() error { return nil }, nil
+}
+
+// newGCPKMS 使用应用默认凭证创建 Cloud KMS 客户端
+func newGCPKMS(ctx context.Context, keyName string) (config.KMSClient, func() error, error) {
+	if keyName == "" {
+		return nil, nil, errors.New("-kms-key is required with -kms gcp")
+	}
+	client, err := gcpkmsapi.NewKeyManagementClient(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return gcpkms.New(client, keyName), client.Close, nil
+}
+
+// runEncrypt 加密单个取值 或加密 YAML 配置文件中指定路径的取值
+// 默认输出 ENC[...] 格式 -format enc 输出 enc: 前缀格式
+func runEncrypt(args []string) error {
+	fs := flag.NewFlagSet("encrypt", flag.ContinueOnError)
+	var flags cryptFlags
+	flags.bind(fs)
+	paths := fs.String("paths", "", "comma separated dotted paths to encrypt in -file, e.g. db.password,tokens.0")
+	format := fs.String("format", formatBracketed, "inline format of encrypted values: ENC for ENC[...] or enc for enc:...")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	encrypt, err := valueEncrypter(*format)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	key, err := flags.dataKey(ctx)
+	if err != nil {
+		return err
+	}
+
+	if *flags.file == "" {
+		value, err := readValue(fs.Args(), os.Stdin)
+		if err != nil {
+			return err
+		}
+		encrypted, err := encrypt(key, value)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stdout, encrypted)
+		return nil
+	}
+	if *paths == "" {
+		return errors.New("-paths is required with -file")
+	}
+	return processFile(*flags.file, *flags.write, func(data []byte) ([]byte, error) {
+		return encryptPaths(data, key, strings.Split(*paths, ","), encrypt)
+	})
+}
+
+// valueEncrypter 返回 -format 对应的加密函数
+func valueEncrypter(format string) (func(key []byte, plaintext string) (string, error), error) {
+	switch format {
+	case formatBracketed:
+		return config.EncryptValueBracketed, nil
+	case formatPrefix:
+		return config.EncryptValue, nil
+	default:
+		return nil, fmt.Errorf("unsupported -format %q, want %s or %s", format, formatBracketed, formatPrefix)
+	}
+}
+
+// runDecrypt 解密单个取值 或解密 YAML 配置文件
+// 整个文件由 SOPS age 或 EncryptFile 加密时先解密整个文件 再解密其中全部 ENC[...] 与 enc: 格式的取值
+func runDecrypt(args []string) error {
+	fs := flag.NewFlagSet("decrypt", flag.ContinueOnError)
+	var flags cryptFlags
+	flags.bind(fs)
+	sops := fs.String("sops", "sops", "sops binary used to decrypt SOPS-encrypted files")
+	ageIdentity := fs.String("age-identity", "", "age identity file used to decrypt age-encrypted files")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ctx := context.Background()
+	keys := &keySource{flags: &flags}
+
+	if *flags.file == "" {
+		value, err := readValue(fs.Args(), os.Stdin)
+		if err != nil {
+			return err
+		}
+		key, err := keys.DataKey(ctx)
+		if err != nil {
+			return err
+		}
+		plain, err := config.DecryptValue(key, value)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stdout, plain)
+		return nil
+	}
+	files := fileDecrypter(keys, *flags.file, *sops, *ageIdentity)
+	return processFile(*flags.file, *flags.write, func(data []byte) ([]byte, error) {
+		plain, err := files.Decrypt(ctx, data)
+		if err != nil {
+			return nil, err
+		}
+		return decryptAll(ctx, plain, keys)
+	})
+}
+
+// fileDecrypter 与库中 DecryptingParser 使用相同的格式识别 SOPS 与 age 文件交给对应的命令行工具解密
+func fileDecrypter(keys config.KeySource, path, sops, ageIdentity string) *config.DecryptingParser[any] {
+	files := &config.DecryptingParser[any]{
+		Keys: keys,
+		SOPS: config.CommandDecryptor(sops, "--decrypt", "--input-type", sopsType(path), "--output-type", sopsType(path), "/dev/stdin"),
+	}
+	if ageIdentity != "" {
+		files.Age = config.CommandDecryptor("age", "--decrypt", "-i", ageIdentity)
+	}
+	return files
+}
+
+// sopsType 按扩展名返回 sops 的文件类型
+func sopsType(path string) string {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return "json"
+	}
+	return "yaml"
+}
+
+// loadDataKey 从文件或环境变量读取 base64 编码的数据密钥
+func loadDataKey(keyFile, keyEnv string) ([]byte, error) {
+	key, err := readKey(keyFile, keyEnv)
+	if err != nil {
+		return nil, err
+	}
+	return checkKeyLength(key)
+}
+
+// readKey 从文件或环境变量读取 base64 编码的密钥
+func readKey(keyFile, keyEnv string) ([]byte, error) {
+	var encoded string
+	switch {
+	case keyFile != "":
+		data, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		encoded = string(data)
+	case keyEnv != "" && os.Getenv(keyEnv) != "":
+		encoded = os.Getenv(keyEnv)
+	default:
+		return nil, fmt.Errorf("no data key: set -key-file or $%s", keyEnv)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("data key is not valid base64: %w", err)
+	}
+	return key, nil
+}
+
+// checkKeyLength 检查数据密钥的长度
+func checkKeyLength(key []byte) ([]byte, error) {
+	switch len(key) {
+	case 16, 24, 32:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("data key must be 16, 24 or 32 bytes, got %d", len(key))
+	}
+}
+
+// readValue 取命令行参数中的取值 未提供时读取标准输入 去掉末尾换行
+func readValue(args []string, in io.Reader) (string, error) {
+	if len(args) > 1 {
+		return "", errors.New("expected a single value")
+	}
+	if len(args) == 1 {
+		return args[0], nil
+	}
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// processFile 变换配置文件内容 输出到标准输出或原子写回原文件
+func processFile(path string, write bool, transform func([]byte) ([]byte, error)) error {
+	fs := afero.NewOsFs()
+	data, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return err
+	}
+	out, err := transform(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if !write {
+		_, err = os.Stdout.Write(out)
+		return err
+	}
+	info, err := fs.Stat(path)
+	if err != nil {
+		return err
+	}
+	return config.WriteAtomic(fs, path, out, info.Mode().Perm())
+}
+
+// encryptPaths 加密 YAML 文档中指定路径的标量 已加密的取值保持不变 注释与顺序保持不变
+func encryptPaths(data []byte, key []byte, paths []string, encrypt func(key []byte, plaintext string) (string, error)) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		node, err := findNode(&doc, strings.Split(path, "."))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if node.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("%s: only scalar values can be encrypted", path)
+		}
+		if config.IsEncrypted(node.Value) {
+			continue
+		}
+		encrypted, err := encrypt(key, node.Value)
+		if err != nil {
+			return nil, err
+		}
+		node.Value, node.Tag, node.Style = encrypted, "!!str", 0
+	}
+	return encodeYAML(&doc)
+}
+
+// decryptAll 解密 YAML 文档中全部 ENC[...] 与 enc: 格式的标量 解密后的取值按内容重新推断类型
+// 文档中没有加密取值时不读取数据密钥
+func decryptAll(ctx context.Context, data []byte, keys config.KeySource) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	var errs config.MultiError
+	var keyErr error
+	walkScalars(&doc, "", func(node *yaml.Node, path string) {
+		if keyErr != nil || !config.IsEncrypted(node.Value) {
+			return
+		}
+		key, err := keys.DataKey(ctx)
+		if err != nil {
+			keyErr = err
+			return
+		}
+		plain, err := config.DecryptValue(key, node.Value)
+		if err != nil {
+			errs.Append(&config.FieldError{Path: path, Line: node.Line, Column: node.Column, Message: err.Error(), Err: err})
+			return
+		}
+		node.Value, node.Tag, node.Style = plain, "", 0
+	})
+	if keyErr != nil {
+		return nil, keyErr
+	}
+	if err := errs.ErrorOrNil(); err != nil {
+		return nil, err
+	}
+	return encodeYAML(&doc)
+}
+
+// findNode 按路径查找节点 数字路径段用于序列下标
+func findNode(node *yaml.Node, keys []string) (*yaml.Node, error) {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	for _, key := range keys {
+		switch node.Kind {
+		case yaml.MappingNode:
+			var next *yaml.Node
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == key {
+					next = node.Content[i+1]
+					break
+				}
+			}
+			if next == nil {
+				return nil, errors.New("not found")
+			}
+			node = next
+		case yaml.SequenceNode:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(node.Content) {
+				return nil, errors.New("not found")
+			}
+			node = node.Content[index]
+		default:
+			return nil, errors.New("not found")
+		}
+	}
+	return node, nil
+}
+
+// walkScalars 深度优先遍历全部标量节点 path 为点分路径
+func walkScalars(node *yaml.Node, path string, fn func(node *yaml.Node, path string)) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			walkScalars(child, path, fn)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			walkScalars(node.Content[i+1], joinPath(path, node.Content[i].Value), fn)
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			walkScalars(child, joinPath(path, strconv.Itoa(i)), fn)
+		}
+	case yaml.ScalarNode:
+		fn(node, path)
+	}
+}
+
+// joinPath 拼接点分路径
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// encodeYAML 以两个空格缩进输出 YAML 文档
+func encodeYAML(doc *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
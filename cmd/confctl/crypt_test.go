@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	config "github.com/omeyang/practices/pkg/conf"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestEncryptDecryptFile 测试加密指定路径后解密还原 注释保持不变
+func TestEncryptDecryptFile(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	content := "# database settings\ndb:\n  host: localhost\n  password: s3cret # rotated monthly\ntokens:\n  - abc\n  - def\n"
+
+	encrypted, err := encryptPaths([]byte(content), key, []string{"db.password"}, config.EncryptValueBracketed)
+	assert.NoError(t, err)
+	encrypted, err = encryptPaths(encrypted, key, []string{" tokens.1"}, config.EncryptValue)
+	assert.NoError(t, err)
+	out := string(encrypted)
+	assert.NotContains(t, out, "s3cret")
+	assert.NotContains(t, out, "def")
+	assert.Contains(t, out, "host: localhost")
+	assert.Contains(t, out, "# rotated monthly")
+	assert.Contains(t, out, "password: ENC[")
+	assert.Contains(t, out, "- "+config.EncryptedPrefix)
+
+	// 重复加密时已加密的取值保持不变
+	again, err := encryptPaths(encrypted, key, []string{"db.password", "tokens.1"}, config.EncryptValueBracketed)
+	assert.NoError(t, err)
+	assert.Equal(t, out, string(again))
+
+	ctx := context.Background()
+	decrypted, err := decryptAll(ctx, encrypted, config.StaticKeySource(key))
+	assert.NoError(t, err)
+	assert.Contains(t, string(decrypted), "password: s3cret # rotated monthly")
+	assert.Contains(t, string(decrypted), "- def")
+	assert.Contains(t, string(decrypted), "# database settings")
+
+	_, err = decryptAll(ctx, encrypted, config.StaticKeySource(bytes.Repeat([]byte{8}, 32)))
+	assert.ErrorIs(t, err, config.ErrDecrypt)
+	assert.ErrorContains(t, err, "db.password (line 4")
+
+	_, err = encryptPaths([]byte(content), key, []string{"db.user"}, config.EncryptValue)
+	assert.ErrorContains(t, err, "db.user: not found")
+	_, err = encryptPaths([]byte(content), key, []string{"db"}, config.EncryptValue)
+	assert.ErrorContains(t, err, "only scalar values")
+	_, err = valueEncrypter("sops")
+	assert.ErrorContains(t, err, "unsupported -format")
+}
+
+// TestDecryptFile 测试先解密整个文件再解密其中的取值 没有加密取值时不需要数据密钥
+func TestDecryptFile(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	password, err := config.EncryptValueBracketed(key, "s3cret")
+	assert.NoError(t, err)
+	envelope, err := config.EncryptFile(key, []byte("db:\n  password: "+password+"\n"))
+	assert.NoError(t, err)
+
+	t.Setenv("TEST_CONFCTL_KEY", base64.StdEncoding.EncodeToString(key))
+	keyEnv, kmsName, kmsKey := "TEST_CONFCTL_KEY", "", ""
+	keys := &keySource{flags: &cryptFlags{keyFile: new(string), keyEnv: &keyEnv, kms: &kmsName, kmsKey: &kmsKey}}
+	ctx := context.Background()
+	plain, err := fileDecrypter(keys, "config.yaml", "sops", "").Decrypt(ctx, envelope)
+	assert.NoError(t, err)
+	out, err := decryptAll(ctx, plain, keys)
+	assert.NoError(t, err)
+	assert.Equal(t, "db:\n  password: s3cret\n", string(out))
+
+	unset := "TEST_CONFCTL_UNSET"
+	noKey := &keySource{flags: &cryptFlags{keyFile: new(string), keyEnv: &unset, kms: &kmsName, kmsKey: &kmsKey}}
+	out, err = decryptAll(ctx, []byte("db:\n  user: app\n"), noKey)
+	assert.NoError(t, err)
+	assert.Equal(t, "db:\n  user: app\n", string(out))
+	_, err = decryptAll(ctx, plain, noKey)
+	assert.ErrorContains(t, err, "$TEST_CONFCTL_UNSET")
+
+	// age 文件未提供身份文件时无法解密
+	_, err = fileDecrypter(keys, "config.yaml", "sops", "").Decrypt(ctx, []byte("age-encryption.org/v1\n"))
+	assert.ErrorIs(t, err, config.ErrUnsupportedEncryption)
+	assert.Equal(t, "json", sopsType("/etc/app/config.JSON"))
+}
+
+// fakeKMS 测试用 KMS 客户端
+type fakeKMS struct {
+	keyName string
+}
+
+func (f *fakeKMS) Decrypt(_ context.Context, ciphertext []byte) ([]byte, error) {
+	if string(ciphertext) != "wrapped:"+f.keyName {
+		return nil, errors.New("unknown key")
+	}
+	return bytes.Repeat([]byte{3}, 32), nil
+}
+
+// TestDataKeyKMS 测试通过 KMS 解密信封加密的数据密钥
+func TestDataKeyKMS(t *testing.T) {
+	closed := false
+	kmsProviders["fake"] = func(_ context.Context, keyName string) (config.KMSClient, func() error, error) {
+		return &fakeKMS{keyName: keyName}, func() error { closed = true; return nil }, nil
+	}
+	t.Cleanup(func() { delete(kmsProviders, "fake") })
+
+	path := filepath.Join(t.TempDir(), "data.key.enc")
+	assert.NoError(t, os.WriteFile(path, []byte(base64.StdEncoding.EncodeToString([]byte("wrapped:app"))), 0o600))
+	keyEnv, kmsName, kmsKey := "", "fake", "app"
+	flags := &cryptFlags{keyFile: &path, keyEnv: &keyEnv, kms: &kmsName, kmsKey: &kmsKey}
+	key, err := flags.dataKey(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, bytes.Repeat([]byte{3}, 32), key)
+	assert.True(t, closed)
+
+	kmsKey = "other"
+	_, err = flags.dataKey(context.Background())
+	assert.ErrorContains(t, err, "kms decrypt data key")
+	kmsName = "vault"
+	_, err = flags.dataKey(context.Background())
+	assert.ErrorContains(t, err, `unsupported -kms "vault"`)
+}
+
+// TestLoadDataKey 测试从文件与环境变量读取数据密钥
+func TestLoadDataKey(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 16)
+	path := filepath.Join(t.TempDir(), "data.key")
+	assert.NoError(t, os.WriteFile(path, []byte(base64.StdEncoding.EncodeToString(key)+"\n"), 0o600))
+
+	got, err := loadDataKey(path, "")
+	assert.NoError(t, err)
+	assert.Equal(t, key, got)
+
+	t.Setenv("TEST_CONFCTL_KEY", base64.StdEncoding.EncodeToString(key[:10]))
+	_, err = loadDataKey("", "TEST_CONFCTL_KEY")
+	assert.ErrorContains(t, err, "got 10")
+
+	_, err = loadDataKey("", "TEST_CONFCTL_UNSET")
+	assert.ErrorContains(t, err, "$TEST_CONFCTL_UNSET")
+
+	value, err := readValue(nil, strings.NewReader("s3cret\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cret", value)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+
+	"github.com/omeyang/practices/pkg/conf/docgen"
+)
+
+// runDoc 根据配置结构体源码生成参考文档
+func runDoc(args []string) error {
+	fs := flag.NewFlagSet("doc", flag.ContinueOnError)
+	dir := fs.String("dir", "internal/entity", "directory containing the config structs")
+	typeName := fs.String("type", "AppConf", "root config struct name")
+	format := fs.String("format", string(docgen.FormatMarkdown), "output format: markdown or html")
+	title := fs.String("title", "Configuration Reference", "document title")
+	out := fs.String("out", "", "output file (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	fields, err := docgen.Parse(*dir, *typeName)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	return docgen.Render(w, *title, fields, docgen.Format(*format))
+}
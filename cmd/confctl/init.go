@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/omeyang/practices/internal/entity"
+	config "github.com/omeyang/practices/pkg/conf"
+	"github.com/omeyang/practices/pkg/conf/docgen"
+
+	"github.com/spf13/afero"
+	"go.uber.org/zap"
+)
+
+// runInit 交互式询问关键配置项 生成并校验初始配置文件
+func runInit(args []string) error {
+	fs := flag.NewFlagSet("init", flag.ContinueOnError)
+	dir := fs.String("dir", "internal/entity", "directory containing the config structs")
+	typeName := fs.String("type", "AppConf", "root config struct name")
+	out := fs.String("out", "config.yaml", "output file, format is taken from the extension")
+	force := fs.Bool("force", false, "overwrite an existing output file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(*out); err == nil && !*force {
+		return fmt.Errorf("%s already exists, use -force to overwrite", *out)
+	}
+
+	fields, err := docgen.Parse(*dir, *typeName)
+	if err != nil {
+		return err
+	}
+	if fields, err = askFields(os.Stdin, os.Stdout, fields); err != nil {
+		return err
+	}
+
+	format := strings.TrimPrefix(filepath.Ext(*out), ".")
+	data, err := renderStarter[entity.AppConf](fields, format)
+	if err != nil {
+		return err
+	}
+	if err := config.WriteAtomic(afero.NewOsFs(), *out, data, 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "wrote %s\n", *out)
+	return nil
+}
+
+// askFields 依次询问每个标量配置项 回车保留默认值 非法输入会重新询问
+func askFields(in io.Reader, out io.Writer, fields []docgen.Field) ([]docgen.Field, error) {
+	scanner := bufio.NewScanner(in)
+	answered := make([]docgen.Field, len(fields))
+	copy(answered, fields)
+
+	for i, f := range answered {
+		if !promptable(f) {
+			continue
+		}
+		for {
+			prompt := f.Key
+			if f.Description != "" {
+				prompt += " (" + f.Description + ")"
+			}
+			fmt.Fprintf(out, "%s [%s]: ", prompt, f.Default)
+
+			if !scanner.Scan() {
+				if err := scanner.Err(); err != nil {
+					return nil, err
+				}
+				return nil, errors.New("input closed before all values were provided")
+			}
+			value := strings.TrimSpace(scanner.Text())
+			if value == "" {
+				break
+			}
+			if err := checkValue(f.Type, value); err != nil {
+				fmt.Fprintf(out, "  invalid value: %v\n", err)
+				continue
+			}
+			answered[i].Default = value
+			break
+		}
+	}
+	return answered, nil
+}
+
+// promptable 仅询问结构体中直接声明的基础类型字段
+func promptable(f docgen.Field) bool {
+	if strings.Contains(f.Key, "[]") || strings.Contains(f.Key, "<key>") {
+		return false
+	}
+	switch f.Type {
+	case "string", "bool", "int", "int32", "int64", "uint", "uint32", "uint64", "float32", "float64":
+		return true
+	default:
+		return false
+	}
+}
+
+// checkValue 按字段类型检查输入值
+func checkValue(typ, value string) error {
+	var err error
+	switch typ {
+	case "bool":
+		_, err = strconv.ParseBool(value)
+	case "int", "int32", "int64":
+		_, err = strconv.ParseInt(value, 10, 64)
+	case "uint", "uint32", "uint64":
+		_, err = strconv.ParseUint(value, 10, 64)
+	case "float32", "float64":
+		_, err = strconv.ParseFloat(value, 64)
+	case "time.Time":
+		_, err = time.Parse(time.RFC3339, value)
+	}
+	return err
+}
+
+// renderStarter 生成初始配置 并按管理器加载时的流程解析 填充默认值与校验 确认其可以被正确加载
+func renderStarter[T any](fields []docgen.Field, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := docgen.Sample(&buf, fields, format); err != nil {
+		return nil, err
+	}
+
+	fs := afero.NewMemMapFs()
+	name := "starter." + format
+	if err := afero.WriteFile(fs, name, buf.Bytes(), 0o644); err != nil {
+		return nil, err
+	}
+	file, err := fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	parser, err := config.NewParser[T](format, zap.NewNop())
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := parser.Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("generated config is invalid: %w", err)
+	}
+	if err := config.ApplyDefaults(parsed); err != nil {
+		return nil, fmt.Errorf("generated config is invalid: %w", err)
+	}
+	cm := config.NewConfigManager[T](nil, nil, zap.NewNop(), config.RetryPolicy{})
+	if err := cm.Validate(parsed); err != nil {
+		return nil, fmt.Errorf("generated config is invalid: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/omeyang/practices/internal/entity"
+	config "github.com/omeyang/practices/pkg/conf"
+	"github.com/omeyang/practices/pkg/conf/docgen"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAskFields 测试交互式询问 回车保留默认值 非法输入重新询问
+func TestAskFields(t *testing.T) {
+	fields := []docgen.Field{
+		{Key: "prometheusCfg", Type: "PrometheusConf"},
+		{Key: "prometheusCfg.enable", Type: "bool"},
+		{Key: "prometheusCfg.port", Type: "int", Default: "9090"},
+		{Key: "prometheusCfg.address", Type: "string"},
+	}
+	in := strings.NewReader("yes\ntrue\n\n0.0.0.0\n")
+
+	var out bytes.Buffer
+	answered, err := askFields(in, &out, fields)
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "invalid value")
+	assert.Equal(t, "true", answered[1].Default)
+	assert.Equal(t, "9090", answered[2].Default)
+	assert.Equal(t, "0.0.0.0", answered[3].Default)
+
+	_, err = askFields(strings.NewReader(""), &out, fields)
+	assert.Error(t, err)
+
+	data, err := renderStarter[entity.AppConf](answered, "yaml")
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "port: 9090")
+}
+
+// starterConf 带校验规则的初始配置
+type starterConf struct {
+	Port int    `yaml:"port" validate:"range=1-65535"`
+	Mode string `yaml:"mode" validate:"oneof=dev|prod"`
+}
+
+// TestRenderStarterValidates 测试未通过校验规则的回答不会生成配置文件
+func TestRenderStarterValidates(t *testing.T) {
+	fields := []docgen.Field{
+		{Key: "port", Type: "int", Default: "70000"},
+		{Key: "mode", Type: "string", Default: "test"},
+	}
+	_, err := renderStarter[starterConf](fields, "yaml")
+	var validationErr *config.ValidationError
+	if assert.ErrorAs(t, err, &validationErr) {
+		assert.Len(t, validationErr.Errors, 2)
+	}
+
+	fields[0].Default, fields[1].Default = "8080", "prod"
+	data, err := renderStarter[starterConf](fields, "yaml")
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "port: 8080")
+}
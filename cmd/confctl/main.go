@@ -0,0 +1,57 @@
+// confctl 配置管理命令行工具
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// command 子命令
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+// commands 全部子命令
+var commands = []command{
+	{name: "doc", usage: "generate the config reference documentation", run: runDoc},
+	{name: "sample", usage: "generate a commented sample config with defaults", run: runSample},
+	{name: "init", usage: "interactively create a starter config file", run: runInit},
+	{name: "compare", usage: "diff a local config file against a remote instance's effective config", run: runCompare},
+	{name: "encrypt", usage: "encrypt a value or selected keys of a YAML config into the ENC[...] or enc: inline format", run: runEncrypt},
+	{name: "decrypt", usage: "decrypt a value, a SOPS, age or enveloped config file, and every ENC[...] or enc: value of a YAML config", run: runDecrypt},
+	{name: "get", usage: "print the value at a JSON pointer in a YAML or JSON config", run: runGet},
+	{name: "set", usage: "set the value at a JSON pointer in a YAML or JSON config, keeping comments and key order", run: runSet},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(2)
+	}
+
+	name := os.Args[1]
+	for _, cmd := range commands {
+		if cmd.name == name {
+			if err := cmd.run(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "confctl %s: %v\n", name, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "confctl: unknown command %q\n", name)
+	printUsage()
+	os.Exit(2)
+}
+
+// printUsage 输出帮助信息
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "Usage: confctl <command> [flags]")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", cmd.name, cmd.usage)
+	}
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	config "github.com/omeyang/practices/pkg/conf"
+
+	"gopkg.in/yaml.v3"
+)
+
+// runGet 按 JSON Pointer 读取配置文件中的取值 标量输出原始值 映射与序列按文件格式输出
+func runGet(args []string) error {
+	fs := flag.NewFlagSet("get", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return errors.New("usage: confctl get <file> <json-pointer>")
+	}
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	out, err := getPointer(data, fs.Arg(1))
+	if err != nil {
+		return fmt.Errorf("%s: %w", fs.Arg(0), err)
+	}
+	_, err = os.Stdout.Write(out)
+	return err
+}
+
+// runSet 按 JSON Pointer 修改配置文件中的取值并原子写回 注释与键的顺序保持不变
+// 取值按 YAML 解析 与加载配置时的类型推断一致 如 8080 为整数 "8080" 为字符串
+func runSet(args []string) error {
+	fs := flag.NewFlagSet("set", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "print the patched file to stdout instead of writing it back")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 3 {
+		return errors.New("usage: confctl set [-dry-run] <file> <json-pointer> <value>")
+	}
+	return processFile(fs.Arg(0), !*dryRun, func(data []byte) ([]byte, error) {
+		return setPointer(data, fs.Arg(1), fs.Arg(2))
+	})
+}
+
+// getPointer 返回 YAML 或 JSON 文档中指针指向的取值
+func getPointer(data []byte, pointer string) ([]byte, error) {
+	format, err := queryFormat(data)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	node, err := findNode(&doc, tokens)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", pointer, err)
+	}
+	if node.Kind == yaml.ScalarNode {
+		return []byte(node.Value + "\n"), nil
+	}
+	if format == ".json" {
+		return encodeJSON(node)
+	}
+	return encodeYAML(node)
+}
+
+// setPointer 设置 YAML 或 JSON 文档中指针指向的取值 父节点必须存在
+// 映射中不存在的键追加到末尾 序列下标为 - 时追加元素 与 JSON Patch 的 add 一致
+func setPointer(data []byte, pointer, raw string) ([]byte, error) {
+	format, err := queryFormat(data)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New("cannot replace the whole document")
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	parent, err := findNode(&doc, tokens[:len(tokens)-1])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", pointer, err)
+	}
+	value, err := parseValue(raw)
+	if err != nil {
+		return nil, err
+	}
+	if err := setChild(parent, tokens[len(tokens)-1], value); err != nil {
+		return nil, fmt.Errorf("%s: %w", pointer, err)
+	}
+	if format == ".json" {
+		return encodeJSON(&doc)
+	}
+	return encodeYAML(&doc)
+}
+
+// queryFormat 识别文件格式 仅支持 YAML 与 JSON
+func queryFormat(data []byte) (string, error) {
+	format := config.DetectFormat(data)
+	if format != ".yaml" && format != ".json" {
+		return "", fmt.Errorf("unsupported format %s, only YAML and JSON files can be queried", format)
+	}
+	return format, nil
+}
+
+// parsePointer 按 RFC 6901 拆分 JSON Pointer 空指针表示整个文档
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q: must start with /", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	unescape := strings.NewReplacer("~1", "/", "~0", "~")
+	for i, token := range tokens {
+		tokens[i] = unescape.Replace(token)
+	}
+	return tokens, nil
+}
+
+// parseValue 按 YAML 解析命令行中的取值 空字符串视为空字符串而不是 null
+func parseValue(raw string) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(raw), &doc); err != nil {
+		return nil, fmt.Errorf("invalid value %q: %w", raw, err)
+	}
+	if len(doc.Content) == 0 {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: raw}, nil
+	}
+	return doc.Content[0], nil
+}
+
+// setChild 替换或追加子节点 替换时保留原节点的注释
+func setChild(node *yaml.Node, key string, value *yaml.Node) error {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				node.Content[i+1] = withComments(value, node.Content[i+1])
+				return nil
+			}
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+	case yaml.SequenceNode:
+		if key == "-" {
+			node.Content = append(node.Content, value)
+			return nil
+		}
+		index, err := strconv.Atoi(key)
+		if err != nil || index < 0 || index >= len(node.Content) {
+			return fmt.Errorf("index %s out of range", key)
+		}
+		node.Content[index] = withComments(value, node.Content[index])
+	default:
+		return errors.New("parent is not a mapping or sequence")
+	}
+	return nil
+}
+
+// withComments 把被替换节点的注释复制到新节点
+func withComments(node, replaced *yaml.Node) *yaml.Node {
+	node.HeadComment, node.LineComment, node.FootComment = replaced.HeadComment, replaced.LineComment, replaced.FootComment
+	return node
+}
+
+// encodeJSON 以两个空格缩进输出 JSON 映射保持原有的键顺序
+func encodeJSON(node *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, node, ""); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// writeJSON 递归输出节点 标量按 YAML 的类型推断转换为 JSON 取值
+func writeJSON(buf *bytes.Buffer, node *yaml.Node, indent string) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("null")
+			return nil
+		}
+		return writeJSON(buf, node.Content[0], indent)
+	case yaml.AliasNode:
+		return writeJSON(buf, node.Alias, indent)
+	case yaml.MappingNode, yaml.SequenceNode:
+		open, end, step := "[", "]", 1
+		if node.Kind == yaml.MappingNode {
+			open, end, step = "{", "}", 2
+		}
+		if len(node.Content) == 0 {
+			buf.WriteString(open + end)
+			return nil
+		}
+		buf.WriteString(open)
+		inner := indent + "  "
+		for i := 0; i+step-1 < len(node.Content); i += step {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString("\n" + inner)
+			if step == 2 {
+				key, err := json.Marshal(node.Content[i].Value)
+				if err != nil {
+					return err
+				}
+				buf.Write(key)
+				buf.WriteString(": ")
+			}
+			if err := writeJSON(buf, node.Content[i+step-1], inner); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("\n" + indent + end)
+	default:
+		var value any
+		if err := node.Decode(&value); err != nil {
+			return err
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+	}
+	return nil
+}
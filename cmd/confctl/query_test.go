@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGetPointer 测试按 JSON Pointer 读取 YAML 与 JSON 中的取值
+func TestGetPointer(t *testing.T) {
+	content := "prometheusCfg:\n  port: 9090\n  a/b: slash\ntenants:\n  acme:\n    hosts: [a.example, b.example]\n"
+	tests := []struct {
+		pointer  string
+		expected string
+	}{
+		{"/prometheusCfg/port", "9090\n"},
+		{"/prometheusCfg/a~1b", "slash\n"},
+		{"/tenants/acme/hosts/1", "b.example\n"},
+		{"/tenants/acme", "hosts: [a.example, b.example]\n"},
+	}
+	for _, tt := range tests {
+		out, err := getPointer([]byte(content), tt.pointer)
+		assert.NoError(t, err, tt.pointer)
+		assert.Equal(t, tt.expected, string(out), tt.pointer)
+	}
+
+	out, err := getPointer([]byte(`{"db": {"port": 5432, "hosts": ["a"]}}`), "/db")
+	assert.NoError(t, err)
+	assert.Equal(t, "{\n  \"port\": 5432,\n  \"hosts\": [\n    \"a\"\n  ]\n}\n", string(out))
+
+	_, err = getPointer([]byte(content), "/prometheusCfg/address")
+	assert.ErrorContains(t, err, "not found")
+	_, err = getPointer([]byte(content), "prometheusCfg")
+	assert.ErrorContains(t, err, "must start with /")
+	_, err = getPointer([]byte("[server]\nport = 80\n"), "/server/port")
+	assert.ErrorContains(t, err, "unsupported format")
+}
+
+// TestSetPointer 测试修改取值时保留注释与键顺序 取值按 YAML 推断类型
+func TestSetPointer(t *testing.T) {
+	content := "# metrics\nprometheusCfg:\n  port: 9090 # scrape port\n  enable: false\ntags:\n  - a\n"
+
+	out, err := setPointer([]byte(content), "/prometheusCfg/port", "9091")
+	assert.NoError(t, err)
+	assert.Equal(t, "# metrics\nprometheusCfg:\n  port: 9091 # scrape port\n  enable: false\ntags:\n  - a\n", string(out))
+
+	out, err = setPointer(out, "/prometheusCfg/address", "0.0.0.0")
+	assert.NoError(t, err)
+	out, err = setPointer(out, "/tags/-", `"1"`)
+	assert.NoError(t, err)
+	assert.Equal(t, "# metrics\nprometheusCfg:\n  port: 9091 # scrape port\n  enable: false\n  address: 0.0.0.0\ntags:\n  - a\n  - \"1\"\n", string(out))
+
+	out, err = setPointer([]byte(`{"b": 1, "a": {"enable": false}}`), "/a/enable", "true")
+	assert.NoError(t, err)
+	assert.Equal(t, "{\n  \"b\": 1,\n  \"a\": {\n    \"enable\": true\n  }\n}\n", string(out))
+
+	_, err = setPointer([]byte(content), "/missing/port", "1")
+	assert.ErrorContains(t, err, "not found")
+	_, err = setPointer([]byte(content), "/tags/3", "b")
+	assert.ErrorContains(t, err, "out of range")
+	_, err = setPointer([]byte(content), "", "{}")
+	assert.Error(t, err)
+}
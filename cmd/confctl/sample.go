@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+
+	"github.com/omeyang/practices/pkg/conf/docgen"
+)
+
+// runSample 根据配置结构体源码生成带注释与默认值的示例配置
+func runSample(args []string) error {
+	fs := flag.NewFlagSet("sample", flag.ContinueOnError)
+	dir := fs.String("dir", "internal/entity", "directory containing the config structs")
+	typeName := fs.String("type", "AppConf", "root config struct name")
+	format := fs.String("format", "yaml", "output format: yaml or json")
+	out := fs.String("out", "", "output file (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	fields, err := docgen.Parse(*dir, *typeName)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	return docgen.Sample(w, fields, *format)
+}
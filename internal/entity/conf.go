@@ -1,13 +1,41 @@
 package entity
 
+//go:generate go run ../../cmd/confctl doc -dir . -type AppConf -out ../../docs/config.md
+
+import "time"
+
 // AppConf 应用配置
 type AppConf struct {
-	PrometheusCfg *PrometheusConf `yaml:"prometheusCfg"` // Prometheus 配置
+	EffectiveAt   *time.Time                `yaml:"effectiveAt"`         // 整份配置的生效时间 为空表示立即生效
+	PrometheusCfg *PrometheusConf           `yaml:"prometheusCfg"`       // Prometheus 配置
+	WorkerCfg     *WorkerConf               `yaml:"workerCfg,omitempty"` // 工作池配置
+	LimitsCfg     *LimitsConf               `yaml:"limitsCfg,omitempty"` // 限额配置
+	Tenants       map[string]map[string]any `yaml:"tenants,omitempty"`   // 租户覆盖配置 按租户名覆盖上面的基础配置
 }
 
 // PrometheusConf Prometheus 配置
 type PrometheusConf struct {
-	Enable  bool   `yaml:"enable"`
-	Port    int    `yaml:"port"`
-	Address string `yaml:"address"`
+	Enable  bool   `yaml:"enable"`  // 是否启用
+	Port    int    `yaml:"port"`    // 监听端口
+	Address string `yaml:"address"` // 监听地址
+}
+
+// WorkerConf 工作池配置
+type WorkerConf struct {
+	PoolSize int `yaml:"poolSize"` // 工作协程数量 修改后在线调整
+}
+
+// LimitsConf 限额配置 取值为 0 表示不限
+type LimitsConf struct {
+	MaxConnections int64            `yaml:"maxConnections"` // 最大连接数
+	MaxRequestSize int64            `yaml:"maxRequestSize"` // 请求体的最大字节数
+	TenantQuotas   map[string]int64 `yaml:"tenantQuotas"`   // 按租户名设置的并发请求配额
+}
+
+// EffectiveTime 返回配置的生效时间
+func (c *AppConf) EffectiveTime() time.Time {
+	if c == nil || c.EffectiveAt == nil {
+		return time.Time{}
+	}
+	return *c.EffectiveAt
 }
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ackTimeout 单次回报的超时时间
+const ackTimeout = 5 * time.Second
+
+// Versioned 能够报告当前配置版本的加载器
+type Versioned interface {
+	Version() string
+}
+
+// ApplyReport 配置版本的应用结果 供配置控制面跟踪各实例运行的版本
+type ApplyReport struct {
+	Source   string    `json:"source"`          // 配置来源 即 GetConfigPath
+	Version  string    `json:"version"`         // 配置版本 加载器未实现 Versioned 时为空
+	Instance string    `json:"instance"`        // 实例标识
+	Applied  bool      `json:"applied"`         // 是否已应用
+	Error    string    `json:"error,omitempty"` // 拒绝原因
+	Time     time.Time `json:"time"`            // 应用或拒绝的时间
+}
+
+// AckReporter 回报配置版本的应用结果
+type AckReporter interface {
+	ReportApply(ctx context.Context, report *ApplyReport) error
+}
+
+// AckFunc 函数形式的 AckReporter
+type AckFunc func(ctx context.Context, report *ApplyReport) error
+
+// ReportApply 实现 AckReporter
+func (f AckFunc) ReportApply(ctx context.Context, report *ApplyReport) error {
+	return f(ctx, report)
+}
+
+// HTTPAckReporter 以 JSON POST 的方式向控制面回报应用结果
+type HTTPAckReporter struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewHTTPAckReporter 创建 HTTP 回报器 client 为空时使用 http.DefaultClient
+func NewHTTPAckReporter(url string, client *http.Client) *HTTPAckReporter {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPAckReporter{URL: url, Client: client}
+}
+
+// ReportApply 发送回报 非 2xx 响应视为失败
+func (h *HTTPAckReporter) ReportApply(ctx context.Context, report *ApplyReport) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("apply report to %s: unexpected status %s", h.URL, resp.Status)
+	}
+	return nil
+}
+
+// ReportApply 通过推送流回执实现 AckReporter
+func (s *StreamingLoader[T]) ReportApply(_ context.Context, report *ApplyReport) error {
+	var applyErr error
+	if !report.Applied {
+		applyErr = errors.New(report.Error)
+	}
+	return s.Ack(report.Version, applyErr)
+}
+
+// reportApply 异步回报配置的应用结果 applyErr 为空表示已应用 未设置回报器时不做任何事
+func (cm *CfgManager[T]) reportApply(ctx context.Context, applyErr error) {
+	reporter := cm.opts.ackReporter
+	if reporter == nil {
+		return
+	}
+
+	report := &ApplyReport{
+		Source:   cm.loader.GetConfigPath(),
+		Instance: cm.opts.instance,
+		Applied:  applyErr == nil,
+		Time:     cm.opts.clock.Now(),
+	}
+	if v, ok := cm.loader.(Versioned); ok {
+		report.Version = v.Version()
+	}
+	if applyErr != nil {
+		report.Error = applyErr.Error()
+	}
+
+	// 回报可能涉及网络请求 不能在持有配置锁时同步执行
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), ackTimeout)
+		defer cancel()
+		if err := reporter.ReportApply(ctx, report); err != nil {
+			cm.logger.Warn("Failed to report config apply result", zap.String("version", report.Version), zap.Bool("applied", report.Applied), zap.Error(err))
+		}
+	}()
+}
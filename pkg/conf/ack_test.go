@@ -0,0 +1,99 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/omeyang/practices/internal/entity"
+	mocks "github.com/omeyang/practices/mocks/conf"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+// TestHTTPAckReporter 测试 HTTP 回报的请求内容与状态码处理
+func TestHTTPAckReporter(t *testing.T) {
+	received := make(chan ApplyReport, 1)
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		var report ApplyReport
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&report))
+		received <- report
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	reporter := NewHTTPAckReporter(server.URL, nil)
+	report := &ApplyReport{Source: "app.yaml", Version: "7", Instance: "host-1", Applied: true, Time: time.Unix(0, 0).UTC()}
+	assert.NoError(t, reporter.ReportApply(context.Background(), report))
+	assert.Equal(t, *report, <-received)
+
+	status = http.StatusInternalServerError
+	assert.Error(t, reporter.ReportApply(context.Background(), report))
+}
+
+// TestCfgManager_reportApply 测试重载成功与失败时的回报
+func TestCfgManager_reportApply(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockWatcher := mocks.NewMockWatcherInterface(ctrl)
+	logger, _ := zap.NewDevelopment()
+	mockLoader.EXPECT().GetConfigPath().Return("/path/to/config").AnyTimes()
+
+	reports := make(chan *ApplyReport, 2)
+	cm := NewConfigManager[entity.AppConf](mockLoader, mockWatcher, logger, RetryPolicy{MaxAttempts: 1},
+		WithInstance("host-1"),
+		WithAckReporter(AckFunc(func(_ context.Context, report *ApplyReport) error {
+			reports <- report
+			return nil
+		})))
+
+	ctx := context.Background()
+	mockLoader.EXPECT().LoadConfig(ctx).Return(&entity.AppConf{}, nil).Times(1)
+	cm.reloadConfig(ctx)
+	report := <-reports
+	assert.True(t, report.Applied)
+	assert.Equal(t, "/path/to/config", report.Source)
+	assert.Equal(t, "host-1", report.Instance)
+
+	mockLoader.EXPECT().LoadConfig(ctx).Return(nil, errors.New("invalid config")).Times(1)
+	cm.reloadConfig(ctx)
+	report = <-reports
+	assert.False(t, report.Applied)
+	assert.Equal(t, "invalid config", report.Error)
+}
+
+// TestStreamingLoader_ReportApply 测试推送流加载器将回报转为回执
+func TestStreamingLoader_ReportApply(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	stream := &fakeStream{revisions: make(chan *Revision, 1)}
+	loader := NewStreamingLoader[entity.AppConf]("stream:test", func(ctx context.Context) (ConfigStream, error) {
+		stream.ctx = ctx
+		return stream, nil
+	}, logger)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	loader.Start(ctx)
+	defer loader.Close()
+
+	stream.revisions <- &Revision{Version: "3", Format: "yaml", Data: []byte("prometheusCfg: [\n")}
+	_, err := loader.LoadConfig(ctx)
+	assert.Error(t, err)
+	assert.Equal(t, "3", loader.Version())
+
+	assert.NoError(t, loader.ReportApply(ctx, &ApplyReport{Version: loader.Version(), Error: "bad revision"}))
+	stream.mu.Lock()
+	assert.Equal(t, []*RevisionAck{{Version: "3", Error: "bad revision"}}, stream.acks)
+	stream.mu.Unlock()
+}
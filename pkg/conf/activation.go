@@ -0,0 +1,192 @@
+package config
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// EffectiveTimer 支持定时生效的配置或配置段 返回零值表示立即生效
+// 由配置结构体实现时整份配置推迟生效 由顶层字段的配置段实现时只推迟该配置段 其余配置段立即生效
+type EffectiveTimer interface {
+	EffectiveTime() time.Time
+}
+
+// pendingActivation 等待生效的配置
+type pendingActivation[T any] struct {
+	mu     sync.Mutex
+	timer  Timer
+	config *T
+	at     time.Time
+}
+
+// effectiveTime 获取配置的生效时间
+func effectiveTime(config any) time.Time {
+	if e, ok := config.(EffectiveTimer); ok {
+		return e.EffectiveTime()
+	}
+	return time.Time{}
+}
+
+// gateSections 将 config 中生效时间晚于 now 的配置段替换为 current 中的取值 current 为 nil 时替换为零值
+// 返回现在可以生效的配置与最早的配置段生效时间 没有推迟的配置段时原样返回 config 与零值
+func gateSections[T any](current, config *T, now time.Time) (*T, time.Time) {
+	v := reflect.ValueOf(config).Elem()
+	if v.Kind() != reflect.Struct {
+		return config, time.Time{}
+	}
+
+	var gated reflect.Value
+	var next time.Time
+	for i := 0; i < v.NumField(); i++ {
+		if !v.Type().Field(i).IsExported() {
+			continue
+		}
+		at := sectionEffectiveTime(v.Field(i))
+		if !at.After(now) {
+			continue
+		}
+		if !gated.IsValid() {
+			gated = reflect.New(v.Type())
+			gated.Elem().Set(v)
+		}
+		held := reflect.Zero(v.Field(i).Type())
+		if current != nil {
+			held = reflect.ValueOf(current).Elem().Field(i)
+		}
+		gated.Elem().Field(i).Set(held)
+		if next.IsZero() || at.Before(next) {
+			next = at
+		}
+	}
+	if !gated.IsValid() {
+		return config, time.Time{}
+	}
+	return gated.Interface().(*T), next
+}
+
+// sectionEffectiveTime 获取配置段的生效时间 未实现 EffectiveTimer 的配置段返回零值
+func sectionEffectiveTime(field reflect.Value) time.Time {
+	switch field.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+		if field.IsNil() {
+			return time.Time{}
+		}
+	}
+	if e, ok := field.Interface().(EffectiveTimer); ok {
+		return e.EffectiveTime()
+	}
+	if e, ok := field.Addr().Interface().(EffectiveTimer); ok {
+		return e.EffectiveTime()
+	}
+	return time.Time{}
+}
+
+// applyConfig 应用新加载的配置 若配置声明了未来的生效时间则推迟到该时刻 配置段的生效时间见 activate
+// 新配置总会取消之前尚未生效的配置 修改了重启键的配置不会应用
+// 调用方需持有 applyMu 返回是否立即生效
+func (cm *CfgManager[T]) applyConfig(ctx context.Context, newConfig *T) (bool, error) {
+	return cm.applyConfigAfter(ctx, newConfig, 0)
+}
+
+// applyConfigAfter 与 applyConfig 相同 但在生效时间之后再推迟 stagger 用于错开实例的生效时刻
+func (cm *CfgManager[T]) applyConfigAfter(ctx context.Context, newConfig *T, stagger time.Duration) (bool, error) {
+	cm.pending.mu.Lock()
+	defer cm.pending.mu.Unlock()
+
+	if cm.pending.timer != nil {
+		cm.pending.timer.Stop()
+		cm.pending.timer, cm.pending.config, cm.pending.at = nil, nil, time.Time{}
+	}
+	if cm.checkRestart(newConfig) {
+		return false, nil
+	}
+
+	at := effectiveTime(newConfig)
+	if stagger > 0 {
+		if now := cm.opts.clock.Now(); at.Before(now) {
+			at = now
+		}
+		at = at.Add(stagger)
+	}
+	if at.IsZero() || !at.After(cm.opts.clock.Now()) {
+		if err := cm.activate(ctx, newConfig); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	cm.schedule(ctx, newConfig, at)
+	cm.logger.Info("Config scheduled for activation", zap.Time("effectiveAt", at), zap.String("configPath", cm.loader.GetConfigPath()))
+	return false, nil
+}
+
+// activate 应用已到生效时间的配置 生效时间未到的配置段暂时保留当前的取值
+// 并在最早的配置段生效时间再次应用 config 调用方需持有 applyMu 与 pending.mu
+func (cm *CfgManager[T]) activate(ctx context.Context, config *T) error {
+	current, _ := cm.config.Load().(*T)
+	effective, next := gateSections(current, config, cm.opts.clock.Now())
+	if err := cm.storeConfig(effective); err != nil {
+		return err
+	}
+	if !next.IsZero() {
+		cm.schedule(ctx, config, next)
+		cm.logger.Info("Config sections scheduled for activation", zap.Time("effectiveAt", next), zap.String("configPath", cm.loader.GetConfigPath()))
+	}
+	return nil
+}
+
+// schedule 排定配置在 at 时刻生效 调用方需持有 pending.mu
+func (cm *CfgManager[T]) schedule(ctx context.Context, config *T, at time.Time) {
+	cm.pending.config, cm.pending.at = config, at
+	cm.pending.timer = cm.opts.clock.AfterFunc(at.Sub(cm.opts.clock.Now()), func() {
+		cm.activatePending(ctx, config)
+	})
+}
+
+// activatePending 到达生效时间后应用等待中的配置 管理器关闭后不做任何事
+func (cm *CfgManager[T]) activatePending(ctx context.Context, config *T) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	cm.applyMu.Lock()
+	defer cm.applyMu.Unlock()
+	if cm.life.closing() {
+		return
+	}
+	cm.pending.mu.Lock()
+	defer cm.pending.mu.Unlock()
+
+	// 等待期间已被更新的配置取代
+	if cm.pending.config != config {
+		return
+	}
+	cm.pending.timer, cm.pending.config, cm.pending.at = nil, nil, time.Time{}
+	if err := cm.activate(ctx, config); err != nil {
+		cm.logger.Error("Scheduled config rejected by change handlers", zap.Error(err))
+		cm.reportApply(ctx, err)
+		return
+	}
+	cm.logger.Info("Scheduled config activated", zap.String("configPath", cm.loader.GetConfigPath()))
+	cm.reportApply(ctx, nil)
+}
+
+// PendingConfig 返回等待生效的配置及其生效时间 没有则返回 nil
+func (cm *CfgManager[T]) PendingConfig() (*T, time.Time) {
+	cm.pending.mu.Lock()
+	defer cm.pending.mu.Unlock()
+	return cm.pending.config, cm.pending.at
+}
+
+// StaggerDelay 将实例标识的哈希映射到 [0, window) 中的延迟 同一实例总是得到相同的延迟
+// 实例的延迟在窗口内均匀分布 整个集群的生效时刻被错开
+func StaggerDelay(instanceKey string, window time.Duration) time.Duration {
+	if window <= 0 {
+		return 0
+	}
+	return time.Duration(float64(window) * float64(VariantBucket(instanceKey, "stagger")) / variantBuckets)
+}
@@ -0,0 +1,186 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/omeyang/practices/internal/entity"
+	mocks "github.com/omeyang/practices/mocks/conf"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+// TestCfgManager_applyConfig 测试定时生效的配置
+func TestCfgManager_applyConfig(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockWatcher := mocks.NewMockWatcherInterface(ctrl)
+	logger, _ := zap.NewDevelopment()
+	mockLoader.EXPECT().GetConfigPath().Return("/path/to/config").AnyTimes()
+
+	cm := NewConfigManager[entity.AppConf](mockLoader, mockWatcher, logger, RetryPolicy{})
+	ctx := context.Background()
+
+	current := &entity.AppConf{}
+	applied, err := cm.applyConfig(ctx, current)
+	assert.NoError(t, err)
+	assert.True(t, applied, "Config without effectiveAt should apply immediately")
+	assert.Same(t, current, cm.GetConfig())
+
+	// 未来生效的配置先挂起 到期后自动生效
+	at := time.Now().Add(50 * time.Millisecond)
+	scheduled := &entity.AppConf{EffectiveAt: &at}
+	applied, err = cm.applyConfig(ctx, scheduled)
+	assert.NoError(t, err)
+	assert.False(t, applied)
+	pending, pendingAt := cm.PendingConfig()
+	assert.Same(t, scheduled, pending)
+	assert.Equal(t, at, pendingAt)
+	assert.Same(t, current, cm.GetConfig())
+
+	assert.Eventually(t, func() bool {
+		return cm.GetConfig() == scheduled
+	}, time.Second, 10*time.Millisecond)
+	pending, _ = cm.PendingConfig()
+	assert.Nil(t, pending)
+
+	// 更新的配置会取消尚未生效的配置
+	later := time.Now().Add(time.Hour)
+	applied, err = cm.applyConfig(ctx, &entity.AppConf{EffectiveAt: &later})
+	assert.NoError(t, err)
+	assert.False(t, applied)
+	replacement := &entity.AppConf{}
+	applied, err = cm.applyConfig(ctx, replacement)
+	assert.NoError(t, err)
+	assert.True(t, applied)
+	pending, _ = cm.PendingConfig()
+	assert.Nil(t, pending)
+	assert.Same(t, replacement, cm.GetConfig())
+}
+
+// TestStaggerDelay 测试延迟由实例标识确定 且在窗口内分散
+func TestStaggerDelay(t *testing.T) {
+	assert.Equal(t, 2328*time.Millisecond, StaggerDelay("web-1", time.Minute))
+	assert.Equal(t, StaggerDelay("web-1", time.Minute), StaggerDelay("web-1", time.Minute))
+	assert.Zero(t, StaggerDelay("web-1", 0))
+
+	seen := map[time.Duration]bool{}
+	for i := 0; i < 100; i++ {
+		delay := StaggerDelay(fmt.Sprintf("web-%d", i), time.Minute)
+		assert.GreaterOrEqual(t, delay, time.Duration(0))
+		assert.Less(t, delay, time.Minute)
+		seen[delay] = true
+	}
+	assert.Greater(t, len(seen), 90)
+}
+
+// TestCfgManager_StaggeredReload 测试重载的配置按实例延迟生效 Set 不受影响
+func TestCfgManager_StaggeredReload(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return("/path/to/config").AnyTimes()
+
+	clock := NewFakeClock(time.Unix(0, 0))
+	cm := NewConfigManager[entity.AppConf](mockLoader, nil, zap.NewNop(), RetryPolicy{},
+		WithClock(clock), WithInstance("web-1"), WithStaggeredReload(time.Minute))
+	ctx := context.Background()
+	current := &entity.AppConf{}
+	assert.NoError(t, cm.Set(ctx, current))
+	assert.Same(t, current, cm.GetConfig())
+
+	reloaded := &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9090}}
+	mockLoader.EXPECT().LoadConfig(gomock.Any()).Return(reloaded, nil)
+	assert.NoError(t, cm.Reload(ctx))
+	pending, at := cm.PendingConfig()
+	assert.Same(t, reloaded, pending)
+	assert.Equal(t, time.Unix(0, 0).Add(2328*time.Millisecond), at)
+	assert.Same(t, current, cm.GetConfig())
+
+	// FakeClock 在新的协程中执行到期的回调
+	clock.Advance(2328 * time.Millisecond)
+	assert.Eventually(t, func() bool {
+		return cm.GetConfig() == reloaded
+	}, time.Second, time.Millisecond)
+}
+
+// scheduledSection 带有生效时间的配置段
+type scheduledSection struct {
+	EffectiveAt *time.Time `yaml:"effectiveAt"`
+	Port        int        `yaml:"port"`
+}
+
+// EffectiveTime 实现 EffectiveTimer
+func (s *scheduledSection) EffectiveTime() time.Time {
+	if s.EffectiveAt == nil {
+		return time.Time{}
+	}
+	return *s.EffectiveAt
+}
+
+// sectionedConf 配置段各自声明生效时间的配置
+type sectionedConf struct {
+	Name    string            `yaml:"name"`
+	HTTP    *scheduledSection `yaml:"http"`
+	Metrics scheduledSection  `yaml:"metrics"`
+}
+
+// TestCfgManager_SectionEffectiveAt 测试配置段的生效时间 未到时保留当前取值 其余配置段立即生效
+func TestCfgManager_SectionEffectiveAt(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[sectionedConf](ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return("/path/to/config").AnyTimes()
+
+	start := time.Unix(0, 0)
+	clock := NewFakeClock(start)
+	cm := NewConfigManager[sectionedConf](mockLoader, nil, zap.NewNop(), RetryPolicy{}, WithClock(clock))
+	ctx := context.Background()
+	current := &sectionedConf{Name: "v1", HTTP: &scheduledSection{Port: 80}, Metrics: scheduledSection{Port: 9090}}
+	assert.NoError(t, cm.Set(ctx, current))
+
+	httpAt, metricsAt := start.Add(time.Minute), start.Add(time.Hour)
+	next := &sectionedConf{
+		Name:    "v2",
+		HTTP:    &scheduledSection{EffectiveAt: &httpAt, Port: 8080},
+		Metrics: scheduledSection{EffectiveAt: &metricsAt, Port: 9091},
+	}
+	assert.NoError(t, cm.Set(ctx, next))
+	assert.Equal(t, "v2", cm.GetConfig().Name)
+	assert.Same(t, current.HTTP, cm.GetConfig().HTTP)
+	assert.Equal(t, 9090, cm.GetConfig().Metrics.Port)
+	pending, at := cm.PendingConfig()
+	assert.Same(t, next, pending)
+	assert.Equal(t, httpAt, at)
+
+	// 各配置段到期后依次生效 全部生效后为原配置
+	clock.Advance(time.Minute)
+	assert.Eventually(t, func() bool {
+		return cm.GetConfig().HTTP == next.HTTP
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, 9090, cm.GetConfig().Metrics.Port)
+	_, at = cm.PendingConfig()
+	assert.Equal(t, metricsAt, at)
+
+	clock.Advance(time.Hour)
+	assert.Eventually(t, func() bool {
+		return cm.GetConfig() == next
+	}, time.Second, time.Millisecond)
+	pending, _ = cm.PendingConfig()
+	assert.Nil(t, pending)
+
+	// 首份配置中未到生效时间的配置段为零值
+	cm = NewConfigManager[sectionedConf](mockLoader, nil, zap.NewNop(), RetryPolicy{}, WithClock(clock))
+	later := clock.Now().Add(time.Minute)
+	assert.NoError(t, cm.Set(ctx, &sectionedConf{Name: "v1", HTTP: &scheduledSection{EffectiveAt: &later, Port: 80}}))
+	assert.Equal(t, "v1", cm.GetConfig().Name)
+	assert.Nil(t, cm.GetConfig().HTTP)
+}
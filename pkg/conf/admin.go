@@ -0,0 +1,158 @@
+package config
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// VersionHeader 管理端响应中携带当前配置版本号的头部
+const VersionHeader = "X-Config-Version"
+
+// RedactionProfileHeader 管理端请求中选择脱敏配置的头部 通常由完成鉴权的网关按角色设置
+const RedactionProfileHeader = "X-Redaction-Profile"
+
+// historyEntry GET /config/history 返回的单个版本
+type historyEntry struct {
+	Version uint64    `json:"version"`           // 版本号
+	Time    time.Time `json:"time"`              // 生效时间
+	Current bool      `json:"current"`           // 是否为当前版本
+	Changes []Change  `json:"changes,omitempty"` // 相对上一个保留版本的变更 敏感取值已脱敏
+}
+
+// AdminHandler 返回管理端 HTTP 处理器 便于确认运行中的实例实际生效的配置
+//
+//	GET  /config          脱敏后的当前配置 版本号在 X-Config-Version 头部 Accept 含 yaml 时返回 YAML
+//	GET  /config/history  保留的配置版本及相邻版本之间的变更
+//	POST /config/reload   立即重新加载配置
+//
+// GET 请求可以通过 X-Redaction-Profile 头部或 profile 查询参数选择 WithRedactionProfile 声明的脱敏配置
+// 脱敏配置只会增加脱敏的字段 未声明的脱敏配置返回 400
+// 处理器不做鉴权 应只挂载在内部管理端口上
+func (cm *CfgManager[T]) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/config", cm.serveConfig)
+	mux.HandleFunc("/config/history", cm.serveHistory)
+	mux.HandleFunc("/config/reload", cm.serveReload)
+	return mux
+}
+
+// serveConfig 返回脱敏后的当前配置
+func (cm *CfgManager[T]) serveConfig(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	select {
+	case <-cm.ready:
+	default:
+		http.Error(w, "config not loaded", http.StatusServiceUnavailable)
+		return
+	}
+
+	profile, ok := cm.requestProfile(w, r)
+	if !ok {
+		return
+	}
+	config, version := cm.GetVersioned()
+	tree, err := cm.RedactWithProfile(config, profile)
+	if err != nil {
+		cm.logger.Error("Failed to redact config", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(VersionHeader, strconv.FormatUint(version, 10))
+	if !strings.Contains(r.Header.Get("Accept"), "yaml") {
+		writeJSON(w, http.StatusOK, tree)
+		return
+	}
+	data, err := yaml.Marshal(tree)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/yaml")
+	_, _ = w.Write(data)
+}
+
+// serveHistory 返回保留的配置版本 按版本号从旧到新排列
+func (cm *CfgManager[T]) serveHistory(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	profile, ok := cm.requestProfile(w, r)
+	if !ok {
+		return
+	}
+
+	snapshots := cm.Versions()
+	entries := make([]historyEntry, len(snapshots))
+	var prev map[string]any
+	for i, snapshot := range snapshots {
+		tree, err := cm.RedactWithProfile(snapshot.Config, profile)
+		if err != nil {
+			cm.logger.Error("Failed to redact config", zap.Uint64("version", snapshot.Version), zap.Error(err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		entries[i] = historyEntry{Version: snapshot.Version, Time: snapshot.Time, Current: snapshot.Version == cm.Version()}
+		if i > 0 {
+			changes, err := Diff(snapshots[i-1].Config, snapshot.Config)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			entries[i].Changes = redactChanges(changes, prev, tree)
+		}
+		prev = tree
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+// serveReload 立即重新加载配置 返回重载后的版本号
+func (cm *CfgManager[T]) serveReload(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	cm.logger.Info("Config reload requested", zap.String("remoteAddr", r.RemoteAddr))
+	if err := cm.Reload(r.Context()); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error(), "version": cm.Version()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"version": cm.Version()})
+}
+
+// requestProfile 返回请求选择的脱敏配置 头部优先于查询参数 未声明时返回 400
+func (cm *CfgManager[T]) requestProfile(w http.ResponseWriter, r *http.Request) (string, bool) {
+	profile := r.Header.Get(RedactionProfileHeader)
+	if profile == "" {
+		profile = r.URL.Query().Get("profile")
+	}
+	if _, err := cm.redactionPaths(profile); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return "", false
+	}
+	return profile, true
+}
+
+// allowMethod 请求方法不匹配时返回 405
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method || (method == http.MethodGet && r.Method == http.MethodHead) {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	return false
+}
+
+// writeJSON 以 JSON 写出响应
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
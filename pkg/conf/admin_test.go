@@ -0,0 +1,85 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mocks "github.com/omeyang/practices/mocks/conf"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+// adminDatabaseConf 测试用的数据库配置
+type adminDatabaseConf struct {
+	User     string `yaml:"user"`
+	Password string `yaml:"password" sensitive:"true"`
+}
+
+// adminConf 测试用的应用配置
+type adminConf struct {
+	Name      string                       `yaml:"name"`
+	Database  adminDatabaseConf            `yaml:"database"`
+	Replicas  []adminDatabaseConf          `yaml:"replicas,omitempty"`
+	Upstreams map[string]adminDatabaseConf `yaml:"upstreams,omitempty"`
+}
+
+// TestCfgManager_AdminHandler 测试管理端返回脱敏配置与历史 并支持手动重载
+func TestCfgManager_AdminHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[adminConf](ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return("/path/to/config").AnyTimes()
+
+	cm := NewConfigManager[adminConf](mockLoader, nil, zap.NewNop(), RetryPolicy{})
+	handler := cm.AdminHandler()
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusServiceUnavailable, serve(http.MethodGet, "/config").Code)
+
+	ctx := context.Background()
+	assert.NoError(t, cm.Set(ctx, &adminConf{Name: "app", Database: adminDatabaseConf{User: "root", Password: "old"}}))
+	assert.NoError(t, cm.Set(ctx, &adminConf{Name: "app", Database: adminDatabaseConf{User: "admin", Password: "new"}}))
+
+	rec := serve(http.MethodGet, "/config")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get(VersionHeader))
+	assert.JSONEq(t, `{"name":"app","database":{"user":"admin","password":"******"}}`, rec.Body.String())
+	assert.NotContains(t, serve(http.MethodGet, "/config").Body.String(), "new")
+
+	rec = serve(http.MethodGet, "/config/history")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var history []historyEntry
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &history))
+	assert.Len(t, history, 2)
+	assert.False(t, history[0].Current)
+	assert.True(t, history[1].Current)
+	assert.Equal(t, []Change{
+		{Path: "database.password", Old: RedactedValue, New: RedactedValue},
+		{Path: "database.user", Old: "root", New: "admin"},
+	}, history[1].Changes)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(http.MethodGet, "/config/reload").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(http.MethodPost, "/config").Code)
+
+	mockLoader.EXPECT().LoadConfig(gomock.Any()).Return(&adminConf{Name: "reloaded"}, nil)
+	rec = serve(http.MethodPost, "/config/reload")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"version":3}`, rec.Body.String())
+	assert.Equal(t, "reloaded", cm.GetConfig().Name)
+
+	mockLoader.EXPECT().LoadConfig(gomock.Any()).Return(nil, errors.New("file not found"))
+	rec = serve(http.MethodPost, "/config/reload")
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Contains(t, rec.Body.String(), "file not found")
+}
@@ -0,0 +1,252 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrAnomalyRejected 异常检查拒绝了配置变更
+	ErrAnomalyRejected = errors.New("config change rejected by anomaly check")
+	// ErrNoPendingApproval 没有等待批准的配置
+	ErrNoPendingApproval = errors.New("no config pending approval")
+)
+
+// AnomalyAction 异常配置变更的处理方式 取值越大越严格
+type AnomalyAction int
+
+const (
+	// AnomalyWarn 只记录警告 照常应用
+	AnomalyWarn AnomalyAction = iota
+	// AnomalyRequireApproval 暂不应用 等待 Approve 批准
+	AnomalyRequireApproval
+	// AnomalyReject 拒绝新配置 当前配置保持不变
+	AnomalyReject
+)
+
+// String 返回处理方式的名称
+func (a AnomalyAction) String() string {
+	switch a {
+	case AnomalyWarn:
+		return "warn"
+	case AnomalyRequireApproval:
+		return "require-approval"
+	case AnomalyReject:
+		return "reject"
+	default:
+		return fmt.Sprintf("AnomalyAction(%d)", int(a))
+	}
+}
+
+// MarshalText 以名称编码 便于在 Status 中展示
+func (a AnomalyAction) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// Anomaly 异常检查发现的可疑变更
+type Anomaly struct {
+	Path   string        `json:"path"`   // 配置键路径
+	Reason string        `json:"reason"` // 可疑的原因 不包含取值 避免泄露敏感配置
+	Action AnomalyAction `json:"action"` // 处理方式
+}
+
+// AnomalyChecker 检查重载的配置相对当前配置的变更 返回发现的异常 没有异常时返回空
+type AnomalyChecker func(changes []Change) []Anomaly
+
+// AnomalyError 被异常检查拒绝的配置变更 可用 errors.Is(err, ErrAnomalyRejected) 判断
+type AnomalyError struct {
+	Anomalies []Anomaly
+}
+
+// Error 实现 error 接口
+func (e *AnomalyError) Error() string {
+	reasons := make([]string, len(e.Anomalies))
+	for i, anomaly := range e.Anomalies {
+		reasons[i] = anomaly.Path + ": " + anomaly.Reason
+	}
+	return fmt.Sprintf("%v: %s", ErrAnomalyRejected, strings.Join(reasons, "; "))
+}
+
+// Unwrap 返回 ErrAnomalyRejected
+func (e *AnomalyError) Unwrap() error {
+	return ErrAnomalyRejected
+}
+
+// PendingApproval 因异常等待批准的配置
+type PendingApproval[T any] struct {
+	Anomalies []Anomaly // 需要批准的异常
+	Changes   []Change  // 相对当前配置的全部变更
+	Config    *T        // 未应用的新配置
+}
+
+// approvalGate 异常检查器与等待批准的配置
+type approvalGate[T any] struct {
+	mu       sync.Mutex
+	checkers []AnomalyChecker
+	pending  *PendingApproval[T]
+}
+
+// AddAnomalyChecker 注册异常检查 每次重载在校验 探测与重载前钩子通过后 以相对当前配置的变更调用全部检查
+// 取全部异常中最严格的处理方式: 拒绝时错误发送到错误通道 需要批准时新配置挂起 由 Approve 应用
+// 挂起期间的下一次重载会重新检查并取代挂起的配置 Set 与回滚不做检查
+func (cm *CfgManager[T]) AddAnomalyChecker(checker AnomalyChecker) {
+	cm.approval.mu.Lock()
+	defer cm.approval.mu.Unlock()
+	cm.approval.checkers = append(cm.approval.checkers, checker)
+}
+
+// ApprovalPending 返回等待批准的配置 没有则返回 nil
+func (cm *CfgManager[T]) ApprovalPending() *PendingApproval[T] {
+	cm.approval.mu.Lock()
+	defer cm.approval.mu.Unlock()
+	return cm.approval.pending
+}
+
+// Approve 应用等待批准的配置 与重载一样遵循生效时间 错峰与重启键的规则 并执行重载后钩子
+func (cm *CfgManager[T]) Approve(ctx context.Context) error {
+	if err := cm.guardMutation("approve"); err != nil {
+		return err
+	}
+	cm.reloadMu.Lock()
+	defer cm.reloadMu.Unlock()
+	cm.approval.mu.Lock()
+	pending := cm.approval.pending
+	cm.approval.pending = nil
+	cm.approval.mu.Unlock()
+	if pending == nil {
+		return ErrNoPendingApproval
+	}
+
+	cm.logger.Info("Config approved", zap.String("configPath", cm.loader.GetConfigPath()))
+	oldConfig, applied, err := cm.applyReloaded(ctx, pending.Config)
+	if err != nil {
+		return err
+	}
+	if applied {
+		cm.runPostReloadHooks(oldConfig, pending.Config)
+	}
+	return nil
+}
+
+// DenyPending 丢弃等待批准的配置 没有则返回 ErrNoPendingApproval
+func (cm *CfgManager[T]) DenyPending() error {
+	cm.approval.mu.Lock()
+	defer cm.approval.mu.Unlock()
+	if cm.approval.pending == nil {
+		return ErrNoPendingApproval
+	}
+	cm.approval.pending = nil
+	cm.logger.Info("Config pending approval denied", zap.String("configPath", cm.loader.GetConfigPath()))
+	return nil
+}
+
+// checkAnomalies 以相对当前配置的变更执行全部异常检查 返回 true 表示新配置已挂起等待批准
+func (cm *CfgManager[T]) checkAnomalies(newConfig *T) (bool, error) {
+	cm.approval.mu.Lock()
+	defer cm.approval.mu.Unlock()
+	// 新的重载总会取代挂起的配置
+	cm.approval.pending = nil
+	current := cm.sharedConfig()
+	if len(cm.approval.checkers) == 0 || current == nil {
+		return false, nil
+	}
+
+	changes, err := Diff(current, newConfig)
+	if err != nil {
+		return false, err
+	}
+	var anomalies []Anomaly
+	action := AnomalyWarn
+	for _, checker := range cm.approval.checkers {
+		for _, anomaly := range checker(changes) {
+			anomalies = append(anomalies, anomaly)
+			action = max(action, anomaly.Action)
+		}
+	}
+	for _, anomaly := range anomalies {
+		cm.logger.Warn("Config change looks anomalous", zap.String("path", anomaly.Path), zap.String("reason", anomaly.Reason),
+			zap.Stringer("action", anomaly.Action), zap.String("configPath", cm.loader.GetConfigPath()))
+	}
+
+	switch action {
+	case AnomalyReject:
+		return false, &AnomalyError{Anomalies: anomalies}
+	case AnomalyRequireApproval:
+		cm.approval.pending = &PendingApproval[T]{Anomalies: anomalies, Changes: changes, Config: newConfig}
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// RatioChecker 数值或时长的取值放大或缩小超过 factor 倍时报告异常 如超时从 1s 改为 1m
+// paths 限定检查的键及其子路径 为空时检查全部键 新增与删除的键以及变为或变自 0 的取值不做检查
+func RatioChecker(factor float64, action AnomalyAction, paths ...string) AnomalyChecker {
+	return func(changes []Change) []Anomaly {
+		var anomalies []Anomaly
+		for _, change := range changes {
+			if len(paths) > 0 && !matchesAny(change.Path, paths) {
+				continue
+			}
+			oldValue, okOld := magnitude(change.Old)
+			newValue, okNew := magnitude(change.New)
+			if !okOld || !okNew || oldValue == 0 || newValue == 0 {
+				continue
+			}
+			if ratio := math.Abs(newValue / oldValue); ratio > factor || ratio < 1/factor {
+				anomalies = append(anomalies, Anomaly{
+					Path:   change.Path,
+					Reason: fmt.Sprintf("changed by a factor of %.3g, more than %gx", ratio, factor),
+					Action: action,
+				})
+			}
+		}
+		return anomalies
+	}
+}
+
+// ShrinkChecker 列表的元素减少超过 fraction 时报告异常 如 0.5 表示减少了一半以上
+// paths 限定检查的键及其子路径 为空时检查全部列表 列表被整体删除同样视为减少到 0
+func ShrinkChecker(fraction float64, action AnomalyAction, paths ...string) AnomalyChecker {
+	return func(changes []Change) []Anomaly {
+		var anomalies []Anomaly
+		for _, change := range changes {
+			if len(paths) > 0 && !matchesAny(change.Path, paths) {
+				continue
+			}
+			oldItems, ok := change.Old.([]any)
+			if !ok || len(oldItems) == 0 {
+				continue
+			}
+			newItems, _ := change.New.([]any)
+			if removed := float64(len(oldItems)-len(newItems)) / float64(len(oldItems)); removed > fraction {
+				anomalies = append(anomalies, Anomaly{
+					Path:   change.Path,
+					Reason: fmt.Sprintf("shrank from %d to %d items", len(oldItems), len(newItems)),
+					Action: action,
+				})
+			}
+		}
+		return anomalies
+	}
+}
+
+// magnitude 返回数值或时长字符串的大小
+func magnitude(v any) (float64, bool) {
+	if n, ok := toFloat(v); ok {
+		return n, true
+	}
+	if s, ok := v.(string); ok {
+		if d, err := time.ParseDuration(s); err == nil {
+			return float64(d), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"context"
+	"testing"
+
+	"github.com/omeyang/practices/internal/entity"
+	mocks "github.com/omeyang/practices/mocks/conf"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+// TestRatioChecker 测试数值与时长的倍数变化检查
+func TestRatioChecker(t *testing.T) {
+	checker := RatioChecker(10, AnomalyRequireApproval, "server")
+	anomalies := checker([]Change{
+		{Path: "server.timeout", Old: "1s", New: "1m"},
+		{Path: "server.port", Old: 8080, New: 8081},
+		{Path: "server.retries", Old: 100, New: 5},
+		{Path: "server.workers", Old: 0, New: 64},
+		{Path: "client.timeout", Old: "1s", New: "1h"},
+	})
+	assert.Equal(t, []Anomaly{
+		{Path: "server.timeout", Reason: "changed by a factor of 60, more than 10x", Action: AnomalyRequireApproval},
+		{Path: "server.retries", Reason: "changed by a factor of 0.05, more than 10x", Action: AnomalyRequireApproval},
+	}, anomalies)
+}
+
+// TestShrinkChecker 测试列表减少比例检查
+func TestShrinkChecker(t *testing.T) {
+	checker := ShrinkChecker(0.5, AnomalyReject)
+	anomalies := checker([]Change{
+		{Path: "brokers", Old: []any{"a", "b", "c", "d"}, New: []any{"a"}},
+		{Path: "peers", Old: []any{"a", "b"}, New: []any{"a"}},
+		{Path: "hosts", Old: []any{"a", "b"}},
+	})
+	assert.Equal(t, []Anomaly{
+		{Path: "brokers", Reason: "shrank from 4 to 1 items", Action: AnomalyReject},
+		{Path: "hosts", Reason: "shrank from 2 to 0 items", Action: AnomalyReject},
+	}, anomalies)
+}
+
+// TestCfgManager_AnomalyCheck 测试异常检查拒绝或挂起重载的配置 批准后应用
+func TestCfgManager_AnomalyCheck(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return("/path/to/config").AnyTimes()
+
+	cm := NewConfigManager[entity.AppConf](mockLoader, nil, zap.NewNop(), RetryPolicy{})
+	initial := &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9090}}
+	assert.NoError(t, cm.storeConfig(initial))
+	cm.AddAnomalyChecker(RatioChecker(100, AnomalyReject))
+	cm.AddAnomalyChecker(RatioChecker(1.05, AnomalyRequireApproval, "prometheusCfg.port"))
+
+	ctx := context.Background()
+	mockLoader.EXPECT().LoadConfig(gomock.Any()).Return(&entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 90}}, nil)
+	cm.reloadConfig(ctx)
+	err := <-cm.ListenForConfigErrors()
+	assert.ErrorIs(t, err, ErrAnomalyRejected)
+	assert.ErrorContains(t, err, "prometheusCfg.port")
+	assert.Same(t, initial, cm.GetConfig())
+	assert.Nil(t, cm.ApprovalPending())
+
+	held := &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 10000}}
+	mockLoader.EXPECT().LoadConfig(gomock.Any()).Return(held, nil)
+	assert.NoError(t, cm.Reload(ctx))
+	assert.Same(t, initial, cm.GetConfig())
+	pending := cm.ApprovalPending()
+	assert.NotNil(t, pending)
+	assert.Same(t, held, pending.Config)
+	assert.Equal(t, []Anomaly{{Path: "prometheusCfg.port", Reason: "changed by a factor of 1.1, more than 1.05x", Action: AnomalyRequireApproval}}, pending.Anomalies)
+	assert.Equal(t, pending.Anomalies, cm.Status().ApprovalRequired)
+
+	assert.NoError(t, cm.Approve(ctx))
+	assert.Same(t, held, cm.GetConfig())
+	assert.Nil(t, cm.ApprovalPending())
+	assert.ErrorIs(t, cm.Approve(ctx), ErrNoPendingApproval)
+
+	// 挂起的配置可以被丢弃
+	mockLoader.EXPECT().LoadConfig(gomock.Any()).Return(&entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9000}}, nil)
+	assert.NoError(t, cm.Reload(ctx))
+	assert.Equal(t, 9000, cm.ApprovalPending().Config.PrometheusCfg.Port)
+	assert.NoError(t, cm.DenyPending())
+	assert.Same(t, held, cm.GetConfig())
+	assert.ErrorIs(t, cm.DenyPending(), ErrNoPendingApproval)
+}
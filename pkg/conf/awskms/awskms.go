@@ -0,0 +1,45 @@
+// Package awskms 基于 AWS SDK v2 的 KMS 客户端实现 config.KMSClient 供 KMSKeySource 解密数据密钥
+package awskms
+
+import (
+	"context"
+
+	config "github.com/omeyang/practices/pkg/conf"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+)
+
+// API AWS KMS 的解密接口 *kms.Client 实现了该接口
+type API interface {
+	Decrypt(ctx context.Context, params *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error)
+}
+
+// Client AWS KMS 解密客户端
+type Client struct {
+	api               API
+	keyID             string
+	encryptionContext map[string]string
+}
+
+var _ config.KMSClient = (*Client)(nil)
+
+// New 使用已创建的 AWS KMS 客户端创建解密客户端
+// keyID 为加密数据密钥的 KMS 密钥 可以为空 对称密钥的密文中已包含密钥标识
+// encryptionContext 须与加密数据密钥时使用的一致 没有时传 nil
+func New(api API, keyID string, encryptionContext map[string]string) *Client {
+	return &Client{api: api, keyID: keyID, encryptionContext: encryptionContext}
+}
+
+// Decrypt 解密 KMS 加密的数据密钥 实现 config.KMSClient
+func (c *Client) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	input := &kms.DecryptInput{CiphertextBlob: ciphertext, EncryptionContext: c.encryptionContext}
+	if c.keyID != "" {
+		input.KeyId = aws.String(c.keyID)
+	}
+	out, err := c.api.Decrypt(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return out.Plaintext, nil
+}
@@ -0,0 +1,39 @@
+package awskms
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	config "github.com/omeyang/practices/pkg/conf"
+
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeAPI 记录请求并返回固定明文
+type fakeAPI struct {
+	input *kms.DecryptInput
+}
+
+func (f *fakeAPI) Decrypt(_ context.Context, params *kms.DecryptInput, _ ...func(*kms.Options)) (*kms.DecryptOutput, error) {
+	f.input = params
+	return &kms.DecryptOutput{Plaintext: bytes.Repeat([]byte{1}, 32)}, nil
+}
+
+// TestClient 测试解密请求携带密钥标识与加密上下文 并作为 KMSKeySource 的客户端使用
+func TestClient(t *testing.T) {
+	api := &fakeAPI{}
+	source := config.NewKMSKeySource(New(api, "alias/app", map[string]string{"app": "web"}), []byte("wrapped"))
+	key, err := source.DataKey(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, bytes.Repeat([]byte{1}, 32), key)
+	assert.Equal(t, []byte("wrapped"), api.input.CiphertextBlob)
+	assert.Equal(t, "alias/app", *api.input.KeyId)
+	assert.Equal(t, map[string]string{"app": "web"}, api.input.EncryptionContext)
+
+	// 未指定密钥时由密文中的标识决定
+	_, err = New(api, "", nil).Decrypt(context.Background(), []byte("wrapped"))
+	assert.NoError(t, err)
+	assert.Nil(t, api.input.KeyId)
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff 重试间隔策略 attempt 为已失败的次数 从 1 开始
+type Backoff interface {
+	Next(attempt int) time.Duration
+}
+
+// ConstantBackoff 固定的重试间隔
+type ConstantBackoff time.Duration
+
+// Next 实现 Backoff
+func (b ConstantBackoff) Next(int) time.Duration {
+	return time.Duration(b)
+}
+
+// ExponentialBackoff 指数增长的重试间隔 叠加随机抖动 避免多个实例同时重试
+type ExponentialBackoff struct {
+	Initial    time.Duration // 首次重试间隔
+	Max        time.Duration // 间隔上限 0 表示不限制
+	Multiplier float64       // 增长倍数 小于等于 1 时取 2
+	Jitter     float64       // 抖动比例 取值 [0, 1] 间隔在 ±Jitter 范围内随机
+}
+
+// Next 实现 Backoff
+func (b ExponentialBackoff) Next(attempt int) time.Duration {
+	multiplier := b.Multiplier
+	if multiplier <= 1 {
+		multiplier = 2
+	}
+	d := float64(b.Initial) * math.Pow(multiplier, float64(max(attempt, 1)-1))
+	if b.Max > 0 && d > float64(b.Max) {
+		d = float64(b.Max)
+	}
+	if jitter := min(max(b.Jitter, 0), 1); jitter > 0 {
+		d *= 1 - jitter + 2*jitter*rand.Float64()
+	}
+	if d > math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(d)
+}
+
+// delay 返回第 attempt 次失败后的等待时间 未设置 Backoff 时使用固定的 Timeout
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	if p.Backoff != nil {
+		return p.Backoff.Next(attempt)
+	}
+	return p.Timeout
+}
+
+// exhausted 判断是否应停止重试 已达到最大次数或等待后将超过 MaxElapsed
+func (p RetryPolicy) exhausted(attempt int, elapsed, wait time.Duration) bool {
+	if attempt >= max(p.MaxAttempts, 1) {
+		return true
+	}
+	return p.MaxElapsed > 0 && elapsed+wait > p.MaxElapsed
+}
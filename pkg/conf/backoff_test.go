@@ -0,0 +1,93 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/omeyang/practices/internal/entity"
+	mocks "github.com/omeyang/practices/mocks/conf"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+// TestExponentialBackoff 测试指数增长 上限与抖动范围
+func TestExponentialBackoff(t *testing.T) {
+	b := ExponentialBackoff{Initial: 100 * time.Millisecond, Max: time.Second}
+	assert.Equal(t, 100*time.Millisecond, b.Next(1))
+	assert.Equal(t, 400*time.Millisecond, b.Next(3))
+	assert.Equal(t, time.Second, b.Next(10))
+	assert.Equal(t, time.Second, b.Next(1000))
+
+	b.Jitter = 0.2
+	for i := 0; i < 100; i++ {
+		d := b.Next(2)
+		assert.GreaterOrEqual(t, d, 160*time.Millisecond)
+		assert.LessOrEqual(t, d, 240*time.Millisecond)
+	}
+
+	assert.Equal(t, time.Minute, RetryPolicy{Timeout: time.Minute}.delay(3))
+	assert.Equal(t, time.Second, RetryPolicy{Timeout: time.Minute, Backoff: ConstantBackoff(time.Second)}.delay(3))
+}
+
+// TestCfgManager_ReloadBackoff 测试重试等待期间不阻塞读取 超过最长耗时后停止重试
+func TestCfgManager_ReloadBackoff(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return("/path/to/config").AnyTimes()
+	loadErr := errors.New("load error")
+	mockLoader.EXPECT().LoadConfig(gomock.Any()).Return(nil, loadErr).Times(3)
+
+	clock := NewFakeClock(time.Now())
+	cm := NewConfigManager[entity.AppConf](mockLoader, nil, zap.NewNop(), RetryPolicy{
+		MaxAttempts: 10,
+		Backoff:     ExponentialBackoff{Initial: time.Second},
+		MaxElapsed:  5 * time.Second,
+	}, WithClock(clock))
+	current := &entity.AppConf{}
+	cm.config.Store(current)
+
+	done := make(chan error, 1)
+	go func() { done <- cm.reload(context.Background()) }()
+
+	// 第一次失败后等待 1s 期间仍可读取配置
+	clock.BlockUntil(1)
+	assert.Same(t, current, cm.GetConfig())
+	clock.Advance(time.Second)
+
+	// 第二次失败后等待 2s 第三次失败后再等待 4s 将超过 5s 不再重试
+	clock.BlockUntil(1)
+	clock.Advance(2 * time.Second)
+	assert.ErrorIs(t, <-done, loadErr)
+	assert.Same(t, current, cm.GetConfig())
+}
+
+// TestCfgManager_ReloadCanceled 测试 ctx 结束时停止重试
+func TestCfgManager_ReloadCanceled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return("/path/to/config").AnyTimes()
+	loadErr := errors.New("load error")
+	mockLoader.EXPECT().LoadConfig(gomock.Any()).Return(nil, loadErr).Times(1)
+
+	clock := NewFakeClock(time.Now())
+	cm := NewConfigManager[entity.AppConf](mockLoader, nil, zap.NewNop(), RetryPolicy{
+		MaxAttempts: 3,
+		Timeout:     time.Hour,
+	}, WithClock(clock))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- cm.reload(ctx) }()
+
+	clock.BlockUntil(1)
+	cancel()
+	assert.ErrorIs(t, <-done, loadErr)
+}
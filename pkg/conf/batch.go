@@ -0,0 +1,81 @@
+package config
+
+import (
+	"sort"
+	"time"
+)
+
+// eventBatcher 合并突发的文件事件 在事件停止 quiet 时长后触发一次重载
+// 持续不断的事件最多延迟 maxWait 即强制触发 同一路径的多个事件合并记录
+type eventBatcher struct {
+	quiet   time.Duration
+	maxWait time.Duration
+	clock   Clock
+	timer   Timer
+	first   time.Time           // 本批次第一个事件的时间
+	count   int                 // 本批次的事件数
+	paths   map[string]struct{} // 本批次涉及的路径
+}
+
+// newEventBatcher 创建事件合并器 未启用时返回 nil
+func newEventBatcher(quiet, maxWait time.Duration, clock Clock) *eventBatcher {
+	if quiet <= 0 {
+		return nil
+	}
+	return &eventBatcher{quiet: quiet, maxWait: maxWait, clock: clock, paths: map[string]struct{}{}}
+}
+
+// add 记录路径上的一个事件并重新计时
+func (b *eventBatcher) add(now time.Time, path string) {
+	if b.count == 0 {
+		b.first = now
+	}
+	b.count++
+	b.paths[path] = struct{}{}
+
+	delay := b.quiet
+	if b.maxWait > 0 {
+		if remaining := b.first.Add(b.maxWait).Sub(now); remaining < delay {
+			delay = remaining
+		}
+	}
+	if b.timer == nil {
+		b.timer = b.clock.NewTimer(delay)
+		return
+	}
+	if !b.timer.Stop() {
+		select {
+		case <-b.timer.C():
+		default:
+		}
+	}
+	b.timer.Reset(delay)
+}
+
+// C 返回批次到期通道 没有待处理事件时返回 nil 通道
+func (b *eventBatcher) C() <-chan time.Time {
+	if b == nil || b.count == 0 {
+		return nil
+	}
+	return b.timer.C()
+}
+
+// flush 结束当前批次 返回合并的事件数与涉及的路径
+func (b *eventBatcher) flush() (int, []string) {
+	n := b.count
+	paths := make([]string, 0, len(b.paths))
+	for path := range b.paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	b.count = 0
+	clear(b.paths)
+	return n, paths
+}
+
+// stop 停止计时器
+func (b *eventBatcher) stop() {
+	if b != nil && b.timer != nil {
+		b.timer.Stop()
+	}
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/omeyang/practices/internal/entity"
+	mocks "github.com/omeyang/practices/mocks/conf"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+// TestEventBatcher 测试事件合并计时
+func TestEventBatcher(t *testing.T) {
+	assert.Nil(t, newEventBatcher(0, 0, RealClock))
+	assert.Nil(t, (*eventBatcher)(nil).C())
+
+	clock := NewFakeClock(time.Now())
+	b := newEventBatcher(20*time.Millisecond, 0, clock)
+	defer b.stop()
+	assert.Nil(t, b.C())
+
+	for i := 0; i < 5; i++ {
+		b.add(clock.Now(), []string{"/etc/app/b.yaml", "/etc/app/a.yaml"}[i%2])
+		clock.Advance(5 * time.Millisecond)
+	}
+	assertNotFired(t, b.C())
+	clock.Advance(15 * time.Millisecond)
+	<-b.C()
+	events, paths := b.flush()
+	assert.Equal(t, 5, events)
+	assert.Equal(t, []string{"/etc/app/a.yaml", "/etc/app/b.yaml"}, paths)
+	assert.Nil(t, b.C())
+}
+
+// TestEventBatcherMaxWait 测试持续事件下的最长等待
+func TestEventBatcherMaxWait(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	b := newEventBatcher(time.Hour, 30*time.Millisecond, clock)
+	defer b.stop()
+
+	b.add(clock.Now(), "/path/to/config")
+	clock.Advance(20 * time.Millisecond)
+	b.add(clock.Now(), "/path/to/config")
+	assertNotFired(t, b.C())
+	clock.Advance(10 * time.Millisecond)
+	select {
+	case <-b.C():
+	default:
+		t.Fatal("max wait not honored")
+	}
+}
+
+// TestCfgManager_EventBatching 测试一批事件只触发一次重载
+func TestCfgManager_EventBatching(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockWatcher := mocks.NewMockWatcherInterface(ctrl)
+	logger, _ := zap.NewDevelopment()
+
+	events := make(chan fsnotify.Event, 10)
+	reloaded := make(chan struct{}, 10)
+	mockLoader.EXPECT().GetConfigPath().Return("/path/to/config").AnyTimes()
+	mockLoader.EXPECT().LoadConfig(gomock.Any()).Return(&entity.AppConf{}, nil).Times(1)
+	mockLoader.EXPECT().LoadConfig(gomock.Any()).DoAndReturn(func(context.Context) (*entity.AppConf, error) {
+		reloaded <- struct{}{}
+		return &entity.AppConf{}, nil
+	}).Times(1)
+	mockWatcher.EXPECT().Add("/path/to/config").Return(nil)
+	mockWatcher.EXPECT().Events().Return(events).AnyTimes()
+	mockWatcher.EXPECT().Errors().Return(make(chan error)).AnyTimes()
+	closed := make(chan struct{})
+	mockWatcher.EXPECT().Close().DoAndReturn(func() error {
+		close(closed)
+		return nil
+	})
+
+	cm := NewConfigManager[entity.AppConf](mockLoader, mockWatcher, logger, RetryPolicy{MaxAttempts: 1},
+		WithEventBatching(20*time.Millisecond, 0))
+	ctx, cancel := context.WithCancel(context.Background())
+	assert.NoError(t, cm.Init(ctx))
+
+	for i := 0; i < 5; i++ {
+		events <- fsnotify.Event{Name: "/path/to/config", Op: fsnotify.Write}
+	}
+	<-reloaded
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, reloaded)
+
+	cancel()
+	<-closed
+}
+
+// TestCfgManager_AtomicSaveEvents 测试原子保存的重命名事件后重新监听 并与后续事件合并为一次重载
+func TestCfgManager_AtomicSaveEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockWatcher := mocks.NewMockWatcherInterface(ctrl)
+
+	events := make(chan fsnotify.Event, 10)
+	reloaded := make(chan struct{}, 10)
+	mockLoader.EXPECT().GetConfigPath().Return("/path/to/config").AnyTimes()
+	mockLoader.EXPECT().LoadConfig(gomock.Any()).Return(&entity.AppConf{}, nil).Times(1)
+	mockLoader.EXPECT().LoadConfig(gomock.Any()).DoAndReturn(func(context.Context) (*entity.AppConf, error) {
+		reloaded <- struct{}{}
+		return &entity.AppConf{}, nil
+	}).Times(1)
+	// 初始监听与重命名后的重新监听
+	mockWatcher.EXPECT().Add("/path/to/config").Return(nil).Times(2)
+	mockWatcher.EXPECT().Events().Return(events).AnyTimes()
+	mockWatcher.EXPECT().Errors().Return(make(chan error)).AnyTimes()
+	closed := make(chan struct{})
+	mockWatcher.EXPECT().Close().DoAndReturn(func() error {
+		close(closed)
+		return nil
+	})
+
+	cm := NewConfigManager[entity.AppConf](mockLoader, mockWatcher, zap.NewNop(), RetryPolicy{MaxAttempts: 1},
+		WithEventBatching(20*time.Millisecond, 0), WithAtomicSaveEvents())
+	ctx, cancel := context.WithCancel(context.Background())
+	assert.NoError(t, cm.Init(ctx))
+
+	events <- fsnotify.Event{Name: "/path/to/config", Op: fsnotify.Rename}
+	events <- fsnotify.Event{Name: "/path/to/config", Op: fsnotify.Chmod}
+	events <- fsnotify.Event{Name: "/path/to/config", Op: fsnotify.Create}
+	<-reloaded
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, reloaded)
+
+	cancel()
+	<-closed
+}
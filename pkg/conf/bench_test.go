@@ -0,0 +1,311 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/spf13/afero"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// 基准测试使用的配置规模 以服务条目数量衡量
+var benchSizes = []struct {
+	name     string
+	services int
+}{
+	{"Small", 10},
+	{"Medium", 100},
+	{"Large", 1000},
+}
+
+// benchConf 基准测试使用的配置类型
+type benchConf struct {
+	Name     string                  `yaml:"name" json:"name" validate:"required"`
+	Services map[string]benchService `yaml:"services" json:"services"`
+}
+
+// benchService 单个服务条目
+type benchService struct {
+	Host    string   `yaml:"host" json:"host" validate:"required"`
+	Port    int      `yaml:"port" json:"port" validate:"range=1-65535"`
+	Enable  bool     `yaml:"enable" json:"enable"`
+	Weights []int    `yaml:"weights" json:"weights"`
+	Tags    []string `yaml:"tags" json:"tags"`
+}
+
+// newBenchConf 生成包含 n 个服务条目的配置 offset 用于生成不同的取值
+func newBenchConf(n, offset int) *benchConf {
+	conf := &benchConf{Name: "bench", Services: make(map[string]benchService, n)}
+	for i := 0; i < n; i++ {
+		conf.Services[fmt.Sprintf("svc-%04d", i)] = benchService{
+			Host:    fmt.Sprintf("10.0.%d.%d", i/256, i%256),
+			Port:    8000 + i + offset,
+			Enable:  i%2 == 0,
+			Weights: []int{1, 2, 3},
+			Tags:    []string{"zone-a", "tier-1"},
+		}
+	}
+	return conf
+}
+
+// benchFile 将配置按格式写入内存文件系统并打开
+func benchFile(b *testing.B, fs afero.Fs, path string, data []byte) afero.File {
+	b.Helper()
+	if err := afero.WriteFile(fs, path, data, 0o600); err != nil {
+		b.Fatal(err)
+	}
+	file, err := fs.Open(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { file.Close() })
+	return file
+}
+
+// BenchmarkParse 测量 JSON 与 YAML 解析 以及带配置树变换的解析
+func BenchmarkParse(b *testing.B) {
+	for _, size := range benchSizes {
+		conf := newBenchConf(size.services, 0)
+		for _, format := range []struct {
+			ext        string
+			marshal    func(any) ([]byte, error)
+			transforms []Transform
+		}{
+			{".json", jsonCodec.marshal, nil},
+			{".yaml", yaml.Marshal, nil},
+			{".yaml", yaml.Marshal, []Transform{ExpandEnv()}},
+		} {
+			name := size.name + "/" + format.ext[1:]
+			if len(format.transforms) > 0 {
+				name += "+transforms"
+			}
+			b.Run(name, func(b *testing.B) {
+				data, err := format.marshal(conf)
+				if err != nil {
+					b.Fatal(err)
+				}
+				parser, err := NewParser[benchConf](format.ext, zap.NewNop(), WithTransforms(format.transforms...))
+				if err != nil {
+					b.Fatal(err)
+				}
+				file := benchFile(b, afero.NewMemMapFs(), "/bench"+format.ext, data)
+
+				b.SetBytes(int64(len(data)))
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if _, err := file.Seek(0, io.SeekStart); err != nil {
+						b.Fatal(err)
+					}
+					if _, err := parser.Parse(file); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// validateBudgets 校验阶段每个服务条目允许的分配次数 超出时基准测试失败
+// 预算留有较大余量 只用于发现随条目数量超线性增长的退化 修改校验流程后应同步调整
+var validateBudgets = map[string]float64{
+	"validate": 48, // 标签规则逐字段拼接路径 加上检查端口唯一的校验器
+	"probe":    4,  // 只有与条目数量无关的固定开销 按条目摊薄
+}
+
+// reportBudget 按服务条目报告耗时与分配次数 分配次数超出预算时基准测试失败
+func reportBudget(b *testing.B, stage string, services int, op func()) {
+	b.Helper()
+	b.StopTimer()
+	perService := float64(services)
+	allocs := testing.AllocsPerRun(10, op) / perService
+	b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N)/perService, "ns/service")
+	b.ReportMetric(allocs, "allocs/service")
+	if budget := validateBudgets[stage]; allocs > budget {
+		b.Errorf("%s: %.1f allocs/service exceeds budget %.0f", stage, allocs, budget)
+	}
+}
+
+// BenchmarkValidate 测量候选配置的校验 探测与变更比较 校验与探测按服务条目检查分配预算
+func BenchmarkValidate(b *testing.B) {
+	for _, size := range benchSizes {
+		oldConf, newConf := newBenchConf(size.services, 0), newBenchConf(size.services, 1)
+
+		b.Run(size.name+"/validate", func(b *testing.B) {
+			cm := NewConfigManager[benchConf](nil, nil, zap.NewNop(), RetryPolicy{})
+			cm.AddValidator(ValidatorFunc[benchConf](func(candidate *benchConf) error {
+				var errs MultiError
+				ports := make(map[int]string, len(candidate.Services))
+				for name, svc := range candidate.Services {
+					if other, ok := ports[svc.Port]; ok {
+						errs.Addf(joinPath(joinPath("services", name), "port"), "duplicates port of %s", other)
+					}
+					ports[svc.Port] = name
+				}
+				return errs.ErrorOrNil()
+			}))
+			validate := func() {
+				if err := cm.Validate(newConf); err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				validate()
+			}
+			reportBudget(b, "validate", size.services, validate)
+		})
+
+		b.Run(size.name+"/probe", func(b *testing.B) {
+			cm := NewConfigManager[benchConf](nil, nil, zap.NewNop(), RetryPolicy{})
+			cm.AddProbe("ports", func(_ context.Context, candidate *benchConf) error {
+				for name, svc := range candidate.Services {
+					if svc.Port <= 0 || svc.Port > 65535 {
+						return fmt.Errorf("%s: invalid port %d", name, svc.Port)
+					}
+				}
+				return nil
+			})
+			ctx := context.Background()
+			probe := func() {
+				if err := cm.Probe(ctx, newConf); err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				probe()
+			}
+			reportBudget(b, "probe", size.services, probe)
+		})
+
+		b.Run(size.name+"/diff", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := Diff(oldConf, newConf); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkMerge 测量基础配置与覆盖配置的多源合并
+func BenchmarkMerge(b *testing.B) {
+	for _, size := range benchSizes {
+		b.Run(size.name, func(b *testing.B) {
+			fs := afero.NewMemMapFs()
+			base, err := yaml.Marshal(newBenchConf(size.services, 0))
+			if err != nil {
+				b.Fatal(err)
+			}
+			override, err := yaml.Marshal(newBenchConf(size.services/2, 1))
+			if err != nil {
+				b.Fatal(err)
+			}
+			if err := afero.WriteFile(fs, "/base.yaml", base, 0o600); err != nil {
+				b.Fatal(err)
+			}
+			if err := afero.WriteFile(fs, "/override.yaml", override, 0o600); err != nil {
+				b.Fatal(err)
+			}
+			loader := NewMultiSourceLoader[benchConf](zap.NewNop(), FileSource(fs, "/base.yaml"), FileSource(fs, "/override.yaml"))
+			ctx := context.Background()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := loader.LoadConfig(ctx); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkNotify 测量新配置生效时对同步处理函数与订阅者的通知
+func BenchmarkNotify(b *testing.B) {
+	for _, subscribers := range []int{1, 10, 100} {
+		b.Run(fmt.Sprintf("Subscribers%d", subscribers), func(b *testing.B) {
+			cm := NewConfigManager[benchConf](nil, nil, zap.NewNop(), RetryPolicy{}, WithSyncApply())
+			cm.OnChange(func(_, _ *benchConf) error { return nil })
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			for i := 0; i < subscribers; i++ {
+				cm.SubscribeFunc(ctx, func(_, _ *benchConf) {})
+			}
+			configs := []*benchConf{newBenchConf(100, 0), newBenchConf(100, 1)}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := cm.storeConfig(configs[i%2]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkGetConfig 测量多核并发读取配置 对比读锁与分片快照
+func BenchmarkGetConfig(b *testing.B) {
+	for _, bench := range []struct {
+		name string
+		opts []Option
+	}{
+		{"RWMutex", nil},
+		{"Sharded", []Option{WithShardedSnapshot(0)}},
+		{"CopyOnRead", []Option{WithCopyOnRead()}},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			cm := NewConfigManager[benchConf](nil, nil, zap.NewNop(), RetryPolicy{}, bench.opts...)
+			if err := cm.storeConfig(newBenchConf(10, 0)); err != nil {
+				b.Fatal(err)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if cm.GetConfig() == nil {
+						b.Fatal("config is nil")
+					}
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkSnapshot 测量不同配置规模下深拷贝读取的开销 对比只复制单个条目的 Section
+func BenchmarkSnapshot(b *testing.B) {
+	for _, size := range benchSizes {
+		cm := NewConfigManager[benchConf](nil, nil, zap.NewNop(), RetryPolicy{})
+		if err := cm.storeConfig(newBenchConf(size.services, 0)); err != nil {
+			b.Fatal(err)
+		}
+		b.Run(size.name+"/Snapshot", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if cm.Snapshot().Config == nil {
+					b.Fatal("config is nil")
+				}
+			}
+		})
+		b.Run(size.name+"/Section", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				svc := Section[benchConf](cm, func(c *benchConf) benchService { return c.Services["svc-0000"] })
+				if svc.Host == "" {
+					b.Fatal("service not found")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package config
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/spf13/afero"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// BundleManifestName 配置包中清单文件的名称
+const BundleManifestName = "manifest.yaml"
+
+// BundleManifest 配置包清单 片段按声明顺序合并 后声明的覆盖先声明的
+type BundleManifest struct {
+	Version string           `yaml:"version"` // 配置包版本
+	Files   []BundleFragment `yaml:"files"`   // 配置片段
+}
+
+// BundleFragment 配置包中的一个片段
+type BundleFragment struct {
+	Name   string `yaml:"name"`   // 包内路径
+	SHA256 string `yaml:"sha256"` // 内容的 sha256 校验值 为空表示不校验
+}
+
+// BundleLoader 从 tar/tar.gz/zip 配置包加载配置 实现 CfgLoader
+// 配置包包含清单与多个配置片段 校验通过后合并为一份配置
+type BundleLoader[T any] struct {
+	fs         afero.Fs
+	path       string
+	logger     *zap.Logger
+	transforms []Transform
+	mu         sync.RWMutex
+	version    string // 最近一次加载的配置包版本
+}
+
+// NewBundleLoader 创建配置包加载器 transforms 作用于合并后的配置树
+func NewBundleLoader[T any](fs afero.Fs, path string, logger *zap.Logger, transforms ...Transform) *BundleLoader[T] {
+	return &BundleLoader[T]{
+		fs:         fs,
+		path:       NormalizePath(path),
+		logger:     logger,
+		transforms: transforms,
+	}
+}
+
+// GetConfigPath 返回配置包路径
+func (b *BundleLoader[T]) GetConfigPath() string {
+	return b.path
+}
+
+// Version 返回最近一次成功加载的配置包版本
+func (b *BundleLoader[T]) Version() string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.version
+}
+
+// LoadConfig 读取配置包 校验清单中的全部片段并合并
+func (b *BundleLoader[T]) LoadConfig(_ context.Context) (*T, error) {
+	data, err := afero.ReadFile(b.fs, b.path)
+	if err != nil {
+		return nil, fmt.Errorf("read bundle %s: %w", b.path, err)
+	}
+	files, err := readArchive(b.path, data)
+	if err != nil {
+		return nil, fmt.Errorf("read bundle %s: %w", b.path, err)
+	}
+
+	manifest, err := parseManifest(files)
+	if err != nil {
+		return nil, fmt.Errorf("bundle %s: %w", b.path, err)
+	}
+	tree, err := mergeFragments(manifest, files)
+	if err != nil {
+		return nil, fmt.Errorf("bundle %s: %w", b.path, err)
+	}
+	for _, transform := range b.transforms {
+		if err := transform(tree); err != nil {
+			return nil, fmt.Errorf("bundle %s: %w", b.path, err)
+		}
+	}
+
+	var config T
+	if err := decodeTree(tree, &config); err != nil {
+		return nil, fmt.Errorf("bundle %s: %w", b.path, err)
+	}
+
+	b.mu.Lock()
+	b.version = manifest.Version
+	b.mu.Unlock()
+	b.logger.Info("Loaded config bundle", zap.String("path", b.path), zap.String("version", manifest.Version), zap.Int("fragments", len(manifest.Files)))
+	return &config, nil
+}
+
+// parseManifest 解析并检查清单
+func parseManifest(files map[string][]byte) (*BundleManifest, error) {
+	raw, ok := files[BundleManifestName]
+	if !ok {
+		return nil, fmt.Errorf("missing %s", BundleManifestName)
+	}
+	var manifest BundleManifest
+	if err := yaml.Unmarshal(raw, &manifest); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", BundleManifestName, err)
+	}
+	if len(manifest.Files) == 0 {
+		return nil, fmt.Errorf("%s lists no files", BundleManifestName)
+	}
+	return &manifest, nil
+}
+
+// mergeFragments 校验所有片段后按清单顺序合并 任一片段有误则整体失败
+func mergeFragments(manifest *BundleManifest, files map[string][]byte) (map[string]any, error) {
+	var errs MultiError
+	tree := map[string]any{}
+	for _, fragment := range manifest.Files {
+		data, ok := files[path.Clean(fragment.Name)]
+		if !ok {
+			errs.Add(fragment.Name, "listed in manifest but missing from bundle")
+			continue
+		}
+		if fragment.SHA256 != "" {
+			sum := sha256.Sum256(data)
+			if actual := hex.EncodeToString(sum[:]); !strings.EqualFold(actual, fragment.SHA256) {
+				errs.Addf(fragment.Name, "sha256 mismatch: manifest %s, actual %s", fragment.SHA256, actual)
+				continue
+			}
+		}
+
+		c, err := codecFor(path.Ext(fragment.Name))
+		if err != nil {
+			errs.Add(fragment.Name, err.Error())
+			continue
+		}
+		part := map[string]any{}
+		if err := c.decode(bytes.NewReader(data), &part); err != nil && !errors.Is(err, io.EOF) {
+			errs.Append(asFieldErrors(c.locate(fragment.Name, data, err))...)
+			continue
+		}
+		mergeTree(tree, part)
+	}
+	return tree, errs.ErrorOrNil()
+}
+
+// readArchive 读取 tar/tar.gz/zip 配置包中的全部普通文件
+func readArchive(name string, data []byte) (map[string][]byte, error) {
+	if strings.HasSuffix(strings.ToLower(name), ".zip") {
+		return readZip(data)
+	}
+	// tar 包可能经过 gzip 压缩
+	plain, err := readMaybeGzip(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return readTar(plain)
+}
+
+// readTar 读取 tar 包
+func readTar(data []byte) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		files[path.Clean(hdr.Name)] = content
+	}
+}
+
+// readZip 读取 zip 包
+func readZip(data []byte) (map[string][]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		content, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		files[path.Clean(f.Name)] = content
+	}
+	return files, nil
+}
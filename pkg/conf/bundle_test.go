@@ -0,0 +1,104 @@
+package config
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/omeyang/practices/internal/entity"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// bundleFiles 测试配置包内容
+func bundleFiles(baseSum string) map[string]string {
+	return map[string]string{
+		"manifest.yaml":    fmt.Sprintf("version: v42\nfiles:\n  - name: base.yaml\n    sha256: %s\n  - name: conf.d/prod.json\n", baseSum),
+		"base.yaml":        "prometheusCfg:\n  enable: true\n  port: 9090\n",
+		"conf.d/prod.json": `{"prometheusCfg": {"port": 9100}}`,
+	}
+}
+
+// sha256Hex 计算 sha256
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// makeTarGz 构造 tar.gz 配置包
+func makeTarGz(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(zw)
+	for name, content := range files {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+// makeZip 构造 zip 配置包
+func makeZip(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		assert.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+// TestBundleLoader 测试加载并合并配置包
+func TestBundleLoader(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	files := bundleFiles(sha256Hex("prometheusCfg:\n  enable: true\n  port: 9090\n"))
+
+	fs := afero.NewMemMapFs()
+	assert.NoError(t, afero.WriteFile(fs, "/config.tar.gz", makeTarGz(t, files), 0o644))
+	assert.NoError(t, afero.WriteFile(fs, "/config.zip", makeZip(t, files), 0o644))
+
+	for _, path := range []string{"/config.tar.gz", "/config.zip"} {
+		t.Run(path, func(t *testing.T) {
+			loader := NewBundleLoader[entity.AppConf](fs, path, logger)
+			config, err := loader.LoadConfig(context.Background())
+			assert.NoError(t, err)
+			assert.True(t, config.PrometheusCfg.Enable)
+			assert.Equal(t, 9100, config.PrometheusCfg.Port)
+			assert.Equal(t, "v42", loader.Version())
+		})
+	}
+}
+
+// TestBundleLoaderVerify 测试校验失败时整体拒绝并汇总全部问题
+func TestBundleLoaderVerify(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	files := bundleFiles("deadbeef")
+	delete(files, "conf.d/prod.json")
+
+	fs := afero.NewMemMapFs()
+	assert.NoError(t, afero.WriteFile(fs, "/config.tar.gz", makeTarGz(t, files), 0o644))
+
+	_, err := NewBundleLoader[entity.AppConf](fs, "/config.tar.gz", logger).LoadConfig(context.Background())
+	var multi *MultiError
+	assert.ErrorAs(t, err, &multi)
+	assert.Equal(t, 2, multi.Len())
+
+	delete(files, "manifest.yaml")
+	assert.NoError(t, afero.WriteFile(fs, "/config.tar.gz", makeTarGz(t, files), 0o644))
+	_, err = NewBundleLoader[entity.AppConf](fs, "/config.tar.gz", logger).LoadConfig(context.Background())
+	assert.ErrorContains(t, err, "missing manifest.yaml")
+}
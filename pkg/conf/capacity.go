@@ -0,0 +1,95 @@
+package config
+
+import (
+	"fmt"
+	"sync"
+)
+
+// CapacityBounds 在线调整容量的边界与滞回
+type CapacityBounds struct {
+	Min        int // 容量下限 小于 1 时按 1 处理
+	Max        int // 容量上限 0 表示不限
+	Hysteresis int // 目标与当前容量相差不超过该值时不调整 避免小幅波动反复调整
+}
+
+// CapacityController 将配置中的容量取值安全地应用到在线调整的回调 如 WorkerConf.PoolSize 与工作池的 Resize
+// 目标超出边界时截断到边界 变化小于滞回时忽略 回调失败时保持当前容量
+type CapacityController struct {
+	mu      sync.Mutex
+	bounds  CapacityBounds
+	resize  func(n int) error
+	current int
+}
+
+// NewCapacityController 创建容量控制器 current 为当前的实际容量 resize 将容量调整为 n
+func NewCapacityController(current int, bounds CapacityBounds, resize func(n int) error) *CapacityController {
+	bounds.Min = max(bounds.Min, 1)
+	if bounds.Max > 0 {
+		bounds.Max = max(bounds.Max, bounds.Min)
+	}
+	return &CapacityController{bounds: bounds, resize: resize, current: current}
+}
+
+// Current 返回当前容量
+func (c *CapacityController) Current() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.current
+}
+
+// Update 按目标容量调整 返回调整后的容量 目标小于等于 0 表示未设置 保持当前容量
+func (c *CapacityController) Update(target int) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if target <= 0 {
+		return c.current, nil
+	}
+	target = max(target, c.bounds.Min)
+	if c.bounds.Max > 0 {
+		target = min(target, c.bounds.Max)
+	}
+	// 截断到边界的目标不受滞回限制 保证容量能够到达边界
+	delta := target - c.current
+	if delta == 0 || (abs(delta) <= c.bounds.Hysteresis && target != c.bounds.Min && target != c.bounds.Max) {
+		return c.current, nil
+	}
+	if err := c.resize(target); err != nil {
+		return c.current, fmt.Errorf("resize from %d to %d: %w", c.current, target, err)
+	}
+	c.current = target
+	return c.current, nil
+}
+
+// abs 返回整数的绝对值
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// BindCapacity 在配置段 section 变化时以 pick 选出的容量调用 controller.Update 如
+//
+//	pool := config.NewCapacityController(8, config.CapacityBounds{Min: 1, Max: 256, Hysteresis: 2}, workers.Resize)
+//	err := config.BindCapacity(cm, "workerCfg.poolSize", func(c *entity.AppConf) int {
+//		if c.WorkerCfg == nil {
+//			return 0
+//		}
+//		return c.WorkerCfg.PoolSize
+//	}, pool)
+//
+// 已加载配置时立即按当前配置调整一次 并返回调整的错误 之后的调整失败按 OnSectionChange 的规则重试并记录到 Status
+func BindCapacity[T any](cm *CfgManager[T], section string, pick func(*T) int, controller *CapacityController) error {
+	var err error
+	if config := cm.sharedConfig(); config != nil {
+		_, err = controller.Update(pick(config))
+	}
+	cm.OnSectionChange(section, func(_, newConfig *T) error {
+		if newConfig == nil {
+			return nil
+		}
+		_, err := controller.Update(pick(newConfig))
+		return err
+	})
+	return err
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/omeyang/practices/internal/entity"
+	mocks "github.com/omeyang/practices/mocks/conf"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+// TestCapacityController_Update 测试边界截断 滞回与回调失败
+func TestCapacityController_Update(t *testing.T) {
+	var sizes []int
+	fail := false
+	controller := NewCapacityController(8, CapacityBounds{Min: 2, Max: 64, Hysteresis: 2}, func(n int) error {
+		if fail {
+			return errors.New("pool draining")
+		}
+		sizes = append(sizes, n)
+		return nil
+	})
+
+	tests := []struct {
+		target   int
+		expected int
+	}{
+		{10, 8},    // 在滞回范围内
+		{0, 8},     // 未设置
+		{16, 16},   // 正常调整
+		{1000, 64}, // 截断到上限
+		{63, 64},   // 在滞回范围内
+		{1, 2},     // 截断到下限
+		{3, 2},     // 在滞回范围内
+	}
+	for _, tt := range tests {
+		actual, err := controller.Update(tt.target)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.expected, actual, "target %d", tt.target)
+	}
+	assert.Equal(t, []int{16, 64, 2}, sizes)
+
+	fail = true
+	actual, err := controller.Update(32)
+	assert.ErrorContains(t, err, "resize from 2 to 32: pool draining")
+	assert.Equal(t, 2, actual)
+	assert.Equal(t, 2, controller.Current())
+}
+
+// TestBindCapacity 测试按当前配置立即调整 并在配置段变化时再次调整
+func TestBindCapacity(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return("/path/to/config").AnyTimes()
+
+	cm := NewConfigManager[entity.AppConf](mockLoader, nil, zap.NewNop(), RetryPolicy{})
+	assert.NoError(t, cm.storeConfig(&entity.AppConf{WorkerCfg: &entity.WorkerConf{PoolSize: 4}}))
+
+	sizes := make(chan int, 4)
+	controller := NewCapacityController(8, CapacityBounds{Max: 32}, func(n int) error {
+		sizes <- n
+		return nil
+	})
+	assert.NoError(t, BindCapacity(cm, "workerCfg.poolSize", func(c *entity.AppConf) int {
+		if c.WorkerCfg == nil {
+			return 0
+		}
+		return c.WorkerCfg.PoolSize
+	}, controller))
+	assert.Equal(t, 4, <-sizes)
+
+	ctx := context.Background()
+	assert.NoError(t, cm.Set(ctx, &entity.AppConf{WorkerCfg: &entity.WorkerConf{PoolSize: 100}}))
+	select {
+	case size := <-sizes:
+		assert.Equal(t, 32, size)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for resize")
+	}
+	assert.Eventually(t, func() bool { return controller.Current() == 32 }, time.Second, time.Millisecond)
+}
@@ -0,0 +1,258 @@
+package config
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+// TLSConf 证书配置 启用 WithCertificateWatch 时配置结构体中的 TLSConf 字段会被自动发现并监听
+type TLSConf struct {
+	CertFile string `yaml:"certFile"`         // 证书文件
+	KeyFile  string `yaml:"keyFile"`          // 私钥文件
+	CAFile   string `yaml:"caFile,omitempty"` // CA 证书文件 可选
+}
+
+// files 返回证书配置引用的文件
+func (c TLSConf) files() []string {
+	var files []string
+	for _, file := range []string{c.CertFile, c.KeyFile, c.CAFile} {
+		if file != "" {
+			files = append(files, NormalizePath(file))
+		}
+	}
+	return files
+}
+
+// CertificatesRotated 证书文件发生变化后重新加载的结果 不会触发配置重载
+// 证书与私钥分别写入时 中间状态可能加载失败 写入完成后会再次收到事件
+type CertificatesRotated struct {
+	Path        string           // TLSConf 的配置键路径 如 server.tls
+	TLS         TLSConf          // 证书配置
+	Certificate *tls.Certificate // 重新加载的证书 加载失败时为空
+	Err         error            // 加载错误
+}
+
+// certEventBuffer 证书事件通道的容量 通道满时丢弃最旧的事件
+const certEventBuffer = 8
+
+// certWatch 证书文件的监听状态
+type certWatch struct {
+	mu     sync.Mutex
+	confs  map[string]TLSConf          // 键路径 -> 证书配置
+	certs  map[string]*tls.Certificate // 键路径 -> 当前证书
+	files  map[string]struct{}         // 正在监听的证书文件
+	hook   func(event CertificatesRotated)
+	events chan CertificatesRotated
+}
+
+// CertificateEvents 返回证书轮换事件 通道满时丢弃最旧的事件
+func (cm *CfgManager[T]) CertificateEvents() <-chan CertificatesRotated {
+	return cm.certs.events
+}
+
+// OnCertificatesRotated 设置证书轮换时调用的钩子 钩子在后台协程中执行
+func (cm *CfgManager[T]) OnCertificatesRotated(hook func(event CertificatesRotated)) {
+	cm.certs.mu.Lock()
+	defer cm.certs.mu.Unlock()
+	cm.certs.hook = hook
+}
+
+// GetCertificate 返回键路径处证书配置的当前证书 用作 tls.Config.GetCertificate 实现热轮换
+func (cm *CfgManager[T]) GetCertificate(path string) func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		cm.certs.mu.Lock()
+		defer cm.certs.mu.Unlock()
+		if cert := cm.certs.certs[path]; cert != nil {
+			return cert, nil
+		}
+		return nil, fmt.Errorf("no certificate loaded for %s", path)
+	}
+}
+
+// syncCertificates 按新配置中的证书配置更新监听的文件 并加载新增或变化的证书
+func (cm *CfgManager[T]) syncCertificates(config *T) {
+	if !cm.opts.certificateWatch {
+		return
+	}
+	confs := findTLSConfs(config)
+
+	cm.certs.mu.Lock()
+	defer cm.certs.mu.Unlock()
+	files := map[string]struct{}{}
+	for _, conf := range confs {
+		for _, file := range conf.files() {
+			files[file] = struct{}{}
+		}
+	}
+	for file := range files {
+		if _, ok := cm.certs.files[file]; ok {
+			continue
+		}
+		if err := cm.watchers.add(file); err != nil {
+			cm.logger.Error("Failed to watch certificate file", zap.String("path", file), zap.Error(err))
+		}
+	}
+	for file := range cm.certs.files {
+		if _, ok := files[file]; ok {
+			continue
+		}
+		if err := cm.watchers.remove(file); err != nil {
+			cm.logger.Warn("Failed to stop watching certificate file", zap.String("path", file), zap.Error(err))
+		}
+	}
+
+	certs := make(map[string]*tls.Certificate, len(confs))
+	for path, conf := range confs {
+		if old, ok := cm.certs.confs[path]; ok && old == conf && cm.certs.certs[path] != nil {
+			certs[path] = cm.certs.certs[path]
+			continue
+		}
+		cert, err := loadCertificate(conf)
+		if err != nil {
+			cm.logger.Error("Failed to load certificate", zap.String("path", path), zap.Error(err))
+			continue
+		}
+		certs[path] = cert
+	}
+	cm.certs.confs, cm.certs.certs, cm.certs.files = confs, certs, files
+}
+
+// certificateEvent 处理证书文件的事件 返回 false 表示事件与证书无关
+// 证书文件的变化只重新加载证书 不重新加载配置
+func (cm *CfgManager[T]) certificateEvent(event fsnotify.Event) bool {
+	if !cm.opts.certificateWatch {
+		return false
+	}
+	name := NormalizePath(event.Name)
+	cm.certs.mu.Lock()
+	_, watched := cm.certs.files[name]
+	cm.certs.mu.Unlock()
+	if !watched {
+		return false
+	}
+
+	switch {
+	case event.Op&(fsnotify.Write|fsnotify.Create) != 0:
+	case event.Op&(fsnotify.Rename|fsnotify.Remove) != 0:
+		// 证书通常以原子替换的方式更新 重新监听新文件
+		if !cm.watchers.rewatch(name) {
+			return true
+		}
+	default:
+		return true
+	}
+	cm.rotateCertificates(name)
+	return true
+}
+
+// rotateCertificates 重新加载引用了该文件的全部证书配置并发出事件
+func (cm *CfgManager[T]) rotateCertificates(file string) {
+	cm.certs.mu.Lock()
+	defer cm.certs.mu.Unlock()
+	for path, conf := range cm.certs.confs {
+		if !slices.Contains(conf.files(), file) {
+			continue
+		}
+		event := CertificatesRotated{Path: path, TLS: conf}
+		event.Certificate, event.Err = loadCertificate(conf)
+		if event.Err != nil {
+			cm.logger.Warn("Failed to reload rotated certificate", zap.String("path", path), zap.String("file", file), zap.Error(event.Err))
+		} else {
+			cm.certs.certs[path] = event.Certificate
+			cm.logger.Info("Certificate rotated", zap.String("path", path), zap.String("file", file))
+		}
+		cm.publishCertificateEvent(event)
+	}
+}
+
+// publishCertificateEvent 发出证书事件 调用方需持有 certs.mu
+func (cm *CfgManager[T]) publishCertificateEvent(event CertificatesRotated) {
+	for {
+		select {
+		case cm.certs.events <- event:
+			if hook := cm.certs.hook; hook != nil {
+				go hook(event)
+			}
+			return
+		default:
+		}
+		// 丢弃未被消费的最旧事件
+		select {
+		case <-cm.certs.events:
+		default:
+		}
+	}
+}
+
+// loadCertificate 加载证书与私钥
+func loadCertificate(conf TLSConf) (*tls.Certificate, error) {
+	certPEM, err := os.ReadFile(conf.CertFile)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := os.ReadFile(conf.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	return &cert, nil
+}
+
+// findTLSConfs 返回配置中全部证书文件非空的 TLSConf 键为配置键路径
+func findTLSConfs(config any) map[string]TLSConf {
+	confs := map[string]TLSConf{}
+	collectTLSConfs(reflect.ValueOf(config), "", confs)
+	return confs
+}
+
+// tlsConfType TLSConf 的反射类型
+var tlsConfType = reflect.TypeOf(TLSConf{})
+
+// collectTLSConfs 递归收集 TLSConf 字段 包括映射中的取值
+func collectTLSConfs(v reflect.Value, path string, confs map[string]TLSConf) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	switch {
+	case !v.IsValid():
+	case v.Type() == tlsConfType:
+		if conf := v.Interface().(TLSConf); conf.CertFile != "" && conf.KeyFile != "" {
+			confs[path] = conf
+		}
+	case v.Kind() == reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+			collectTLSConfs(v.Field(i), joinPath(path, name), confs)
+		}
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		iter := v.MapRange()
+		for iter.Next() {
+			collectTLSConfs(iter.Value(), joinPath(path, iter.Key().String()), confs)
+		}
+	}
+}
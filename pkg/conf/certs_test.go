@@ -0,0 +1,111 @@
+package config
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	mocks "github.com/omeyang/practices/mocks/conf"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+// writeCertificate 生成指定序列号的自签名证书 写入 certFile 与 keyFile
+func writeCertificate(t *testing.T, certFile, keyFile string, serial int64) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	assert.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+}
+
+// serverConf 引用证书的配置类型
+type serverConf struct {
+	Port    int                `yaml:"port"`
+	TLS     TLSConf            `yaml:"tls"`
+	Clients map[string]TLSConf `yaml:"clients,omitempty"`
+}
+
+// TestCfgManager_CertificateWatch 测试证书文件变化时只轮换证书 不重新加载配置
+func TestCfgManager_CertificateWatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeCertificate(t, certFile, keyFile, 1)
+
+	// 没有 LoadConfig 的期望 证书事件不能触发配置重载
+	mockLoader := mocks.NewMockCfgLoader[serverConf](ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return(filepath.Join(dir, "server.yaml")).AnyTimes()
+	mockWatcher := mocks.NewMockWatcherInterface(ctrl)
+	mockWatcher.EXPECT().Add(certFile).Return(nil)
+	mockWatcher.EXPECT().Add(keyFile).Return(nil)
+
+	cm := NewConfigManager[serverConf](mockLoader, mockWatcher, zap.NewNop(), RetryPolicy{}, WithCertificateWatch())
+	ctx := context.Background()
+	assert.NoError(t, cm.Set(ctx, &serverConf{Port: 8443, TLS: TLSConf{CertFile: certFile, KeyFile: keyFile}}))
+
+	getCertificate := cm.GetCertificate("tls")
+	cert, err := getCertificate(nil)
+	assert.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), leaf.SerialNumber.Int64())
+	_, err = cm.GetCertificate("clients.api")(nil)
+	assert.Error(t, err)
+
+	writeCertificate(t, certFile, keyFile, 2)
+	cm.processFSNotifyEvent(ctx, fsnotify.Event{Name: keyFile, Op: fsnotify.Write}, nil)
+
+	event := <-cm.CertificateEvents()
+	assert.Equal(t, "tls", event.Path)
+	assert.NoError(t, event.Err)
+	cert, err = getCertificate(nil)
+	assert.NoError(t, err)
+	assert.Same(t, event.Certificate, cert)
+	leaf, err = x509.ParseCertificate(cert.Certificate[0])
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), leaf.SerialNumber.Int64())
+
+	// 配置不再引用证书时停止监听
+	mockWatcher.EXPECT().Remove(certFile).Return(nil)
+	mockWatcher.EXPECT().Remove(keyFile).Return(nil)
+	assert.NoError(t, cm.Set(ctx, &serverConf{Port: 8080}))
+	assert.False(t, cm.certificateEvent(fsnotify.Event{Name: certFile, Op: fsnotify.Write}))
+}
+
+// TestFindTLSConfs 测试发现结构体与映射中的证书配置
+func TestFindTLSConfs(t *testing.T) {
+	config := &serverConf{
+		TLS:     TLSConf{CertFile: "a.crt", KeyFile: "a.key"},
+		Clients: map[string]TLSConf{"api": {CertFile: "b.crt", KeyFile: "b.key"}, "empty": {}},
+	}
+	assert.Equal(t, map[string]TLSConf{
+		"tls":         {CertFile: "a.crt", KeyFile: "a.key"},
+		"clients.api": {CertFile: "b.crt", KeyFile: "b.key"},
+	}, findTLSConfs(config))
+	assert.Empty(t, findTLSConfs((*serverConf)(nil)))
+}
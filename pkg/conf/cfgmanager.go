@@ -2,175 +2,440 @@ package config
 
 import (
 	"context"
-	"errors"
 	"sync"
 	"sync/atomic"
 	"time"
 
-	"github.com/omeyang/practices/internal/entity"
-
 	"github.com/fsnotify/fsnotify"
 	"go.uber.org/zap"
 )
 
 // CfgLoader 接口的定义需要根据你的实际需求来实现。
-type CfgLoader interface {
-	LoadConfig(ctx context.Context) (*entity.AppConf, error)
+type CfgLoader[T any] interface {
+	LoadConfig(ctx context.Context) (*T, error)
 	GetConfigPath() string
 }
 
 // RetryPolicy 重试策略
 type RetryPolicy struct {
 	MaxAttempts int           // 最大重试次数
-	Timeout     time.Duration // 超时时间
+	Timeout     time.Duration // 未设置 Backoff 时的固定重试间隔
+	Backoff     Backoff       // 重试间隔策略 如 ExponentialBackoff
+	MaxElapsed  time.Duration // 重试的最长总耗时 0 表示不限制
 }
 
 // CfgManager 管理配置加载和监听配置变化，以及通知其他部分应用程序的错误。
-type CfgManager struct {
-	loader      CfgLoader            // 配置加载器
-	config      atomic.Value         // 原子值用来存储配置
-	configChan  chan *entity.AppConf // 配置通道
-	errorChan   chan error           // 错误通道
-	watcher     WatcherInterface     // 配置监听器 使用接口
-	rwMutex     sync.RWMutex         // 读写锁 用于保护配置在加载和更新时的并发访问
-	once        sync.Once            // 用于确保只初始化一次
-	logger      *zap.Logger          // 日志
-	retryPolicy RetryPolicy          // 重试策略
+// T 为应用的配置结构体 由加载器解析得到
+type CfgManager[T any] struct {
+	loader      CfgLoader[T]          // 配置加载器
+	config      atomic.Value          // 原子值用来存储配置
+	configChan  chan *T               // 配置通道
+	errorChan   chan error            // 错误通道
+	watchers    *watcherRegistry      // 配置监听器
+	rwMutex     sync.RWMutex          // 读写锁 用于保护配置在更新时的并发访问
+	applyMu     sync.Mutex            // 串行化配置的替换 同步模式下变更处理函数执行期间不持有 rwMutex
+	reloadMu    sync.Mutex            // 串行化重新加载 加载与重试等待期间不持有 rwMutex
+	once        sync.Once             // 用于确保只初始化一次
+	logger      *zap.Logger           // 日志
+	retryPolicy RetryPolicy           // 重试策略
+	opts        options               // 可选项
+	pending     pendingActivation[T]  // 等待生效的配置
+	previous    *T                    // 上一份生效的配置 用于回滚
+	subscribers subscribers[T]        // 配置变更订阅者
+	changes     changeHandlers[T]     // 配置变更处理函数
+	sections    sectionHandlers[T]    // 配置段处理函数的失败状态
+	restart     restartCoordinator[T] // 需要重启才能生效的配置键
+	probes      probeSet[T]           // 预热探测
+	validators  validatorSet[T]       // 自定义校验器
+	defaults    defaultsSet[T]        // 默认值函数
+	tenants     tenantCache[T]        // 已解析的租户配置
+	ready       chan struct{}         // 首次存储配置后关闭
+	readyOnce   sync.Once             // 确保 ready 只关闭一次
+	startup     *StartupReport        // 启动汇总 启用 WithStartupReport 时记录
+	versions    versionHistory[T]     // 最近生效的配置版本
+	certs       certWatch             // 配置引用的证书文件
+	metrics     *configMetrics        // 配置生命周期指标 未启用时为空
+	snapshot    *shardedSnapshot[T]   // GetConfig 的分片快照 未启用时为空
+	writes      selfWrites            // 自身写入配置文件后的内容指纹
+	health      sourceHealth          // 配置源最近一次健康探测的结果
+	hooks       reloadHooks[T]        // 重载前后的钩子
+	approval    approvalGate[T]       // 异常检查与等待批准的配置
+	shadow      shadowSlot[T]         // 影子配置与其订阅者
+	watchStats  watchStats            // 文件事件的统计
+	reconciled  reconcileState        // 与配置源核对的结果
+	life        lifecycle             // 后台协程的生命周期
 }
 
 // NewConfigManager 创建新的配置管理器
-func NewConfigManager(loader CfgLoader, watcher WatcherInterface, logger *zap.Logger, retryPolicy RetryPolicy) *CfgManager {
-	return &CfgManager{
+func NewConfigManager[T any](loader CfgLoader[T], watcher WatcherInterface, logger *zap.Logger, retryPolicy RetryPolicy, opts ...Option) *CfgManager[T] {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	var metrics *configMetrics
+	if o.metrics != nil {
+		source := ""
+		if loader != nil {
+			source = loader.GetConfigPath()
+		}
+		metrics = newConfigMetrics(o.metrics, source, logger)
+	}
+	watchers := newWatcherRegistry(watcher, o.pollingFallback, o.clock, logger)
+	watchers.kubernetes = o.kubernetesWatch
+	watchers.metrics = metrics
+	var snapshot *shardedSnapshot[T]
+	if o.snapshotShards > 0 {
+		snapshot = newShardedSnapshot[T](o.snapshotShards)
+	}
+	return &CfgManager[T]{
 		loader:      loader,
-		configChan:  make(chan *entity.AppConf, 1),
+		configChan:  make(chan *T, 1),
 		errorChan:   make(chan error, 1),
-		watcher:     watcher,
+		watchers:    watchers,
 		logger:      logger,
 		retryPolicy: retryPolicy,
+		opts:        o,
+		restart:     restartCoordinator[T]{events: make(chan RestartRequired[T], 1)},
+		ready:       make(chan struct{}),
+		versions:    versionHistory[T]{limit: o.versionHistory},
+		certs:       certWatch{events: make(chan CertificatesRotated, certEventBuffer)},
+		metrics:     metrics,
+		snapshot:    snapshot,
 	}
 }
 
-// GetConfig 获取当前的配置
-func (cm *CfgManager) GetConfig() *entity.AppConf {
+// GetConfig 获取当前的配置 启用 WithShardedSnapshot 时不加锁读取分片快照
+// 返回的配置与其他调用方共享 不应修改 启用 WithCopyOnRead 时返回深拷贝
+func (cm *CfgManager[T]) GetConfig() *T {
+	if cm.opts.copyOnRead {
+		return DeepCopy(cm.sharedConfig())
+	}
+	return cm.sharedConfig()
+}
+
+// sharedConfig 返回当前配置的共享指针 尚未加载配置时返回 nil
+func (cm *CfgManager[T]) sharedConfig() *T {
+	if cm.snapshot != nil {
+		return cm.snapshot.load()
+	}
 	cm.rwMutex.RLock()
 	defer cm.rwMutex.RUnlock()
-	return cm.config.Load().(*entity.AppConf)
+	config, _ := cm.config.Load().(*T)
+	return config
 }
 
-// Init 初始化配置加载和更新机制
-func (cm *CfgManager) Init(ctx context.Context) error {
+// Init 初始化配置加载和更新机制 Close 之后调用返回 ErrManagerClosed
+func (cm *CfgManager[T]) Init(ctx context.Context) error {
+	if cm.life.closing() {
+		return ErrManagerClosed
+	}
 	var initErr error
 	cm.once.Do(func() {
+		cm.life.mu.Lock()
+		defer cm.life.mu.Unlock()
+		if cm.life.closed {
+			initErr = ErrManagerClosed
+			return
+		}
+		ctx, cm.life.cancel = context.WithCancel(ctx)
 		initErr = cm.loadAndWatchConfig(ctx)
 	})
 	return initErr
 }
 
 // loadAndWatchConfig 加载并监听配置的变化
-func (cm *CfgManager) loadAndWatchConfig(ctx context.Context) error {
+func (cm *CfgManager[T]) loadAndWatchConfig(ctx context.Context) error {
+	start := cm.opts.clock.Now()
+	var warnings []string
 	newConfig, err := cm.loader.LoadConfig(ctx)
 	if err != nil {
 		cm.logger.Error("Failed to load initial config", zap.Error(err))
 		return err
 	}
-	cm.config.Store(newConfig)
+	if err := cm.applyDefaults(newConfig); err != nil {
+		cm.logger.Error("Failed to apply defaults to initial config", zap.Error(err))
+		return err
+	}
+	if err := cm.Validate(newConfig); err != nil {
+		cm.logger.Error("Initial config failed validation", zap.Error(err))
+		return err
+	}
+	if at := effectiveTime(newConfig); at.After(cm.opts.clock.Now()) {
+		if cm.opts.startupReport {
+			warnings = append(warnings, "initial config is not yet effective, applied immediately (effectiveAt "+at.Format(time.RFC3339)+")")
+		} else {
+			cm.logger.Warn("Initial config is not yet effective, applying immediately", zap.Time("effectiveAt", at))
+		}
+	}
+	cm.applyMu.Lock()
+	err = cm.storeConfig(newConfig)
+	cm.applyMu.Unlock()
+	if err != nil {
+		cm.logger.Error("Initial config rejected by change handlers", zap.Error(err))
+		return err
+	}
+	cm.reportApply(ctx, nil)
 
 	configPath := cm.loader.GetConfigPath()
-	if err := cm.watcher.Add(configPath); err != nil {
+	if err := cm.watchers.add(configPath); err != nil {
 		cm.logger.Error("Failed to watch config file", zap.String("path", configPath), zap.Error(err))
 		return err
 	}
+	if cm.opts.startupReport {
+		report := cm.buildStartupReport(start, warnings)
+		cm.rwMutex.Lock()
+		cm.startup = report
+		cm.rwMutex.Unlock()
+		cm.logStartupReport(report)
+	}
+
+	cm.spawn(func() { cm.handleFSNotify(ctx) })
+
+	if cm.opts.reloadSchedule != nil {
+		cm.spawn(func() { cm.runReloadSchedule(ctx) })
+	}
 
-	go cm.handleFSNotify(ctx)
+	if cm.opts.healthInterval > 0 {
+		cm.startHealthChecks(ctx)
+	}
+
+	if cm.opts.reconcileEvery > 0 {
+		cm.spawn(func() { cm.runReconcile(ctx) })
+	}
 
 	return nil
 }
 
 // handleFSNotify 处理配置系统通知事件
-func (cm *CfgManager) handleFSNotify(ctx context.Context) {
+func (cm *CfgManager[T]) handleFSNotify(ctx context.Context) {
+	batcher := newEventBatcher(cm.opts.batchQuiet, cm.opts.batchMaxWait, cm.opts.clock)
+	defer batcher.stop()
+
 	for {
+		watchers := cm.watchers.channels()
 		select {
 		case <-ctx.Done():
 			cm.cleanupWatcher()
 			return
-		case event, ok := <-cm.watcher.Events():
+		case <-cm.watchers.done:
+			cm.logger.Info("Config watcher closed")
+			return
+		case event, ok := <-watchers.events:
 			if !ok {
 				cm.logger.Info("Config watcher events channel closed")
 				return
 			}
-			cm.processFSNotifyEvent(ctx, event)
-		case err, ok := <-cm.watcher.Errors():
+			cm.processFSNotifyEvent(ctx, event, batcher)
+		case err, ok := <-watchers.errors:
 			if !ok {
 				cm.logger.Info("Config watcher errors channel closed")
 				return
 			}
 			cm.logger.Error("Watcher error", zap.Error(err))
+		case event := <-watchers.pollEvents:
+			cm.processFSNotifyEvent(ctx, event, batcher)
+		case err := <-watchers.pollErrors:
+			cm.logger.Error("Polling watcher error", zap.Error(err))
+		case <-cm.watchers.changed:
+		case <-batcher.C():
+			events, paths := batcher.flush()
+			cm.logger.Debug("Coalesced config events into one reload", zap.Int("events", events), zap.Strings("paths", paths))
+			cm.watchStats.reload(events)
+			cm.metrics.observeWatchCoalesced(events - 1)
+			cm.reloadConfig(ctx)
+		}
+	}
+}
+
+// processFSNotifyEvent 处理配置系统通知事件 启用合并时推迟到突发事件结束后统一重载
+func (cm *CfgManager[T]) processFSNotifyEvent(ctx context.Context, event fsnotify.Event, batcher *eventBatcher) {
+	cm.watchStats.received(event.Op, cm.opts.clock.Now())
+	cm.metrics.observeWatchEvent(event.Op)
+	if !cm.reloadsOnEvent(event) {
+		cm.watchStats.ignore()
+		cm.metrics.observeWatchIgnored()
+		return
+	}
+	if batcher != nil {
+		batcher.add(cm.opts.clock.Now(), event.Name)
+		return
+	}
+	cm.watchStats.reload(1)
+	cm.reloadConfig(ctx)
+}
+
+// reloadsOnEvent 判断文件事件是否需要重载配置 证书事件与自身写入的事件不重载
+func (cm *CfgManager[T]) reloadsOnEvent(event fsnotify.Event) bool {
+	if cm.certificateEvent(event) {
+		return false
+	}
+	if cm.opts.kubernetesWatch {
+		if !cm.watchers.kubernetesChanged(event) {
+			return false
 		}
+	} else if !cm.triggersReload(event) {
+		return false
 	}
+	return !cm.ownWrite(event)
 }
 
-// processFSNotifyEvent 处理配置系统通知事件
-func (cm *CfgManager) processFSNotifyEvent(ctx context.Context, event fsnotify.Event) {
-	if event.Op&fsnotify.Write == fsnotify.Write {
-		cm.reloadConfig(ctx)
+// triggersReload 判断文件事件是否需要重载
+// 启用原子保存事件时 Create 同样触发重载 Rename 与 Remove 后重新添加监听 新文件已就位时触发重载
+// 加载器为 DirLoader 等文件集合时 目录中匹配文件的任何增删改都触发重载
+func (cm *CfgManager[T]) triggersReload(event fsnotify.Event) bool {
+	if changed, ok := cm.fileSetChanged(event); ok {
+		return changed
+	}
+	switch {
+	case event.Op&reloadOps != 0:
+		return true
+	case !cm.opts.atomicSave:
+		return false
+	case event.Op&fsnotify.Create != 0:
+		return true
+	case event.Op&(fsnotify.Rename|fsnotify.Remove) != 0:
+		return cm.watchers.rewatch(event.Name)
+	default:
+		return false
+	}
+}
+
+// reloadConfig 重新加载配置 失败时通知错误通道
+func (cm *CfgManager[T]) reloadConfig(ctx context.Context) {
+	if err := cm.reload(ctx); err != nil {
+		// Notify other parts of the application
+		select {
+		case cm.errorChan <- err:
+		case <-ctx.Done():
+		}
 	}
 }
 
-// reloadConfig 重新加载配置
-func (cm *CfgManager) reloadConfig(ctx context.Context) {
-	cm.rwMutex.Lock()
-	defer cm.rwMutex.Unlock()
+// reload 按重试策略重新加载并应用配置 至少尝试一次
+// 加载与重试等待不持有读写锁 读取配置不会被缓慢或失败的加载阻塞 ctx 结束时停止重试
+func (cm *CfgManager[T]) reload(ctx context.Context) error {
+	cm.reloadMu.Lock()
+	defer cm.reloadMu.Unlock()
 
 	var err error
-	for attempt := 1; attempt <= cm.retryPolicy.MaxAttempts; attempt++ {
+	start := cm.opts.clock.Now()
+	for attempt := 1; ; attempt++ {
 		newConfig, loadErr := cm.loader.LoadConfig(ctx)
 		if loadErr == nil {
-			cm.config.Store(newConfig)
-			cm.logger.Info("Config reloaded", zap.String("configPath", cm.loader.GetConfigPath()))
-			return
+			// 默认值 校验与探测失败的配置重试也不会成功 保留当前配置
+			if err = cm.applyDefaults(newConfig); err != nil {
+				cm.logger.Error("Failed to apply defaults to reloaded config", zap.Error(err), zap.String("configPath", cm.loader.GetConfigPath()))
+				break
+			}
+			if err = cm.applyLoaded(ctx, newConfig); err == nil {
+				now := cm.opts.clock.Now()
+				cm.metrics.observeReload(nil, now.Sub(start), now)
+				return nil
+			}
+			break
 		}
 		err = loadErr
-		cm.logger.Error("Error reloading config, retrying...", zap.Error(err), zap.Int("attempt", attempt), zap.String("configPath", cm.loader.GetConfigPath()))
-		time.Sleep(cm.retryPolicy.Timeout)
+		wait := cm.retryPolicy.delay(attempt)
+		if cm.retryPolicy.exhausted(attempt, cm.opts.clock.Now().Sub(start), wait) {
+			break
+		}
+		cm.logger.Error("Error reloading config, retrying...", zap.Error(err), zap.Int("attempt", attempt), zap.Duration("backoff", wait), zap.String("configPath", cm.loader.GetConfigPath()))
+		cm.metrics.observeRetry()
+		if sleepErr := sleepClock(ctx, cm.opts.clock, wait); sleepErr != nil {
+			break
+		}
 	}
 
-	cm.errorChan <- err // Notify other parts of the application
+	now := cm.opts.clock.Now()
+	cm.metrics.observeReload(err, now.Sub(start), now)
+	cm.reportApply(ctx, err)
 	cm.logger.Error("Failed to reload config after retries", zap.Error(err), zap.String("configPath", cm.loader.GetConfigPath()))
+	return err
 }
 
-// cleanupWatcher 清理配置监听器
-func (cm *CfgManager) cleanupWatcher() {
-	cm.logger.Info("Config watcher stopped", zap.String("configPath", cm.loader.GetConfigPath()))
-	if cm.watcher != nil {
-		err := cm.watcher.Close()
-		if err != nil {
-			cm.logger.Error("Failed to close watcher", zap.Error(err))
-		}
-		cm.watcher = nil
+// applyLoaded 校验 探测并应用已加载且已填充默认值的配置 调用方需持有 reloadMu
+// 配置被异常检查扣留等待批准时返回 nil 失败的原因已记录日志
+func (cm *CfgManager[T]) applyLoaded(ctx context.Context, newConfig *T) error {
+	if err := cm.Validate(newConfig); err != nil {
+		cm.logger.Error("Reloaded config failed validation", zap.Error(err), zap.String("configPath", cm.loader.GetConfigPath()))
+		return err
 	}
+	if err := cm.Probe(ctx, newConfig); err != nil {
+		cm.logger.Error("Reloaded config failed probes", zap.Error(err), zap.String("configPath", cm.loader.GetConfigPath()))
+		return err
+	}
+	if err := cm.runPreReloadHooks(newConfig); err != nil {
+		cm.logger.Error("Reloaded config vetoed by hook", zap.Error(err), zap.String("configPath", cm.loader.GetConfigPath()))
+		return err
+	}
+	held, err := cm.checkAnomalies(newConfig)
+	if err != nil {
+		cm.logger.Error("Reloaded config rejected by anomaly check", zap.Error(err), zap.String("configPath", cm.loader.GetConfigPath()))
+		return err
+	}
+	if held {
+		cm.logger.Warn("Reloaded config held for approval", zap.String("configPath", cm.loader.GetConfigPath()))
+		return nil
+	}
+	// 被处理函数拒绝的配置重试也不会成功
+	oldConfig, applied, err := cm.applyReloaded(ctx, newConfig)
+	if err != nil {
+		return err
+	}
+	if applied {
+		cm.runPostReloadHooks(oldConfig, newConfig)
+	}
+	return nil
 }
 
-// AddWatcher 添加配置监听器
-func (cm *CfgManager) AddWatcher(filePath string) error {
-	cm.rwMutex.Lock()
-	defer cm.rwMutex.Unlock()
-	if cm.watcher == nil {
-		return errors.New("watcher not initialized")
+// applyReloaded 持有 applyMu 应用重新加载的配置 返回替换前的配置与新配置是否已立即生效
+func (cm *CfgManager[T]) applyReloaded(ctx context.Context, config *T) (*T, bool, error) {
+	cm.applyMu.Lock()
+	defer cm.applyMu.Unlock()
+	oldConfig, _ := cm.config.Load().(*T)
+	applied, err := cm.applyConfigAfter(ctx, config, StaggerDelay(cm.opts.instance, cm.opts.staggerWindow))
+	if err != nil {
+		return nil, false, err
+	}
+	if applied {
+		cm.logger.Info("Config reloaded", zap.String("configPath", cm.loader.GetConfigPath()))
+		cm.reportApply(ctx, nil)
 	}
-	return cm.watcher.Add(filePath)
+	return oldConfig, applied, nil
 }
 
-// RemoveWatcher 移除监听器
-func (cm *CfgManager) RemoveWatcher(filePath string) error {
-	cm.rwMutex.Lock()
-	defer cm.rwMutex.Unlock()
-	if cm.watcher == nil {
-		return errors.New("watcher not initialized")
+// cleanupWatcher 清理配置监听器 由 Close 停止时变更处理函数留给 Close 处理完已排队的配置
+func (cm *CfgManager[T]) cleanupWatcher() {
+	cm.logger.Info("Config watcher stopped", zap.String("configPath", cm.loader.GetConfigPath()))
+	if err := cm.watchers.close(); err != nil {
+		cm.logger.Error("Failed to close watcher", zap.Error(err))
+	}
+	if !cm.life.closing() {
+		cm.stopChangeHandlers()
 	}
-	return cm.watcher.Remove(filePath)
+}
+
+// AddWatcher 添加配置监听器 监听器关闭后返回 ErrWatcherClosed
+func (cm *CfgManager[T]) AddWatcher(filePath string) error {
+	return cm.watchers.add(NormalizePath(filePath))
+}
+
+// AddWatchers 添加多个监听路径 全部成功或全部不添加 任一路径失败时撤销本次已添加的路径
+// 返回的错误汇总全部失败的路径 监听器关闭后返回 ErrWatcherClosed 已在监听的路径不受影响
+func (cm *CfgManager[T]) AddWatchers(filePaths []string) error {
+	paths := make([]string, len(filePaths))
+	for i, filePath := range filePaths {
+		paths[i] = NormalizePath(filePath)
+	}
+	return cm.watchers.addAll(paths)
+}
+
+// RemoveWatcher 移除监听器 监听器关闭后返回 ErrWatcherClosed
+func (cm *CfgManager[T]) RemoveWatcher(filePath string) error {
+	return cm.watchers.remove(NormalizePath(filePath))
 }
 
 // ListenForConfigErrors 监听配置错误
-func (cm *CfgManager) ListenForConfigErrors() <-chan error {
+func (cm *CfgManager[T]) ListenForConfigErrors() <-chan error {
 	return cm.errorChan
 }
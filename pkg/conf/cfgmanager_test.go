@@ -3,6 +3,7 @@ package config
 import (
 	"context"
 	"errors"
+	"syscall"
 	"testing"
 	"time"
 
@@ -10,6 +11,7 @@ import (
 	mocks "github.com/omeyang/practices/mocks/conf"
 
 	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 	"go.uber.org/zap"
@@ -20,7 +22,7 @@ func TestNewConfigManager(t *testing.T) {
 	defer ctrl.Finish()
 
 	// 创建模拟对象
-	mockLoader := mocks.NewMockCfgLoader(ctrl)
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
 	mockWatcher := mocks.NewMockWatcherInterface(ctrl)
 	logger, _ := zap.NewDevelopment()
 
@@ -31,12 +33,12 @@ func TestNewConfigManager(t *testing.T) {
 	}
 
 	// 调用 NewConfigManager
-	cm := NewConfigManager(mockLoader, mockWatcher, logger, retryPolicy)
+	cm := NewConfigManager[entity.AppConf](mockLoader, mockWatcher, logger, retryPolicy)
 
 	// 验证返回的 CfgManager 是否正确
 	assert.NotNil(t, cm, "ConfigManager should not be nil")
 	assert.Equal(t, mockLoader, cm.loader, "Loader should be set correctly")
-	assert.Equal(t, mockWatcher, cm.watcher, "Watcher should be set correctly")
+	assert.Equal(t, mockWatcher, cm.watchers.primary, "Watcher should be set correctly")
 	assert.Equal(t, logger, cm.logger, "Logger should be set correctly")
 	assert.Equal(t, retryPolicy, cm.retryPolicy, "RetryPolicy should be set correctly")
 	assert.NotNil(t, cm.configChan, "Config channel should be initialized")
@@ -48,7 +50,7 @@ func TestCfgManager_Init(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	mockLoader := mocks.NewMockCfgLoader(ctrl)
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
 	mockWatcher := mocks.NewMockWatcherInterface(ctrl)
 	logger, _ := zap.NewDevelopment()
 
@@ -63,7 +65,7 @@ func TestCfgManager_Init(t *testing.T) {
 	mockWatcher.EXPECT().Events().Return(mockEvents).AnyTimes()
 	mockWatcher.EXPECT().Errors().Return(mockErrors).AnyTimes()
 
-	cm := NewConfigManager(mockLoader, mockWatcher, logger, RetryPolicy{
+	cm := NewConfigManager[entity.AppConf](mockLoader, mockWatcher, logger, RetryPolicy{
 		MaxAttempts: 3,
 		Timeout:     2 * time.Second,
 	})
@@ -82,11 +84,11 @@ func TestCfgManager_AddWatcher(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	mockLoader := mocks.NewMockCfgLoader(ctrl)
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
 	mockWatcher := mocks.NewMockWatcherInterface(ctrl)
 	logger, _ := zap.NewDevelopment()
 
-	cm := NewConfigManager(mockLoader, mockWatcher, logger, RetryPolicy{
+	cm := NewConfigManager[entity.AppConf](mockLoader, mockWatcher, logger, RetryPolicy{
 		MaxAttempts: 3,
 		Timeout:     2 * time.Second,
 	})
@@ -103,11 +105,11 @@ func TestCfgManager_RemoveWatcher(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	mockLoader := mocks.NewMockCfgLoader(ctrl)
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
 	mockWatcher := mocks.NewMockWatcherInterface(ctrl)
 	logger, _ := zap.NewDevelopment()
 
-	cm := NewConfigManager(mockLoader, mockWatcher, logger, RetryPolicy{
+	cm := NewConfigManager[entity.AppConf](mockLoader, mockWatcher, logger, RetryPolicy{
 		MaxAttempts: 3,
 		Timeout:     2 * time.Second,
 	})
@@ -124,11 +126,11 @@ func TestCfgManager_ListenForConfigErrors(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	mockLoader := mocks.NewMockCfgLoader(ctrl)
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
 	mockWatcher := mocks.NewMockWatcherInterface(ctrl)
 	logger, _ := zap.NewDevelopment()
 
-	cm := NewConfigManager(mockLoader, mockWatcher, logger, RetryPolicy{
+	cm := NewConfigManager[entity.AppConf](mockLoader, mockWatcher, logger, RetryPolicy{
 		MaxAttempts: 3,
 		Timeout:     2 * time.Second,
 	})
@@ -143,30 +145,100 @@ func TestCfgManager_reloadConfig(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	mockLoader := mocks.NewMockCfgLoader(ctrl)
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
 	mockWatcher := mocks.NewMockWatcherInterface(ctrl)
 	logger, _ := zap.NewDevelopment()
 
 	configPath := "/path/to/config"
 	mockLoader.EXPECT().GetConfigPath().Return(configPath).AnyTimes()
 
-	cm := NewConfigManager(mockLoader, mockWatcher, logger, RetryPolicy{
+	clock := NewFakeClock(time.Now())
+	cm := NewConfigManager[entity.AppConf](mockLoader, mockWatcher, logger, RetryPolicy{
 		MaxAttempts: 3,
-		Timeout:     50 * time.Millisecond,
-	})
+		Timeout:     time.Minute,
+	}, WithClock(clock))
 
 	ctx := context.Background()
+	reload := func() {
+		done := make(chan struct{})
+		go func() {
+			cm.reloadConfig(ctx)
+			close(done)
+		}()
+		driveClock(t, clock, done, time.Minute)
+	}
 
 	// 测试成功加载配置
 	mockLoader.EXPECT().LoadConfig(ctx).Return(&entity.AppConf{}, nil).Times(1)
-	cm.reloadConfig(ctx)
+	reload()
 
 	// 测试加载配置失败，但在重试中成功
 	mockLoader.EXPECT().LoadConfig(ctx).Return(nil, errors.New("load error")).Times(2)
 	mockLoader.EXPECT().LoadConfig(ctx).Return(&entity.AppConf{}, nil).Times(1)
-	cm.reloadConfig(ctx)
+	reload()
 
 	// 测试加载配置失败，重试次数耗尽
 	mockLoader.EXPECT().LoadConfig(ctx).Return(nil, errors.New("load error")).Times(3)
-	cm.reloadConfig(ctx)
+	reload()
+}
+
+// TestCfgManager_AddWatcherFallback 测试 inotify 监听数耗尽时降级为轮询
+func TestCfgManager_AddWatcherFallback(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockWatcher := mocks.NewMockWatcherInterface(ctrl)
+	logger, _ := zap.NewDevelopment()
+
+	cm := NewConfigManager[entity.AppConf](mockLoader, mockWatcher, logger, RetryPolicy{}, WithPollingFallback(time.Hour))
+
+	mockWatcher.EXPECT().Add("/additional/path").Return(syscall.ENOSPC).Times(1)
+	assert.NoError(t, cm.AddWatcher("/additional/path"))
+	poller := cm.watchers.poller
+	defer poller.Close()
+	assert.True(t, poller.Has("/additional/path"))
+
+	// 已降级的路径从轮询监听器中移除
+	assert.NoError(t, cm.RemoveWatcher("/additional/path"))
+	assert.False(t, poller.Has("/additional/path"))
+
+	// 关闭降级时直接返回错误
+	cm = NewConfigManager[entity.AppConf](mockLoader, mockWatcher, logger, RetryPolicy{}, WithPollingFallback(0))
+	mockWatcher.EXPECT().Add("/additional/path").Return(syscall.EMFILE).Times(1)
+	assert.ErrorIs(t, cm.AddWatcher("/additional/path"), syscall.EMFILE)
+}
+
+// serviceConf 自定义配置类型
+type serviceConf struct {
+	Name    string                    `yaml:"name"`
+	Workers int                       `yaml:"workers"`
+	Tenants map[string]map[string]any `yaml:"tenants,omitempty"`
+}
+
+// TestCfgManager_CustomType 测试使用自定义配置类型
+func TestCfgManager_CustomType(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	content := "name: billing\nworkers: 4\ntenants:\n  acme:\n    workers: 8\n"
+	assert.NoError(t, afero.WriteFile(fs, "/etc/app/service.yaml", []byte(content), 0o600))
+
+	loader, err := NewFileLoader[serviceConf](fs, "/etc/app/service.yaml", zap.NewNop(), WithPermissionCheck(PermissionCheckOff))
+	assert.NoError(t, err)
+	cm := NewConfigManager[serviceConf](loader, nil, zap.NewNop(), RetryPolicy{MaxAttempts: 1}, WithSyncApply())
+
+	var got []*serviceConf
+	cm.OnChange(func(_, newConfig *serviceConf) error {
+		got = append(got, newConfig)
+		return nil
+	})
+	assert.NoError(t, cm.Reload(context.Background()))
+	assert.Equal(t, "billing", cm.GetConfig().Name)
+	assert.Equal(t, 4, cm.GetConfig().Workers)
+	assert.Len(t, got, 1)
+
+	tenant, err := cm.ForTenant("acme")
+	assert.NoError(t, err)
+	assert.Equal(t, "billing", tenant.Name)
+	assert.Equal(t, 8, tenant.Workers)
+	assert.Equal(t, []string{"acme"}, cm.Tenants())
 }
@@ -0,0 +1,234 @@
+package config
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock 时间源 用于重试等待 事件合并 轮询与定时生效 测试中可替换为 FakeClock
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer 计时器 语义与 time.Timer 一致
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Ticker 周期计时器 语义与 time.Ticker 一致
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// RealClock 使用系统时间的时钟
+var RealClock Clock = realClock{}
+
+// realClock 系统时钟
+type realClock struct{}
+
+// Now 返回当前时间
+func (realClock) Now() time.Time { return time.Now() }
+
+// NewTimer 创建计时器
+func (realClock) NewTimer(d time.Duration) Timer { return realTimer{time.NewTimer(d)} }
+
+// NewTicker 创建周期计时器
+func (realClock) NewTicker(d time.Duration) Ticker { return realTicker{time.NewTicker(d)} }
+
+// AfterFunc 在 d 之后于新协程中执行 f
+func (realClock) AfterFunc(d time.Duration, f func()) Timer { return realTimer{time.AfterFunc(d, f)} }
+
+// realTimer 包装 time.Timer
+type realTimer struct{ *time.Timer }
+
+// C 返回到期通道
+func (t realTimer) C() <-chan time.Time { return t.Timer.C }
+
+// realTicker 包装 time.Ticker
+type realTicker struct{ *time.Ticker }
+
+// C 返回到期通道
+func (t realTicker) C() <-chan time.Time { return t.Ticker.C }
+
+// sleepClock 按时钟等待 d 或 ctx 结束
+func sleepClock(ctx context.Context, clock Clock, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := clock.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// FakeClock 由测试推进的时钟 只有调用 Advance 时计时器才会到期
+type FakeClock struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// NewFakeClock 创建从 start 开始的可控时钟
+func NewFakeClock(start time.Time) *FakeClock {
+	c := &FakeClock{now: start}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// Now 返回当前的模拟时间
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTimer 创建模拟计时器
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	return c.addTimer(d, 0, nil)
+}
+
+// NewTicker 创建模拟周期计时器
+func (c *FakeClock) NewTicker(d time.Duration) Ticker {
+	return fakeTicker{c.addTimer(d, d, nil)}
+}
+
+// AfterFunc 到期时在新协程中执行 f
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	return c.addTimer(d, 0, f)
+}
+
+// addTimer 注册计时器
+func (c *FakeClock) addTimer(d, period time.Duration, f func()) *fakeTimer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{clock: c, c: make(chan time.Time, 1), period: period, fn: f}
+	c.schedule(t, d)
+	return t
+}
+
+// schedule 设置计时器到期时间 调用方需持有锁
+func (c *FakeClock) schedule(t *fakeTimer, d time.Duration) {
+	t.at = c.now.Add(d)
+	if !t.active {
+		t.active = true
+		c.timers = append(c.timers, t)
+	}
+	c.cond.Broadcast()
+}
+
+// unschedule 取消计时器 调用方需持有锁
+func (c *FakeClock) unschedule(t *fakeTimer) bool {
+	if !t.active {
+		return false
+	}
+	t.active = false
+	for i, other := range c.timers {
+		if other == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// Advance 推进模拟时间 按到期顺序触发期间到期的全部计时器
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	target := c.now.Add(d)
+	var callbacks []func()
+	for {
+		sort.SliceStable(c.timers, func(i, j int) bool { return c.timers[i].at.Before(c.timers[j].at) })
+		if len(c.timers) == 0 || c.timers[0].at.After(target) {
+			break
+		}
+		t := c.timers[0]
+		c.now = t.at
+		if t.period > 0 {
+			t.at = t.at.Add(t.period)
+		} else {
+			c.unschedule(t)
+		}
+		if t.fn != nil {
+			callbacks = append(callbacks, t.fn)
+			continue
+		}
+		select {
+		case t.c <- c.now:
+		default:
+		}
+	}
+	c.now = target
+	c.mu.Unlock()
+
+	for _, f := range callbacks {
+		go f()
+	}
+}
+
+// Timers 返回尚未到期的计时器数量
+func (c *FakeClock) Timers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+// BlockUntil 阻塞直到至少有 n 个尚未到期的计时器 用于等待被测协程开始等待
+func (c *FakeClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.timers) < n {
+		c.cond.Wait()
+	}
+}
+
+// fakeTimer 模拟计时器
+type fakeTimer struct {
+	clock  *FakeClock
+	c      chan time.Time
+	at     time.Time
+	period time.Duration
+	fn     func()
+	active bool
+}
+
+// C 返回到期通道
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+// Stop 停止计时器 返回计时器是否仍在等待
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.clock.unschedule(t)
+}
+
+// Reset 重新设置到期时间 返回计时器是否仍在等待
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	active := t.active
+	t.clock.schedule(t, d)
+	return active
+}
+
+// fakeTicker 模拟周期计时器
+type fakeTicker struct{ *fakeTimer }
+
+// Stop 停止周期计时器
+func (t fakeTicker) Stop() {
+	t.fakeTimer.Stop()
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// driveClock 在 done 关闭前每当被测协程开始等待就推进 step
+func driveClock(t *testing.T, clock *FakeClock, done <-chan struct{}, step time.Duration) {
+	t.Helper()
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case <-done:
+			return
+		case <-deadline:
+			t.Fatal("timed out driving fake clock")
+		case <-time.After(time.Millisecond):
+			if clock.Timers() > 0 {
+				clock.Advance(step)
+			}
+		}
+	}
+}
+
+// assertNotFired 断言计时器通道尚未到期
+func assertNotFired(t *testing.T, c <-chan time.Time) {
+	t.Helper()
+	select {
+	case <-c:
+		t.Fatal("timer fired early")
+	default:
+	}
+}
+
+// TestFakeClockTimer 测试模拟计时器的到期 停止与重置
+func TestFakeClockTimer(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+
+	timer := clock.NewTimer(time.Second)
+	assertNotFired(t, timer.C())
+	clock.Advance(999 * time.Millisecond)
+	assertNotFired(t, timer.C())
+	clock.Advance(time.Millisecond)
+	assert.Equal(t, start.Add(time.Second), <-timer.C())
+	assert.False(t, timer.Stop())
+
+	assert.False(t, timer.Reset(time.Minute))
+	assert.True(t, timer.Stop())
+	clock.Advance(time.Hour)
+	assertNotFired(t, timer.C())
+	assert.Equal(t, start.Add(time.Hour+time.Second), clock.Now())
+	assert.Zero(t, clock.Timers())
+}
+
+// TestFakeClockTicker 测试模拟周期计时器
+func TestFakeClockTicker(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	ticker := clock.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for i := 0; i < 3; i++ {
+		clock.Advance(time.Second)
+		<-ticker.C()
+	}
+	assert.Equal(t, 1, clock.Timers())
+	ticker.Stop()
+	assert.Zero(t, clock.Timers())
+}
+
+// TestFakeClockAfterFunc 测试到期回调与 BlockUntil
+func TestFakeClockAfterFunc(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	var fired atomic.Bool
+	done := make(chan struct{})
+	go func() {
+		clock.AfterFunc(time.Minute, func() {
+			fired.Store(true)
+			close(done)
+		})
+	}()
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	<-done
+	assert.True(t, fired.Load())
+}
+
+// TestSleepClock 测试按时钟等待与 ctx 取消
+func TestSleepClock(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() { errs <- sleepClock(ctx, clock, time.Hour) }()
+	clock.BlockUntil(1)
+	clock.Advance(time.Hour)
+	assert.NoError(t, <-errs)
+
+	go func() { errs <- sleepClock(ctx, clock, time.Hour) }()
+	clock.BlockUntil(1)
+	cancel()
+	assert.ErrorIs(t, <-errs, context.Canceled)
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// gzipExt gzip 压缩文件扩展名
+const gzipExt = ".gz"
+
+// gzipMagic gzip 文件头
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// GzipParser 先解压 gzip 内容再交给内部解析器 内容未压缩时直接透传
+type GzipParser[T any] struct {
+	Inner CfgParser[T]
+}
+
+// Parse 解压并解析配置
+func (g *GzipParser[T]) Parse(file afero.File) (*T, error) {
+	name := strings.TrimSuffix(file.Name(), gzipExt)
+	data, err := readMaybeGzip(file)
+	if err != nil {
+		return nil, fmt.Errorf("gzip decompress %s: %w", file.Name(), err)
+	}
+	return parseBytes(g.Inner, name, data)
+}
+
+// parseBytes 将内存中的内容以 name 为文件名交给解析器 内容只写入内存文件系统
+func parseBytes[T any](parser CfgParser[T], name string, data []byte) (*T, error) {
+	fs := afero.NewMemMapFs()
+	if err := afero.WriteFile(fs, name, data, 0o600); err != nil {
+		return nil, err
+	}
+	file, err := fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return parser.Parse(file)
+}
+
+// readMaybeGzip 读取全部内容 以 gzip 文件头判断是否需要解压
+func readMaybeGzip(r io.Reader) ([]byte, error) {
+	br := bufio.NewReader(r)
+	head, err := br.Peek(len(gzipMagic))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if !bytes.Equal(head, gzipMagic) {
+		return io.ReadAll(br)
+	}
+
+	zr, err := gzip.NewReader(br)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
+// DecodeContentEncoding 按 HTTP Content-Encoding 解码响应体 目前支持 gzip
+func DecodeContentEncoding(body io.Reader, encoding string) (io.Reader, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "", "identity":
+		return body, nil
+	case "gzip", "x-gzip":
+		return gzip.NewReader(body)
+	default:
+		return nil, fmt.Errorf("unsupported content encoding: %s", encoding)
+	}
+}
+
+// ConfigExt 返回配置文件的格式扩展名 压缩文件保留内层扩展名 如 .yaml.gz
+func ConfigExt(path string) string {
+	ext := filepath.Ext(path)
+	if strings.EqualFold(ext, gzipExt) {
+		return filepath.Ext(strings.TrimSuffix(path, ext)) + gzipExt
+	}
+	return ext
+}
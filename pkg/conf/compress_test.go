@@ -0,0 +1,79 @@
+package config
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/omeyang/practices/internal/entity"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// gzipBytes 压缩测试数据
+func gzipBytes(t *testing.T, data string) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write([]byte(data))
+	assert.NoError(t, err)
+	assert.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+// TestGzipConfig 测试加载 gzip 压缩的配置文件
+func TestGzipConfig(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	fs := afero.NewMemMapFs()
+	assert.NoError(t, afero.WriteFile(fs, "/config.yaml.gz", gzipBytes(t, "prometheusCfg:\n  port: 9090\n"), 0o644))
+	assert.NoError(t, afero.WriteFile(fs, "/plain.json.gz", []byte(`{"prometheusCfg": {"port": 9091}}`), 0o644))
+
+	tests := []struct {
+		path         string
+		expectedPort int
+	}{
+		{"/config.yaml.gz", 9090},
+		{"/plain.json.gz", 9091},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			loader, err := NewFileLoader[entity.AppConf](fs, tt.path, logger)
+			assert.NoError(t, err)
+			config, err := loader.LoadConfig(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedPort, config.PrometheusCfg.Port)
+		})
+	}
+
+	_, err := NewParser[entity.AppConf](".xml.gz", logger)
+	assert.Error(t, err)
+}
+
+// TestConfigExt 测试配置格式扩展名
+func TestConfigExt(t *testing.T) {
+	assert.Equal(t, ".yaml", ConfigExt("/etc/app/config.yaml"))
+	assert.Equal(t, ".yaml.gz", ConfigExt("/etc/app/config.yaml.gz"))
+	assert.Equal(t, ".gz", ConfigExt("/etc/app/config.gz"))
+}
+
+// TestDecodeContentEncoding 测试 HTTP 内容编码解码
+func TestDecodeContentEncoding(t *testing.T) {
+	r, err := DecodeContentEncoding(bytes.NewReader(gzipBytes(t, "hello")), "gzip")
+	assert.NoError(t, err)
+	data, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	r, err = DecodeContentEncoding(strings.NewReader("hello"), "")
+	assert.NoError(t, err)
+	data, _ = io.ReadAll(r)
+	assert.Equal(t, "hello", string(data))
+
+	_, err = DecodeContentEncoding(strings.NewReader("hello"), "br")
+	assert.Error(t, err)
+}
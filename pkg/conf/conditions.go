@@ -0,0 +1,306 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const (
+	// WhenKey 配置树中条件表达式使用的键名 条件不成立时所在的映射整体被移除
+	WhenKey = "when"
+	// ConditionsKey 配置树中条件块使用的键名 条件成立的块会按顺序覆盖同层取值
+	ConditionsKey = "conditions"
+)
+
+// EvaluateConditions 返回按上下文变量求值条件块的配置树变换
+//
+// 条件可以直接声明在映射上 也可以写成按顺序合并的条件块:
+//
+//	debugCfg:
+//	  when: env != "prod"
+//	  enable: true
+//	prometheusCfg:
+//	  port: 9090
+//	  conditions:
+//	    - when: env == "prod" && region == "cn"
+//	      values:
+//	        port: 9100
+//
+// 表达式支持 == != && || ! 与括号 未定义的变量视为空字符串
+// 整份配置无法被移除 顶层的 when 无论是否成立都会报错 应改用顶层的条件块
+func EvaluateConditions(vars map[string]string) Transform {
+	return func(tree map[string]any) error {
+		var errs MultiError
+		if _, ok := tree[WhenKey]; ok {
+			delete(tree, WhenKey)
+			errs.Add(WhenKey, "condition is not allowed at the root, use conditions blocks instead")
+		}
+		evalConditionNode(tree, "", vars, &errs)
+		return errs.ErrorOrNil()
+	}
+}
+
+// evalConditionNode 处理映射上的条件 返回该映射是否保留 错误汇总到 errs 中
+func evalConditionNode(node map[string]any, path string, vars map[string]string, errs *MultiError) bool {
+	if raw, ok := node[WhenKey]; ok {
+		delete(node, WhenKey)
+		if !evalWhen(raw, joinPath(path, WhenKey), vars, errs) {
+			return false
+		}
+	}
+
+	if raw, ok := node[ConditionsKey]; ok {
+		delete(node, ConditionsKey)
+		applyConditionBlocks(node, raw, joinPath(path, ConditionsKey), vars, errs)
+	}
+
+	for key, child := range node {
+		if value, keep := evalConditionValue(child, joinPath(path, key), vars, errs); keep {
+			node[key] = value
+		} else {
+			delete(node, key)
+		}
+	}
+	return true
+}
+
+// evalConditionValue 处理任意子节点 列表中条件不成立的元素会被过滤
+func evalConditionValue(node any, path string, vars map[string]string, errs *MultiError) (any, bool) {
+	switch n := node.(type) {
+	case map[string]any:
+		return n, evalConditionNode(n, path, vars, errs)
+	case []any:
+		kept := make([]any, 0, len(n))
+		for i, item := range n {
+			if value, keep := evalConditionValue(item, indexPath(path, i), vars, errs); keep {
+				kept = append(kept, value)
+			}
+		}
+		return kept, true
+	default:
+		return node, true
+	}
+}
+
+// applyConditionBlocks 按顺序合并条件成立的条件块
+func applyConditionBlocks(node map[string]any, raw any, path string, vars map[string]string, errs *MultiError) {
+	blocks, ok := raw.([]any)
+	if !ok {
+		errs.Add(path, "must be a list")
+		return
+	}
+	for i, item := range blocks {
+		itemPath := indexPath(path, i)
+		block, ok := item.(map[string]any)
+		if !ok {
+			errs.Add(itemPath, "condition block must be a mapping")
+			continue
+		}
+		values, ok := block["values"].(map[string]any)
+		if !ok {
+			errs.Add(joinPath(itemPath, "values"), "must be a mapping")
+			continue
+		}
+		if evalWhen(block[WhenKey], joinPath(itemPath, WhenKey), vars, errs) {
+			mergeTree(node, values)
+		}
+	}
+}
+
+// evalWhen 求值条件表达式 非法表达式记录错误并视为不成立
+func evalWhen(raw any, path string, vars map[string]string, errs *MultiError) bool {
+	expr, ok := raw.(string)
+	if !ok {
+		errs.Add(path, "condition must be a string")
+		return false
+	}
+	matched, err := EvalCondition(expr, vars)
+	if err != nil {
+		errs.Add(path, err.Error())
+		return false
+	}
+	return matched
+}
+
+// EvalCondition 使用上下文变量求值条件表达式
+func EvalCondition(expr string, vars map[string]string) (bool, error) {
+	tokens, err := tokenizeCondition(expr)
+	if err != nil {
+		return false, err
+	}
+	p := &conditionParser{tokens: tokens, vars: vars}
+	result, err := p.parseOr()
+	if err != nil {
+		return false, err
+	}
+	if p.pos < len(p.tokens) {
+		return false, fmt.Errorf("condition %q: unexpected token %q", expr, p.tokens[p.pos].text)
+	}
+	return result, nil
+}
+
+// conditionTokenKind 条件表达式词法单元类型
+type conditionTokenKind int
+
+const (
+	tokenIdent conditionTokenKind = iota
+	tokenString
+	tokenOperator
+)
+
+// conditionToken 条件表达式词法单元
+type conditionToken struct {
+	kind conditionTokenKind
+	text string
+}
+
+// tokenizeCondition 对条件表达式进行词法分析
+func tokenizeCondition(expr string) ([]conditionToken, error) {
+	var tokens []conditionToken
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"' || r == '\'':
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("condition %q: unterminated string", expr)
+			}
+			tokens = append(tokens, conditionToken{kind: tokenString, text: string(runes[i+1 : end])})
+			i = end + 1
+		case unicode.IsLetter(r) || r == '_':
+			end := i
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || strings.ContainsRune("_.-", runes[end])) {
+				end++
+			}
+			tokens = append(tokens, conditionToken{kind: tokenIdent, text: string(runes[i:end])})
+			i = end
+		default:
+			op := ""
+			for _, candidate := range []string{"==", "!=", "&&", "||", "!", "(", ")"} {
+				if strings.HasPrefix(string(runes[i:]), candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("condition %q: unexpected character %q", expr, r)
+			}
+			tokens = append(tokens, conditionToken{kind: tokenOperator, text: op})
+			i += len(op)
+		}
+	}
+	return tokens, nil
+}
+
+// conditionParser 条件表达式递归下降解析器
+type conditionParser struct {
+	tokens []conditionToken
+	pos    int
+	vars   map[string]string
+}
+
+// peekOperator 判断下一个词法单元是否为指定运算符
+func (p *conditionParser) peekOperator(op string) bool {
+	return p.pos < len(p.tokens) && p.tokens[p.pos].kind == tokenOperator && p.tokens[p.pos].text == op
+}
+
+// parseOr 解析 || 表达式
+func (p *conditionParser) parseOr() (bool, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return false, err
+	}
+	for p.peekOperator("||") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return false, err
+		}
+		left = left || right
+	}
+	return left, nil
+}
+
+// parseAnd 解析 && 表达式
+func (p *conditionParser) parseAnd() (bool, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return false, err
+	}
+	for p.peekOperator("&&") {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return false, err
+		}
+		left = left && right
+	}
+	return left, nil
+}
+
+// parseUnary 解析 ! 与括号表达式
+func (p *conditionParser) parseUnary() (bool, error) {
+	if p.peekOperator("!") {
+		p.pos++
+		v, err := p.parseUnary()
+		return !v, err
+	}
+	if p.peekOperator("(") {
+		p.pos++
+		v, err := p.parseOr()
+		if err != nil {
+			return false, err
+		}
+		if !p.peekOperator(")") {
+			return false, errors.New("missing closing parenthesis")
+		}
+		p.pos++
+		return v, nil
+	}
+	return p.parseComparison()
+}
+
+// parseComparison 解析比较表达式 单独的变量在非空且不为 false 时为真
+func (p *conditionParser) parseComparison() (bool, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return false, err
+	}
+	switch {
+	case p.peekOperator("=="):
+		p.pos++
+		right, err := p.parseOperand()
+		return left == right, err
+	case p.peekOperator("!="):
+		p.pos++
+		right, err := p.parseOperand()
+		return left != right, err
+	default:
+		return left != "" && left != "false", nil
+	}
+}
+
+// parseOperand 解析操作数 标识符取变量值 字符串取字面量
+func (p *conditionParser) parseOperand() (string, error) {
+	if p.pos >= len(p.tokens) {
+		return "", errors.New("unexpected end of condition")
+	}
+	tok := p.tokens[p.pos]
+	p.pos++
+	switch tok.kind {
+	case tokenIdent:
+		return p.vars[tok.text], nil
+	case tokenString:
+		return tok.text, nil
+	default:
+		return "", fmt.Errorf("unexpected operator %q", tok.text)
+	}
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/omeyang/practices/internal/entity"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// TestEvalCondition 测试条件表达式求值
+func TestEvalCondition(t *testing.T) {
+	vars := map[string]string{"env": "prod", "region": "cn", "canary": "true"}
+
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{`env == "prod"`, true},
+		{`env != 'prod'`, false},
+		{`env == "prod" && region == "us"`, false},
+		{`env == "dev" || region == "cn"`, true},
+		{`!(env == "dev")`, true},
+		{`canary`, true},
+		{`missing`, false},
+		{`missing == ""`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalCondition(tt.expr, vars)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+
+	for _, expr := range []string{`env ==`, `(env == "prod"`, `env == "prod`, `env = "prod"`, `env "prod"`} {
+		_, err := EvalCondition(expr, vars)
+		assert.Error(t, err, expr)
+	}
+}
+
+// TestEvaluateConditions 测试条件块的配置树变换
+func TestEvaluateConditions(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	content := `
+prometheusCfg:
+  enable: true
+  port: 9090
+  conditions:
+    - when: env == "prod"
+      values:
+        port: 9100
+    - when: env == "dev"
+      values:
+        enable: false
+`
+
+	tests := []struct {
+		name         string
+		env          string
+		expectedPort int
+		expectEnable bool
+	}{
+		{"Prod", "prod", 9100, true},
+		{"Dev", "dev", 9090, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := &YAMLParser[entity.AppConf]{Logger: logger, Transforms: []Transform{EvaluateConditions(map[string]string{"env": tt.env})}}
+			config, err := parser.Parse(mockFile(content))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedPort, config.PrometheusCfg.Port)
+			assert.Equal(t, tt.expectEnable, config.PrometheusCfg.Enable)
+		})
+	}
+}
+
+// TestEvaluateConditionsWhen 测试条件不成立的映射与列表元素被移除
+func TestEvaluateConditionsWhen(t *testing.T) {
+	tree := map[string]any{
+		"debug": map[string]any{"when": `env != "prod"`, "enable": true},
+		"routes": []any{
+			map[string]any{"when": `env == "prod"`, "path": "/a"},
+			map[string]any{"path": "/b"},
+		},
+	}
+
+	assert.NoError(t, EvaluateConditions(map[string]string{"env": "prod"})(tree))
+	assert.NotContains(t, tree, "debug")
+	assert.Equal(t, []any{map[string]any{"path": "/a"}, map[string]any{"path": "/b"}}, tree["routes"])
+
+	assert.Error(t, EvaluateConditions(nil)(map[string]any{"debug": map[string]any{"when": 1}}))
+}
+
+// TestEvaluateConditionsRootWhen 测试顶层的 when 无论是否成立都被拒绝 不会静默保留整份配置
+func TestEvaluateConditionsRootWhen(t *testing.T) {
+	vars := map[string]string{"env": "prod"}
+	for _, expr := range []string{`env == "prod"`, `env != "prod"`} {
+		tree := map[string]any{"when": expr, "debug": map[string]any{"enable": true}}
+		err := EvaluateConditions(vars)(tree)
+		var fieldErr *FieldError
+		if assert.ErrorAs(t, err, &fieldErr, expr) {
+			assert.Equal(t, "when", fieldErr.Path)
+		}
+	}
+
+	// 顶层的条件块仍然可用
+	tree := map[string]any{"conditions": []any{map[string]any{"when": `env == "prod"`, "values": map[string]any{"debug": false}}}}
+	assert.NoError(t, EvaluateConditions(vars)(tree))
+	assert.Equal(t, map[string]any{"debug": false}, tree)
+}
+
+// TestEvaluateConditionsAggregatesErrors 测试一次性汇总所有非法条件
+func TestEvaluateConditionsAggregatesErrors(t *testing.T) {
+	tree := map[string]any{
+		"a": map[string]any{"when": `env ==`},
+		"b": map[string]any{"conditions": []any{map[string]any{"when": `x`}}},
+		"c": map[string]any{"when": 1},
+	}
+
+	err := EvaluateConditions(nil)(tree)
+	var multi *MultiError
+	assert.ErrorAs(t, err, &multi)
+	assert.Equal(t, 3, multi.Len())
+}
@@ -0,0 +1,239 @@
+// Package conftest 提供配置源的故障注入装饰器 用于测试应用在配置源异常时的容错能力
+package conftest
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	config "github.com/omeyang/practices/pkg/conf"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// ErrInjected 注入的故障 Faults.Err 为空时使用
+var ErrInjected = errors.New("injected config source fault")
+
+// Faults 故障注入参数 概率取值范围为 0 到 1
+type Faults struct {
+	ErrorRate   float64       // 调用返回错误的概率 对 FlakyWatcher 同时表示在错误通道注入错误的概率
+	Err         error         // 注入的错误 为空时使用 ErrInjected
+	Latency     time.Duration // 每次调用或转发事件前的固定延迟
+	Jitter      time.Duration // 在固定延迟之上追加的随机延迟上限
+	CorruptRate float64       // FaultyLoader 返回损坏配置的概率
+	DropRate    float64       // FlakyWatcher 丢弃事件的概率
+	Seed        int64         // 随机数种子 相同的种子产生相同的故障序列
+	Clock       config.Clock  // 延迟使用的时钟 默认为 config.RealClock
+}
+
+// injector 按故障参数决定每次调用是否注入故障
+type injector struct {
+	faults Faults
+	mu     sync.Mutex
+	rand   *rand.Rand
+}
+
+// newInjector 创建故障注入器
+func newInjector(faults Faults) *injector {
+	if faults.Err == nil {
+		faults.Err = ErrInjected
+	}
+	if faults.Clock == nil {
+		faults.Clock = config.RealClock
+	}
+	return &injector{faults: faults, rand: rand.New(rand.NewSource(faults.Seed))}
+}
+
+// roll 以概率 p 返回 true
+func (i *injector) roll(p float64) bool {
+	if p <= 0 {
+		return false
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.rand.Float64() < p
+}
+
+// delay 按固定延迟与随机抖动等待 ctx 结束时返回其错误
+func (i *injector) delay(ctx context.Context) error {
+	d := i.faults.Latency
+	if i.faults.Jitter > 0 {
+		i.mu.Lock()
+		d += time.Duration(i.rand.Int63n(int64(i.faults.Jitter)))
+		i.mu.Unlock()
+	}
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := i.faults.Clock.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// FaultyLoader 按故障参数注入错误 延迟与损坏配置的加载器装饰器 实现 CfgLoader
+type FaultyLoader[T any] struct {
+	loader   config.CfgLoader[T]
+	injector *injector
+
+	// Corrupt 生成损坏的配置 为空时返回零值配置 模拟被截断或内容缺失的数据
+	Corrupt func(cfg *T) *T
+}
+
+var _ config.CfgLoader[any] = (*FaultyLoader[any])(nil)
+
+// NewFaultyLoader 创建故障注入加载器
+func NewFaultyLoader[T any](loader config.CfgLoader[T], faults Faults) *FaultyLoader[T] {
+	return &FaultyLoader[T]{loader: loader, injector: newInjector(faults)}
+}
+
+// LoadConfig 延迟后按概率返回注入的错误或损坏的配置 否则返回被装饰加载器的结果
+func (l *FaultyLoader[T]) LoadConfig(ctx context.Context) (*T, error) {
+	if err := l.injector.delay(ctx); err != nil {
+		return nil, err
+	}
+	if l.injector.roll(l.injector.faults.ErrorRate) {
+		return nil, l.injector.faults.Err
+	}
+	cfg, err := l.loader.LoadConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if l.injector.roll(l.injector.faults.CorruptRate) {
+		if l.Corrupt != nil {
+			return l.Corrupt(cfg), nil
+		}
+		return new(T), nil
+	}
+	return cfg, nil
+}
+
+// GetConfigPath 返回被装饰加载器的配置路径
+func (l *FaultyLoader[T]) GetConfigPath() string {
+	return l.loader.GetConfigPath()
+}
+
+// FlakyWatcher 按故障参数注入错误 延迟与丢失事件的监听器装饰器 实现 WatcherInterface
+type FlakyWatcher struct {
+	watcher   config.WatcherInterface
+	injector  *injector
+	events    chan fsnotify.Event
+	errors    chan error
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+var _ config.WatcherInterface = (*FlakyWatcher)(nil)
+
+// NewFlakyWatcher 创建故障注入监听器 并开始转发被装饰监听器的事件与错误
+func NewFlakyWatcher(watcher config.WatcherInterface, faults Faults) *FlakyWatcher {
+	w := &FlakyWatcher{
+		watcher:  watcher,
+		injector: newInjector(faults),
+		events:   make(chan fsnotify.Event),
+		errors:   make(chan error),
+		done:     make(chan struct{}),
+	}
+	go w.forward()
+	return w
+}
+
+// Add 按概率返回注入的错误 否则添加监听
+func (w *FlakyWatcher) Add(name string) error {
+	if w.injector.roll(w.injector.faults.ErrorRate) {
+		return w.injector.faults.Err
+	}
+	return w.watcher.Add(name)
+}
+
+// Remove 按概率返回注入的错误 否则移除监听
+func (w *FlakyWatcher) Remove(name string) error {
+	if w.injector.roll(w.injector.faults.ErrorRate) {
+		return w.injector.faults.Err
+	}
+	return w.watcher.Remove(name)
+}
+
+// Close 停止转发并关闭被装饰的监听器
+func (w *FlakyWatcher) Close() error {
+	var err error
+	w.closeOnce.Do(func() {
+		close(w.done)
+		err = w.watcher.Close()
+	})
+	return err
+}
+
+// Events 返回经过延迟与丢弃后的事件通道
+func (w *FlakyWatcher) Events() <-chan fsnotify.Event {
+	return w.events
+}
+
+// Errors 返回被装饰监听器的错误与注入的错误
+func (w *FlakyWatcher) Errors() <-chan error {
+	return w.errors
+}
+
+// forward 转发事件与错误 关闭时关闭输出通道
+func (w *FlakyWatcher) forward() {
+	defer close(w.events)
+	defer close(w.errors)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-w.done
+		cancel()
+	}()
+
+	events, errs := w.watcher.Events(), w.watcher.Errors()
+	for events != nil || errs != nil {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			w.forwardEvent(ctx, event)
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			w.sendError(err)
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// forwardEvent 按故障参数延迟 丢弃事件或在事件之前注入错误
+func (w *FlakyWatcher) forwardEvent(ctx context.Context, event fsnotify.Event) {
+	if err := w.injector.delay(ctx); err != nil {
+		return
+	}
+	if w.injector.roll(w.injector.faults.ErrorRate) {
+		w.sendError(w.injector.faults.Err)
+	}
+	if w.injector.roll(w.injector.faults.DropRate) {
+		return
+	}
+	select {
+	case w.events <- event:
+	case <-w.done:
+	}
+}
+
+// sendError 发送错误 关闭后放弃
+func (w *FlakyWatcher) sendError(err error) {
+	select {
+	case w.errors <- err:
+	case <-w.done:
+	}
+}
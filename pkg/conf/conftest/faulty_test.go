@@ -0,0 +1,163 @@
+package conftest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/omeyang/practices/internal/entity"
+	config "github.com/omeyang/practices/pkg/conf"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubLoader 测试用加载器 每次返回同一份配置
+type stubLoader struct {
+	config *entity.AppConf
+	calls  int
+}
+
+func (l *stubLoader) LoadConfig(_ context.Context) (*entity.AppConf, error) {
+	l.calls++
+	return l.config, nil
+}
+
+func (l *stubLoader) GetConfigPath() string {
+	return "stub.yaml"
+}
+
+// stubWatcher 测试用监听器
+type stubWatcher struct {
+	events chan fsnotify.Event
+	errors chan error
+	added  []string
+}
+
+func newStubWatcher() *stubWatcher {
+	return &stubWatcher{events: make(chan fsnotify.Event, 4), errors: make(chan error, 4)}
+}
+
+func (w *stubWatcher) Add(name string) error {
+	w.added = append(w.added, name)
+	return nil
+}
+
+func (w *stubWatcher) Remove(string) error           { return nil }
+func (w *stubWatcher) Close() error                  { return nil }
+func (w *stubWatcher) Events() <-chan fsnotify.Event { return w.events }
+func (w *stubWatcher) Errors() <-chan error          { return w.errors }
+
+// TestFaultyLoader 测试加载器的错误注入与损坏配置
+func TestFaultyLoader(t *testing.T) {
+	conf := &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9090}}
+	ctx := context.Background()
+
+	t.Run("No Faults", func(t *testing.T) {
+		loader := NewFaultyLoader[entity.AppConf](&stubLoader{config: conf}, Faults{})
+		got, err := loader.LoadConfig(ctx)
+		assert.NoError(t, err)
+		assert.Same(t, conf, got)
+		assert.Equal(t, "stub.yaml", loader.GetConfigPath())
+	})
+
+	t.Run("Always Fail", func(t *testing.T) {
+		inner := &stubLoader{config: conf}
+		custom := errors.New("backend unavailable")
+		loader := NewFaultyLoader[entity.AppConf](inner, Faults{ErrorRate: 1, Err: custom})
+		_, err := loader.LoadConfig(ctx)
+		assert.ErrorIs(t, err, custom)
+		assert.Zero(t, inner.calls)
+	})
+
+	t.Run("Corrupt", func(t *testing.T) {
+		loader := NewFaultyLoader[entity.AppConf](&stubLoader{config: conf}, Faults{CorruptRate: 1})
+		got, err := loader.LoadConfig(ctx)
+		assert.NoError(t, err)
+		assert.Nil(t, got.PrometheusCfg)
+
+		loader.Corrupt = func(cfg *entity.AppConf) *entity.AppConf {
+			return &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: -1}}
+		}
+		got, err = loader.LoadConfig(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, -1, got.PrometheusCfg.Port)
+	})
+
+	t.Run("Seeded Sequence", func(t *testing.T) {
+		run := func() []bool {
+			loader := NewFaultyLoader[entity.AppConf](&stubLoader{config: conf}, Faults{ErrorRate: 0.5, Seed: 42})
+			var failures []bool
+			for i := 0; i < 20; i++ {
+				_, err := loader.LoadConfig(ctx)
+				failures = append(failures, err != nil)
+			}
+			return failures
+		}
+		first := run()
+		assert.Equal(t, first, run())
+		assert.Contains(t, first, true)
+		assert.Contains(t, first, false)
+	})
+
+	t.Run("Latency", func(t *testing.T) {
+		clock := config.NewFakeClock(time.Unix(0, 0))
+		loader := NewFaultyLoader[entity.AppConf](&stubLoader{config: conf}, Faults{Latency: time.Second, Clock: clock})
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, _ = loader.LoadConfig(ctx)
+		}()
+		clock.BlockUntil(1)
+		select {
+		case <-done:
+			t.Fatal("load returned before latency elapsed")
+		default:
+		}
+		clock.Advance(time.Second)
+		<-done
+	})
+}
+
+// TestFlakyWatcher 测试监听器的错误注入与事件丢弃
+func TestFlakyWatcher(t *testing.T) {
+	event := fsnotify.Event{Name: "app.yaml", Op: fsnotify.Write}
+
+	t.Run("Forward", func(t *testing.T) {
+		inner := newStubWatcher()
+		w := NewFlakyWatcher(inner, Faults{})
+		defer w.Close()
+
+		assert.NoError(t, w.Add("app.yaml"))
+		assert.Equal(t, []string{"app.yaml"}, inner.added)
+		inner.events <- event
+		assert.Equal(t, event, <-w.Events())
+		inner.errors <- errors.New("inotify overflow")
+		assert.EqualError(t, <-w.Errors(), "inotify overflow")
+	})
+
+	t.Run("Fail And Drop", func(t *testing.T) {
+		inner := newStubWatcher()
+		w := NewFlakyWatcher(inner, Faults{ErrorRate: 1, DropRate: 1})
+		defer w.Close()
+
+		assert.ErrorIs(t, w.Add("app.yaml"), ErrInjected)
+		assert.Empty(t, inner.added)
+		inner.events <- event
+		assert.ErrorIs(t, <-w.Errors(), ErrInjected)
+		select {
+		case <-w.Events():
+			t.Fatal("dropped event was delivered")
+		case <-time.After(10 * time.Millisecond):
+		}
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		w := NewFlakyWatcher(newStubWatcher(), Faults{})
+		assert.NoError(t, w.Close())
+		assert.NoError(t, w.Close())
+		_, ok := <-w.Events()
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"context"
+	"net/http"
+)
+
+// providerKey context 中保存 ConfigProvider 使用的键
+type providerKey struct{}
+
+// pinnedKey context 中保存固定配置使用的键
+type pinnedKey struct{}
+
+// NewContext 返回携带配置提供者的 context 便于调用链深处读取配置而无需全局单例
+func NewContext[T any](ctx context.Context, provider ConfigProvider[T]) context.Context {
+	return context.WithValue(ctx, providerKey{}, provider)
+}
+
+// FromContext 取出 context 中的配置提供者 不存在时返回 false
+func FromContext[T any](ctx context.Context) (ConfigProvider[T], bool) {
+	provider, ok := ctx.Value(providerKey{}).(ConfigProvider[T])
+	return provider, ok
+}
+
+// PinConfig 返回固定了配置快照的 context 之后通过 ConfigFromContext 读取的都是这份配置
+func PinConfig[T any](ctx context.Context, config *T) context.Context {
+	return context.WithValue(ctx, pinnedKey{}, config)
+}
+
+// PinnedConfig 取出 context 中固定的配置 不存在时返回 false
+func PinnedConfig[T any](ctx context.Context) (*T, bool) {
+	config, ok := ctx.Value(pinnedKey{}).(*T)
+	return config, ok && config != nil
+}
+
+// ConfigFromContext 返回 context 中固定的配置 未固定时返回配置提供者的当前配置 两者都不存在时返回 false
+func ConfigFromContext[T any](ctx context.Context) (*T, bool) {
+	if config, ok := PinnedConfig[T](ctx); ok {
+		return config, true
+	}
+	if provider, ok := FromContext[T](ctx); ok {
+		return provider.GetConfig(), true
+	}
+	return nil, false
+}
+
+// PinMiddleware 返回在请求开始时固定当前配置的 HTTP 中间件
+// 处理请求期间即使配置重新加载 通过 ConfigFromContext 读取的仍是同一份配置 不会在一个请求中混用两个版本
+// 请求 context 中同时携带配置提供者 需要最新配置的代码仍可通过 FromContext 读取
+func PinMiddleware[T any](provider ConfigProvider[T]) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := NewContext(r.Context(), provider)
+			ctx = PinConfig(ctx, provider.GetConfig())
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
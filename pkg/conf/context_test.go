@@ -0,0 +1,69 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/omeyang/practices/internal/entity"
+	mocks "github.com/omeyang/practices/mocks/conf"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+// TestNewContext 测试在 context 中传递配置提供者
+func TestNewContext(t *testing.T) {
+	_, ok := FromContext[entity.AppConf](context.Background())
+	assert.False(t, ok)
+
+	cm := NewConfigManager[entity.AppConf](nil, nil, zap.NewNop(), RetryPolicy{})
+	ctx := NewContext[entity.AppConf](context.Background(), cm)
+	provider, ok := FromContext[entity.AppConf](ctx)
+	assert.True(t, ok)
+	assert.Same(t, cm, provider)
+
+	// 派生的 context 同样可以取到
+	child, cancel := context.WithCancel(ctx)
+	defer cancel()
+	provider, ok = FromContext[entity.AppConf](child)
+	assert.True(t, ok)
+	assert.Same(t, cm, provider)
+}
+
+// TestPinMiddleware 测试请求期间配置重新加载后 请求读取的仍是开始时的配置
+func TestPinMiddleware(t *testing.T) {
+	_, ok := ConfigFromContext[entity.AppConf](context.Background())
+	assert.False(t, ok)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return("/path/to/config").AnyTimes()
+	cm := NewConfigManager[entity.AppConf](mockLoader, mocks.NewMockWatcherInterface(ctrl), zap.NewNop(), RetryPolicy{})
+	first := &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9090}}
+	assert.NoError(t, cm.storeConfig(first))
+
+	handler := PinMiddleware[entity.AppConf](cm)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		before, ok := ConfigFromContext[entity.AppConf](r.Context())
+		assert.True(t, ok)
+		assert.NoError(t, cm.storeConfig(&entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9091}}))
+		after, _ := ConfigFromContext[entity.AppConf](r.Context())
+		assert.Same(t, before, after)
+		assert.Same(t, first, after)
+
+		provider, _ := FromContext[entity.AppConf](r.Context())
+		assert.Equal(t, 9091, provider.GetConfig().PrometheusCfg.Port)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+
+	// 未固定时读取配置提供者的当前配置
+	current, ok := ConfigFromContext[entity.AppConf](NewContext[entity.AppConf](context.Background(), cm))
+	assert.True(t, ok)
+	assert.Equal(t, 9091, current.PrometheusCfg.Port)
+}
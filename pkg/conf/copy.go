@@ -0,0 +1,101 @@
+package config
+
+import "reflect"
+
+// DeepCopy 返回配置的深拷贝 修改副本中的结构体 映射与列表不会影响原配置
+// 未导出字段按值复制 其中的指针与原配置共享 配置中不能有循环引用
+func DeepCopy[T any](v *T) *T {
+	if v == nil {
+		return nil
+	}
+	out := reflect.New(reflect.TypeOf(v).Elem())
+	copyValue(out.Elem(), reflect.ValueOf(v).Elem())
+	return out.Interface().(*T)
+}
+
+// copyValue 将 src 深拷贝到 dst dst 必须可设置
+func copyValue(dst, src reflect.Value) {
+	switch src.Kind() {
+	case reflect.Pointer:
+		if src.IsNil() {
+			dst.Set(reflect.Zero(src.Type()))
+			return
+		}
+		dst.Set(reflect.New(src.Type().Elem()))
+		copyValue(dst.Elem(), src.Elem())
+	case reflect.Interface:
+		if src.IsNil() {
+			dst.Set(reflect.Zero(src.Type()))
+			return
+		}
+		elem := reflect.New(src.Elem().Type()).Elem()
+		copyValue(elem, src.Elem())
+		dst.Set(elem)
+	case reflect.Struct:
+		dst.Set(src)
+		for i := 0; i < src.NumField(); i++ {
+			if src.Type().Field(i).IsExported() {
+				copyValue(dst.Field(i), src.Field(i))
+			}
+		}
+	case reflect.Map:
+		if src.IsNil() {
+			dst.Set(reflect.Zero(src.Type()))
+			return
+		}
+		m := reflect.MakeMapWithSize(src.Type(), src.Len())
+		iter := src.MapRange()
+		for iter.Next() {
+			value := reflect.New(src.Type().Elem()).Elem()
+			copyValue(value, iter.Value())
+			m.SetMapIndex(iter.Key(), value)
+		}
+		dst.Set(m)
+	case reflect.Slice:
+		if src.IsNil() {
+			dst.Set(reflect.Zero(src.Type()))
+			return
+		}
+		s := reflect.MakeSlice(src.Type(), src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			copyValue(s.Index(i), src.Index(i))
+		}
+		dst.Set(s)
+	case reflect.Array:
+		for i := 0; i < src.Len(); i++ {
+			copyValue(dst.Index(i), src.Index(i))
+		}
+	default:
+		dst.Set(src)
+	}
+}
+
+// Snapshot 返回当前配置的深拷贝及其版本号与生效时间 调用方可以任意修改 不会与重载竞争
+// 尚未加载配置时返回零值
+func (cm *CfgManager[T]) Snapshot() Snapshot[T] {
+	cm.rwMutex.RLock()
+	defer cm.rwMutex.RUnlock()
+	cm.versions.mu.RLock()
+	defer cm.versions.mu.RUnlock()
+	if len(cm.versions.snapshots) == 0 {
+		return Snapshot[T]{}
+	}
+	current := cm.versions.snapshots[len(cm.versions.snapshots)-1]
+	current.Config = DeepCopy(current.Config)
+	return current
+}
+
+// Section 返回 pick 从当前配置中选出的部分的深拷贝 用于实现类型化的访问方法 如
+//
+//	func (a *App) Prometheus() *entity.PrometheusConf {
+//		return config.Section(a.cm, func(c *entity.AppConf) *entity.PrometheusConf { return c.PrometheusCfg })
+//	}
+//
+// 调用方修改返回值不会影响生效的配置 比复制整个配置开销更小
+func Section[T, S any](provider ConfigProvider[T], pick func(*T) S) S {
+	var section S
+	if config := provider.GetConfig(); config != nil {
+		section = pick(config)
+	}
+	return *DeepCopy(&section)
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/omeyang/practices/internal/entity"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// TestDeepCopy 测试副本中的指针 映射与列表与原配置互不影响
+func TestDeepCopy(t *testing.T) {
+	at := time.Unix(1000, 0)
+	original := &entity.AppConf{
+		EffectiveAt:   &at,
+		PrometheusCfg: &entity.PrometheusConf{Port: 9090},
+		Tenants:       map[string]map[string]any{"acme": {"ports": []any{1, 2}}},
+	}
+	copied := DeepCopy(original)
+	assert.Equal(t, original, copied)
+	assert.NotSame(t, original.PrometheusCfg, copied.PrometheusCfg)
+	assert.NotSame(t, original.EffectiveAt, copied.EffectiveAt)
+
+	copied.PrometheusCfg.Port = 9100
+	copied.Tenants["acme"]["ports"].([]any)[0] = 3
+	copied.Tenants["other"] = nil
+	assert.Equal(t, 9090, original.PrometheusCfg.Port)
+	assert.Equal(t, []any{1, 2}, original.Tenants["acme"]["ports"])
+	assert.Len(t, original.Tenants, 1)
+	assert.Nil(t, DeepCopy[entity.AppConf](nil))
+}
+
+// TestCfgManager_CopyOnRead 测试启用深拷贝后修改读取的配置不影响生效的配置 以及 Snapshot 与 Section
+func TestCfgManager_CopyOnRead(t *testing.T) {
+	cm := NewConfigManager[entity.AppConf](nil, nil, zap.NewNop(), RetryPolicy{}, WithCopyOnRead())
+	assert.Zero(t, cm.Snapshot())
+	current := &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9090}}
+	assert.NoError(t, cm.storeConfig(current))
+
+	cm.GetConfig().PrometheusCfg.Port = 1
+	config, version := cm.GetVersioned()
+	config.PrometheusCfg.Port = 2
+	assert.Equal(t, uint64(1), version)
+	assert.Equal(t, 9090, current.PrometheusCfg.Port)
+
+	snapshot := cm.Snapshot()
+	assert.Equal(t, uint64(1), snapshot.Version)
+	assert.Equal(t, current, snapshot.Config)
+	assert.NotSame(t, current, snapshot.Config)
+
+	section := Section[entity.AppConf](cm, func(c *entity.AppConf) *entity.PrometheusConf { return c.PrometheusCfg })
+	section.Port = 3
+	assert.Equal(t, 9090, current.PrometheusCfg.Port)
+	assert.Equal(t, 9090, Section[entity.AppConf](cm, func(c *entity.AppConf) int { return c.PrometheusCfg.Port }))
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrNoDefault 未设置默认配置提供者 或默认提供者的配置类型不匹配
+var ErrNoDefault = errors.New("no default config provider")
+
+// 包级默认配置提供者 仅供 CLI 和脚本等小程序使用
+// 需要显式调用 SetDefault 才会生效 库代码应通过依赖注入或 NewContext 传递 ConfigProvider
+var defaultProvider struct {
+	mu       sync.RWMutex
+	provider any
+}
+
+// SetDefault 设置包级默认配置提供者 传入 nil 时清除
+func SetDefault[T any](provider ConfigProvider[T]) {
+	defaultProvider.mu.Lock()
+	defer defaultProvider.mu.Unlock()
+	if provider == nil {
+		defaultProvider.provider = nil
+		return
+	}
+	defaultProvider.provider = provider
+}
+
+// Default 返回包级默认配置提供者 未设置或配置类型不匹配时返回 false
+func Default[T any]() (ConfigProvider[T], bool) {
+	defaultProvider.mu.RLock()
+	defer defaultProvider.mu.RUnlock()
+	provider, ok := defaultProvider.provider.(ConfigProvider[T])
+	return provider, ok
+}
+
+// Get 读取默认配置提供者的当前配置 未设置默认提供者时返回 nil
+func Get[T any]() *T {
+	provider, ok := Default[T]()
+	if !ok {
+		return nil
+	}
+	return provider.GetConfig()
+}
+
+// OnChange 在默认配置提供者上注册配置变更处理函数 未设置默认提供者时返回 ErrNoDefault
+func OnChange[T any](handler ChangeHandler[T]) error {
+	provider, ok := Default[T]()
+	if !ok {
+		return ErrNoDefault
+	}
+	provider.OnChange(handler)
+	return nil
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/omeyang/practices/internal/entity"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// TestDefault 测试包级默认配置提供者需要显式设置
+func TestDefault(t *testing.T) {
+	t.Cleanup(func() { SetDefault[entity.AppConf](nil) })
+
+	_, ok := Default[entity.AppConf]()
+	assert.False(t, ok)
+	assert.Nil(t, Get[entity.AppConf]())
+	assert.ErrorIs(t, OnChange(func(_, _ *entity.AppConf) error { return nil }), ErrNoDefault)
+
+	cm := NewConfigManager[entity.AppConf](nil, nil, zap.NewNop(), RetryPolicy{}, WithSyncApply())
+	SetDefault[entity.AppConf](cm)
+
+	provider, ok := Default[entity.AppConf]()
+	assert.True(t, ok)
+	assert.Same(t, cm, provider)
+
+	var changed *entity.AppConf
+	assert.NoError(t, OnChange(func(_, newConfig *entity.AppConf) error {
+		changed = newConfig
+		return nil
+	}))
+	config := &entity.AppConf{}
+	assert.NoError(t, cm.storeConfig(config))
+	assert.Same(t, config, Get[entity.AppConf]())
+	assert.Same(t, config, changed)
+
+	// 配置类型不匹配时视为未设置
+	_, ok = Default[struct{}]()
+	assert.False(t, ok)
+
+	SetDefault[entity.AppConf](nil)
+	assert.Nil(t, Get[entity.AppConf]())
+}
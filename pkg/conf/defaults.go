@@ -0,0 +1,139 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultTag 声明配置项默认值的结构体标签 取值按 YAML 解析 如 default:"9090" default:"5s" default:"[a, b]"
+// 字段为零值时视为配置文件中未设置 因此布尔字段的默认值为 true 时应使用指针区分显式的 false
+const DefaultTag = "default"
+
+// defaultsSet 已注册的默认值函数
+type defaultsSet[T any] struct {
+	mu   sync.Mutex
+	list []func(config *T)
+}
+
+// AddDefaults 注册默认值函数 按注册顺序在标签默认值之后执行 用于标签无法表达的默认值
+// 函数直接修改候选配置 应只填充零值字段 避免覆盖配置文件中的取值
+func (cm *CfgManager[T]) AddDefaults(fn func(config *T)) {
+	cm.defaults.mu.Lock()
+	defer cm.defaults.mu.Unlock()
+	cm.defaults.list = append(cm.defaults.list, fn)
+}
+
+// applyDefaults 为候选配置填充默认值 在校验之前执行
+func (cm *CfgManager[T]) applyDefaults(config *T) error {
+	if err := ApplyDefaults(config); err != nil {
+		return err
+	}
+	cm.defaults.mu.Lock()
+	fns := slices.Clone(cm.defaults.list)
+	cm.defaults.mu.Unlock()
+	for _, fn := range fns {
+		fn(config)
+	}
+	return nil
+}
+
+// ApplyDefaults 按 default 标签为配置结构体中的零值字段填充默认值
+// 子结构体中声明了默认值时 为空的结构体指针会被创建 标签取值非法时返回汇总的 MultiError
+func ApplyDefaults(config any) error {
+	var errs MultiError
+	applyDefaultsValue(reflect.ValueOf(config), "", &errs)
+	return errs.ErrorOrNil()
+}
+
+// applyDefaultsValue 递归填充默认值 v 需可寻址才能修改
+func applyDefaultsValue(v reflect.Value, path string, errs *MultiError) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+			fieldPath := joinPath(path, name)
+			fv := v.Field(i)
+			if !fv.CanSet() {
+				continue
+			}
+			if value, ok := field.Tag.Lookup(DefaultTag); ok && fv.IsZero() {
+				if err := yaml.Unmarshal([]byte(value), fv.Addr().Interface()); err != nil {
+					errs.Add(fieldPath, fmt.Sprintf("invalid default %q: %v", value, err))
+					continue
+				}
+			}
+			if fv.Kind() == reflect.Pointer && fv.IsNil() && hasDefaults(fv.Type().Elem()) {
+				fv.Set(reflect.New(fv.Type().Elem()))
+			}
+			applyDefaultsValue(fv, fieldPath, errs)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			applyDefaultsValue(v.Index(i), indexPath(path, i), errs)
+		}
+	case reflect.Map:
+		// 映射的取值不可寻址 只处理指针取值
+		iter := v.MapRange()
+		for iter.Next() {
+			if iter.Value().Kind() == reflect.Pointer {
+				applyDefaultsValue(iter.Value(), joinPath(path, fmt.Sprint(iter.Key().Interface())), errs)
+			}
+		}
+	}
+}
+
+// defaultTypes 结构体类型是否声明了默认值的缓存
+var defaultTypes sync.Map
+
+// hasDefaults 判断结构体类型或其嵌套的结构体字段是否声明了默认值
+func hasDefaults(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	if cached, ok := defaultTypes.Load(t); ok {
+		return cached.(bool)
+	}
+	// 先记为 false 防止自引用类型无限递归
+	defaultTypes.Store(t, false)
+	found := false
+	for i := 0; i < t.NumField() && !found; i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if _, ok := field.Tag.Lookup(DefaultTag); ok {
+			found = true
+			continue
+		}
+		ft := field.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		found = hasDefaults(ft)
+	}
+	defaultTypes.Store(t, found)
+	return found
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mocks "github.com/omeyang/practices/mocks/conf"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+// defaultsServerConf 测试用的服务配置
+type defaultsServerConf struct {
+	Port    int           `yaml:"port" default:"8080" validate:"range=1-65535"`
+	Timeout time.Duration `yaml:"timeout" default:"5s"`
+	Hosts   []string      `yaml:"hosts" default:"[a, b]"`
+	Enable  *bool         `yaml:"enable" default:"true"`
+}
+
+// defaultsAppConf 测试用的应用配置
+type defaultsAppConf struct {
+	Name    string                         `yaml:"name" default:"app"`
+	Server  *defaultsServerConf            `yaml:"server"`
+	Workers map[string]*defaultsServerConf `yaml:"workers"`
+	Plain   *struct{ Value int }           `yaml:"plain"`
+}
+
+// TestApplyDefaults 测试按标签填充零值字段 不覆盖已有取值
+func TestApplyDefaults(t *testing.T) {
+	config := &defaultsAppConf{Workers: map[string]*defaultsServerConf{"w1": {Port: 9000}}}
+	assert.NoError(t, ApplyDefaults(config))
+
+	enable := true
+	assert.Equal(t, "app", config.Name)
+	assert.Equal(t, &defaultsServerConf{Port: 8080, Timeout: 5 * time.Second, Hosts: []string{"a", "b"}, Enable: &enable}, config.Server)
+	assert.Equal(t, 9000, config.Workers["w1"].Port)
+	assert.Equal(t, 5*time.Second, config.Workers["w1"].Timeout)
+	// 未声明默认值的子结构体保持为空
+	assert.Nil(t, config.Plain)
+
+	disabled := false
+	config = &defaultsAppConf{Name: "svc", Server: &defaultsServerConf{Enable: &disabled}}
+	assert.NoError(t, ApplyDefaults(config))
+	assert.Equal(t, "svc", config.Name)
+	assert.False(t, *config.Server.Enable)
+
+	var invalid struct {
+		Port int `yaml:"port" default:"http"`
+	}
+	err := ApplyDefaults(&invalid)
+	var fieldErr *FieldError
+	assert.ErrorAs(t, err, &fieldErr)
+	assert.Equal(t, "port", fieldErr.Path)
+}
+
+// TestCfgManager_Defaults 测试默认值在校验之前填充 默认值函数在标签之后执行
+func TestCfgManager_Defaults(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[defaultsAppConf](ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return("/path/to/config").AnyTimes()
+
+	cm := NewConfigManager[defaultsAppConf](mockLoader, nil, zap.NewNop(), RetryPolicy{}, WithSyncApply())
+	cm.AddDefaults(func(config *defaultsAppConf) {
+		if config.Name == "app" {
+			config.Name = "app-" + config.Server.Hosts[0]
+		}
+	})
+
+	assert.NoError(t, cm.Set(context.Background(), &defaultsAppConf{}))
+	config := cm.GetConfig()
+	assert.Equal(t, "app-a", config.Name)
+	assert.Equal(t, 8080, config.Server.Port)
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	// ReloadTag 声明配置项重载方式的结构体标签
+	ReloadTag = "reload"
+	// ReloadRestart 标签取值 配置项修改后需要重启才能生效
+	ReloadRestart = "restart"
+)
+
+// Change 单个配置项的变更
+type Change struct {
+	Path    string `json:"path"`    // 配置键路径
+	Old     any    `json:"old"`     // 旧值 新增时为空
+	New     any    `json:"new"`     // 新值 删除时为空
+	Restart bool   `json:"restart"` // 是否需要重启才能生效
+}
+
+// ChangeSet 按重载方式分类的配置变更
+type ChangeSet struct {
+	Hot     []Change `json:"hot"`     // 可以热更新的变更
+	Restart []Change `json:"restart"` // 需要重启的变更
+}
+
+// Empty 没有任何变更
+func (c ChangeSet) Empty() bool {
+	return len(c.Hot) == 0 && len(c.Restart) == 0
+}
+
+// RestartPaths 返回需要重启的变更路径
+func (c ChangeSet) RestartPaths() []string {
+	paths := make([]string, len(c.Restart))
+	for i, change := range c.Restart {
+		paths[i] = change.Path
+	}
+	return paths
+}
+
+// Diff 比较两份配置 返回按键路径排序的叶子变更 列表整体作为一个取值比较
+func Diff[T any](oldConfig, newConfig *T) ([]Change, error) {
+	oldTree, err := configTree(oldConfig)
+	if err != nil {
+		return nil, err
+	}
+	newTree, err := configTree(newConfig)
+	if err != nil {
+		return nil, err
+	}
+	var changes []Change
+	diffTree(oldTree, newTree, "", &changes)
+	return changes, nil
+}
+
+// diffTree 递归比较配置树
+func diffTree(oldTree, newTree map[string]any, path string, changes *[]Change) {
+	keys := make(map[string]struct{}, len(oldTree)+len(newTree))
+	for key := range oldTree {
+		keys[key] = struct{}{}
+	}
+	for key := range newTree {
+		keys[key] = struct{}{}
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	for _, key := range sorted {
+		oldValue, newValue := oldTree[key], newTree[key]
+		keyPath := joinPath(path, key)
+		oldMap, oldIsMap := oldValue.(map[string]any)
+		newMap, newIsMap := newValue.(map[string]any)
+		switch {
+		case oldIsMap && newIsMap:
+			diffTree(oldMap, newMap, keyPath, changes)
+		case oldIsMap && newValue == nil:
+			diffTree(oldMap, nil, keyPath, changes)
+		case newIsMap && oldValue == nil:
+			diffTree(nil, newMap, keyPath, changes)
+		case !reflect.DeepEqual(oldValue, newValue):
+			*changes = append(*changes, Change{Path: keyPath, Old: oldValue, New: newValue})
+		}
+	}
+}
+
+// Classify 按重启键将变更分为热更新与需要重启两类 键本身及其子路径都视为需要重启
+func Classify(changes []Change, restartKeys []string) ChangeSet {
+	var set ChangeSet
+	for _, change := range changes {
+		change.Restart = matchesAny(change.Path, restartKeys)
+		if change.Restart {
+			set.Restart = append(set.Restart, change)
+		} else {
+			set.Hot = append(set.Hot, change)
+		}
+	}
+	return set
+}
+
+// matchesAny 判断路径是否为某个键或其子路径
+func matchesAny(path string, keys []string) bool {
+	for _, key := range keys {
+		if path == key || strings.HasPrefix(path, key+".") || strings.HasPrefix(path, key+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+// RestartKeys 返回结构体中带 reload:"restart" 标签的配置键路径
+func RestartKeys(config any) []string {
+	var keys []string
+	collectRestartKeys(reflect.TypeOf(config), "", &keys)
+	return keys
+}
+
+// collectRestartKeys 递归收集带重启标签的字段
+func collectRestartKeys(t reflect.Type, path string, keys *[]string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fieldPath := joinPath(path, name)
+		if field.Tag.Get(ReloadTag) == ReloadRestart {
+			*keys = append(*keys, fieldPath)
+			continue
+		}
+		collectRestartKeys(field.Type, fieldPath, keys)
+	}
+}
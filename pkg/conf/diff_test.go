@@ -0,0 +1,52 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/omeyang/practices/internal/entity"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDiff 测试配置差异与变更分类
+func TestDiff(t *testing.T) {
+	oldConfig := &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9090, Address: "0.0.0.0"}}
+	newConfig := &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9091, Address: "0.0.0.0", Enable: true}}
+
+	changes, err := Diff(oldConfig, newConfig)
+	assert.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Path: "prometheusCfg.enable", Old: false, New: true},
+		{Path: "prometheusCfg.port", Old: 9090, New: 9091},
+	}, changes)
+
+	set := Classify(changes, []string{"prometheusCfg.port"})
+	assert.Equal(t, []string{"prometheusCfg.port"}, set.RestartPaths())
+	assert.Equal(t, []Change{{Path: "prometheusCfg.enable", Old: false, New: true}}, set.Hot)
+	assert.True(t, Classify(nil, nil).Empty())
+
+	// 整个分节被移除时逐项列出
+	changes, err = Diff(oldConfig, &entity.AppConf{})
+	assert.NoError(t, err)
+	assert.Len(t, changes, 3)
+	assert.Empty(t, Classify(changes, []string{"prometheusCfg"}).Hot)
+}
+
+// TestRestartKeys 测试从结构体标签收集重启键
+func TestRestartKeys(t *testing.T) {
+	type listener struct {
+		Port    int    `yaml:"port" reload:"restart"`
+		Timeout string `yaml:"timeout"`
+	}
+	type server struct {
+		Listener *listener `yaml:"listener"`
+		TLS      struct {
+			Cert string `yaml:"cert"`
+		} `yaml:"tls" reload:"restart"`
+		Ignored int `yaml:"-" reload:"restart"`
+	}
+
+	assert.Equal(t, []string{"listener.port", "tls"}, RestartKeys(server{}))
+	assert.Empty(t, RestartKeys((*entity.AppConf)(nil)))
+	assert.Equal(t, []Change{{Path: "tls.cert", Restart: true}}, Classify([]Change{{Path: "tls.cert"}}, RestartKeys(server{})).Restart)
+}
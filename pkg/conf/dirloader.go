@@ -0,0 +1,166 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/afero"
+	"go.uber.org/zap"
+)
+
+// fileSetOps 目录中匹配的文件触发重载的事件类型 新增 删除与改名同样改变了配置文件集合
+const fileSetOps = fsnotify.Write | fsnotify.Create | fsnotify.Remove | fsnotify.Rename
+
+// fileSet 由一组文件组成配置的加载器 GetConfigPath 返回所在的目录
+// CfgManager 监听该目录 只有匹配的文件发生变化时才重新加载
+type fileSet interface {
+	Matches(path string) bool
+}
+
+// DirLoader 加载目录中的全部配置文件并按文件名顺序深度合并 实现 CfgLoader
+// 适用于 conf.d 形式的配置目录 如 10-base.yaml 20-db.json 后面的文件覆盖前面的文件
+type DirLoader[T any] struct {
+	fs      afero.Fs
+	dir     string
+	pattern string // 文件名的匹配模式 如 *.yaml
+	logger  *zap.Logger
+
+	mu   sync.Mutex
+	last *MultiSourceLoader[T] // 上次加载使用的多源加载器 用于查询来源
+}
+
+// NewDirLoader 创建目录加载器 path 为目录 如 /etc/app/conf.d 或带有通配符的文件模式 如 /etc/app/conf.d/*.yaml
+// 只有目录时加载其中全部受支持格式的文件 以点开头的文件与子目录总是被忽略
+func NewDirLoader[T any](fs afero.Fs, path string, logger *zap.Logger) (*DirLoader[T], error) {
+	dir, pattern := NormalizePath(path), "*"
+	if strings.ContainsAny(filepath.Base(dir), "*?[") {
+		dir, pattern = filepath.Dir(dir), filepath.Base(dir)
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid config file pattern %q: %w", path, err)
+		}
+	}
+	return &DirLoader[T]{fs: fs, dir: dir, pattern: pattern, logger: logger}, nil
+}
+
+// GetConfigPath 返回配置目录
+func (l *DirLoader[T]) GetConfigPath() string {
+	return l.dir
+}
+
+// Matches 判断文件是否属于配置文件集合
+func (l *DirLoader[T]) Matches(path string) bool {
+	path = NormalizePath(path)
+	if filepath.Dir(path) != l.dir {
+		return false
+	}
+	name := filepath.Base(path)
+	if strings.HasPrefix(name, ".") {
+		return false
+	}
+	if matched, _ := filepath.Match(l.pattern, name); !matched {
+		return false
+	}
+	_, err := codecFor(filepath.Ext(name))
+	return err == nil
+}
+
+// Files 返回当前匹配的配置文件 按文件名排序
+func (l *DirLoader[T]) Files() ([]string, error) {
+	infos, err := afero.ReadDir(l.fs, l.dir)
+	if err != nil {
+		return nil, fmt.Errorf("read config directory %s: %w", l.dir, err)
+	}
+	var files []string
+	for _, info := range infos {
+		path := filepath.Join(l.dir, info.Name())
+		if info.IsDir() || !l.Matches(path) {
+			continue
+		}
+		files = append(files, path)
+	}
+	return files, nil
+}
+
+// LoadConfig 读取全部匹配的文件 按文件名顺序深度合并后解码 没有匹配的文件时返回错误
+func (l *DirLoader[T]) LoadConfig(ctx context.Context) (*T, error) {
+	files, err := l.Files()
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no config files match %s: %w", filepath.Join(l.dir, l.pattern), os.ErrNotExist)
+	}
+	sources := make([]Source, len(files))
+	for i, file := range files {
+		sources[i] = FileSource(l.fs, file)
+	}
+	loader := NewMultiSourceLoader[T](l.logger, sources...)
+	config, err := loader.LoadConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	l.mu.Lock()
+	l.last = loader
+	l.mu.Unlock()
+	return config, nil
+}
+
+// Origins 返回上次加载中每个叶子键路径来自哪个文件
+func (l *DirLoader[T]) Origins() map[string]string {
+	l.mu.Lock()
+	last := l.last
+	l.mu.Unlock()
+	if last == nil {
+		return map[string]string{}
+	}
+	return last.Origins()
+}
+
+// Fingerprint 以匹配文件的名称 大小与修改时间作为配置文件集合的指纹 实现 Fingerprint
+// 目录本身的修改时间不反映文件内容的变化 轮询监听目录时应使用该指纹
+func (l *DirLoader[T]) Fingerprint(string) (string, error) {
+	files, err := l.Files()
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	for _, file := range files {
+		info, err := l.fs.Stat(file)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(hash, "%s\x00%d\x00%d\n", file, info.Size(), info.ModTime().UnixNano())
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Watcher 返回按 interval 比较配置文件集合指纹的轮询监听器 用于不支持 fsnotify 的文件系统
+func (l *DirLoader[T]) Watcher(interval time.Duration, opts ...PollingOption) *PollingWatcher {
+	return NewPollingWatcher(interval, l.Fingerprint, opts...)
+}
+
+// fileSetChanged 判断目录事件是否改变了配置文件集合 ok 为 false 表示加载器不是文件集合或事件不在其目录中
+func (cm *CfgManager[T]) fileSetChanged(event fsnotify.Event) (changed, ok bool) {
+	set, isSet := cm.loader.(fileSet)
+	if !isSet {
+		return false, false
+	}
+	dir, path := NormalizePath(cm.loader.GetConfigPath()), NormalizePath(event.Name)
+	switch {
+	case path == dir:
+		// 轮询监听器以目录本身的路径报告变化
+		return event.Op&fsnotify.Write != 0, true
+	case filepath.Dir(path) == dir:
+		return set.Matches(path) && event.Op&fileSetOps != 0, true
+	default:
+		return false, false
+	}
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/omeyang/practices/internal/entity"
+	mocks "github.com/omeyang/practices/mocks/conf"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+// TestDirLoader 测试目录中的配置文件按文件名顺序合并 忽略隐藏文件与不支持的格式
+func TestDirLoader(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NoError(t, afero.WriteFile(fs, "/etc/app/conf.d/10-base.yaml", []byte("prometheusCfg:\n  port: 9090\n  address: 127.0.0.1\n"), 0o600))
+	assert.NoError(t, afero.WriteFile(fs, "/etc/app/conf.d/20-prod.json", []byte(`{"prometheusCfg": {"enable": true}}`), 0o600))
+	assert.NoError(t, afero.WriteFile(fs, "/etc/app/conf.d/.30-draft.yaml", []byte("prometheusCfg:\n  port: 1\n"), 0o600))
+	assert.NoError(t, afero.WriteFile(fs, "/etc/app/conf.d/README.md", []byte("# notes\n"), 0o600))
+
+	loader, err := NewDirLoader[entity.AppConf](fs, "/etc/app/conf.d/", zap.NewNop())
+	assert.NoError(t, err)
+	assert.Equal(t, NormalizePath("/etc/app/conf.d"), loader.GetConfigPath())
+	config, err := loader.LoadConfig(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, &entity.PrometheusConf{Enable: true, Port: 9090, Address: "127.0.0.1"}, config.PrometheusCfg)
+	assert.Equal(t, NormalizePath("/etc/app/conf.d/20-prod.json"), loader.Origins()["prometheusCfg.enable"])
+
+	yamlOnly, err := NewDirLoader[entity.AppConf](fs, "/etc/app/conf.d/*.yaml", zap.NewNop())
+	assert.NoError(t, err)
+	files, err := yamlOnly.Files()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{NormalizePath("/etc/app/conf.d/10-base.yaml")}, files)
+
+	before, err := loader.Fingerprint(loader.GetConfigPath())
+	assert.NoError(t, err)
+	assert.NoError(t, afero.WriteFile(fs, "/etc/app/conf.d/30-local.yaml", []byte("prometheusCfg:\n  port: 9100\n"), 0o600))
+	after, err := loader.Fingerprint(loader.GetConfigPath())
+	assert.NoError(t, err)
+	assert.NotEqual(t, before, after)
+
+	empty, err := NewDirLoader[entity.AppConf](fs, "/etc/app/conf.d/*.toml", zap.NewNop())
+	assert.NoError(t, err)
+	_, err = empty.LoadConfig(context.Background())
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	_, err = NewDirLoader[entity.AppConf](fs, "/etc/app/conf.d/[", zap.NewNop())
+	assert.Error(t, err)
+}
+
+// TestCfgManager_DirLoaderEvents 测试目录中匹配文件的增删改触发重载 其他文件不触发
+func TestCfgManager_DirLoaderEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	loader, err := NewDirLoader[entity.AppConf](afero.NewMemMapFs(), "/etc/app/conf.d/*.yaml", zap.NewNop())
+	assert.NoError(t, err)
+	cm := NewConfigManager[entity.AppConf](loader, mocks.NewMockWatcherInterface(ctrl), zap.NewNop(), RetryPolicy{})
+
+	dir := loader.GetConfigPath()
+	for _, tt := range []struct {
+		event    fsnotify.Event
+		expected bool
+	}{
+		{fsnotify.Event{Name: dir + "/10-base.yaml", Op: fsnotify.Write}, true},
+		{fsnotify.Event{Name: dir + "/20-db.yaml", Op: fsnotify.Create}, true},
+		{fsnotify.Event{Name: dir + "/20-db.yaml", Op: fsnotify.Remove}, true},
+		{fsnotify.Event{Name: dir + "/20-db.yaml", Op: fsnotify.Chmod}, false},
+		{fsnotify.Event{Name: dir + "/20-db.json", Op: fsnotify.Write}, false},
+		{fsnotify.Event{Name: dir + "/.10-base.yaml.swp", Op: fsnotify.Write}, false},
+		{fsnotify.Event{Name: dir, Op: fsnotify.Write}, true},
+	} {
+		assert.Equal(t, tt.expected, cm.triggersReload(tt.event), tt.event.String())
+	}
+}
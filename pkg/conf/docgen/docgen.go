@@ -0,0 +1,188 @@
+// Package docgen 根据配置结构体的源码(字段 标签 注释)生成配置参考文档
+package docgen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Field 配置参考文档中的一项
+type Field struct {
+	Key         string // 配置键路径 如 prometheusCfg.port
+	Type        string // 字段类型
+	Default     string // default 标签声明的默认值
+	Validate    string // validate 标签声明的校验规则
+	Description string // 字段注释
+}
+
+// Parse 解析目录下的 Go 源码 展开 typeName 结构体的全部配置项
+func Parse(dir, typeName string) ([]Field, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	types := map[string]*ast.StructType{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", name, err)
+		}
+		collectStructs(file, types)
+	}
+
+	root, ok := types[typeName]
+	if !ok {
+		return nil, fmt.Errorf("struct %s not found in %s", typeName, dir)
+	}
+
+	w := &walker{types: types, visiting: map[string]bool{typeName: true}}
+	w.walkStruct(root, "")
+	return w.fields, nil
+}
+
+// collectStructs 收集文件中声明的结构体类型
+func collectStructs(file *ast.File, types map[string]*ast.StructType) {
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts := spec.(*ast.TypeSpec)
+			if st, ok := ts.Type.(*ast.StructType); ok {
+				types[ts.Name.Name] = st
+			}
+		}
+	}
+}
+
+// walker 递归展开结构体字段
+type walker struct {
+	types    map[string]*ast.StructType
+	visiting map[string]bool // 防止递归类型无限展开
+	fields   []Field
+}
+
+// walkStruct 展开结构体的每个导出字段
+func (w *walker) walkStruct(st *ast.StructType, prefix string) {
+	for _, f := range st.Fields.List {
+		tag := reflect.StructTag("")
+		if f.Tag != nil {
+			if unquoted, err := strconv.Unquote(f.Tag.Value); err == nil {
+				tag = reflect.StructTag(unquoted)
+			}
+		}
+
+		for _, name := range f.Names {
+			if !name.IsExported() {
+				continue
+			}
+			key := fieldKey(name.Name, tag)
+			if key == "-" {
+				continue
+			}
+			w.walkField(f, joinKey(prefix, key), tag)
+		}
+	}
+}
+
+// walkField 记录字段 并在字段为本包结构体时继续展开
+func (w *walker) walkField(f *ast.Field, key string, tag reflect.StructTag) {
+	w.fields = append(w.fields, Field{
+		Key:         key,
+		Type:        typeString(f.Type),
+		Default:     tag.Get("default"),
+		Validate:    tag.Get("validate"),
+		Description: fieldComment(f),
+	})
+
+	name, suffix := nestedStruct(f.Type)
+	st, ok := w.types[name]
+	if !ok || w.visiting[name] {
+		return
+	}
+	w.visiting[name] = true
+	w.walkStruct(st, key+suffix)
+	delete(w.visiting, name)
+}
+
+// nestedStruct 返回字段指向的本包类型名及其子键的路径后缀
+func nestedStruct(expr ast.Expr) (string, string) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name, ""
+	case *ast.StarExpr:
+		return nestedStruct(t.X)
+	case *ast.ArrayType:
+		name, suffix := nestedStruct(t.Elt)
+		return name, "[]" + suffix
+	case *ast.MapType:
+		name, suffix := nestedStruct(t.Value)
+		return name, ".<key>" + suffix
+	default:
+		return "", ""
+	}
+}
+
+// fieldKey 依次取 yaml/json 标签名 缺省时使用字段名
+func fieldKey(name string, tag reflect.StructTag) string {
+	for _, key := range []string{"yaml", "json"} {
+		if v, ok := tag.Lookup(key); ok {
+			if n := strings.Split(v, ",")[0]; n != "" {
+				return n
+			}
+		}
+	}
+	return name
+}
+
+// joinKey 拼接配置键路径
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// fieldComment 优先取字段上方的文档注释 其次取行尾注释
+func fieldComment(f *ast.Field) string {
+	for _, group := range []*ast.CommentGroup{f.Doc, f.Comment} {
+		if text := strings.TrimSpace(group.Text()); text != "" {
+			return strings.Join(strings.Fields(text), " ")
+		}
+	}
+	return ""
+}
+
+// typeString 将类型表达式格式化为字符串
+func typeString(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return typeString(t.X)
+	case *ast.SelectorExpr:
+		return typeString(t.X) + "." + t.Sel.Name
+	case *ast.ArrayType:
+		return "[]" + typeString(t.Elt)
+	case *ast.MapType:
+		return "map[" + typeString(t.Key) + "]" + typeString(t.Value)
+	case *ast.InterfaceType:
+		return "any"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,65 @@
+package docgen
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSource = `package sample
+
+// Conf 示例配置
+type Conf struct {
+	Server  *ServerConf           ` + "`yaml:\"server\"`" + ` // 服务配置
+	Tenants map[string]TenantConf ` + "`yaml:\"tenants\"`" + `
+	Ignored string                ` + "`yaml:\"-\"`" + `
+	hidden  string
+}
+
+// ServerConf 服务配置
+type ServerConf struct {
+	// Port 监听端口
+	Port int ` + "`yaml:\"port\" default:\"8080\" validate:\"range=1-65535\"`" + `
+}
+
+// TenantConf 租户配置
+type TenantConf struct {
+	Name string ` + "`json:\"name\"`" + ` // 租户名称
+}
+`
+
+// TestParse 测试从源码展开配置项
+func TestParse(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "conf.go"), []byte(testSource), 0o644))
+
+	fields, err := Parse(dir, "Conf")
+	assert.NoError(t, err)
+	assert.Equal(t, []Field{
+		{Key: "server", Type: "ServerConf", Description: "服务配置"},
+		{Key: "server.port", Type: "int", Default: "8080", Validate: "range=1-65535", Description: "Port 监听端口"},
+		{Key: "tenants", Type: "map[string]TenantConf"},
+		{Key: "tenants.<key>.name", Type: "string", Description: "租户名称"},
+	}, fields)
+
+	_, err = Parse(dir, "Missing")
+	assert.Error(t, err)
+}
+
+// TestRender 测试文档输出格式
+func TestRender(t *testing.T) {
+	fields := []Field{{Key: "server.port", Type: "int", Default: "8080", Description: "a | b"}}
+
+	var md bytes.Buffer
+	assert.NoError(t, Render(&md, "Reference", fields, FormatMarkdown))
+	assert.Contains(t, md.String(), "| `server.port` | int | `8080` |  | a \\| b |")
+
+	var html bytes.Buffer
+	assert.NoError(t, Render(&html, "Reference", fields, FormatHTML))
+	assert.Contains(t, html.String(), "<td><code>server.port</code></td>")
+
+	assert.Error(t, Render(&md, "Reference", fields, "pdf"))
+}
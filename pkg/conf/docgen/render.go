@@ -0,0 +1,72 @@
+package docgen
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// Format 参考文档输出格式
+type Format string
+
+const (
+	// FormatMarkdown Markdown 表格
+	FormatMarkdown Format = "markdown"
+	// FormatHTML HTML 表格
+	FormatHTML Format = "html"
+)
+
+// Render 按指定格式输出配置参考文档
+func Render(w io.Writer, title string, fields []Field, format Format) error {
+	switch format {
+	case FormatMarkdown, "md", "":
+		return RenderMarkdown(w, title, fields)
+	case FormatHTML:
+		return RenderHTML(w, title, fields)
+	default:
+		return fmt.Errorf("unsupported reference format: %s", format)
+	}
+}
+
+// RenderMarkdown 输出 Markdown 格式的配置参考文档
+func RenderMarkdown(w io.Writer, title string, fields []Field) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	b.WriteString("| Key | Type | Default | Validation | Description |\n")
+	b.WriteString("| --- | --- | --- | --- | --- |\n")
+	for _, f := range fields {
+		fmt.Fprintf(&b, "| `%s` | %s | %s | %s | %s |\n",
+			f.Key, markdownCell(f.Type), markdownCode(f.Default), markdownCode(f.Validate), markdownCell(f.Description))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// RenderHTML 输出 HTML 格式的配置参考文档
+func RenderHTML(w io.Writer, title string, fields []Field) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<h1>%s</h1>\n<table>\n", html.EscapeString(title))
+	b.WriteString("<tr><th>Key</th><th>Type</th><th>Default</th><th>Validation</th><th>Description</th></tr>\n")
+	for _, f := range fields {
+		fmt.Fprintf(&b, "<tr><td><code>%s</code></td><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>\n",
+			html.EscapeString(f.Key), html.EscapeString(f.Type), html.EscapeString(f.Default),
+			html.EscapeString(f.Validate), html.EscapeString(f.Description))
+	}
+	b.WriteString("</table>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownCell 转义表格单元格中的竖线
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// markdownCode 非空值以代码格式输出
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + markdownCell(s) + "`"
+}
@@ -0,0 +1,211 @@
+package docgen
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// sampleKind 示例节点的类型
+type sampleKind int
+
+const (
+	kindScalar sampleKind = iota // 标量字段
+	kindObject                   // 结构体字段
+	kindList                     // 结构体切片字段
+	kindMap                      // 结构体映射字段
+)
+
+// sampleMapKey 结构体映射在示例中使用的键名
+const sampleMapKey = "example"
+
+// sampleNode 示例配置树中的节点
+type sampleNode struct {
+	field    Field
+	name     string
+	kind     sampleKind
+	children []*sampleNode
+}
+
+// Sample 根据配置项生成带注释与默认值的示例配置 格式支持 yaml 与 json
+// json 不支持注释 仅包含默认值
+func Sample(w io.Writer, fields []Field, format string) error {
+	roots := buildSampleTree(fields)
+
+	var b strings.Builder
+	switch format {
+	case "yaml", "yml", "":
+		writeYAMLNodes(&b, roots, 0)
+	case "json":
+		writeJSONObject(&b, roots, 0)
+		b.WriteByte('\n')
+	default:
+		return fmt.Errorf("unsupported sample format: %s", format)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// buildSampleTree 根据配置键路径还原字段层级
+func buildSampleTree(fields []Field) []*sampleNode {
+	var roots []*sampleNode
+	nodes := map[string]*sampleNode{}
+	for _, f := range fields {
+		n := &sampleNode{field: f, name: f.Key}
+		nodes[f.Key] = n
+
+		parentKey, kind := parentOf(f.Key)
+		parent, ok := nodes[parentKey]
+		if !ok {
+			roots = append(roots, n)
+			continue
+		}
+		n.name = f.Key[strings.LastIndex(f.Key, ".")+1:]
+		parent.kind = kind
+		parent.children = append(parent.children, n)
+	}
+	return roots
+}
+
+// parentOf 计算父字段的键路径及父字段的类型
+func parentOf(key string) (string, sampleKind) {
+	idx := strings.LastIndex(key, ".")
+	if idx < 0 {
+		return "", kindScalar
+	}
+	parent := key[:idx]
+	switch {
+	case strings.HasSuffix(parent, "[]"):
+		return strings.TrimSuffix(parent, "[]"), kindList
+	case strings.HasSuffix(parent, ".<key>"):
+		return strings.TrimSuffix(parent, ".<key>"), kindMap
+	default:
+		return parent, kindObject
+	}
+}
+
+// writeYAMLNodes 输出 yaml 示例
+func writeYAMLNodes(b *strings.Builder, nodes []*sampleNode, indent int) {
+	for _, n := range nodes {
+		writeYAMLNode(b, n, indent, "")
+	}
+}
+
+// writeYAMLNode 输出单个节点 prefix 用于列表首个元素的 "- " 前缀
+func writeYAMLNode(b *strings.Builder, n *sampleNode, indent int, prefix string) {
+	pad := strings.Repeat(" ", indent)
+	commentPad := pad
+	if prefix != "" {
+		commentPad = strings.Repeat(" ", indent-len(prefix))
+	}
+	if comment := sampleComment(n.field); comment != "" {
+		fmt.Fprintf(b, "%s# %s\n", commentPad, comment)
+	}
+
+	head := pad
+	if prefix != "" {
+		head = commentPad + prefix
+	}
+	switch {
+	case len(n.children) == 0:
+		fmt.Fprintf(b, "%s%s: %s\n", head, n.name, yamlValue(n.field))
+	case n.kind == kindList:
+		fmt.Fprintf(b, "%s%s:\n", head, n.name)
+		for i, child := range n.children {
+			childPrefix := ""
+			if i == 0 {
+				childPrefix = "- "
+			}
+			writeYAMLNode(b, child, indent+4, childPrefix)
+		}
+	case n.kind == kindMap:
+		fmt.Fprintf(b, "%s%s:\n%s  %s:\n", head, n.name, pad, sampleMapKey)
+		writeYAMLNodes(b, n.children, indent+4)
+	default:
+		fmt.Fprintf(b, "%s%s:\n", head, n.name)
+		writeYAMLNodes(b, n.children, indent+2)
+	}
+}
+
+// sampleComment 字段注释 附带类型与校验规则
+func sampleComment(f Field) string {
+	var parts []string
+	if f.Description != "" {
+		parts = append(parts, f.Description)
+	}
+	meta := "type: " + f.Type
+	if f.Validate != "" {
+		meta += ", validate: " + f.Validate
+	}
+	parts = append(parts, "("+meta+")")
+	return strings.Join(parts, " ")
+}
+
+// yamlValue 标量字段的示例值 优先使用默认值
+func yamlValue(f Field) string {
+	switch {
+	case strings.HasPrefix(f.Type, "[]"):
+		if f.Default != "" {
+			return "[" + f.Default + "]"
+		}
+		return "[]"
+	case strings.HasPrefix(f.Type, "map["):
+		return "{}"
+	}
+	return scalarValue(f)
+}
+
+// scalarValue 标量示例值 数值与布尔值原样输出 其余按字符串引用
+func scalarValue(f Field) string {
+	switch f.Type {
+	case "bool":
+		if f.Default == "" {
+			return "false"
+		}
+		return f.Default
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "float32", "float64":
+		if f.Default == "" {
+			return "0"
+		}
+		return f.Default
+	case "time.Time":
+		if f.Default == "" {
+			return "null"
+		}
+	}
+	return strconv.Quote(f.Default)
+}
+
+// writeJSONObject 按字段声明顺序输出 json 对象
+func writeJSONObject(b *strings.Builder, nodes []*sampleNode, indent int) {
+	pad := strings.Repeat("  ", indent+1)
+	b.WriteString("{\n")
+	for i, n := range nodes {
+		fmt.Fprintf(b, "%s%q: ", pad, n.name)
+		writeJSONValue(b, n, indent+1)
+		if i < len(nodes)-1 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('\n')
+	}
+	b.WriteString(strings.Repeat("  ", indent) + "}")
+}
+
+// writeJSONValue 输出节点的 json 取值
+func writeJSONValue(b *strings.Builder, n *sampleNode, indent int) {
+	switch {
+	case len(n.children) == 0:
+		b.WriteString(yamlValue(n.field))
+	case n.kind == kindList:
+		b.WriteString("[")
+		writeJSONObject(b, n.children, indent)
+		b.WriteString("]")
+	case n.kind == kindMap:
+		fmt.Fprintf(b, "{%q: ", sampleMapKey)
+		writeJSONObject(b, n.children, indent)
+		b.WriteString("}")
+	default:
+		writeJSONObject(b, n.children, indent)
+	}
+}
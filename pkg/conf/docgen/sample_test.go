@@ -0,0 +1,52 @@
+package docgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+// sampleFields 示例配置项
+var sampleFields = []Field{
+	{Key: "server", Type: "ServerConf", Description: "服务配置"},
+	{Key: "server.port", Type: "int", Default: "8080", Validate: "range=1-65535", Description: "监听端口"},
+	{Key: "server.host", Type: "string"},
+	{Key: "routes", Type: "[]RouteConf"},
+	{Key: "routes[].path", Type: "string", Default: "/"},
+	{Key: "routes[].weight", Type: "int"},
+	{Key: "tenants", Type: "map[string]TenantConf"},
+	{Key: "tenants.<key>.name", Type: "string"},
+	{Key: "tags", Type: "[]string"},
+}
+
+// TestSampleYAML 测试生成 yaml 示例配置
+func TestSampleYAML(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, Sample(&buf, sampleFields, "yaml"))
+	assert.Contains(t, buf.String(), "  # 监听端口 (type: int, validate: range=1-65535)\n  port: 8080\n")
+
+	var decoded map[string]any
+	assert.NoError(t, yaml.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, map[string]any{
+		"server":  map[string]any{"port": 8080, "host": ""},
+		"routes":  []any{map[string]any{"path": "/", "weight": 0}},
+		"tenants": map[string]any{"example": map[string]any{"name": ""}},
+		"tags":    []any{},
+	}, decoded)
+}
+
+// TestSampleJSON 测试生成 json 示例配置
+func TestSampleJSON(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, Sample(&buf, sampleFields, "json"))
+
+	var decoded map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, map[string]any{"port": float64(8080), "host": ""}, decoded["server"])
+	assert.Equal(t, []any{map[string]any{"path": "/", "weight": float64(0)}}, decoded["routes"])
+
+	assert.Error(t, Sample(&buf, sampleFields, "xml"))
+}
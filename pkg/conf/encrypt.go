@@ -0,0 +1,370 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// EncryptedPrefix 加密取值的前缀 其后为 base64 编码的 nonce 与 AES-GCM 密文
+const EncryptedPrefix = "enc:"
+
+// 加密取值的另一种写法 ENC[...] 方括号中为与 enc: 前缀之后相同的内容 两种写法可以在同一文件中混用
+const (
+	encryptedOpen  = "ENC["
+	encryptedClose = "]"
+)
+
+// ErrDecrypt 加密取值无法解密
+var ErrDecrypt = errors.New("cannot decrypt config value")
+
+// keyTimeout 单次变换或解析中获取数据密钥的超时 避免 KMS 不可用时加载一直阻塞
+const keyTimeout = 10 * time.Second
+
+// KeySource 提供解密配置取值所需的数据密钥 长度为 16 24 或 32 字节
+type KeySource interface {
+	DataKey(ctx context.Context) ([]byte, error)
+}
+
+// keyInvalidator 支持在解密失败后丢弃缓存密钥的 KeySource
+type keyInvalidator interface {
+	Invalidate()
+}
+
+// StaticKeySource 固定的数据密钥 主要用于测试与本地开发
+type StaticKeySource []byte
+
+// DataKey 实现 KeySource
+func (k StaticKeySource) DataKey(context.Context) ([]byte, error) {
+	return k, nil
+}
+
+// KMSClient KMS 的解密操作 awskms 与 gcpkms 包分别适配 AWS KMS 与 GCP KMS 的客户端
+type KMSClient interface {
+	Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error)
+}
+
+// KMSKeySource 通过 KMS 解密信封加密的数据密钥 明文密钥只保存在内存中
+// 密钥在首次使用时获取并缓存 解密失败时丢弃缓存 下次使用时重新获取 以适应密钥轮换
+type KMSKeySource struct {
+	client       KMSClient
+	encryptedKey []byte
+
+	mu  sync.Mutex
+	key []byte
+}
+
+// NewKMSKeySource 创建 KMS 密钥源 encryptedKey 为 KMS 加密后的数据密钥
+func NewKMSKeySource(client KMSClient, encryptedKey []byte) *KMSKeySource {
+	return &KMSKeySource{client: client, encryptedKey: encryptedKey}
+}
+
+// DataKey 返回缓存的数据密钥 尚未缓存时调用 KMS 解密
+func (k *KMSKeySource) DataKey(ctx context.Context) ([]byte, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.key != nil {
+		return k.key, nil
+	}
+	key, err := k.client.Decrypt(ctx, k.encryptedKey)
+	if err != nil {
+		return nil, fmt.Errorf("kms decrypt data key: %w", err)
+	}
+	k.key = key
+	return key, nil
+}
+
+// Invalidate 丢弃缓存的数据密钥
+func (k *KMSKeySource) Invalidate() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.key = nil
+}
+
+// DecryptValues 返回解密配置树中 enc: 前缀取值的配置树变换
+// 使用缓存密钥解密失败时会刷新一次密钥后重试 每次变换获取密钥的总时长不超过 keyTimeout
+func DecryptValues(keys KeySource) Transform {
+	return func(tree map[string]any) error {
+		// 变换在每次加载时执行 不能沿用创建变换时的 ctx
+		ctx, cancel := context.WithTimeout(context.Background(), keyTimeout)
+		defer cancel()
+		d := &valueDecrypter{ctx: ctx, keys: keys}
+		var errs MultiError
+		for key, value := range tree {
+			tree[key] = d.decryptValue(value, key, &errs)
+		}
+		return errs.ErrorOrNil()
+	}
+}
+
+// valueDecrypter 一次变换中共享的解密状态
+type valueDecrypter struct {
+	ctx       context.Context
+	keys      KeySource
+	refreshed bool
+}
+
+// decryptValue 递归解密配置树中的加密取值
+func (d *valueDecrypter) decryptValue(node any, path string, errs *MultiError) any {
+	switch n := node.(type) {
+	case map[string]any:
+		for key, child := range n {
+			n[key] = d.decryptValue(child, joinPath(path, key), errs)
+		}
+		return n
+	case []any:
+		for i, item := range n {
+			n[i] = d.decryptValue(item, indexPath(path, i), errs)
+		}
+		return n
+	case string:
+		if !IsEncrypted(n) {
+			return n
+		}
+		plain, err := d.decrypt(n)
+		if err != nil {
+			errs.Append(&FieldError{Path: path, Message: err.Error(), Code: CodeDecryptFailed, Params: []any{err.Error()}, Err: err})
+			return n
+		}
+		return plain
+	default:
+		return node
+	}
+}
+
+// decrypt 解密单个取值 失败时刷新一次密钥
+func (d *valueDecrypter) decrypt(value string) (string, error) {
+	key, err := d.keys.DataKey(d.ctx)
+	if err != nil {
+		return "", err
+	}
+	plain, err := DecryptValue(key, value)
+	if err == nil || d.refreshed {
+		return plain, err
+	}
+
+	invalidator, ok := d.keys.(keyInvalidator)
+	if !ok {
+		return "", err
+	}
+	d.refreshed = true
+	invalidator.Invalidate()
+	if key, err = d.keys.DataKey(d.ctx); err != nil {
+		return "", err
+	}
+	return DecryptValue(key, value)
+}
+
+// EncryptValue 加密配置取值 返回带 enc: 前缀的字符串
+func EncryptValue(key []byte, plaintext string) (string, error) {
+	sealed, err := seal(key, plaintext)
+	if err != nil {
+		return "", err
+	}
+	return EncryptedPrefix + sealed, nil
+}
+
+// EncryptValueBracketed 加密配置取值 返回 ENC[...] 格式的字符串
+func EncryptValueBracketed(key []byte, plaintext string) (string, error) {
+	sealed, err := seal(key, plaintext)
+	if err != nil {
+		return "", err
+	}
+	return encryptedOpen + sealed + encryptedClose, nil
+}
+
+// seal 以 AES-GCM 加密 返回 base64 编码的 nonce 与密文
+func seal(key []byte, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(plaintext), nil)), nil
+}
+
+// IsEncrypted 判断取值是否为 enc: 前缀或 ENC[...] 格式的加密取值
+// SOPS 的 ENC[AES256_GCM,...] 取值含有逗号 不属于这两种格式 需由 SOPS 解密整个文件
+func IsEncrypted(value string) bool {
+	_, ok := encryptedPayload(value)
+	return ok
+}
+
+// encryptedPayload 去掉加密取值的前缀或方括号 返回 base64 内容
+func encryptedPayload(value string) (string, bool) {
+	switch {
+	case strings.HasPrefix(value, EncryptedPrefix):
+		return strings.TrimPrefix(value, EncryptedPrefix), true
+	case strings.HasPrefix(value, encryptedOpen) && strings.HasSuffix(value, encryptedClose) && !strings.Contains(value, ","):
+		return value[len(encryptedOpen) : len(value)-len(encryptedClose)], true
+	default:
+		return "", false
+	}
+}
+
+// DecryptValue 解密 enc: 前缀或 ENC[...] 格式的配置取值
+func DecryptValue(key []byte, value string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	payload, ok := encryptedPayload(value)
+	if !ok {
+		return "", ErrDecrypt
+	}
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil || len(data) < gcm.NonceSize() {
+		return "", ErrDecrypt
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	return string(plain), nil
+}
+
+// newGCM 使用数据密钥创建 AES-GCM
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// ErrUnsupportedEncryption 配置文件的加密格式没有可用的解密器
+var ErrUnsupportedEncryption = errors.New("unsupported config file encryption")
+
+// FileDecryptor 解密整个配置文件 由 SOPS 或 age 的客户端库适配实现
+type FileDecryptor interface {
+	Decrypt(ctx context.Context, data []byte) ([]byte, error)
+}
+
+// FileDecryptorFunc 函数形式的 FileDecryptor
+type FileDecryptorFunc func(ctx context.Context, data []byte) ([]byte, error)
+
+// Decrypt 实现 FileDecryptor
+func (f FileDecryptorFunc) Decrypt(ctx context.Context, data []byte) ([]byte, error) {
+	return f(ctx, data)
+}
+
+// CommandDecryptor 调用外部命令解密文件 密文写入标准输入 从标准输出读取明文
+// 例如 CommandDecryptor("age", "--decrypt", "-i", identityFile)
+// 或 CommandDecryptor("sops", "--decrypt", "--input-type", "yaml", "--output-type", "yaml", "/dev/stdin")
+func CommandDecryptor(name string, args ...string) FileDecryptor {
+	return FileDecryptorFunc(func(ctx context.Context, data []byte) ([]byte, error) {
+		cmd := exec.CommandContext(ctx, name, args...)
+		cmd.Stdin = bytes.NewReader(data)
+		return cmd.Output()
+	})
+}
+
+var (
+	// ageHeaders age 加密文件的二进制与 ASCII armor 文件头
+	ageHeaders = [][]byte{[]byte("age-encryption.org/v1\n"), []byte("-----BEGIN AGE ENCRYPTED FILE-----")}
+	// sopsMarker SOPS 加密取值的标记
+	sopsMarker = []byte("ENC[AES256_GCM,")
+	// sopsMetadata SOPS 在 YAML 与 JSON 文件中写入的元数据键
+	sopsMetadata = regexp.MustCompile(`(?m)^sops:\s*$|"sops"\s*:\s*\{`)
+)
+
+// DecryptingParser 在内存中解密整个配置文件后交给内部解析器 解密后的明文不会落盘或写入日志
+//
+// 支持三种格式 文件内容整体为 enc: 前缀的 AES-GCM 密文时使用 Keys 解密 由 EncryptFile 生成
+// SOPS 与 age 加密的文件分别交给 SOPS 与 Age 解密 未设置对应解密器时返回 ErrUnsupportedEncryption
+// 未加密的文件直接透传 设置 RequireEncryption 时拒绝
+type DecryptingParser[T any] struct {
+	Inner             CfgParser[T]
+	Keys              KeySource     // AES-GCM 的数据密钥来源
+	SOPS              FileDecryptor // SOPS 解密器 可选
+	Age               FileDecryptor // age 解密器 可选
+	RequireEncryption bool          // 拒绝未加密的文件
+}
+
+// Parse 解密并解析配置
+func (p *DecryptingParser[T]) Parse(file afero.File) (*T, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), keyTimeout)
+	defer cancel()
+	plain, err := p.Decrypt(ctx, data)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt %s: %w", file.Name(), err)
+	}
+	return parseBytes(p.Inner, file.Name(), plain)
+}
+
+// Decrypt 识别加密格式并解密整个文件 不经过 Inner 解析 未加密的文件按 RequireEncryption 透传或拒绝
+func (p *DecryptingParser[T]) Decrypt(ctx context.Context, data []byte) ([]byte, error) {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte(EncryptedPrefix)) && !bytes.ContainsAny(trimmed, "\r\n"):
+		if p.Keys == nil {
+			return nil, fmt.Errorf("%w: aes-gcm envelope requires a key source", ErrUnsupportedEncryption)
+		}
+		d := &valueDecrypter{ctx: ctx, keys: p.Keys}
+		plain, err := d.decrypt(string(trimmed))
+		if err != nil {
+			return nil, err
+		}
+		return []byte(plain), nil
+	case isAgeEncrypted(data):
+		return decryptWith(ctx, p.Age, "age", data)
+	case bytes.Contains(data, sopsMarker) && sopsMetadata.Match(data):
+		return decryptWith(ctx, p.SOPS, "sops", data)
+	case p.RequireEncryption:
+		return nil, errors.New("config file is not encrypted")
+	default:
+		return data, nil
+	}
+}
+
+// isAgeEncrypted 判断是否为 age 加密的文件
+func isAgeEncrypted(data []byte) bool {
+	for _, header := range ageHeaders {
+		if bytes.HasPrefix(data, header) {
+			return true
+		}
+	}
+	return false
+}
+
+// decryptWith 使用外部解密器解密 未设置时返回 ErrUnsupportedEncryption
+func decryptWith(ctx context.Context, decryptor FileDecryptor, format string, data []byte) ([]byte, error) {
+	if decryptor == nil {
+		return nil, fmt.Errorf("%w: %s-encrypted file requires a %s decryptor", ErrUnsupportedEncryption, format, format)
+	}
+	plain, err := decryptor.Decrypt(ctx, data)
+	if err != nil {
+		// 外部解密器的错误可能带有文件内容 只保留格式信息
+		return nil, fmt.Errorf("%s: %w", format, ErrDecrypt)
+	}
+	return plain, nil
+}
+
+// EncryptFile 以 AES-GCM 加密整个配置文件 结果可由 DecryptingParser 解密
+func EncryptFile(key, plaintext []byte) ([]byte, error) {
+	value, err := EncryptValue(key, string(plaintext))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(value + "\n"), nil
+}
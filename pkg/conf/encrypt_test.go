@@ -0,0 +1,159 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"testing"
+
+	"github.com/omeyang/practices/internal/entity"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// fakeKMS 测试用 KMS 客户端 按调用次数依次返回密钥
+type fakeKMS struct {
+	keys  [][]byte
+	calls int
+}
+
+func (f *fakeKMS) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		return nil, errors.New("no deadline")
+	}
+	if !bytes.Equal(ciphertext, []byte("wrapped")) {
+		return nil, errors.New("unknown key")
+	}
+	key := f.keys[min(f.calls, len(f.keys)-1)]
+	f.calls++
+	return key, nil
+}
+
+// TestEncryptValue 测试加解密往返
+func TestEncryptValue(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 32)
+	value, err := EncryptValue(key, "s3cret")
+	assert.NoError(t, err)
+	assert.Contains(t, value, EncryptedPrefix)
+
+	plain, err := DecryptValue(key, value)
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cret", plain)
+
+	_, err = DecryptValue(bytes.Repeat([]byte{2}, 32), value)
+	assert.ErrorIs(t, err, ErrDecrypt)
+	_, err = EncryptValue([]byte("short"), "x")
+	assert.Error(t, err)
+
+	// ENC[...] 格式与 enc: 前缀格式使用同样的密钥 SOPS 的取值不属于这两种格式
+	bracketed, err := EncryptValueBracketed(key, "s3cret")
+	assert.NoError(t, err)
+	assert.Regexp(t, `^ENC\[[A-Za-z0-9+/=]+\]$`, bracketed)
+	assert.True(t, IsEncrypted(bracketed))
+	plain, err = DecryptValue(key, bracketed)
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cret", plain)
+	assert.False(t, IsEncrypted("ENC[AES256_GCM,data:abc,type:str]"))
+	_, err = DecryptValue(key, "s3cret")
+	assert.ErrorIs(t, err, ErrDecrypt)
+}
+
+// TestDecryptValues 测试解密变换 缓存密钥以及密钥轮换后的重新获取
+func TestDecryptValues(t *testing.T) {
+	oldKey, newKey := bytes.Repeat([]byte{1}, 32), bytes.Repeat([]byte{2}, 32)
+	kms := &fakeKMS{keys: [][]byte{oldKey, newKey}}
+	source := NewKMSKeySource(kms, []byte("wrapped"))
+
+	encrypted, err := EncryptValue(oldKey, "first")
+	assert.NoError(t, err)
+	tree := map[string]any{"db": map[string]any{"password": encrypted, "user": "app"}}
+	assert.NoError(t, DecryptValues(source)(tree))
+	assert.Equal(t, map[string]any{"password": "first", "user": "app"}, tree["db"])
+
+	// 密钥轮换后使用缓存的旧密钥解密失败 刷新后成功
+	encrypted, err = EncryptValue(newKey, "second")
+	assert.NoError(t, err)
+	bracketed, err := EncryptValueBracketed(newKey, "second")
+	assert.NoError(t, err)
+	tree = map[string]any{"list": []any{encrypted, bracketed}}
+	assert.NoError(t, DecryptValues(source)(tree))
+	assert.Equal(t, []any{"second", "second"}, tree["list"])
+	assert.Equal(t, 2, kms.calls)
+
+	tree = map[string]any{"db": map[string]any{"password": "enc:garbage"}}
+	err = DecryptValues(StaticKeySource(newKey))(tree)
+	assert.ErrorIs(t, err, ErrDecrypt)
+	assert.ErrorContains(t, err, "db.password")
+}
+
+// TestDecryptingParser 测试按格式解密整个配置文件后解析
+func TestDecryptingParser(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 32)
+	plain := []byte("prometheusCfg:\n  port: 9090\n")
+	encrypted, err := EncryptFile(key, plain)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(encrypted), "9090")
+
+	fs := afero.NewMemMapFs()
+	parse := func(parser *DecryptingParser[entity.AppConf], data []byte) (*entity.AppConf, error) {
+		assert.NoError(t, afero.WriteFile(fs, "/config.yaml", data, 0o600))
+		file, err := fs.Open("/config.yaml")
+		assert.NoError(t, err)
+		defer file.Close()
+		return parser.Parse(file)
+	}
+	inner := &YAMLParser[entity.AppConf]{Logger: zap.NewNop()}
+	parser := &DecryptingParser[entity.AppConf]{Inner: inner, Keys: StaticKeySource(key)}
+
+	config, err := parse(parser, encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, 9090, config.PrometheusCfg.Port)
+
+	// 未加密的文件透传 要求加密时拒绝
+	config, err = parse(parser, plain)
+	assert.NoError(t, err)
+	assert.Equal(t, 9090, config.PrometheusCfg.Port)
+	_, err = parse(&DecryptingParser[entity.AppConf]{Inner: inner, RequireEncryption: true}, plain)
+	assert.ErrorContains(t, err, "not encrypted")
+
+	_, err = parse(&DecryptingParser[entity.AppConf]{Inner: inner, Keys: StaticKeySource(bytes.Repeat([]byte{2}, 32))}, encrypted)
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	// SOPS 与 age 加密的文件交给外部解密器
+	sops := []byte("prometheusCfg:\n  port: ENC[AES256_GCM,data:abc,type:int]\nsops:\n  version: 3.8.1\n")
+	_, err = parse(parser, sops)
+	assert.ErrorIs(t, err, ErrUnsupportedEncryption)
+	parser.SOPS = FileDecryptorFunc(func(_ context.Context, data []byte) ([]byte, error) {
+		assert.Equal(t, sops, data)
+		return plain, nil
+	})
+	config, err = parse(parser, sops)
+	assert.NoError(t, err)
+	assert.Equal(t, 9090, config.PrometheusCfg.Port)
+
+	age := []byte("-----BEGIN AGE ENCRYPTED FILE-----\nYWdlLWVuY3J5cHRpb24=\n-----END AGE ENCRYPTED FILE-----\n")
+	_, err = parse(parser, age)
+	assert.ErrorIs(t, err, ErrUnsupportedEncryption)
+	parser.Age = FileDecryptorFunc(func(context.Context, []byte) ([]byte, error) {
+		return nil, errors.New("no identity matched: " + string(plain))
+	})
+	_, err = parse(parser, age)
+	assert.ErrorIs(t, err, ErrDecrypt)
+	assert.NotContains(t, err.Error(), "9090")
+}
+
+// TestCommandDecryptor 测试通过外部命令的标准输入输出解密
+func TestCommandDecryptor(t *testing.T) {
+	if _, err := exec.LookPath("cat"); err != nil {
+		t.Skip("cat not available")
+	}
+	plain, err := CommandDecryptor("cat").Decrypt(context.Background(), []byte("port: 9090\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "port: 9090\n", string(plain))
+
+	_, err = CommandDecryptor("cat", "/nonexistent").Decrypt(context.Background(), nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,277 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// envOptions 环境变量展开选项
+type envOptions struct {
+	lookup   func(string) (string, bool)
+	strict   bool
+	prefixes []string
+	allowed  map[string]bool
+	metadata map[string]string
+}
+
+// EnvOption 环境变量展开选项
+type EnvOption func(*envOptions)
+
+// WithEnvStrict 引用未定义且没有默认值的变量时报错 默认展开为空字符串
+func WithEnvStrict() EnvOption {
+	return func(o *envOptions) {
+		o.strict = true
+	}
+}
+
+// WithEnvPrefix 只允许引用带指定前缀的变量 如 APP_
+func WithEnvPrefix(prefixes ...string) EnvOption {
+	return func(o *envOptions) {
+		o.prefixes = append(o.prefixes, prefixes...)
+	}
+}
+
+// WithEnvAllow 只允许引用指定的变量 与 WithEnvPrefix 同时使用时满足其一即可
+func WithEnvAllow(names ...string) EnvOption {
+	return func(o *envOptions) {
+		if o.allowed == nil {
+			o.allowed = map[string]bool{}
+		}
+		for _, name := range names {
+			o.allowed[name] = true
+		}
+	}
+}
+
+// WithEnvLookup 替换变量查找函数 默认为 os.LookupEnv
+func WithEnvLookup(lookup func(string) (string, bool)) EnvOption {
+	return func(o *envOptions) {
+		o.lookup = lookup
+	}
+}
+
+// permitted 判断变量是否允许引用 未设置白名单与前缀时允许全部变量
+func (o *envOptions) permitted(name string) bool {
+	if len(o.prefixes) == 0 && len(o.allowed) == 0 {
+		return true
+	}
+	if o.allowed[name] {
+		return true
+	}
+	for _, prefix := range o.prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExpandEnv 返回展开字符串取值中环境变量引用的配置树变换
+//
+// 支持 ${NAME} 与带默认值的 ${NAME:-default} 或 ${NAME:default} 写成 $${NAME} 时保留字面量 ${NAME}
+// 取值整体为单个引用时 展开结果按整数 浮点数与布尔值还原类型 以便赋值给数值字段
+// 引用不在白名单内的变量总是报错 防止任意进程环境变量被带入配置
+func ExpandEnv(opts ...EnvOption) Transform {
+	o := envOptions{lookup: os.LookupEnv}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return func(tree map[string]any) error {
+		var errs MultiError
+		for key, value := range tree {
+			tree[key] = expandEnvValue(value, key, &o, &errs)
+		}
+		return errs.ErrorOrNil()
+	}
+}
+
+// OverrideEnv 返回以环境变量覆盖配置树中已有叶子取值的配置树变换
+//
+// 变量名由前缀与键路径拼接 键名的驼峰按单词拆开 层级与列表下标之间以下划线分隔
+// 如前缀 APP 时 APP_PROMETHEUS_CFG_PORT 覆盖 prometheusCfg.port APP_SERVERS_0_HOST 覆盖 servers[0].host
+// 取值会还原为整数 浮点数与布尔值 配置文件中不存在的键不会被添加 需要时使用 EnvSource
+func OverrideEnv(prefix string, opts ...EnvOption) Transform {
+	o := envOptions{lookup: os.LookupEnv}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return func(tree map[string]any) error {
+		for key, value := range tree {
+			tree[key] = overrideEnvValue(value, joinEnvName(prefix, envKey(key)), &o)
+		}
+		return nil
+	}
+}
+
+// overrideEnvValue 递归以环境变量覆盖叶子取值 name 为当前节点对应的变量名
+func overrideEnvValue(node any, name string, o *envOptions) any {
+	switch n := node.(type) {
+	case map[string]any:
+		for key, child := range n {
+			n[key] = overrideEnvValue(child, joinEnvName(name, envKey(key)), o)
+		}
+		return n
+	case []any:
+		for i, item := range n {
+			n[i] = overrideEnvValue(item, joinEnvName(name, strconv.Itoa(i)), o)
+		}
+		return n
+	default:
+		if value, ok := o.lookup(name); ok {
+			return scalarFromEnv(value)
+		}
+		return node
+	}
+}
+
+// joinEnvName 以下划线拼接变量名
+func joinEnvName(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "_" + key
+}
+
+// envKey 将配置键转换为变量名片段 如 prometheusCfg 转换为 PROMETHEUS_CFG
+func envKey(key string) string {
+	var b strings.Builder
+	var prev rune
+	for i, r := range key {
+		switch {
+		case r == '-' || r == '.':
+			b.WriteByte('_')
+		case unicode.IsUpper(r) && i > 0 && (unicode.IsLower(prev) || unicode.IsDigit(prev)):
+			b.WriteByte('_')
+			b.WriteRune(r)
+		default:
+			b.WriteRune(unicode.ToUpper(r))
+		}
+		prev = r
+	}
+	return b.String()
+}
+
+// expandEnvValue 递归展开配置树中的字符串取值
+func expandEnvValue(node any, path string, o *envOptions, errs *MultiError) any {
+	switch n := node.(type) {
+	case map[string]any:
+		for key, child := range n {
+			n[key] = expandEnvValue(child, joinPath(path, key), o, errs)
+		}
+		return n
+	case []any:
+		for i, item := range n {
+			n[i] = expandEnvValue(item, indexPath(path, i), o, errs)
+		}
+		return n
+	case string:
+		expanded, whole, err := expandEnvString(n, o)
+		if err != nil {
+			errs.Add(path, err.Error())
+			return n
+		}
+		if whole {
+			return scalarFromEnv(expanded)
+		}
+		return expanded
+	default:
+		return node
+	}
+}
+
+// expandEnvString 展开字符串中的引用 whole 表示字符串整体为单个引用
+func expandEnvString(s string, o *envOptions) (expanded string, whole bool, err error) {
+	if !strings.Contains(s, "${") {
+		return s, false, nil
+	}
+
+	var b strings.Builder
+	refs := 0
+	for i := 0; i < len(s); {
+		switch {
+		case strings.HasPrefix(s[i:], "$${"):
+			b.WriteString("${")
+			i += 3
+		case strings.HasPrefix(s[i:], "${"):
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				return "", false, fmt.Errorf("unterminated variable reference in %q", s)
+			}
+			value, err := resolveEnv(s[i+2:i+end], o)
+			if err != nil {
+				return "", false, err
+			}
+			b.WriteString(value)
+			refs++
+			whole = i == 0 && i+end+1 == len(s)
+			i += end + 1
+		default:
+			b.WriteByte(s[i])
+			i++
+		}
+	}
+	return b.String(), whole && refs == 1, nil
+}
+
+// resolveEnv 解析单个引用 ref 为花括号内的内容
+func resolveEnv(ref string, o *envOptions) (string, error) {
+	name, fallback, hasDefault := strings.Cut(ref, ":-")
+	if !hasDefault {
+		name, fallback, hasDefault = strings.Cut(ref, ":")
+	}
+	if name == "" {
+		return "", errors.New("empty variable reference")
+	}
+	if key, ok := strings.CutPrefix(name, MetadataPrefix); ok && o.metadata != nil {
+		return resolveMetadata(key, fallback, hasDefault, o)
+	}
+	if !o.permitted(name) {
+		return "", fmt.Errorf("environment variable %s is not allowed", name)
+	}
+	if value, ok := o.lookup(name); ok {
+		return value, nil
+	}
+	if hasDefault {
+		return fallback, nil
+	}
+	if o.strict {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return "", nil
+}
+
+// scalarFromEnv 还原展开结果的标量类型
+func scalarFromEnv(s string) any {
+	if n, err := strconv.Atoi(s); err == nil {
+		return n
+	}
+	// ParseFloat 同样接受 inf 与 nan 这类取值应保留为字符串
+	if f, err := strconv.ParseFloat(s, 64); err == nil && !strings.ContainsAny(strings.ToLower(s), "in") {
+		return f
+	}
+	switch s {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	return s
+}
+
+// resolveMetadata 从模板上下文解析 ${meta.xxx} 引用
+func resolveMetadata(key, fallback string, hasDefault bool, o *envOptions) (string, error) {
+	if value, ok := o.metadata[key]; ok {
+		return value, nil
+	}
+	if hasDefault {
+		return fallback, nil
+	}
+	if o.strict {
+		return "", fmt.Errorf("metadata %s is not available", key)
+	}
+	return "", nil
+}
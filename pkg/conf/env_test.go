@@ -0,0 +1,109 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/omeyang/practices/internal/entity"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// TestExpandEnv 测试环境变量展开 转义与类型还原
+func TestExpandEnv(t *testing.T) {
+	env := map[string]string{"APP_PORT": "9091", "APP_HOST": "10.0.0.1", "SECRET": "s3cret"}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	tests := []struct {
+		name     string
+		opts     []EnvOption
+		value    any
+		expected any
+		wantErr  bool
+	}{
+		{"Whole Reference Keeps Type", nil, "${APP_PORT}", 9091, false},
+		{"Embedded Reference", nil, "http://${APP_HOST}:${APP_PORT}", "http://10.0.0.1:9091", false},
+		{"Escaped", nil, "$${APP_PORT}", "${APP_PORT}", false},
+		{"Default", nil, "${APP_MISSING:-8080}", 8080, false},
+		{"Colon Default", nil, "${APP_MISSING:http://localhost}", "http://localhost", false},
+		{"Colon Default Unused", nil, "${APP_PORT:8080}", 9091, false},
+		{"Missing Lenient", nil, "x${APP_MISSING}", "x", false},
+		{"Missing Strict", []EnvOption{WithEnvStrict()}, "${APP_MISSING}", nil, true},
+		{"Prefix Allowed", []EnvOption{WithEnvPrefix("APP_")}, "${APP_HOST}", "10.0.0.1", false},
+		{"Prefix Rejected", []EnvOption{WithEnvPrefix("APP_")}, "${SECRET}", nil, true},
+		{"Allow List", []EnvOption{WithEnvPrefix("APP_"), WithEnvAllow("SECRET")}, "${SECRET}", "s3cret", false},
+		{"Unterminated", nil, "${APP_PORT", nil, true},
+		{"Not A Reference", nil, "$APP_PORT", "$APP_PORT", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := map[string]any{"section": map[string]any{"list": []any{tt.value}}}
+			err := ExpandEnv(append(tt.opts, WithEnvLookup(lookup))...)(tree)
+			if tt.wantErr {
+				assert.ErrorContains(t, err, "section.list[0]")
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, tree["section"].(map[string]any)["list"].([]any)[0])
+		})
+	}
+}
+
+// TestExpandEnvParser 测试在解析器中展开数值字段
+func TestExpandEnvParser(t *testing.T) {
+	t.Setenv("APP_PORT", "9092")
+	parser := &YAMLParser[entity.AppConf]{Logger: zap.NewNop(), Transforms: []Transform{ExpandEnv(WithEnvPrefix("APP_"))}}
+	config, err := parser.Parse(mockFile("prometheusCfg:\n  port: ${APP_PORT}\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, 9092, config.PrometheusCfg.Port)
+}
+
+// TestOverrideEnv 测试按键路径以环境变量覆盖叶子取值
+func TestOverrideEnv(t *testing.T) {
+	env := map[string]string{
+		"APP_PROMETHEUS_CFG_PORT":   "9100",
+		"APP_PROMETHEUS_CFG_ENABLE": "true",
+		"APP_SERVERS_1_HOST":        "10.0.0.2",
+		"APP_MISSING_KEY":           "ignored",
+	}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	tree := map[string]any{
+		"prometheusCfg": map[string]any{"port": 9090, "enable": false, "address": "0.0.0.0"},
+		"servers":       []any{map[string]any{"host": "10.0.0.1"}, map[string]any{"host": "10.0.0.1"}},
+	}
+	assert.NoError(t, OverrideEnv("APP", WithEnvLookup(lookup))(tree))
+	assert.Equal(t, map[string]any{
+		"prometheusCfg": map[string]any{"port": 9100, "enable": true, "address": "0.0.0.0"},
+		"servers":       []any{map[string]any{"host": "10.0.0.1"}, map[string]any{"host": "10.0.0.2"}},
+	}, tree)
+}
+
+// TestEnvKey 测试配置键到变量名片段的转换
+func TestEnvKey(t *testing.T) {
+	assert.Equal(t, "PROMETHEUS_CFG", envKey("prometheusCfg"))
+	assert.Equal(t, "EFFECTIVE_AT", envKey("effectiveAt"))
+	assert.Equal(t, "TLS_CERT_FILE", envKey("tls-certFile"))
+	assert.Equal(t, "PORT", envKey("port"))
+	assert.Equal(t, "HTTP2_ENABLED", envKey("http2Enabled"))
+}
+
+// TestOverrideEnvParser 测试在解析器中先展开引用再按路径覆盖
+func TestOverrideEnvParser(t *testing.T) {
+	t.Setenv("APP_PROMETHEUS_CFG_PORT", "9200")
+	parser := &JSONParser[entity.AppConf]{
+		Logger:     zap.NewNop(),
+		Transforms: []Transform{ExpandEnv(WithEnvPrefix("APP_")), OverrideEnv("APP")},
+	}
+	config, err := parser.Parse(mockFile(`{"prometheusCfg": {"port": 9090, "address": "${APP_UNSET_ADDRESS:127.0.0.1}"}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, 9200, config.PrometheusCfg.Port)
+	assert.Equal(t, "127.0.0.1", config.PrometheusCfg.Address)
+}
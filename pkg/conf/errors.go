@@ -0,0 +1,165 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrorCode 配置错误的稳定代码 用于按代码展示本地化的提示 见 RegisterMessages
+type ErrorCode string
+
+const (
+	// CodeInvalid 未分类的错误 如自定义校验器返回的错误 本地化时使用原始描述
+	CodeInvalid ErrorCode = "config.invalid"
+	// CodeParse 无法解析配置内容 参数为解析器的错误描述
+	CodeParse ErrorCode = "config.parse"
+	// CodeSyntax 配置内容有语法错误 参数为解析器的错误描述
+	CodeSyntax ErrorCode = "config.syntax"
+	// CodeTypeMismatch 取值与配置项的类型不符 参数为解析器的错误描述
+	CodeTypeMismatch ErrorCode = "config.type_mismatch"
+	// CodeUnknownField 严格解码时发现未知的配置项 参数为键名与所在的类型
+	CodeUnknownField ErrorCode = "config.unknown_field"
+	// CodeRequired 必填的配置项未设置
+	CodeRequired ErrorCode = "config.required"
+	// CodeOutOfRange 取值超出范围 参数为范围与实际取值
+	CodeOutOfRange ErrorCode = "config.out_of_range"
+	// CodeNotOneOf 取值不在允许的列表中 参数为允许的取值与实际取值
+	CodeNotOneOf ErrorCode = "config.not_one_of"
+	// CodeInvalidRule 校验标签中的规则无效 参数为规则
+	CodeInvalidRule ErrorCode = "config.invalid_rule"
+	// CodeSecretUnresolved 无法解析密钥引用 参数为解析错误
+	CodeSecretUnresolved ErrorCode = "config.secret_unresolved"
+	// CodeDecryptFailed 无法解密加密的取值 参数为解密错误
+	CodeDecryptFailed ErrorCode = "config.decrypt_failed"
+)
+
+// FieldError 单个配置项的错误
+type FieldError struct {
+	File    string    // 文件名 未知时为空
+	Path    string    // 配置键路径 如 prometheusCfg.port
+	Line    int       // 行号 未知时为 0
+	Column  int       // 列号 未知时为 0
+	Message string    // 错误描述
+	Code    ErrorCode // 错误代码 为空时视为 CodeInvalid
+	Params  []any     // 代码对应消息模板的参数
+	Err     error     // 原始错误
+}
+
+// codedError 按代码创建配置项错误 描述为原始消息 参数为 params
+func codedError(code ErrorCode, message string, params ...any) *FieldError {
+	return &FieldError{Message: message, Code: code, Params: params}
+}
+
+// Error 实现 error 接口 格式为 "路径 (文件, 行, 列): 描述"
+func (e *FieldError) Error() string {
+	return e.format(e.Message)
+}
+
+// format 以 message 作为描述 按 Error 的格式输出
+func (e *FieldError) format(message string) string {
+	var location []string
+	if e.File != "" {
+		location = append(location, e.File)
+	}
+	if e.Line > 0 {
+		location = append(location, fmt.Sprintf("line %d", e.Line))
+	}
+	if e.Column > 0 {
+		location = append(location, fmt.Sprintf("column %d", e.Column))
+	}
+
+	var b strings.Builder
+	switch {
+	case e.Path != "" && len(location) > 0:
+		fmt.Fprintf(&b, "%s (%s): ", e.Path, strings.Join(location, ", "))
+	case e.Path != "":
+		b.WriteString(e.Path + ": ")
+	case len(location) > 0:
+		b.WriteString(strings.Join(location, ", ") + ": ")
+	}
+	b.WriteString(message)
+	return b.String()
+}
+
+// Unwrap 返回原始错误
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// MultiError 汇总一次加载中发现的全部配置错误 便于一次性修复
+type MultiError struct {
+	Errors []*FieldError
+}
+
+// Add 追加一个未分类的配置项错误
+func (m *MultiError) Add(path, message string) {
+	m.Errors = append(m.Errors, &FieldError{Path: path, Message: message, Code: CodeInvalid})
+}
+
+// Addf 按格式追加一个配置项错误
+func (m *MultiError) Addf(path, format string, args ...any) {
+	m.Add(path, fmt.Sprintf(format, args...))
+}
+
+// Append 追加已有的配置项错误
+func (m *MultiError) Append(errs ...*FieldError) {
+	m.Errors = append(m.Errors, errs...)
+}
+
+// Len 返回错误数量
+func (m *MultiError) Len() int {
+	return len(m.Errors)
+}
+
+// Error 实现 error 接口 每行输出一个错误
+func (m *MultiError) Error() string {
+	if len(m.Errors) == 1 {
+		return m.Errors[0].Error()
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d config errors:", len(m.Errors))
+	for _, err := range m.Errors {
+		b.WriteString("\n  - ")
+		b.WriteString(err.Error())
+	}
+	return b.String()
+}
+
+// Unwrap 支持 errors.Is/As 逐个检查
+func (m *MultiError) Unwrap() []error {
+	errs := make([]error, len(m.Errors))
+	for i, err := range m.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+// ErrorOrNil 没有错误时返回 nil
+func (m *MultiError) ErrorOrNil() error {
+	if m == nil || len(m.Errors) == 0 {
+		return nil
+	}
+	return m
+}
+
+// asFieldErrors 将任意错误展开为配置项错误列表
+func asFieldErrors(err error) []*FieldError {
+	var (
+		validationErr *ValidationError
+		multi         *MultiError
+		fieldErr      *FieldError
+	)
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &validationErr):
+		return validationErr.Errors
+	case errors.As(err, &multi):
+		return multi.Errors
+	case errors.As(err, &fieldErr):
+		return []*FieldError{fieldErr}
+	default:
+		return []*FieldError{{Message: err.Error(), Code: CodeInvalid, Err: err}}
+	}
+}
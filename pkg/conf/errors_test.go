@@ -0,0 +1,46 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFieldError_Error 测试配置项错误的格式化
+func TestFieldError_Error(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      *FieldError
+		expected string
+	}{
+		{"Message Only", &FieldError{Message: "bad"}, "bad"},
+		{"With Path", &FieldError{Path: "a.b", Message: "bad"}, "a.b: bad"},
+		{"With Line", &FieldError{Path: "a.b", Line: 3, Message: "bad"}, "a.b (line 3): bad"},
+		{"With Column", &FieldError{Path: "a.b", Line: 3, Column: 5, Message: "bad"}, "a.b (line 3, column 5): bad"},
+		{"With File", &FieldError{File: "app.yaml", Path: "a.b", Line: 3, Message: "bad"}, "a.b (app.yaml, line 3): bad"},
+		{"Location Only", &FieldError{File: "app.yaml", Line: 3, Message: "bad"}, "app.yaml, line 3: bad"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.err.Error())
+		})
+	}
+}
+
+// TestMultiError 测试错误汇总
+func TestMultiError(t *testing.T) {
+	var errs MultiError
+	assert.NoError(t, errs.ErrorOrNil())
+
+	errs.Add("a", "first")
+	assert.Equal(t, "a: first", errs.Error())
+
+	errs.Addf("b", "second %d", 2)
+	assert.Equal(t, "2 config errors:\n  - a: first\n  - b: second 2", errs.Error())
+
+	var fieldErr *FieldError
+	assert.True(t, errors.As(errs.ErrorOrNil(), &fieldErr))
+	assert.Equal(t, "a", fieldErr.Path)
+}
@@ -0,0 +1,86 @@
+// Package etcdkv 基于 etcd v3 客户端实现 config.KVBackend 供 RemoteLoader 使用
+package etcdkv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	config "github.com/omeyang/practices/pkg/conf"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// Backend etcd 键值存储
+type Backend struct {
+	client *clientv3.Client
+}
+
+var (
+	_ config.KVBackend     = (*Backend)(nil)
+	_ config.HealthChecker = (*Backend)(nil)
+)
+
+// New 使用已建立的 etcd 客户端创建键值存储 客户端由调用方关闭
+func New(client *clientv3.Client) *Backend {
+	return &Backend{client: client}
+}
+
+// List 返回前缀下的全部键值与集群当前的版本号
+func (b *Backend) List(ctx context.Context, prefix string) ([]config.KVPair, int64, error) {
+	resp, err := b.client.Get(ctx, prefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, 0, err
+	}
+	pairs := make([]config.KVPair, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		pairs = append(pairs, config.KVPair{Key: string(kv.Key), Value: kv.Value})
+	}
+	return pairs, resp.Header.Revision, nil
+}
+
+// Watch 监听前缀下版本号大于 revision 的变更 要求连接到有 leader 的节点 以便分区时及时断开重连
+func (b *Backend) Watch(ctx context.Context, prefix string, revision int64) (<-chan config.KVWatchResponse, error) {
+	opts := []clientv3.OpOption{clientv3.WithPrefix()}
+	if revision > 0 {
+		opts = append(opts, clientv3.WithRev(revision+1))
+	}
+	watch := b.client.Watch(clientv3.WithRequireLeader(ctx), prefix, opts...)
+
+	out := make(chan config.KVWatchResponse)
+	go func() {
+		defer close(out)
+		for resp := range watch {
+			result := config.KVWatchResponse{Revision: resp.Header.Revision, Err: resp.Err()}
+			select {
+			case out <- result:
+			case <-ctx.Done():
+				return
+			}
+			if result.Err != nil {
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// CheckHealth 依次查询各节点的状态 任一节点可达且集群有 leader 时视为健康 实现 config.HealthChecker
+func (b *Backend) CheckHealth(ctx context.Context) error {
+	var errs []error
+	for _, endpoint := range b.client.Endpoints() {
+		resp, err := b.client.Status(ctx, endpoint)
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("%s: %w", endpoint, err))
+		case resp.Leader == 0:
+			errs = append(errs, fmt.Errorf("%s: no leader", endpoint))
+		default:
+			return nil
+		}
+	}
+	if len(errs) == 0 {
+		return errors.New("no etcd endpoints")
+	}
+	return errors.Join(errs...)
+}
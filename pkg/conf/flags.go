@@ -0,0 +1,134 @@
+package config
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// FlagUsageTag 命令行参数说明使用的结构体标签 未设置时以配置键路径作为说明
+const FlagUsageTag = "usage"
+
+// flagSource 命令行参数配置源
+type flagSource struct {
+	fs    *pflag.FlagSet
+	flags map[string]func() any // 参数名即配置键路径 取值为读取参数的函数
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// BindFlags 按 T 的 yaml 标签为每个标量字段在 fs 上定义命令行参数 并返回读取这些参数的配置源
+//
+// 参数名为配置键路径 如 --prometheusCfg.port 说明取自 usage 标签 支持布尔 整数 浮点数 字符串
+// time.Duration 与 []string 字段 映射 其他列表与自定义解码的字段不生成参数
+// 配置源只包含命令行中显式设置的参数 放在 NewMultiSourceLoader 的最后即可得到 参数 > 环境变量 > 文件 的优先级
+// 参数名与 fs 上已有的参数冲突时 pflag 会 panic 需要在 fs.Parse 之前调用
+func BindFlags[T any](fs *pflag.FlagSet) Source {
+	s := &flagSource{fs: fs, flags: map[string]func() any{}}
+	s.bind(reflect.TypeOf((*T)(nil)).Elem(), "")
+	return s
+}
+
+// bind 递归定义结构体字段的参数
+func (s *flagSource) bind(t reflect.Type, prefix string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if opts == "inline" {
+			s.bind(field.Type, prefix)
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		path := joinPath(prefix, name)
+		usage := field.Tag.Get(FlagUsageTag)
+		if usage == "" {
+			usage = "sets config key " + path
+		}
+		if get := s.define(field.Type, path, usage); get != nil {
+			s.flags[path] = get
+			continue
+		}
+		s.bind(field.Type, path)
+	}
+}
+
+// define 为标量字段定义参数 返回读取取值的函数 不支持的类型返回 nil
+func (s *flagSource) define(t reflect.Type, name, usage string) func() any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(jsonUnmarshalerType) || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return nil
+	}
+	if t == durationType {
+		v := s.fs.Duration(name, 0, usage)
+		return func() any { return v.String() }
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		v := s.fs.Bool(name, false, usage)
+		return func() any { return *v }
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v := s.fs.Int64(name, 0, usage)
+		return func() any { return *v }
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v := s.fs.Uint64(name, 0, usage)
+		return func() any { return *v }
+	case reflect.Float32, reflect.Float64:
+		v := s.fs.Float64(name, 0, usage)
+		return func() any { return *v }
+	case reflect.String:
+		v := s.fs.String(name, "", usage)
+		return func() any { return *v }
+	case reflect.Slice:
+		if t.Elem().Kind() != reflect.String {
+			return nil
+		}
+		v := s.fs.StringSlice(name, nil, usage)
+		return func() any {
+			items := make([]any, len(*v))
+			for i, item := range *v {
+				items[i] = item
+			}
+			return items
+		}
+	default:
+		return nil
+	}
+}
+
+// Name 返回配置源名称
+func (s *flagSource) Name() string {
+	return "flags"
+}
+
+// Load 返回命令行中显式设置的参数 未设置的参数不覆盖其他配置源
+func (s *flagSource) Load(_ context.Context) (map[string]any, error) {
+	tree := map[string]any{}
+	s.fs.Visit(func(f *pflag.Flag) {
+		get, ok := s.flags[f.Name]
+		if !ok {
+			return
+		}
+		keys := strings.Split(f.Name, ".")
+		nodeAt(tree, keys[:len(keys)-1])[keys[len(keys)-1]] = get()
+	})
+	return tree, nil
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/omeyang/practices/internal/entity"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// flagConf 测试用配置 覆盖参数支持的字段类型
+type flagConf struct {
+	Name    string            `yaml:"name" usage:"service name"`
+	Timeout time.Duration     `yaml:"timeout"`
+	Tags    []string          `yaml:"tags"`
+	Labels  map[string]string `yaml:"labels"`
+	Server  *struct {
+		Port  int     `yaml:"port"`
+		Ratio float64 `yaml:"ratio"`
+		Debug bool    `yaml:"debug"`
+	} `yaml:"server"`
+}
+
+// TestBindFlags 测试按结构体标签生成参数 只有显式设置的参数进入配置源
+func TestBindFlags(t *testing.T) {
+	fs := pflag.NewFlagSet("app", pflag.ContinueOnError)
+	source := BindFlags[flagConf](fs)
+	assert.Equal(t, "service name", fs.Lookup("name").Usage)
+	assert.Equal(t, "sets config key server.port", fs.Lookup("server.port").Usage)
+	assert.Nil(t, fs.Lookup("labels"))
+
+	assert.NoError(t, fs.Parse([]string{"--server.port=8080", "--server.debug", "--timeout=5s", "--tags=a,b"}))
+	tree, err := source.Load(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"timeout": "5s",
+		"tags":    []any{"a", "b"},
+		"server":  map[string]any{"port": int64(8080), "debug": true},
+	}, tree)
+
+	var config flagConf
+	assert.NoError(t, decodeTree(tree, &config))
+	assert.Equal(t, 5*time.Second, config.Timeout)
+	assert.Equal(t, 8080, config.Server.Port)
+}
+
+// TestBindFlags_Precedence 测试参数覆盖环境变量 环境变量覆盖文件
+func TestBindFlags_Precedence(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte("prometheusCfg:\n  port: 9090\n  address: 127.0.0.1\n"), 0o600))
+	t.Setenv("APP__PROMETHEUSCFG__PORT", "9100")
+	t.Setenv("APP__PROMETHEUSCFG__ADDRESS", "0.0.0.0")
+
+	flags := pflag.NewFlagSet("app", pflag.ContinueOnError)
+	loader := NewMultiSourceLoader[entity.AppConf](zap.NewNop(),
+		FileSource(fs, "/etc/app/config.yaml"),
+		EnvSource("APP"),
+		BindFlags[entity.AppConf](flags),
+	)
+	assert.NoError(t, flags.Parse([]string{"--prometheusCfg.port", "9200"}))
+
+	config, err := loader.LoadConfig(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, &entity.PrometheusConf{Port: 9200, Address: "0.0.0.0"}, config.PrometheusCfg)
+	assert.Equal(t, "flags", loader.Origin("prometheusCfg.port"))
+	assert.Equal(t, "env:APP", loader.Origin("prometheusCfg.address"))
+}
@@ -0,0 +1,503 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/afero"
+	"go.uber.org/zap"
+)
+
+// TOMLParser TOML配置解析器
+type TOMLParser[T any] struct {
+	Logger     *zap.Logger
+	Transforms []Transform // 解码前的配置树变换
+}
+
+// INIParser INI配置解析器 节名以点分隔层级 如 [prometheusCfg.tls]
+type INIParser[T any] struct {
+	Logger     *zap.Logger
+	Transforms []Transform // 解码前的配置树变换
+}
+
+// DotenvParser .env配置解析器 变量名中的双下划线分隔层级 如 PROMETHEUSCFG__PORT=9090
+type DotenvParser[T any] struct {
+	Logger     *zap.Logger
+	Transforms []Transform // 解码前的配置树变换
+}
+
+// SniffingParser 根据内容识别格式的解析器 用于扩展名无法确定格式的文件 如 .conf
+type SniffingParser[T any] struct {
+	Logger     *zap.Logger
+	Transforms []Transform // 解码前的配置树变换
+}
+
+// Parse 解析toml配置文件
+func (p *TOMLParser[T]) Parse(file afero.File) (*T, error) {
+	var config T
+	err := decodeWithTransforms(file, tomlCodec, p.Transforms, &config)
+	if err != nil {
+		p.Logger.Error("Failed to parse TOML config", zap.Error(err))
+		return nil, fmt.Errorf("toml parsing error: %w", err)
+	}
+	p.Logger.Info("Successfully parsed TOML config")
+	return &config, nil
+}
+
+// Parse 解析ini配置文件
+func (p *INIParser[T]) Parse(file afero.File) (*T, error) {
+	var config T
+	err := decodeWithTransforms(file, iniCodec, p.Transforms, &config)
+	if err != nil {
+		p.Logger.Error("Failed to parse INI config", zap.Error(err))
+		return nil, fmt.Errorf("ini parsing error: %w", err)
+	}
+	p.Logger.Info("Successfully parsed INI config")
+	return &config, nil
+}
+
+// Parse 解析.env配置文件
+func (p *DotenvParser[T]) Parse(file afero.File) (*T, error) {
+	var config T
+	err := decodeWithTransforms(file, dotenvCodec, p.Transforms, &config)
+	if err != nil {
+		p.Logger.Error("Failed to parse dotenv config", zap.Error(err))
+		return nil, fmt.Errorf("dotenv parsing error: %w", err)
+	}
+	p.Logger.Info("Successfully parsed dotenv config")
+	return &config, nil
+}
+
+// Parse 识别内容格式后解析
+func (p *SniffingParser[T]) Parse(file afero.File) (*T, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	ext := DetectFormat(data)
+	c, err := codecFor(ext)
+	if err != nil {
+		return nil, err
+	}
+
+	var config T
+	if err := decodeDataWithTransforms(file.Name(), data, c, p.Transforms, &config); err != nil {
+		p.Logger.Error("Failed to parse sniffed config", zap.String("format", ext), zap.Error(err))
+		return nil, fmt.Errorf("%s parsing error: %w", strings.TrimPrefix(ext, "."), err)
+	}
+	p.Logger.Info("Successfully parsed sniffed config", zap.String("format", ext))
+	return &config, nil
+}
+
+var (
+	tomlCodec = codec{
+		decode:  treeDecoder(decodeTOML),
+		marshal: marshalTOML,
+		locate:  locateTOMLError,
+	}
+	iniCodec = codec{
+		decode:  treeDecoder(decodeINI),
+		marshal: marshalINI,
+		locate:  locateLineError,
+	}
+	dotenvCodec = codec{
+		decode:  treeDecoder(decodeDotenv),
+		marshal: marshalDotenv,
+		locate:  locateLineError,
+	}
+)
+
+// treeDecoder 先解析为配置树 再按目标类型的 yaml 标签对齐键名后解码
+// 这些格式没有与 yaml 标签对应的结构体标签 且 .env 变量名不区分大小写
+func treeDecoder(parse func(data []byte) (map[string]any, error)) func(r io.Reader, v any) error {
+	return func(r io.Reader, v any) error {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		tree, err := parse(data)
+		if err != nil {
+			return err
+		}
+		if m, ok := v.(*map[string]any); ok {
+			*m = tree
+			return nil
+		}
+		foldKeys(tree, reflect.TypeOf(v))
+		return decodeTree(tree, v)
+	}
+}
+
+// FoldKeys 将配置树中与 T 的字段仅在大小写 下划线或连字符上不同的键改为 yaml 标签中的键名
+// 用于对齐来自环境变量等不区分大小写来源的配置树 使其可以解码到 T
+func FoldKeys[T any](tree map[string]any) {
+	foldKeys(tree, reflect.TypeOf((*T)(nil)))
+}
+
+// foldKeys 将与结构体字段名不完全一致的键改为 yaml 标签中的键名
+// 比较时忽略大小写 下划线与连字符 无法对应字段的键保持不变
+func foldKeys(tree map[string]any, t reflect.Type) {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil {
+		return
+	}
+	t = lazyElem(t)
+	switch t.Kind() {
+	case reflect.Struct:
+		fields := map[string]reflect.StructField{}
+		names := map[string]string{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+			fields[foldKey(name)] = field
+			names[foldKey(name)] = name
+		}
+		keys := make([]string, 0, len(tree))
+		for key := range tree {
+			keys = append(keys, key)
+		}
+		for _, key := range keys {
+			field, ok := fields[foldKey(key)]
+			if !ok {
+				continue
+			}
+			value := tree[key]
+			delete(tree, key)
+			tree[names[foldKey(key)]] = value
+			foldValue(value, field.Type)
+		}
+	case reflect.Map:
+		for _, value := range tree {
+			foldValue(value, t.Elem())
+		}
+	}
+}
+
+// foldValue 对齐子树中的键名
+func foldValue(value any, t reflect.Type) {
+	switch v := value.(type) {
+	case map[string]any:
+		foldKeys(v, t)
+	case []any:
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		t = lazyElem(t)
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for _, item := range v {
+				foldValue(item, t.Elem())
+			}
+		}
+	}
+}
+
+// foldKey 键名比较时使用的形式
+func foldKey(key string) string {
+	return strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(key))
+}
+
+// decodeTOML 解析 toml 内容
+func decodeTOML(data []byte) (map[string]any, error) {
+	tree := map[string]any{}
+	if err := toml.Unmarshal(data, &tree); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// marshalTOML 输出 toml 内容 键名取自 yaml 标签
+func marshalTOML(v any) ([]byte, error) {
+	tree, err := configTree(v)
+	if err != nil {
+		return nil, err
+	}
+	return toml.Marshal(pruneNil(tree))
+}
+
+// locateTOMLError 从 toml 解码错误中提取行列号
+func locateTOMLError(file string, _ []byte, err error) error {
+	var decodeErr *toml.DecodeError
+	if errors.As(err, &decodeErr) {
+		line, column := decodeErr.Position()
+		return &FieldError{File: file, Path: strings.Join(decodeErr.Key(), "."), Line: line, Column: column, Message: decodeErr.Error(), Code: CodeSyntax, Params: []any{decodeErr.Error()}, Err: err}
+	}
+	return &FieldError{File: file, Message: err.Error(), Code: CodeParse, Params: []any{err.Error()}, Err: err}
+}
+
+// locateLineError 为按行解析的格式补充文件名
+func locateLineError(file string, _ []byte, err error) error {
+	var fieldErr *FieldError
+	if errors.As(err, &fieldErr) {
+		located := *fieldErr
+		located.File = file
+		return &located
+	}
+	return &FieldError{File: file, Message: err.Error(), Code: CodeParse, Params: []any{err.Error()}, Err: err}
+}
+
+// lineError 按行解析的格式中的语法错误
+func lineError(line int, path, message string) *FieldError {
+	err := codedError(CodeSyntax, message, message)
+	err.Line, err.Path = line, path
+	return err
+}
+
+// decodeINI 解析 ini 内容 节之前的键位于根层级
+func decodeINI(data []byte) (map[string]any, error) {
+	tree := map[string]any{}
+	section := tree
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || text[0] == ';' || text[0] == '#' {
+			continue
+		}
+		if text[0] == '[' {
+			name, ok := strings.CutSuffix(text[1:], "]")
+			if !ok || strings.TrimSpace(name) == "" {
+				return nil, lineError(line, "", fmt.Sprintf("invalid section header %q", text))
+			}
+			section = nodeAt(tree, strings.Split(strings.TrimSpace(name), "."))
+			continue
+		}
+		key, value, ok := strings.Cut(text, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, lineError(line, "", fmt.Sprintf("expected key = value, got %q", text))
+		}
+		parsed, err := parseLineValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, lineError(line, strings.TrimSpace(key), err.Error())
+		}
+		section[strings.TrimSpace(key)] = parsed
+	}
+	return tree, scanner.Err()
+}
+
+// marshalINI 输出 ini 内容 嵌套映射输出为以点分隔的节 不支持列表
+func marshalINI(v any) ([]byte, error) {
+	tree, err := configTree(v)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := writeINISection(&buf, pruneNil(tree), ""); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeINISection 先输出本节的标量 再依次输出子节
+func writeINISection(buf *bytes.Buffer, node map[string]any, name string) error {
+	keys := sortedKeys(node)
+	var children []string
+	wroteHeader := name == ""
+	for _, key := range keys {
+		switch value := node[key].(type) {
+		case map[string]any:
+			children = append(children, key)
+		case []any:
+			return fmt.Errorf("%s: ini does not support lists", joinPath(name, key))
+		default:
+			if !wroteHeader {
+				fmt.Fprintf(buf, "\n[%s]\n", name)
+				wroteHeader = true
+			}
+			fmt.Fprintf(buf, "%s = %s\n", key, formatLineValue(value))
+		}
+	}
+	for _, key := range children {
+		if err := writeINISection(buf, node[key].(map[string]any), joinPath(name, key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dotenvLinePattern 匹配 .env 中的一行赋值
+var dotenvLinePattern = regexp.MustCompile(`^(?:export\s+)?([A-Za-z_][A-Za-z0-9_.]*)\s*=\s*(.*)$`)
+
+// dotenvNamePattern 匹配 .env 中的变量名
+var dotenvNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// decodeDotenv 解析 .env 内容 变量名中的双下划线分隔层级 与 EnvSource 一致
+func decodeDotenv(data []byte) (map[string]any, error) {
+	tree := map[string]any{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || text[0] == '#' {
+			continue
+		}
+		m := dotenvLinePattern.FindStringSubmatch(text)
+		if m == nil {
+			return nil, lineError(line, "", fmt.Sprintf("expected NAME=value, got %q", text))
+		}
+		value, err := parseLineValue(m[2])
+		if err != nil {
+			return nil, lineError(line, m[1], err.Error())
+		}
+		keys := strings.Split(strings.ToLower(m[1]), envSeparator)
+		nodeAt(tree, keys[:len(keys)-1])[keys[len(keys)-1]] = value
+	}
+	return tree, scanner.Err()
+}
+
+// marshalDotenv 输出 .env 内容 嵌套的键以双下划线连接 不支持列表
+func marshalDotenv(v any) ([]byte, error) {
+	tree, err := configTree(v)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := writeDotenv(&buf, pruneNil(tree), ""); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeDotenv 递归输出赋值
+func writeDotenv(buf *bytes.Buffer, node map[string]any, prefix string) error {
+	for _, key := range sortedKeys(node) {
+		name := strings.ToUpper(key)
+		if prefix != "" {
+			name = prefix + envSeparator + name
+		}
+		switch value := node[key].(type) {
+		case map[string]any:
+			if err := writeDotenv(buf, value, name); err != nil {
+				return err
+			}
+		case []any:
+			return fmt.Errorf("%s: dotenv does not support lists", name)
+		default:
+			fmt.Fprintf(buf, "%s=%s\n", name, formatLineValue(value))
+		}
+	}
+	return nil
+}
+
+// parseLineValue 解析 ini 与 .env 的取值
+// 双引号内支持转义 单引号内按字面量处理 未加引号的取值去掉行尾注释后还原标量类型
+func parseLineValue(value string) (any, error) {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		end := strings.LastIndex(value, `"`)
+		if end == 0 {
+			return nil, errors.New("unterminated double-quoted value")
+		}
+		return strconv.Unquote(value[:end+1])
+	case strings.HasPrefix(value, "'"):
+		end := strings.LastIndex(value, "'")
+		if end == 0 {
+			return nil, errors.New("unterminated single-quoted value")
+		}
+		return value[1:end], nil
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = value[:i]
+	}
+	if i := strings.Index(value, " ;"); i >= 0 {
+		value = value[:i]
+	}
+	return scalarFromEnv(strings.TrimSpace(value)), nil
+}
+
+// formatLineValue 输出 ini 与 .env 的取值 含空白或特殊字符的字符串加双引号
+func formatLineValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		if v == "" || strings.ContainsAny(v, " \t\"'#;=\\\n") || scalarFromEnv(v) != any(v) {
+			return strconv.Quote(v)
+		}
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// pruneNil 删除取值为空的键 这些格式没有空值的表示
+func pruneNil(tree map[string]any) map[string]any {
+	for key, value := range tree {
+		switch v := value.(type) {
+		case nil:
+			delete(tree, key)
+		case map[string]any:
+			pruneNil(v)
+		}
+	}
+	return tree
+}
+
+// sortedKeys 返回排序后的键 使输出稳定
+func sortedKeys(node map[string]any) []string {
+	keys := make([]string, 0, len(node))
+	for key := range node {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// iniSectionPattern 匹配 ini 与 toml 的节名
+var iniSectionPattern = regexp.MustCompile(`(?m)^\s*\[[^\]]+\]\s*$`)
+
+// DetectFormat 根据内容识别配置格式 返回对应的扩展名
+// 依次识别 JSON .env TOML 与 INI 都不符合时按 YAML 处理
+func DetectFormat(data []byte) string {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed) {
+		return ".json"
+	}
+	if len(trimmed) > 0 && isDotenv(trimmed) {
+		return ".env"
+	}
+	if bytes.Contains(trimmed, []byte("=")) {
+		if _, err := decodeTOML(trimmed); err == nil {
+			return ".toml"
+		}
+		if _, err := decodeINI(trimmed); err == nil && (iniSectionPattern.Match(trimmed) || !bytes.Contains(trimmed, []byte(":"))) {
+			return ".ini"
+		}
+	}
+	return ".yaml"
+}
+
+// isDotenv 每个非注释行都是 NAME=value 赋值且没有节名时视为 .env
+func isDotenv(data []byte) bool {
+	if iniSectionPattern.Match(data) {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		// .env 的赋值号两侧没有空白 以此区分 ini 与 toml 的 key = value
+		name, _, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if !ok || !dotenvNamePattern.MatchString(name) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/omeyang/practices/internal/entity"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// TestNewParser_Formats 测试新增格式的解析器注册
+func TestNewParser_Formats(t *testing.T) {
+	for ext, expected := range map[string]string{
+		".toml": "TOMLParser",
+		".ini":  "INIParser",
+		".env":  "DotenvParser",
+		".conf": "SniffingParser",
+		"":      "SniffingParser",
+	} {
+		parser, err := NewParser[entity.AppConf](ext, zap.NewNop())
+		assert.NoError(t, err)
+		assert.Contains(t, fmt.Sprintf("%T", parser), "*config."+expected+"[")
+	}
+}
+
+// TestFormatParsers 测试各格式解析到同一份配置
+func TestFormatParsers(t *testing.T) {
+	expected := &entity.PrometheusConf{Enable: true, Port: 9090, Address: "0.0.0.0"}
+	tests := []struct {
+		name    string
+		parser  CfgParser[entity.AppConf]
+		content string
+	}{
+		{"TOML", &TOMLParser[entity.AppConf]{Logger: zap.NewNop()},
+			"[prometheusCfg]\nenable = true\nport = 9090\naddress = \"0.0.0.0\"\n"},
+		{"INI", &INIParser[entity.AppConf]{Logger: zap.NewNop()},
+			"; metrics\n[prometheusCfg]\nenable = true\nport = 9090 ; default\naddress = \"0.0.0.0\"\n"},
+		{"Dotenv", &DotenvParser[entity.AppConf]{Logger: zap.NewNop()},
+			"# metrics\nPROMETHEUS_CFG__ENABLE=true\nexport PROMETHEUS_CFG__PORT=9090\nPROMETHEUS_CFG__ADDRESS='0.0.0.0'\n"},
+		{"Sniff TOML", &SniffingParser[entity.AppConf]{Logger: zap.NewNop()},
+			"[prometheusCfg]\nenable = true\nport = 9090\naddress = \"0.0.0.0\"\n"},
+		{"Sniff INI", &SniffingParser[entity.AppConf]{Logger: zap.NewNop()},
+			"[prometheusCfg]\nenable = true\nport = 9090\naddress = 0.0.0.0\n"},
+		{"Sniff Dotenv", &SniffingParser[entity.AppConf]{Logger: zap.NewNop()},
+			"PROMETHEUSCFG__ENABLE=true\nPROMETHEUSCFG__PORT=9090\nPROMETHEUSCFG__ADDRESS=0.0.0.0\n"},
+		{"Sniff JSON", &SniffingParser[entity.AppConf]{Logger: zap.NewNop()},
+			`{"prometheusCfg": {"enable": true, "port": 9090, "address": "0.0.0.0"}}`},
+		{"Sniff YAML", &SniffingParser[entity.AppConf]{Logger: zap.NewNop()},
+			"prometheusCfg:\n  enable: true\n  port: 9090\n  address: 0.0.0.0\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := tt.parser.Parse(mockFile(tt.content))
+			assert.NoError(t, err)
+			assert.Equal(t, expected, config.PrometheusCfg)
+		})
+	}
+}
+
+// TestFormatParsers_Errors 测试按行解析的错误附带文件名与行号
+func TestFormatParsers_Errors(t *testing.T) {
+	_, err := (&INIParser[entity.AppConf]{Logger: zap.NewNop()}).Parse(mockFile("[prometheusCfg]\nport\n"))
+	var fieldErr *FieldError
+	assert.ErrorAs(t, err, &fieldErr)
+	assert.Equal(t, "test", fieldErr.File)
+	assert.Equal(t, 2, fieldErr.Line)
+
+	_, err = (&DotenvParser[entity.AppConf]{Logger: zap.NewNop()}).Parse(mockFile("PORT=\"9090\n"))
+	assert.ErrorAs(t, err, &fieldErr)
+	assert.Equal(t, 1, fieldErr.Line)
+
+	_, err = (&TOMLParser[entity.AppConf]{Logger: zap.NewNop()}).Parse(mockFile("[prometheusCfg]\nport = = 1\n"))
+	assert.ErrorAs(t, err, &fieldErr)
+	assert.Equal(t, 2, fieldErr.Line)
+}
+
+// TestDetectFormat 测试根据内容识别格式
+func TestDetectFormat(t *testing.T) {
+	assert.Equal(t, ".json", DetectFormat([]byte(" {\"a\": 1}")))
+	assert.Equal(t, ".env", DetectFormat([]byte("# comment\nA=1\nexport B=two\n")))
+	assert.Equal(t, ".toml", DetectFormat([]byte("a = 1\n[b]\nc = \"d\"\n")))
+	assert.Equal(t, ".ini", DetectFormat([]byte("[b]\nc = d\n")))
+	assert.Equal(t, ".yaml", DetectFormat([]byte("a: 1\nurl: http://x?y=1\n")))
+	assert.Equal(t, ".yaml", DetectFormat([]byte("- a\n- b\n")))
+}
+
+// TestFormatCodecs_RoundTrip 测试保存为新格式后可以重新加载
+func TestFormatCodecs_RoundTrip(t *testing.T) {
+	original := &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Enable: true, Port: 9090, Address: "0.0.0.0"}}
+	for _, ext := range []string{".toml", ".ini", ".env"} {
+		t.Run(ext, func(t *testing.T) {
+			c, err := codecFor(ext)
+			assert.NoError(t, err)
+			data, err := c.marshal(original)
+			assert.NoError(t, err)
+
+			fs := afero.NewMemMapFs()
+			assert.NoError(t, afero.WriteFile(fs, "/etc/app/config"+ext, data, 0o600))
+			loader, err := NewFileLoader[entity.AppConf](fs, "/etc/app/config"+ext, zap.NewNop())
+			assert.NoError(t, err)
+			config, err := loader.LoadConfig(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, original.PrometheusCfg, config.PrometheusCfg)
+		})
+	}
+}
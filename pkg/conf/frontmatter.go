@@ -0,0 +1,104 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/spf13/afero"
+	"go.uber.org/zap"
+)
+
+// BodyReceiver 由需要保留混合文件正文的配置类型实现 FrontMatterParser 解析后调用
+// 正文随配置一同重载 如网关规则文件中的模板
+type BodyReceiver interface {
+	SetBody(body []byte)
+}
+
+// FrontMatterParser 混合文件解析器 文件以前置块开头 前置块解析为配置 其余内容作为正文
+//
+// 前置块以单独一行的 --- 包围时为 yaml 以 +++ 包围时为 toml 没有前置块时整个文件都是正文
+// 配置类型实现 BodyReceiver 时正文随配置返回 也可以调用 ParseFrontMatter 同时得到配置与正文
+type FrontMatterParser[T any] struct {
+	Logger     *zap.Logger
+	Transforms []Transform // 解码前的配置树变换
+	Strict     bool        // yaml 前置块拒绝 T 中不存在的键
+}
+
+// Parse 解析混合文件
+func (p *FrontMatterParser[T]) Parse(file afero.File) (*T, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	config, body, err := p.parse(file.Name(), data)
+	if err != nil {
+		p.Logger.Error("Failed to parse front matter", zap.Error(err))
+		return nil, fmt.Errorf("front matter parsing error: %w", err)
+	}
+	if receiver, ok := any(config).(BodyReceiver); ok {
+		receiver.SetBody(body)
+	}
+	p.Logger.Info("Successfully parsed front matter config", zap.Int("bodyBytes", len(body)))
+	return config, nil
+}
+
+// ParseFrontMatter 解析混合文件内容 返回前置块解码的配置与正文
+func ParseFrontMatter[T any](data []byte, opts ...ParserOption) (*T, []byte, error) {
+	var o parserOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	p := &FrontMatterParser[T]{Transforms: o.transforms, Strict: o.strict}
+	return p.parse("", data)
+}
+
+// parse 拆分前置块并解码 错误中的行号相对整个文件
+func (p *FrontMatterParser[T]) parse(name string, data []byte) (*T, []byte, error) {
+	var config T
+	frontMatter, body, delimiter := splitFrontMatter(data)
+	if delimiter == "" {
+		return &config, body, nil
+	}
+	c := tomlCodec
+	if delimiter == "---" {
+		c = yamlCodec
+		if p.Strict {
+			c = strictYAMLCodec
+		}
+	}
+	if err := decodeDataWithTransforms(name, frontMatter, c, p.Transforms, &config); err != nil {
+		// 前置块从文件第二行开始
+		for _, fieldErr := range asFieldErrors(err) {
+			if fieldErr.Line > 0 {
+				fieldErr.Line++
+			}
+		}
+		return nil, nil, err
+	}
+	return &config, body, nil
+}
+
+// splitFrontMatter 拆分前置块与正文 返回前置块的分隔符 没有前置块时为空
+// 前置块未结束时视为没有前置块 整个文件都是正文
+func splitFrontMatter(data []byte) (frontMatter, body []byte, delimiter string) {
+	first, rest, found := cutLine(data)
+	delimiter = string(bytes.TrimSpace(first))
+	if !found || (delimiter != "---" && delimiter != "+++") {
+		return nil, data, ""
+	}
+	for offset := 0; offset < len(rest); {
+		line, next, _ := cutLine(rest[offset:])
+		if string(bytes.TrimSpace(line)) == delimiter {
+			return rest[:offset], next, delimiter
+		}
+		offset = len(rest) - len(next)
+	}
+	return nil, data, ""
+}
+
+// cutLine 拆分第一行与其余内容 返回的行不含换行符
+func cutLine(data []byte) (line, rest []byte, found bool) {
+	line, rest, found = bytes.Cut(data, []byte("\n"))
+	return bytes.TrimSuffix(line, []byte("\r")), rest, found
+}
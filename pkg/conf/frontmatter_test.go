@@ -0,0 +1,63 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// ruleFile 测试用的网关规则文件 前置块为规则属性 正文为模板
+type ruleFile struct {
+	Name     string `yaml:"name"`
+	Priority int    `yaml:"priority"`
+	body     []byte
+}
+
+// SetBody 保存正文
+func (r *ruleFile) SetBody(body []byte) {
+	r.body = body
+}
+
+// TestFrontMatterParser 测试前置块解析为配置 正文随配置返回
+func TestFrontMatterParser(t *testing.T) {
+	parser := &FrontMatterParser[ruleFile]{Logger: zap.NewNop()}
+	rule, err := parser.Parse(mockFile("---\nname: rewrite\npriority: 10\n---\nlocation /api {\n  proxy_pass {{ .Upstream }};\n}\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "rewrite", rule.Name)
+	assert.Equal(t, 10, rule.Priority)
+	assert.Equal(t, "location /api {\n  proxy_pass {{ .Upstream }};\n}\n", string(rule.body))
+
+	// 前置块中的错误报告文件中的行号
+	_, err = parser.Parse(mockFile("---\nname: rewrite\npriority: high\n---\nbody\n"))
+	var fieldErr *FieldError
+	assert.True(t, errors.As(err, &fieldErr), "%v", err)
+	assert.Equal(t, 3, fieldErr.Line)
+}
+
+// TestParseFrontMatter 测试 toml 前置块与没有前置块的文件
+func TestParseFrontMatter(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected ruleFile
+		body     string
+	}{
+		{"toml", "+++\r\nname = \"deny\"\r\npriority = 5\r\n+++\r\ndeny all;\r\n", ruleFile{Name: "deny", Priority: 5}, "deny all;\r\n"},
+		{"empty body", "---\nname: allow\n---", ruleFile{Name: "allow"}, ""},
+		{"no front matter", "allow all;\n", ruleFile{}, "allow all;\n"},
+		{"unterminated", "---\nname: allow\n", ruleFile{}, "---\nname: allow\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, body, err := ParseFrontMatter[ruleFile]([]byte(tt.content))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, *rule)
+			assert.Equal(t, tt.body, string(body))
+		})
+	}
+
+	_, _, err := ParseFrontMatter[ruleFile]([]byte("---\nname: a\nprio: 1\n---\n"), WithStrictDecoding())
+	assert.ErrorContains(t, err, "prio")
+}
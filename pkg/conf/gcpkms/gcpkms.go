@@ -0,0 +1,58 @@
+// Package gcpkms 基于 Cloud KMS 客户端实现 config.KMSClient 供 KMSKeySource 解密数据密钥
+package gcpkms
+
+import (
+	"context"
+	"errors"
+	"hash/crc32"
+
+	config "github.com/omeyang/practices/pkg/conf"
+
+	"cloud.google.com/go/kms/apiv1/kmspb"
+	"github.com/googleapis/gax-go/v2"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// ErrCorrupted 密文或解密结果在传输中损坏
+var ErrCorrupted = errors.New("cloud kms: checksum mismatch")
+
+// API Cloud KMS 的解密接口 *kms.KeyManagementClient 实现了该接口
+type API interface {
+	Decrypt(ctx context.Context, req *kmspb.DecryptRequest, opts ...gax.CallOption) (*kmspb.DecryptResponse, error)
+}
+
+// Client Cloud KMS 解密客户端
+type Client struct {
+	api     API
+	keyName string
+}
+
+var _ config.KMSClient = (*Client)(nil)
+
+// New 使用已创建的 Cloud KMS 客户端创建解密客户端 客户端由调用方关闭
+// keyName 为加密数据密钥的 CryptoKey 资源名 形如 projects/p/locations/l/keyRings/r/cryptoKeys/k
+func New(api API, keyName string) *Client {
+	return &Client{api: api, keyName: keyName}
+}
+
+// Decrypt 解密 KMS 加密的数据密钥 实现 config.KMSClient
+// 请求与响应均附带 CRC32C 校验和 按 Cloud KMS 的建议校验数据完整性
+func (c *Client) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	resp, err := c.api.Decrypt(ctx, &kmspb.DecryptRequest{
+		Name:             c.keyName,
+		Ciphertext:       ciphertext,
+		CiphertextCrc32C: wrapperspb.Int64(checksum(ciphertext)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.PlaintextCrc32C == nil || resp.PlaintextCrc32C.Value != checksum(resp.Plaintext) {
+		return nil, ErrCorrupted
+	}
+	return resp.Plaintext, nil
+}
+
+// checksum 计算 Cloud KMS 使用的 CRC32C 校验和
+func checksum(data []byte) int64 {
+	return int64(crc32.Checksum(data, crc32.MakeTable(crc32.Castagnoli)))
+}
@@ -0,0 +1,44 @@
+package gcpkms
+
+import (
+	"context"
+	"testing"
+
+	"cloud.google.com/go/kms/apiv1/kmspb"
+	"github.com/googleapis/gax-go/v2"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// fakeAPI 记录请求并返回给定的响应
+type fakeAPI struct {
+	req  *kmspb.DecryptRequest
+	resp *kmspb.DecryptResponse
+}
+
+func (f *fakeAPI) Decrypt(_ context.Context, req *kmspb.DecryptRequest, _ ...gax.CallOption) (*kmspb.DecryptResponse, error) {
+	f.req = req
+	return f.resp, nil
+}
+
+// TestClient 测试解密请求携带密钥资源名与校验和 并校验响应的完整性
+func TestClient(t *testing.T) {
+	const name = "projects/p/locations/global/keyRings/r/cryptoKeys/k"
+	plain := []byte("data key")
+	api := &fakeAPI{resp: &kmspb.DecryptResponse{Plaintext: plain, PlaintextCrc32C: wrapperspb.Int64(checksum(plain))}}
+	client := New(api, name)
+
+	key, err := client.Decrypt(context.Background(), []byte("wrapped"))
+	assert.NoError(t, err)
+	assert.Equal(t, plain, key)
+	assert.Equal(t, name, api.req.Name)
+	assert.Equal(t, checksum([]byte("wrapped")), api.req.CiphertextCrc32C.Value)
+
+	// 校验和缺失或不一致时拒绝
+	api.resp.PlaintextCrc32C = wrapperspb.Int64(checksum(plain) + 1)
+	_, err = client.Decrypt(context.Background(), []byte("wrapped"))
+	assert.ErrorIs(t, err, ErrCorrupted)
+	api.resp.PlaintextCrc32C = nil
+	_, err = client.Decrypt(context.Background(), []byte("wrapped"))
+	assert.ErrorIs(t, err, ErrCorrupted)
+}
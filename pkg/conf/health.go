@@ -0,0 +1,118 @@
+package config
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// defaultHealthTimeout 单次健康探测的默认超时时间 探测间隔更短时取探测间隔
+const defaultHealthTimeout = 5 * time.Second
+
+// HealthChecker 可以探测配置源连通性的加载器 如 HTTP 的 HEAD 请求或 etcd 的状态查询
+// 探测应足够轻量 不读取与解码配置内容 用于区分配置没有变化与已经失去与配置服务的联系
+type HealthChecker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+// SourceHealth 配置源的连通状态 独立于配置的重载结果
+type SourceHealth struct {
+	Healthy             bool       `json:"healthy"`                       // 最近一次探测是否成功
+	LastCheck           time.Time  `json:"lastCheck"`                     // 最近一次探测的时间
+	LastSuccess         *time.Time `json:"lastSuccess,omitempty"`         // 最近一次探测成功的时间 从未成功时为空
+	ConsecutiveFailures int        `json:"consecutiveFailures,omitempty"` // 连续探测失败的次数
+	Error               string     `json:"error,omitempty"`               // 最近一次探测失败的原因
+}
+
+// sourceHealth 最近一次健康探测的结果
+type sourceHealth struct {
+	mu    sync.Mutex
+	state *SourceHealth // 尚未探测时为空
+}
+
+// snapshot 返回探测结果的副本 尚未探测时返回 nil
+func (h *sourceHealth) snapshot() *SourceHealth {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.state == nil {
+		return nil
+	}
+	state := *h.state
+	return &state
+}
+
+// record 记录一次探测的结果 返回记录前是否健康与是否探测过
+func (h *sourceHealth) record(err error, now time.Time) (wasHealthy, checked bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.state == nil {
+		h.state = &SourceHealth{}
+	} else {
+		wasHealthy, checked = h.state.Healthy, true
+	}
+	h.state.LastCheck = now
+	if err != nil {
+		h.state.Healthy = false
+		h.state.ConsecutiveFailures++
+		h.state.Error = err.Error()
+		return wasHealthy, checked
+	}
+	h.state.Healthy = true
+	h.state.ConsecutiveFailures = 0
+	h.state.Error = ""
+	h.state.LastSuccess = &now
+	return wasHealthy, checked
+}
+
+// SourceHealth 返回配置源最近一次健康探测的结果 未启用 WithSourceHealthCheck 或尚未探测时返回 nil
+func (cm *CfgManager[T]) SourceHealth() *SourceHealth {
+	return cm.health.snapshot()
+}
+
+// startHealthChecks 加载器实现 HealthChecker 时启动健康探测 否则记录警告
+func (cm *CfgManager[T]) startHealthChecks(ctx context.Context) {
+	checker, ok := cm.loader.(HealthChecker)
+	if !ok {
+		cm.logger.Warn("Config loader does not support health checks", zap.String("source", cm.loader.GetConfigPath()))
+		return
+	}
+	cm.spawn(func() { cm.runHealthChecks(ctx, checker) })
+}
+
+// runHealthChecks 立即探测一次 之后按间隔探测配置源 直到 ctx 结束
+func (cm *CfgManager[T]) runHealthChecks(ctx context.Context, checker HealthChecker) {
+	ticker := cm.opts.clock.NewTicker(cm.opts.healthInterval)
+	defer ticker.Stop()
+	for {
+		cm.checkSourceHealth(ctx, checker)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+	}
+}
+
+// checkSourceHealth 探测一次配置源 记录结果与指标 并在状态变化时记录日志
+func (cm *CfgManager[T]) checkSourceHealth(ctx context.Context, checker HealthChecker) {
+	probeCtx, cancel := context.WithTimeout(ctx, min(cm.opts.healthInterval, defaultHealthTimeout))
+	err := checker.CheckHealth(probeCtx)
+	cancel()
+	if ctx.Err() != nil {
+		// 管理器关闭导致的失败不是配置源的问题
+		return
+	}
+
+	now := cm.opts.clock.Now()
+	wasHealthy, checked := cm.health.record(err, now)
+	cm.metrics.observeHealth(err, now)
+	source := zap.String("source", cm.loader.GetConfigPath())
+	switch {
+	case err != nil && (wasHealthy || !checked):
+		cm.logger.Warn("Lost contact with config source", source, zap.Error(err))
+	case err == nil && checked && !wasHealthy:
+		cm.logger.Info("Config source is reachable again", source)
+	}
+}
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/omeyang/practices/internal/entity"
+	mocks "github.com/omeyang/practices/mocks/conf"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+// fakeChecker 测试用健康探测 返回预设的错误
+type fakeChecker struct {
+	mu  sync.Mutex
+	err error
+}
+
+func (f *fakeChecker) CheckHealth(context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.err
+}
+
+func (f *fakeChecker) set(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+// TestCfgManager_SourceHealth 测试健康探测按间隔执行 失败与恢复反映在 Status 与指标中
+func TestCfgManager_SourceHealth(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return("https://config.example.com/app.yaml").AnyTimes()
+
+	clock := NewFakeClock(time.Unix(1000, 0))
+	reg := prometheus.NewRegistry()
+	cm := NewConfigManager[entity.AppConf](mockLoader, nil, zap.NewNop(), RetryPolicy{},
+		WithClock(clock), WithMetrics(reg), WithSourceHealthCheck(10*time.Second))
+	assert.Nil(t, cm.Status().SourceHealth)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	checker := &fakeChecker{}
+	done := make(chan struct{})
+	go func() {
+		cm.runHealthChecks(ctx, checker)
+		close(done)
+	}()
+	assert.Eventually(t, func() bool { return cm.SourceHealth() != nil }, time.Second, time.Millisecond)
+	healthy := cm.SourceHealth()
+	assert.True(t, healthy.Healthy)
+	assert.Equal(t, time.Unix(1000, 0), *healthy.LastSuccess)
+	assert.Equal(t, 1.0, testutil.ToFloat64(cm.metrics.sourceUp))
+	assert.Equal(t, 1000.0, testutil.ToFloat64(cm.metrics.contact))
+
+	checker.set(errors.New("connection refused"))
+	for i := 1; i <= 2; i++ {
+		clock.Advance(10 * time.Second)
+		assert.Eventually(t, func() bool { return cm.SourceHealth().ConsecutiveFailures == i }, time.Second, time.Millisecond)
+	}
+	status := cm.Status().SourceHealth
+	assert.False(t, status.Healthy)
+	assert.Equal(t, "connection refused", status.Error)
+	assert.Equal(t, time.Unix(1020, 0), status.LastCheck)
+	assert.Equal(t, time.Unix(1000, 0), *status.LastSuccess)
+	assert.Equal(t, 0.0, testutil.ToFloat64(cm.metrics.sourceUp))
+	assert.Equal(t, 1000.0, testutil.ToFloat64(cm.metrics.contact))
+
+	checker.set(nil)
+	clock.Advance(10 * time.Second)
+	assert.Eventually(t, func() bool { return cm.SourceHealth().Healthy }, time.Second, time.Millisecond)
+	assert.Zero(t, cm.SourceHealth().ConsecutiveFailures)
+	assert.Equal(t, 1030.0, testutil.ToFloat64(cm.metrics.contact))
+
+	cancel()
+	<-done
+}
+
+// TestHealthCheckers 测试 HTTPLoader 与 RemoteLoader 的健康探测
+func TestHealthCheckers(t *testing.T) {
+	status := http.StatusOK
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, http.MethodHead, r.Method)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	loader, err := NewHTTPLoader[entity.AppConf](server.URL+"/app.yaml", zap.NewNop())
+	assert.NoError(t, err)
+	ctx := context.Background()
+	assert.NoError(t, loader.CheckHealth(ctx))
+	mu.Lock()
+	status = http.StatusMethodNotAllowed
+	mu.Unlock()
+	assert.NoError(t, loader.CheckHealth(ctx))
+	mu.Lock()
+	status = http.StatusServiceUnavailable
+	mu.Unlock()
+	assert.ErrorContains(t, loader.CheckHealth(ctx), "503")
+
+	remote := NewRemoteLoader[entity.AppConf](newFakeKV(), "/app/", zap.NewNop())
+	assert.NoError(t, remote.CheckHealth(ctx))
+	checked := NewRemoteLoader[entity.AppConf](struct {
+		KVBackend
+		HealthChecker
+	}{newFakeKV(), &fakeChecker{err: errors.New("no leader")}}, "/app/", zap.NewNop())
+	assert.EqualError(t, checked.CheckHealth(ctx), "no leader")
+}
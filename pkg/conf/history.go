@@ -0,0 +1,81 @@
+package config
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// HistoryRecord 配置历史文件中的一条记录 文件为每行一条记录的 JSON Lines
+type HistoryRecord[T any] struct {
+	Time    time.Time `json:"time"`              // 配置生效的时间
+	Version string    `json:"version,omitempty"` // 加载器提供的版本
+	Config  *T        `json:"config"`            // 生效的配置
+}
+
+// ReadHistory 读取配置历史文件 记录按文件中的顺序返回
+func ReadHistory[T any](r io.Reader) ([]HistoryRecord[T], error) {
+	var records []HistoryRecord[T]
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record HistoryRecord[T]
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("history line %d: %w", line, err)
+		}
+		if record.Config == nil {
+			return nil, fmt.Errorf("history line %d: config is missing", line)
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+// HistoryRecorder 返回将每份生效配置追加到配置历史文件的变更处理函数
+// version 为空时记录中不包含版本 写入失败时返回错误
+func HistoryRecorder[T any](w io.Writer, version func() string) ChangeHandler[T] {
+	var mu sync.Mutex
+	encoder := json.NewEncoder(w)
+	return func(_, newConfig *T) error {
+		record := HistoryRecord[T]{Time: time.Now(), Config: newConfig}
+		if version != nil {
+			record.Version = version()
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		return encoder.Encode(record)
+	}
+}
+
+// Replay 将记录的配置序列依次送入管理器 用于确定性地复现变更处理函数的问题
+// 相邻记录的间隔按 speed 倍速压缩 speed 不大于 0 时不等待
+// 配置经过与重载相同的应用流程 搭配 WithSyncApply 使用时处理函数在返回前执行完毕
+func (cm *CfgManager[T]) Replay(ctx context.Context, records []HistoryRecord[T], speed float64) error {
+	for i, record := range records {
+		if i > 0 && speed > 0 {
+			delay := time.Duration(float64(record.Time.Sub(records[i-1].Time)) / speed)
+			if err := sleepClock(ctx, cm.opts.clock, delay); err != nil {
+				return err
+			}
+		}
+		if err := cm.replayRecord(ctx, record.Config); err != nil {
+			return fmt.Errorf("replay record %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// replayRecord 应用一条历史记录
+func (cm *CfgManager[T]) replayRecord(ctx context.Context, config *T) error {
+	cm.applyMu.Lock()
+	defer cm.applyMu.Unlock()
+	_, err := cm.applyConfig(ctx, config)
+	return err
+}
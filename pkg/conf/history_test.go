@@ -0,0 +1,67 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omeyang/practices/internal/entity"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// TestHistoryRecordAndReplay 测试记录配置历史并回放到另一个管理器
+func TestHistoryRecordAndReplay(t *testing.T) {
+	var buf bytes.Buffer
+	source := NewConfigManager[entity.AppConf](nil, nil, zap.NewNop(), RetryPolicy{}, WithSyncApply())
+	source.OnChange(HistoryRecorder[entity.AppConf](&buf, func() string { return "v1" }))
+	for _, port := range []int{9090, 9091, 9092} {
+		assert.NoError(t, source.storeConfig(&entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: port}}))
+	}
+
+	records, err := ReadHistory[entity.AppConf](&buf)
+	assert.NoError(t, err)
+	assert.Len(t, records, 3)
+	assert.Equal(t, "v1", records[0].Version)
+
+	target := NewConfigManager[entity.AppConf](nil, nil, zap.NewNop(), RetryPolicy{}, WithSyncApply())
+	var ports []int
+	target.OnChange(func(_, newConfig *entity.AppConf) error {
+		ports = append(ports, newConfig.PrometheusCfg.Port)
+		if newConfig.PrometheusCfg.Port == 9092 {
+			return errors.New("bad port")
+		}
+		return nil
+	})
+	err = target.Replay(context.Background(), records, 0)
+	assert.ErrorContains(t, err, "replay record 2: change handler 0: bad port")
+	assert.Equal(t, []int{9090, 9091, 9092}, ports)
+	assert.Equal(t, 9091, target.GetConfig().PrometheusCfg.Port)
+}
+
+// TestReplaySpeed 测试回放按倍速压缩记录间隔
+func TestReplaySpeed(t *testing.T) {
+	now := time.Now()
+	records := []HistoryRecord[entity.AppConf]{
+		{Time: now, Config: &entity.AppConf{}},
+		{Time: now.Add(time.Hour), Config: &entity.AppConf{}},
+	}
+	cm := NewConfigManager[entity.AppConf](nil, nil, zap.NewNop(), RetryPolicy{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, cm.Replay(ctx, records, 1), context.DeadlineExceeded)
+	assert.NoError(t, cm.Replay(context.Background(), records, float64(time.Hour/time.Millisecond)))
+}
+
+// TestReadHistoryErrors 测试历史文件格式错误
+func TestReadHistoryErrors(t *testing.T) {
+	_, err := ReadHistory[entity.AppConf](strings.NewReader("{\"config\": {}}\nnot json\n"))
+	assert.ErrorContains(t, err, "history line 2")
+	_, err = ReadHistory[entity.AppConf](strings.NewReader("{\"time\": \"2024-01-01T00:00:00Z\"}\n"))
+	assert.ErrorContains(t, err, "config is missing")
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// ErrReloadVetoed 重载前钩子否决了新配置
+var ErrReloadVetoed = errors.New("reload vetoed by pre-reload hook")
+
+// PreReloadHook 重载的新配置通过校验与探测后 存储前执行的钩子 返回错误时否决替换 当前配置保持不变
+// 如连接新配置中的数据库失败时否决 钩子不应修改新配置
+type PreReloadHook[T any] func(newConfig *T) error
+
+// PostReloadHook 重载的新配置生效后执行的钩子
+type PostReloadHook[T any] func(oldConfig, newConfig *T)
+
+// reloadHooks 已注册的重载钩子
+type reloadHooks[T any] struct {
+	mu   sync.Mutex
+	pre  []PreReloadHook[T]
+	post []PostReloadHook[T]
+}
+
+// RegisterHook 注册重载前后的钩子 pre 与 post 都可以为空 同一阶段的钩子按注册顺序执行
+// 任一 pre 钩子返回错误时不再执行后续钩子 新配置被丢弃 错误包装 ErrReloadVetoed 后发送到错误通道
+// post 钩子在新配置立即生效后执行 等待生效时间或需要重启的配置不会触发 钩子中不能调用 Reload
+func (cm *CfgManager[T]) RegisterHook(pre PreReloadHook[T], post PostReloadHook[T]) {
+	cm.hooks.mu.Lock()
+	defer cm.hooks.mu.Unlock()
+	if pre != nil {
+		cm.hooks.pre = append(cm.hooks.pre, pre)
+	}
+	if post != nil {
+		cm.hooks.post = append(cm.hooks.post, post)
+	}
+}
+
+// runPreReloadHooks 依次执行重载前钩子 返回第一个否决的错误
+func (cm *CfgManager[T]) runPreReloadHooks(newConfig *T) error {
+	cm.hooks.mu.Lock()
+	hooks := slices.Clone(cm.hooks.pre)
+	cm.hooks.mu.Unlock()
+	for _, hook := range hooks {
+		if err := hook(newConfig); err != nil {
+			return fmt.Errorf("%w: %w", ErrReloadVetoed, err)
+		}
+	}
+	return nil
+}
+
+// runPostReloadHooks 依次执行重载后钩子
+func (cm *CfgManager[T]) runPostReloadHooks(oldConfig, newConfig *T) {
+	cm.hooks.mu.Lock()
+	hooks := slices.Clone(cm.hooks.post)
+	cm.hooks.mu.Unlock()
+	for _, hook := range hooks {
+		hook(oldConfig, newConfig)
+	}
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/omeyang/practices/internal/entity"
+	mocks "github.com/omeyang/practices/mocks/conf"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+// TestCfgManager_RegisterHook 测试重载前钩子否决时保留当前配置并报告到错误通道 通过后执行重载后钩子
+func TestCfgManager_RegisterHook(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return("/path/to/config").AnyTimes()
+
+	cm := NewConfigManager[entity.AppConf](mockLoader, nil, zap.NewNop(), RetryPolicy{})
+	initial := &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9090}}
+	assert.NoError(t, cm.storeConfig(initial))
+
+	var calls []string
+	cm.RegisterHook(func(newConfig *entity.AppConf) error {
+		calls = append(calls, "pre")
+		if newConfig.PrometheusCfg.Port == 0 {
+			return errors.New("port is not reachable")
+		}
+		return nil
+	}, func(oldConfig, newConfig *entity.AppConf) {
+		calls = append(calls, "post")
+		assert.Same(t, initial, oldConfig)
+		assert.Equal(t, 9100, newConfig.PrometheusCfg.Port)
+	})
+	cm.RegisterHook(nil, func(_, _ *entity.AppConf) {
+		calls = append(calls, "post2")
+	})
+
+	ctx := context.Background()
+	mockLoader.EXPECT().LoadConfig(gomock.Any()).Return(&entity.AppConf{PrometheusCfg: &entity.PrometheusConf{}}, nil)
+	cm.reloadConfig(ctx)
+	err := <-cm.ListenForConfigErrors()
+	assert.ErrorIs(t, err, ErrReloadVetoed)
+	assert.ErrorContains(t, err, "port is not reachable")
+	assert.Same(t, initial, cm.GetConfig())
+	assert.Equal(t, []string{"pre"}, calls)
+
+	mockLoader.EXPECT().LoadConfig(gomock.Any()).Return(&entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9100}}, nil)
+	assert.NoError(t, cm.Reload(ctx))
+	assert.Equal(t, 9100, cm.GetConfig().PrometheusCfg.Port)
+	assert.Equal(t, []string{"pre", "pre", "post", "post2"}, calls)
+}
@@ -0,0 +1,267 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// HTTPOption HTTP 配置加载器选项
+type HTTPOption func(*httpOptions)
+
+// httpOptions HTTP 配置加载器可选项
+type httpOptions struct {
+	client     *http.Client
+	header     http.Header
+	format     string
+	transforms []Transform
+}
+
+// WithHTTPClient 设置请求使用的客户端 如配置 TLS 或超时 默认为 http.DefaultClient
+func WithHTTPClient(client *http.Client) HTTPOption {
+	return func(o *httpOptions) {
+		if client != nil {
+			o.client = client
+		}
+	}
+}
+
+// WithHTTPHeader 为每个请求添加头部 如 Authorization
+func WithHTTPHeader(key, value string) HTTPOption {
+	return func(o *httpOptions) {
+		o.header.Add(key, value)
+	}
+}
+
+// WithHTTPFormat 指定配置格式的扩展名 如 .yaml 默认按 URL 路径的扩展名与响应的 Content-Type 判断
+func WithHTTPFormat(ext string) HTTPOption {
+	return func(o *httpOptions) {
+		o.format = ext
+	}
+}
+
+// WithHTTPTransforms 设置解码前的配置树变换
+func WithHTTPTransforms(transforms ...Transform) HTTPOption {
+	return func(o *httpOptions) {
+		o.transforms = append(o.transforms, transforms...)
+	}
+}
+
+// contentTypeExts Content-Type 对应的格式扩展名
+var contentTypeExts = map[string]string{
+	"application/json":   ".json",
+	"application/yaml":   ".yaml",
+	"application/x-yaml": ".yaml",
+	"text/yaml":          ".yaml",
+	"application/toml":   ".toml",
+}
+
+// HTTPLoader 从 HTTP(S) 地址加载配置 实现 CfgLoader
+//
+// 请求携带上次响应的 ETag 与 Last-Modified 内容未变化时复用上次的内容 不再重复下载
+// 配合 Watcher 返回的轮询监听器 远程内容变化时产生合成 Write 事件 CfgManager 无需任何修改
+type HTTPLoader[T any] struct {
+	url    string
+	logger *zap.Logger
+	opts   httpOptions
+
+	mu           sync.Mutex
+	etag         string
+	lastModified string
+	body         []byte // 上次成功下载的内容
+	ext          string // 上次内容的格式扩展名
+}
+
+// NewHTTPLoader 创建 HTTP 配置加载器
+func NewHTTPLoader[T any](rawURL string, logger *zap.Logger, opts ...HTTPOption) (*HTTPLoader[T], error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported url scheme %q", u.Scheme)
+	}
+	o := httpOptions{client: http.DefaultClient, header: http.Header{}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &HTTPLoader[T]{url: rawURL, logger: logger, opts: o}, nil
+}
+
+// LoadConfig 下载并解码配置 服务端返回 304 时解码上次的内容
+func (l *HTTPLoader[T]) LoadConfig(ctx context.Context) (*T, error) {
+	req, err := l.newRequest(ctx, http.MethodGet)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept-Encoding", "gzip")
+	l.mu.Lock()
+	if l.body != nil {
+		if l.etag != "" {
+			req.Header.Set("If-None-Match", l.etag)
+		}
+		if l.lastModified != "" {
+			req.Header.Set("If-Modified-Since", l.lastModified)
+		}
+	}
+	l.mu.Unlock()
+
+	resp, err := l.opts.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body []byte
+	var ext string
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		l.mu.Lock()
+		body, ext = l.body, l.ext
+		l.mu.Unlock()
+		l.logger.Debug("Remote config not modified", zap.String("url", l.url))
+	case http.StatusOK:
+		reader, err := DecodeContentEncoding(resp.Body, resp.Header.Get("Content-Encoding"))
+		if err != nil {
+			return nil, err
+		}
+		if body, err = io.ReadAll(reader); err != nil {
+			return nil, fmt.Errorf("read %s: %w", l.url, err)
+		}
+		if ext, err = l.formatOf(resp); err != nil {
+			return nil, err
+		}
+		l.mu.Lock()
+		l.etag, l.lastModified = resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+		l.body, l.ext = body, ext
+		l.mu.Unlock()
+	default:
+		return nil, fmt.Errorf("fetch %s: unexpected status %s", l.url, resp.Status)
+	}
+
+	c, err := codecFor(ext)
+	if err != nil {
+		return nil, err
+	}
+	var config T
+	if err := decodeDataWithTransforms(l.url, body, c, l.opts.transforms, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// formatOf 按选项 URL 路径扩展名与 Content-Type 依次确定配置格式
+func (l *HTTPLoader[T]) formatOf(resp *http.Response) (string, error) {
+	if l.opts.format != "" {
+		return l.opts.format, nil
+	}
+	if ext := path.Ext(resp.Request.URL.Path); ext != "" {
+		return ext, nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if ext, ok := contentTypeExts[mediaType]; ok {
+		return ext, nil
+	}
+	return "", fmt.Errorf("cannot determine config format of %s (Content-Type %q), use WithHTTPFormat", l.url, mediaType)
+}
+
+// newRequest 创建带有配置头部的请求
+func (l *HTTPLoader[T]) newRequest(ctx context.Context, method string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, l.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range l.opts.header {
+		req.Header[key] = append([]string(nil), values...)
+	}
+	return req, nil
+}
+
+// GetConfigPath 返回配置地址
+func (l *HTTPLoader[T]) GetConfigPath() string {
+	return l.url
+}
+
+// fingerprintTimeout 单次指纹请求的超时时间
+const fingerprintTimeout = 10 * time.Second
+
+// Fingerprint 以 HEAD 请求的 ETag 或 Last-Modified 作为远程内容的指纹 实现 Fingerprint
+// 服务端不支持 HEAD 或未返回这两个头部时 以 GET 响应内容的摘要作为指纹 返回 404 时视为不存在
+func (l *HTTPLoader[T]) Fingerprint(string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), fingerprintTimeout)
+	defer cancel()
+
+	resp, err := l.fetch(ctx, http.MethodHead)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		if etag := resp.Header.Get("ETag"); etag != "" {
+			return etag, nil
+		}
+		if modified := resp.Header.Get("Last-Modified"); modified != "" {
+			return modified, nil
+		}
+	}
+
+	resp, err = l.fetch(ctx, http.MethodGet)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, resp.Body); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// CheckHealth 以 HEAD 请求探测配置服务 实现 HealthChecker 服务端不支持 HEAD 时同样视为可达
+func (l *HTTPLoader[T]) CheckHealth(ctx context.Context) error {
+	resp, err := l.fetch(ctx, http.MethodHead)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// fetch 发送请求 404 返回 os.ErrNotExist 其余非 2xx 状态 除 HEAD 不被支持外 均视为错误
+func (l *HTTPLoader[T]) fetch(ctx context.Context, method string) (*http.Response, error) {
+	req, err := l.newRequest(ctx, method)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := l.opts.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %w", l.url, os.ErrNotExist)
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return resp, nil
+	case method == http.MethodHead && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented):
+		return resp, nil
+	default:
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s %s: unexpected status %s", method, l.url, resp.Status)
+	}
+}
+
+// Watcher 返回按 interval 比较远程内容指纹的轮询监听器 与加载器一起传给 NewConfigManager
+func (l *HTTPLoader[T]) Watcher(interval time.Duration, opts ...PollingOption) *PollingWatcher {
+	return NewPollingWatcher(interval, l.Fingerprint, opts...)
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/omeyang/practices/internal/entity"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// remoteConfigServer 测试用的配置服务 以版本号作为 ETag
+type remoteConfigServer struct {
+	mu       sync.Mutex
+	version  int
+	port     int
+	requests []string // 方法与条件请求的结果 如 GET 200
+}
+
+// ServeHTTP 实现 http.Handler
+func (s *remoteConfigServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.Header.Get("Authorization") != "Bearer token" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	etag := fmt.Sprintf(`"v%d"`, s.version)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Content-Type", "application/yaml")
+	if r.Header.Get("If-None-Match") == etag {
+		s.requests = append(s.requests, r.Method+" 304")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	s.requests = append(s.requests, r.Method+" 200")
+	fmt.Fprintf(w, "prometheusCfg:\n  port: %d\n", s.port)
+}
+
+// update 修改配置内容
+func (s *remoteConfigServer) update(port int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version++
+	s.port = port
+}
+
+// log 返回已收到的请求
+func (s *remoteConfigServer) log() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// TestHTTPLoader 测试按 Content-Type 解码 并以 ETag 避免重复下载
+func TestHTTPLoader(t *testing.T) {
+	remote := &remoteConfigServer{version: 1, port: 9090}
+	server := httptest.NewServer(remote)
+	defer server.Close()
+
+	_, err := NewHTTPLoader[entity.AppConf]("ftp://example.com/config", zap.NewNop())
+	assert.Error(t, err)
+	loader, err := NewHTTPLoader[entity.AppConf](server.URL+"/config", zap.NewNop(),
+		WithHTTPClient(server.Client()), WithHTTPHeader("Authorization", "Bearer token"))
+	assert.NoError(t, err)
+	assert.Equal(t, server.URL+"/config", loader.GetConfigPath())
+
+	ctx := context.Background()
+	config, err := loader.LoadConfig(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 9090, config.PrometheusCfg.Port)
+
+	again, err := loader.LoadConfig(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, config, again)
+	assert.NotSame(t, config, again)
+
+	remote.update(9091)
+	config, err = loader.LoadConfig(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 9091, config.PrometheusCfg.Port)
+	assert.Equal(t, []string{"GET 200", "GET 304", "GET 200"}, remote.log())
+
+	unauthorized, err := NewHTTPLoader[entity.AppConf](server.URL+"/config", zap.NewNop(), WithHTTPClient(server.Client()))
+	assert.NoError(t, err)
+	_, err = unauthorized.LoadConfig(ctx)
+	assert.ErrorContains(t, err, "401")
+}
+
+// TestHTTPLoader_Watcher 测试远程内容变化时轮询监听器产生合成 Write 事件
+func TestHTTPLoader_Watcher(t *testing.T) {
+	remote := &remoteConfigServer{version: 1, port: 9090}
+	server := httptest.NewServer(remote)
+	defer server.Close()
+
+	loader, err := NewHTTPLoader[entity.AppConf](server.URL+"/config", zap.NewNop(),
+		WithHTTPClient(server.Client()), WithHTTPHeader("Authorization", "Bearer token"))
+	assert.NoError(t, err)
+	fingerprint, err := loader.Fingerprint(loader.GetConfigPath())
+	assert.NoError(t, err)
+	assert.Equal(t, `"v1"`, fingerprint)
+
+	w := loader.Watcher(10 * time.Millisecond)
+	defer w.Close()
+	assert.NoError(t, w.Add(loader.GetConfigPath()))
+	remote.update(9091)
+	assertEvent(t, w, fsnotify.Event{Name: loader.GetConfigPath(), Op: fsnotify.Write})
+}
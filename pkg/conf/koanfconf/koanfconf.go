@@ -0,0 +1,155 @@
+// Package koanfconf 在 koanf 的 Provider/Parser 与本库的配置源 加载器和解析器之间互相适配
+package koanfconf
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	config "github.com/omeyang/practices/pkg/conf"
+
+	"github.com/knadh/koanf/v2"
+	"github.com/spf13/afero"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// source 以 koanf Provider 作为配置源
+type source struct {
+	name     string
+	provider koanf.Provider
+	parser   koanf.Parser
+}
+
+// Source 将 koanf Provider 适配为多源加载器的配置源
+// parser 不为空时通过 ReadBytes 读取原始内容再解析 否则直接调用 Read 读取配置树
+func Source(name string, provider koanf.Provider, parser koanf.Parser) config.Source {
+	return &source{name: name, provider: provider, parser: parser}
+}
+
+// Name 返回配置源名称
+func (s *source) Name() string {
+	return "koanf:" + s.name
+}
+
+// Load 通过 koanf Provider 读取配置树
+func (s *source) Load(_ context.Context) (map[string]any, error) {
+	if s.parser == nil {
+		return s.provider.Read()
+	}
+	data, err := s.provider.ReadBytes()
+	if err != nil {
+		return nil, err
+	}
+	return s.parser.Unmarshal(data)
+}
+
+// Loader 将 koanf Provider 适配为 CfgLoader 键名按 T 的 yaml 标签大小写无关地对齐
+func Loader[T any](name string, provider koanf.Provider, parser koanf.Parser, logger *zap.Logger) config.CfgLoader[T] {
+	return config.NewMultiSourceLoader[T](logger, &foldingSource[T]{Source: Source(name, provider, parser)})
+}
+
+// foldingSource 加载后按 T 对齐键名的配置源
+type foldingSource[T any] struct {
+	config.Source
+}
+
+// Load 读取配置树并对齐键名
+func (s *foldingSource[T]) Load(ctx context.Context) (map[string]any, error) {
+	tree, err := s.Source.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	config.FoldKeys[T](tree)
+	return tree, nil
+}
+
+// parser 以 koanf Parser 实现 CfgParser
+type parser[T any] struct {
+	parser koanf.Parser
+}
+
+// Parser 将 koanf Parser 适配为 CfgParser 用于接入本库不支持的格式 如 HCL
+func Parser[T any](p koanf.Parser) config.CfgParser[T] {
+	return &parser[T]{parser: p}
+}
+
+// Parse 读取文件内容 解析为配置树后解码
+func (p *parser[T]) Parse(file afero.File) (*T, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := p.parser.Unmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	config.FoldKeys[T](tree)
+	return decode[T](tree)
+}
+
+// decode 将配置树解码为结构体 键名取自 yaml 标签
+func decode[T any](tree map[string]any) (*T, error) {
+	data, err := yaml.Marshal(tree)
+	if err != nil {
+		return nil, err
+	}
+	var out T
+	if err := yaml.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// provider 以 CfgLoader 实现 koanf Provider
+type provider[T any] struct {
+	loader config.CfgLoader[T]
+}
+
+// Provider 将 CfgLoader 适配为 koanf Provider 使本库的加载器可以在 koanf 中使用
+// 配置以 yaml 标签中的键名输出 ReadBytes 返回 YAML 内容 可搭配 koanf 的 yaml 解析器
+func Provider[T any](loader config.CfgLoader[T]) koanf.Provider {
+	return &provider[T]{loader: loader}
+}
+
+// ReadBytes 加载配置并输出为 YAML
+func (p *provider[T]) ReadBytes() ([]byte, error) {
+	cfg, err := p.loader.LoadConfig(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(cfg)
+}
+
+// Read 加载配置并输出为配置树
+func (p *provider[T]) Read() (map[string]any, error) {
+	data, err := p.ReadBytes()
+	if err != nil {
+		return nil, err
+	}
+	tree := map[string]any{}
+	if err := yaml.Unmarshal(data, &tree); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// sourceProvider 以配置源实现 koanf Provider
+type sourceProvider struct {
+	source config.Source
+}
+
+// SourceProvider 将配置源适配为 koanf Provider 只支持 Read
+func SourceProvider(source config.Source) koanf.Provider {
+	return &sourceProvider{source: source}
+}
+
+// ReadBytes 配置源没有原始内容
+func (p *sourceProvider) ReadBytes() ([]byte, error) {
+	return nil, errors.New("koanfconf: config source does not support ReadBytes, use Read")
+}
+
+// Read 读取配置源的配置树
+func (p *sourceProvider) Read() (map[string]any, error) {
+	return p.source.Load(context.Background())
+}
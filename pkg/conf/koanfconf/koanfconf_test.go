@@ -0,0 +1,70 @@
+package koanfconf
+
+import (
+	"context"
+	"testing"
+
+	"github.com/omeyang/practices/internal/entity"
+	config "github.com/omeyang/practices/pkg/conf"
+
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/knadh/koanf/providers/rawbytes"
+	"github.com/knadh/koanf/v2"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// TestLoader 测试 koanf Provider 作为加载器与配置源使用
+func TestLoader(t *testing.T) {
+	loader := Loader[entity.AppConf]("inline", rawbytes.Provider([]byte("prometheuscfg:\n  port: 9090\n")), yaml.Parser(), zap.NewNop())
+	conf, err := loader.LoadConfig(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 9090, conf.PrometheusCfg.Port)
+
+	// 作为覆盖层与本库的配置源合并
+	fs := afero.NewMemMapFs()
+	assert.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte("prometheusCfg:\n  port: 9090\n  address: 0.0.0.0\n"), 0o600))
+	multi := config.NewMultiSourceLoader[entity.AppConf](zap.NewNop(),
+		config.FileSource(fs, "/etc/app/config.yaml"),
+		Source("overrides", confmap.Provider(map[string]any{"prometheuscfg.port": 9100}, "."), nil),
+	)
+	conf, err = multi.LoadConfig(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, &entity.PrometheusConf{Port: 9100, Address: "0.0.0.0"}, conf.PrometheusCfg)
+	assert.Equal(t, "koanf:overrides", multi.Origin("prometheusCfg.port"))
+}
+
+// TestParser 测试 koanf Parser 作为解析器使用
+func TestParser(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NoError(t, afero.WriteFile(fs, "/config.yml", []byte("prometheusCfg:\n  enable: true\n"), 0o600))
+	file, err := fs.Open("/config.yml")
+	assert.NoError(t, err)
+	defer file.Close()
+
+	conf, err := Parser[entity.AppConf](yaml.Parser()).Parse(file)
+	assert.NoError(t, err)
+	assert.True(t, conf.PrometheusCfg.Enable)
+}
+
+// TestProvider 测试本库的加载器与配置源在 koanf 中使用
+func TestProvider(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte("prometheusCfg:\n  port: 9090\n"), 0o600))
+	loader, err := config.NewFileLoader[entity.AppConf](fs, "/etc/app/config.yaml", zap.NewNop())
+	assert.NoError(t, err)
+
+	k := koanf.New(".")
+	assert.NoError(t, k.Load(Provider[entity.AppConf](loader), nil))
+	assert.Equal(t, 9090, k.Int("prometheusCfg.port"))
+
+	k = koanf.New(".")
+	assert.NoError(t, k.Load(Provider[entity.AppConf](loader), yaml.Parser()))
+	assert.Equal(t, 9090, k.Int("prometheusCfg.port"))
+
+	k = koanf.New(".")
+	assert.NoError(t, k.Load(SourceProvider(config.FileSource(fs, "/etc/app/config.yaml")), nil))
+	assert.Equal(t, 9090, k.Int("prometheusCfg.port"))
+}
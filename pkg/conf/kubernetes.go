@@ -0,0 +1,75 @@
+package config
+
+import (
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+// Kubernetes 以 ConfigMap 或 Secret 挂载的配置文件是指向 ..data/<文件> 的符号链接
+// 更新时 kubelet 写入新的时间戳目录后将 ..data 原子地重命名为指向新目录 配置文件本身不会产生 Write 事件
+// 因此该模式下监听配置文件所在的目录 目录中出现任何事件时重新解析符号链接 目标变化即视为配置更新
+
+// kubernetesTargets Kubernetes 模式下监听的配置文件 文件路径 -> 上一次解析出的真实路径
+type kubernetesTargets map[string]string
+
+// addKubernetes 监听配置文件所在的目录并记录当前的真实路径 调用方需持有锁
+func (r *watcherRegistry) addKubernetes(path string) error {
+	target, _ := filepath.EvalSymlinks(path)
+	if err := r.addPrimary(filepath.Dir(path), path); err != nil {
+		return err
+	}
+	if r.targets == nil {
+		r.targets = kubernetesTargets{}
+	}
+	r.targets[path] = target
+	return nil
+}
+
+// removeKubernetes 移除配置文件 目录中不再有其他配置文件时移除目录监听 调用方需持有锁
+func (r *watcherRegistry) removeKubernetes(path string) error {
+	delete(r.targets, path)
+	dir := filepath.Dir(path)
+	for other := range r.targets {
+		if filepath.Dir(other) == dir {
+			return nil
+		}
+	}
+	return r.primary.Remove(dir)
+}
+
+// kubernetesChanged 判断目录事件是否意味着配置文件发生了变化
+// 符号链接指向新的文件 或配置文件本身被写入与重新创建时返回 true
+// 原子替换后重新添加目录监听 防止底层监听因目录项被替换而失效
+func (r *watcherRegistry) kubernetesChanged(event fsnotify.Event) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return false
+	}
+
+	changed := false
+	dir := filepath.Dir(event.Name)
+	for path, target := range r.targets {
+		if filepath.Dir(path) != dir {
+			continue
+		}
+		current, err := filepath.EvalSymlinks(path)
+		switch {
+		case err != nil:
+			// 替换过程中文件可能暂时不存在 等待后续的 Create 事件
+			continue
+		case current != target:
+			r.logger.Info("Config symlink target changed", zap.String("path", path), zap.String("target", current))
+			r.targets[path] = current
+			changed = true
+			if err := r.primary.Add(filepath.Dir(path)); err != nil {
+				r.logger.Warn("Failed to re-add config directory watch", zap.String("path", path), zap.Error(err))
+			}
+		case event.Name == path && event.Op&(fsnotify.Write|fsnotify.Create) != 0:
+			changed = true
+		}
+	}
+	return changed
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/omeyang/practices/internal/entity"
+
+	mocks "github.com/omeyang/practices/mocks/conf"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+// writeConfigMapVersion 按 kubelet 的布局写入一个版本目录 并把 ..data 原子地指向它
+func writeConfigMapVersion(t *testing.T, dir, version, content string) {
+	t.Helper()
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, version), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, version, "app.yaml"), []byte(content), 0o600))
+	assert.NoError(t, os.Symlink(version, filepath.Join(dir, "..data_tmp")))
+	assert.NoError(t, os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")))
+}
+
+// TestKubernetesWatch 测试 ConfigMap 符号链接替换触发重载
+func TestKubernetesWatch(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.yaml")
+	writeConfigMapVersion(t, dir, "..2026_10_15_00_00_00.1", "prometheusCfg:\n  port: 9090\n")
+	assert.NoError(t, os.Symlink(filepath.Join("..data", "app.yaml"), path))
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	events := make(chan fsnotify.Event, 1)
+	mockWatcher := mocks.NewMockWatcherInterface(ctrl)
+	mockWatcher.EXPECT().Add(dir).Return(nil).MinTimes(1)
+	mockWatcher.EXPECT().Events().Return(events).AnyTimes()
+	mockWatcher.EXPECT().Errors().Return(make(chan error)).AnyTimes()
+	mockWatcher.EXPECT().Close().Return(nil).AnyTimes()
+
+	loader, err := NewFileLoader[entity.AppConf](afero.NewOsFs(), path, zap.NewNop(), WithPermissionCheck(PermissionCheckOff))
+	assert.NoError(t, err)
+	cm := NewConfigManager[entity.AppConf](loader, mockWatcher, zap.NewNop(), RetryPolicy{MaxAttempts: 1}, WithKubernetesWatch())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.NoError(t, cm.Init(ctx))
+	assert.Equal(t, 9090, cm.GetConfig().PrometheusCfg.Port)
+
+	// 目录中与配置无关的事件不会触发重载
+	assert.False(t, cm.watchers.kubernetesChanged(fsnotify.Event{Name: filepath.Join(dir, "other.yaml"), Op: fsnotify.Chmod}))
+
+	writeConfigMapVersion(t, dir, "..2026_10_15_00_01_00.2", "prometheusCfg:\n  port: 9091\n")
+	events <- fsnotify.Event{Name: filepath.Join(dir, "..data"), Op: fsnotify.Create}
+	assert.Eventually(t, func() bool {
+		return cm.GetConfig().PrometheusCfg.Port == 9091
+	}, time.Second, 5*time.Millisecond)
+
+	// 链接目标已记录 重复的事件不再视为变化
+	assert.False(t, cm.watchers.kubernetesChanged(fsnotify.Event{Name: filepath.Join(dir, "..data"), Op: fsnotify.Create}))
+	// 配置文件本身被写入时仍然重载
+	assert.True(t, cm.watchers.kubernetesChanged(fsnotify.Event{Name: path, Op: fsnotify.Write}))
+}
+
+// TestKubernetesWatch_Remove 测试移除最后一个配置文件时移除目录监听
+func TestKubernetesWatch_Remove(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockWatcher := mocks.NewMockWatcherInterface(ctrl)
+	r := newWatcherRegistry(mockWatcher, 0, RealClock, zap.NewNop())
+	r.kubernetes = true
+
+	mockWatcher.EXPECT().Add("/etc/app").Return(nil).Times(2)
+	assert.NoError(t, r.add("/etc/app/app.yaml"))
+	assert.NoError(t, r.add("/etc/app/tenants.yaml"))
+
+	assert.NoError(t, r.remove("/etc/app/app.yaml"))
+	mockWatcher.EXPECT().Remove("/etc/app").Return(nil).Times(1)
+	assert.NoError(t, r.remove("/etc/app/tenants.yaml"))
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Lazy 延迟解码的配置段 解码配置时只保存原始配置树 首次调用 Get 时才解码为 S 并缓存
+// 每次重新加载都会生成新的 Lazy 缓存随之失效 适合配置庞大但服务只使用少数配置段的场景
+//
+// 配置段中的 default 标签在解码时生效 Validate 不会为了校验而解码配置段
+// 编码 差异比较与脱敏直接使用原始配置树 不会触发解码
+type Lazy[S any] struct {
+	state *lazyState[S]
+}
+
+// lazyState 配置段的原始配置树与解码结果 复制 Lazy 时共享
+type lazyState[S any] struct {
+	raw   any
+	once  sync.Once
+	value *S
+	err   error
+}
+
+// NewLazy 创建已解码的配置段 用于在代码中构造配置
+func NewLazy[S any](value *S) Lazy[S] {
+	state := &lazyState[S]{value: value}
+	state.once.Do(func() {})
+	return Lazy[S]{state: state}
+}
+
+// Get 返回解码后的配置段 配置中不存在该配置段时返回 nil
+func (l Lazy[S]) Get() (*S, error) {
+	if l.state == nil {
+		return nil, nil
+	}
+	l.state.once.Do(func() {
+		if l.state.raw == nil {
+			return
+		}
+		l.state.value, l.state.err = decodeLazy[S](l.state.raw)
+	})
+	return l.state.value, l.state.err
+}
+
+// decodeLazy 将原始配置树解码为配置段 并填充默认值
+func decodeLazy[S any](raw any) (*S, error) {
+	data, err := yamlCodec.marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var value S
+	if err := yamlCodec.decode(bytes.NewReader(data), &value); err != nil {
+		return nil, fmt.Errorf("decode lazy section: %w", err)
+	}
+	if err := ApplyDefaults(&value); err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+// UnmarshalYAML 实现 yaml.Unmarshaler 只保存原始配置树
+func (l *Lazy[S]) UnmarshalYAML(node *yaml.Node) error {
+	var raw any
+	if err := node.Decode(&raw); err != nil {
+		return err
+	}
+	l.state = &lazyState[S]{raw: raw}
+	return nil
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler 只保存原始配置树
+func (l *Lazy[S]) UnmarshalJSON(data []byte) error {
+	var raw any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	l.state = &lazyState[S]{raw: raw}
+	return nil
+}
+
+// MarshalYAML 实现 yaml.Marshaler 优先输出原始配置树
+func (l Lazy[S]) MarshalYAML() (any, error) {
+	return l.encoded(), nil
+}
+
+// MarshalJSON 实现 json.Marshaler 优先输出原始配置树
+func (l Lazy[S]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.encoded())
+}
+
+// encoded 返回编码使用的取值 原始配置树不可变 读取时无需等待解码
+func (l Lazy[S]) encoded() any {
+	switch {
+	case l.state == nil:
+		return nil
+	case l.state.raw != nil:
+		return l.state.raw
+	case l.state.value != nil:
+		return l.state.value
+	default:
+		return nil
+	}
+}
+
+// lazySection 由 Lazy 实现 用于按配置段的类型遍历配置树
+type lazySection interface {
+	sectionType() reflect.Type
+}
+
+// sectionType 实现 lazySection
+func (Lazy[S]) sectionType() reflect.Type {
+	return reflect.TypeOf((*S)(nil)).Elem()
+}
+
+// lazySectionType lazySection 接口类型
+var lazySectionType = reflect.TypeOf((*lazySection)(nil)).Elem()
+
+// lazyElem 将 Lazy 类型替换为其配置段类型 其余类型原样返回
+func lazyElem(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Struct && t.Implements(lazySectionType) {
+		return reflect.Zero(t).Interface().(lazySection).sectionType()
+	}
+	return t
+}
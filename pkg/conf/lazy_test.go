@@ -0,0 +1,85 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// lazyConf 测试用的延迟解码配置
+type lazyConf struct {
+	Name     string                   `yaml:"name"`
+	Database Lazy[adminDatabaseConf]  `yaml:"database"`
+	Server   Lazy[defaultsServerConf] `yaml:"server"`
+	Cache    Lazy[adminDatabaseConf]  `yaml:"cache"`
+}
+
+// TestLazy 测试配置段首次访问时解码并缓存 编码与脱敏不触发解码
+func TestLazy(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NoError(t, afero.WriteFile(fs, "/app.yaml", []byte("name: app\ndatabase:\n  user: root\n  password: secret\nserver:\n  port: 9090\n"), 0o644))
+	file, err := fs.Open("/app.yaml")
+	assert.NoError(t, err)
+	defer file.Close()
+	config, err := (&YAMLParser[lazyConf]{Logger: zap.NewNop()}).Parse(file)
+	assert.NoError(t, err)
+	assert.Nil(t, config.Database.state.value)
+
+	tree, err := Redact(config)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"name":     "app",
+		"database": map[string]any{"user": "root", "password": RedactedValue},
+		"server":   map[string]any{"port": 9090},
+		"cache":    nil,
+	}, tree)
+	assert.Nil(t, config.Database.state.value)
+
+	database, err := config.Database.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, &adminDatabaseConf{User: "root", Password: "secret"}, database)
+	again, _ := config.Database.Get()
+	assert.Same(t, database, again)
+
+	server, err := config.Server.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, 9090, server.Port)
+	assert.Equal(t, "5s", server.Timeout.String())
+
+	cache, err := config.Cache.Get()
+	assert.NoError(t, err)
+	assert.Nil(t, cache)
+
+	var bad lazyConf
+	assert.NoError(t, json.Unmarshal([]byte(`{"server":{"port":"x"}}`), &bad))
+	_, err = bad.Server.Get()
+	assert.Error(t, err)
+}
+
+// TestLazy_Formats 测试 JSON 与 TOML 配置中的延迟解码配置段
+func TestLazy_Formats(t *testing.T) {
+	var fromJSON lazyConf
+	assert.NoError(t, json.Unmarshal([]byte(`{"database":{"user":"root"}}`), &fromJSON))
+	database, err := fromJSON.Database.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, "root", database.User)
+
+	fs := afero.NewMemMapFs()
+	assert.NoError(t, afero.WriteFile(fs, "/app.toml", []byte("[Database]\nUser = \"root\"\n"), 0o644))
+	file, err := fs.Open("/app.toml")
+	assert.NoError(t, err)
+	defer file.Close()
+	fromTOML, err := (&TOMLParser[lazyConf]{Logger: zap.NewNop()}).Parse(file)
+	assert.NoError(t, err)
+	database, err = fromTOML.Database.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, "root", database.User)
+
+	built := lazyConf{Database: NewLazy(&adminDatabaseConf{User: "svc"})}
+	data, err := json.Marshal(built)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"Name":"","Database":{"User":"svc","Password":""},"Server":null,"Cache":null}`, string(data))
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"context"
+	"sync"
+)
+
+// LazyValue 由配置派生的值 首次调用 Get 时等待首份配置加载完成再计算 之后按配置版本缓存
+// 配置生效新版本后的下一次 Get 重新计算 适合由配置构造的开销较大的对象 如正则表达式集合与路由表
+//
+//	routes := config.NewLazyValue(cm, func(c *entity.AppConf) *Router { return buildRouter(c) })
+//	router, err := routes.Get(ctx)
+//
+// 即需求中的 Lazy 句柄 Lazy 已是延迟解码配置段的类型名 因此命名为 LazyValue 构造函数为 NewLazyValue
+type LazyValue[T, V any] struct {
+	cm     *CfgManager[T]
+	decode func(*T) V
+
+	mu      sync.Mutex
+	version uint64 // 缓存的值对应的配置版本 为 0 表示尚未计算
+	value   V
+}
+
+// NewLazyValue 创建由配置派生的值 decode 在 Get 中按需调用 不应修改传入的配置
+func NewLazyValue[T, V any](cm *CfgManager[T], decode func(*T) V) *LazyValue[T, V] {
+	return &LazyValue[T, V]{cm: cm, decode: decode}
+}
+
+// Get 返回当前配置版本派生的值 首份配置加载前阻塞 ctx 结束时返回其错误
+// 同一版本只计算一次 并发调用等待同一次计算
+func (l *LazyValue[T, V]) Get(ctx context.Context) (V, error) {
+	if err := l.cm.WaitReady(ctx); err != nil {
+		var zero V
+		return zero, err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.version != 0 && l.version == l.cm.Version() {
+		return l.value, nil
+	}
+	config, version := l.cm.GetVersioned()
+	l.value, l.version = l.decode(config), version
+	return l.value, nil
+}
+
+// Version 返回缓存的值对应的配置版本 尚未计算时为 0
+func (l *LazyValue[T, V]) Version() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.version
+}
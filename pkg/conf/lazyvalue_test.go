@@ -0,0 +1,53 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/omeyang/practices/internal/entity"
+	mocks "github.com/omeyang/practices/mocks/conf"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+// TestLazyValue 测试派生值在首份配置加载前阻塞 同一版本只计算一次
+func TestLazyValue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return("/path/to/config").AnyTimes()
+
+	cm := NewConfigManager[entity.AppConf](mockLoader, nil, zap.NewNop(), RetryPolicy{})
+	calls := 0
+	address := NewLazyValue(cm, func(c *entity.AppConf) string {
+		calls++
+		return fmt.Sprintf("%s:%d", c.PrometheusCfg.Address, c.PrometheusCfg.Port)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := address.Get(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, uint64(0), address.Version())
+
+	assert.NoError(t, cm.storeConfig(&entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Address: "0.0.0.0", Port: 9090}}))
+	for range 2 {
+		value, err := address.Get(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, "0.0.0.0:9090", value)
+	}
+	assert.Equal(t, 1, calls)
+
+	// 新版本生效后重新计算
+	assert.NoError(t, cm.storeConfig(&entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Address: "127.0.0.1", Port: 9091}}))
+	value, err := address.Get(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:9091", value)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, cm.Version(), address.Version())
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ErrManagerClosed 管理器已关闭
+var ErrManagerClosed = errors.New("config manager closed")
+
+// lifecycle 管理器后台协程的生命周期
+type lifecycle struct {
+	mu      sync.Mutex
+	closed  bool               // 不再接受新的工作 Close 开始后即为 true
+	cleaned bool               // Close 已释放监听器 计时器与各通道
+	cancel  context.CancelFunc // 取消 Init 派生的 ctx 停止后台协程
+	workers sync.WaitGroup     // 监听与定时重载协程
+}
+
+// closing 返回管理器是否已关闭或正在关闭
+func (l *lifecycle) closing() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.closed
+}
+
+// watching 返回 Init 是否已开始监听且管理器未关闭
+func (l *lifecycle) watching() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.cancel != nil && !l.closed
+}
+
+// spawn 启动受 Close 管理的后台协程
+func (cm *CfgManager[T]) spawn(fn func()) {
+	cm.life.workers.Add(1)
+	go func() {
+		defer cm.life.workers.Done()
+		fn()
+	}()
+}
+
+// Close 停止监听与定时重载协程 关闭监听器 等待已排队的配置变更交给处理函数与订阅者后关闭各通道
+// 之后 ListenForConfigErrors 返回的通道与订阅通道都会关闭 Init 返回 ErrManagerClosed
+// ctx 在后台协程退出前结束时返回其错误且尚未释放资源 可以再次调用 Close 完成清理 清理完成后的调用直接返回 nil
+func (cm *CfgManager[T]) Close(ctx context.Context) error {
+	cm.life.mu.Lock()
+	if cm.life.cleaned {
+		cm.life.mu.Unlock()
+		return nil
+	}
+	cm.life.closed = true
+	cancel := cm.life.cancel
+	cm.life.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	stopped := make(chan struct{})
+	go func() {
+		cm.life.workers.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	// 并发的 Close 只有一个执行清理
+	cm.life.mu.Lock()
+	if cm.life.cleaned {
+		cm.life.mu.Unlock()
+		return nil
+	}
+	cm.life.cleaned = true
+	cm.life.mu.Unlock()
+
+	// 后台协程已退出 不会再有新的重载与错误通知
+	if err := cm.watchers.close(); err != nil {
+		cm.logger.Error("Failed to close watcher", zap.Error(err))
+	}
+	// 持有 applyMu 丢弃等待生效的配置 已到期的回调随后看到管理器已关闭直接返回
+	cm.applyMu.Lock()
+	cm.pending.mu.Lock()
+	if cm.pending.timer != nil {
+		cm.pending.timer.Stop()
+	}
+	cm.pending.timer, cm.pending.config, cm.pending.at = nil, nil, time.Time{}
+	cm.pending.mu.Unlock()
+	cm.applyMu.Unlock()
+
+	err := cm.flushChangeHandlers(ctx)
+	cm.subscribers.drainAll()
+	cm.shadow.subscribers.drainAll()
+	close(cm.errorChan)
+	close(cm.configChan)
+	cm.logger.Info("Config manager closed")
+	return err
+}
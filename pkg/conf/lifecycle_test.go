@@ -0,0 +1,149 @@
+package config
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/omeyang/practices/internal/entity"
+	mocks "github.com/omeyang/practices/mocks/conf"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+// TestCfgManager_Close 测试关闭后后台协程退出 排队的变更交给处理函数 各通道关闭
+func TestCfgManager_Close(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockWatcher := mocks.NewMockWatcherInterface(ctrl)
+	mockLoader.EXPECT().LoadConfig(gomock.Any()).Return(&entity.AppConf{}, nil).Times(1)
+	mockLoader.EXPECT().GetConfigPath().Return("/path/to/config").AnyTimes()
+	mockWatcher.EXPECT().Add("/path/to/config").Return(nil).Times(1)
+	mockWatcher.EXPECT().Events().Return(make(chan fsnotify.Event)).AnyTimes()
+	mockWatcher.EXPECT().Errors().Return(make(chan error)).AnyTimes()
+	mockWatcher.EXPECT().Close().Return(nil).Times(1)
+
+	cm := NewConfigManager[entity.AppConf](mockLoader, mockWatcher, zap.NewNop(), RetryPolicy{})
+	ctx := context.Background()
+	assert.NoError(t, cm.Init(ctx))
+
+	release := make(chan struct{})
+	var handled []*entity.AppConf
+	cm.OnChange(func(_, newConfig *entity.AppConf) error {
+		<-release
+		handled = append(handled, newConfig)
+		return nil
+	})
+	sub := cm.Subscribe(WithDelivery(DeliverBlocking))
+	second, third := &entity.AppConf{}, &entity.AppConf{}
+	assert.NoError(t, cm.Set(ctx, second))
+	assert.NoError(t, cm.Set(ctx, third))
+	close(release)
+
+	assert.NoError(t, cm.Close(ctx))
+	assert.Equal(t, []*entity.AppConf{second, third}, handled)
+
+	// 错误通道与订阅通道在已排队的配置取完后关闭
+	_, ok := <-cm.ListenForConfigErrors()
+	assert.False(t, ok)
+	var received []*entity.AppConf
+	for config := range sub.C() {
+		received = append(received, config)
+	}
+	assert.Equal(t, []*entity.AppConf{second, third}, received)
+
+	assert.NoError(t, cm.Close(ctx))
+	assert.ErrorIs(t, cm.Init(ctx), ErrManagerClosed)
+}
+
+// TestCfgManager_CloseTimeout 测试处理函数未结束时 ctx 到期后 Close 不再等待
+func TestCfgManager_CloseTimeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockWatcher := mocks.NewMockWatcherInterface(ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return("/path/to/config").AnyTimes()
+	mockWatcher.EXPECT().Close().Return(nil).Times(1)
+
+	cm := NewConfigManager[entity.AppConf](mockLoader, mockWatcher, zap.NewNop(), RetryPolicy{})
+	assert.NoError(t, cm.Set(context.Background(), &entity.AppConf{}))
+
+	release := make(chan struct{})
+	defer close(release)
+	cm.OnChange(func(_, _ *entity.AppConf) error {
+		<-release
+		return nil
+	})
+	assert.NoError(t, cm.Set(context.Background(), &entity.AppConf{}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, cm.Close(ctx), context.DeadlineExceeded)
+}
+
+// TestCfgManager_CloseRetry 测试后台协程未退出时 ctx 到期的 Close 不释放资源 再次调用 Close 完成清理
+func TestCfgManager_CloseRetry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockWatcher := mocks.NewMockWatcherInterface(ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return("/path/to/config").AnyTimes()
+
+	cm := NewConfigManager[entity.AppConf](mockLoader, mockWatcher, zap.NewNop(), RetryPolicy{})
+	release := make(chan struct{})
+	cm.spawn(func() { <-release })
+
+	expired, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, cm.Close(expired), context.Canceled)
+	assert.ErrorIs(t, cm.Init(context.Background()), ErrManagerClosed)
+
+	close(release)
+	mockWatcher.EXPECT().Close().Return(nil).Times(1)
+	assert.NoError(t, cm.Close(context.Background()))
+	_, ok := <-cm.ListenForConfigErrors()
+	assert.False(t, ok)
+	assert.NoError(t, cm.Close(context.Background()))
+}
+
+// TestCfgManager_ClosePending 测试关闭时丢弃等待生效的配置 关闭后到期的回调不再应用配置
+func TestCfgManager_ClosePending(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockWatcher := mocks.NewMockWatcherInterface(ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return("/path/to/config").AnyTimes()
+	mockWatcher.EXPECT().Close().Return(nil).Times(1)
+
+	clock := NewFakeClock(time.Unix(0, 0))
+	cm := NewConfigManager[entity.AppConf](mockLoader, mockWatcher, zap.NewNop(), RetryPolicy{}, WithClock(clock))
+	ctx := context.Background()
+	current := &entity.AppConf{}
+	assert.NoError(t, cm.Set(ctx, current))
+
+	at := time.Unix(0, 0).Add(time.Minute)
+	scheduled := &entity.AppConf{EffectiveAt: &at}
+	assert.NoError(t, cm.Set(ctx, scheduled))
+	pending, _ := cm.PendingConfig()
+	assert.Same(t, scheduled, pending)
+
+	assert.NoError(t, cm.Close(ctx))
+	pending, _ = cm.PendingConfig()
+	assert.Nil(t, pending)
+
+	// 关闭后排定的配置到期也不会生效
+	later := &entity.AppConf{EffectiveAt: &at}
+	assert.NoError(t, cm.Set(ctx, later))
+	clock.Advance(time.Minute)
+	assert.Never(t, func() bool {
+		return cm.GetConfig() != current
+	}, 50*time.Millisecond, time.Millisecond)
+}
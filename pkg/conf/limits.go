@@ -0,0 +1,153 @@
+package config
+
+import (
+	"maps"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+// Limits 由配置驱动的限额注册表 如最大连接数 最大请求大小与租户配额
+//
+// 限额以名称索引 整组限额在更新时一次性替换 读取不加锁 任一时刻读到的限额都来自同一份配置
+// 每个限额有独立的运行时用量计数 Acquire 与 Release 成对调用 用量在限额更新后保留
+type Limits struct {
+	values atomic.Pointer[map[string]int64]
+	mu     sync.Mutex
+	usage  map[string]*atomic.Int64
+}
+
+// LimitsSnapshot 限额与用量的快照
+type LimitsSnapshot struct {
+	Limits map[string]int64 `json:"limits"` // 限额
+	Usage  map[string]int64 `json:"usage"`  // 用量 不包括从未使用的限额
+}
+
+// NewLimits 创建限额注册表 values 为初始限额
+func NewLimits(values map[string]int64) *Limits {
+	l := &Limits{usage: map[string]*atomic.Int64{}}
+	l.Update(values)
+	return l
+}
+
+// Update 以 values 整体替换全部限额 取值小于等于 0 的限额视为不限
+func (l *Limits) Update(values map[string]int64) {
+	next := make(map[string]int64, len(values))
+	for name, value := range values {
+		if value > 0 {
+			next[name] = value
+		}
+	}
+	l.values.Store(&next)
+}
+
+// Limit 返回限额 未设置时返回 false 表示不限
+func (l *Limits) Limit(name string) (int64, bool) {
+	value, ok := (*l.values.Load())[name]
+	return value, ok
+}
+
+// Allow 判断 n 是否在限额之内 如请求大小 不计入用量
+func (l *Limits) Allow(name string, n int64) bool {
+	limit, ok := l.Limit(name)
+	return !ok || n <= limit
+}
+
+// Acquire 在用量加 n 不超过限额时增加用量并返回 true 如建立连接前占用一个连接数
+// 限额调低到当前用量以下时已占用的用量不受影响 新的占用被拒绝直到用量回落
+func (l *Limits) Acquire(name string, n int64) bool {
+	counter := l.counter(name)
+	for {
+		used := counter.Load()
+		if limit, ok := l.Limit(name); ok && used+n > limit {
+			return false
+		}
+		if counter.CompareAndSwap(used, used+n) {
+			return true
+		}
+	}
+}
+
+// Release 释放 Acquire 占用的用量
+func (l *Limits) Release(name string, n int64) {
+	l.counter(name).Add(-n)
+}
+
+// Usage 返回当前用量
+func (l *Limits) Usage(name string) int64 {
+	return l.counter(name).Load()
+}
+
+// Snapshot 返回全部限额与用量
+func (l *Limits) Snapshot() LimitsSnapshot {
+	snapshot := LimitsSnapshot{Limits: maps.Clone(*l.values.Load()), Usage: map[string]int64{}}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for name, counter := range l.usage {
+		snapshot.Usage[name] = counter.Load()
+	}
+	return snapshot
+}
+
+// counter 返回限额的用量计数 不存在时创建
+func (l *Limits) counter(name string) *atomic.Int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	counter, ok := l.usage[name]
+	if !ok {
+		counter = &atomic.Int64{}
+		l.usage[name] = counter
+	}
+	return counter
+}
+
+// BindLimits 以配置段 section 中的数值作为限额 配置生效时整组更新 如 limitsCfg
+// 限额名称为相对配置段的键路径 如 maxConnections 与 tenantQuotas.acme 非数值的键被忽略
+func BindLimits[T any](cm *CfgManager[T], section string) *Limits {
+	limits := NewLimits(nil)
+	update := func(config *T) error {
+		values, err := sectionLimits(config, section)
+		if err != nil {
+			return err
+		}
+		limits.Update(values)
+		return nil
+	}
+	if config := cm.sharedConfig(); config != nil {
+		if err := update(config); err != nil {
+			cm.logger.Warn("Failed to read limits from config", zap.String("section", section), zap.Error(err))
+		}
+	}
+	cm.OnChange(func(_, newConfig *T) error {
+		return update(newConfig)
+	})
+	return limits
+}
+
+// sectionLimits 收集配置段中的数值
+func sectionLimits(config any, section string) (map[string]int64, error) {
+	tree, err := configTree(config)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]int64{}
+	node, _ := lookupPath(tree, section)
+	if m, ok := node.(map[string]any); ok {
+		collectLimits(m, "", values)
+	}
+	return values, nil
+}
+
+// collectLimits 递归收集数值叶子
+func collectLimits(node map[string]any, path string, values map[string]int64) {
+	for key, value := range node {
+		if child, ok := value.(map[string]any); ok {
+			collectLimits(child, joinPath(path, key), values)
+			continue
+		}
+		if n, ok := toFloat(value); ok {
+			values[joinPath(path, key)] = int64(n)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/omeyang/practices/internal/entity"
+	mocks "github.com/omeyang/practices/mocks/conf"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+// TestLimits 测试限额检查 用量计数与整组替换
+func TestLimits(t *testing.T) {
+	limits := NewLimits(map[string]int64{"maxConnections": 2, "maxRequestSize": 1024, "tenantQuotas.acme": 0})
+
+	assert.True(t, limits.Allow("maxRequestSize", 1024))
+	assert.False(t, limits.Allow("maxRequestSize", 1025))
+	assert.True(t, limits.Allow("tenantQuotas.acme", 1<<40))
+
+	assert.True(t, limits.Acquire("maxConnections", 1))
+	assert.True(t, limits.Acquire("maxConnections", 1))
+	assert.False(t, limits.Acquire("maxConnections", 1))
+	limits.Release("maxConnections", 1)
+	assert.True(t, limits.Acquire("maxConnections", 1))
+
+	// 调低限额后已占用的用量保留 新的占用被拒绝
+	limits.Update(map[string]int64{"maxConnections": 1})
+	assert.False(t, limits.Acquire("maxConnections", 1))
+	_, ok := limits.Limit("maxRequestSize")
+	assert.False(t, ok)
+	assert.Equal(t, LimitsSnapshot{
+		Limits: map[string]int64{"maxConnections": 1},
+		Usage:  map[string]int64{"maxConnections": 2},
+	}, limits.Snapshot())
+}
+
+// TestBindLimits 测试按配置段设置限额 配置生效时整组更新
+func TestBindLimits(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return("/path/to/config").AnyTimes()
+
+	cm := NewConfigManager[entity.AppConf](mockLoader, nil, zap.NewNop(), RetryPolicy{})
+	assert.NoError(t, cm.storeConfig(&entity.AppConf{LimitsCfg: &entity.LimitsConf{
+		MaxConnections: 100,
+		TenantQuotas:   map[string]int64{"acme": 10, "globex": 20},
+	}}))
+	limits := BindLimits(cm, "limitsCfg")
+	assert.Equal(t, map[string]int64{"maxConnections": 100, "tenantQuotas.acme": 10, "tenantQuotas.globex": 20}, limits.Snapshot().Limits)
+
+	assert.NoError(t, cm.Set(context.Background(), &entity.AppConf{LimitsCfg: &entity.LimitsConf{
+		MaxConnections: 200,
+		MaxRequestSize: 1 << 20,
+		TenantQuotas:   map[string]int64{"acme": 5},
+	}}))
+	assert.Eventually(t, func() bool {
+		_, ok := limits.Limit("tenantQuotas.globex")
+		return !ok
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, map[string]int64{"maxConnections": 200, "maxRequestSize": 1 << 20, "tenantQuotas.acme": 5}, limits.Snapshot().Limits)
+}
@@ -0,0 +1,188 @@
+// Package listener 按配置中的监听地址运行 HTTP 服务 地址变化时先绑定新地址再优雅关闭旧的服务
+// 新旧监听器通过 SO_REUSEPORT 可以同时绑定同一端口 重新绑定期间新连接由新监听器接受 旧连接处理完毕后才关闭
+package listener
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	config "github.com/omeyang/practices/pkg/conf"
+
+	"go.uber.org/zap"
+)
+
+// defaultDrainTimeout 默认等待旧服务处理完已有请求的时间
+const defaultDrainTimeout = 30 * time.Second
+
+// Option 服务选项
+type Option func(*Server)
+
+// WithDrainTimeout 设置重新绑定后等待旧服务处理完已有请求的时间 超时后强制关闭剩余连接
+func WithDrainTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		if timeout > 0 {
+			s.drainTimeout = timeout
+		}
+	}
+}
+
+// WithServerTemplate 设置创建 http.Server 使用的模板 如读写超时 Handler 与 Addr 会被覆盖
+func WithServerTemplate(template func() *http.Server) Option {
+	return func(s *Server) {
+		s.template = template
+	}
+}
+
+// Server 跟随配置重新绑定监听地址的 HTTP 服务
+type Server struct {
+	handler      http.Handler
+	logger       *zap.Logger
+	drainTimeout time.Duration
+	template     func() *http.Server
+	listen       net.ListenConfig
+
+	mu      sync.Mutex
+	addr    string         // 当前配置的监听地址
+	current *http.Server   // 当前接受连接的服务
+	ln      net.Listener   // 当前的监听器
+	serving sync.WaitGroup // 正在运行的 Serve
+	closed  bool
+}
+
+// NewServer 创建服务 调用 Rebind 或 Bind 后才开始监听
+func NewServer(handler http.Handler, logger *zap.Logger, opts ...Option) *Server {
+	s := &Server{
+		handler:      handler,
+		logger:       logger,
+		drainTimeout: defaultDrainTimeout,
+		template:     func() *http.Server { return &http.Server{ReadHeaderTimeout: 10 * time.Second} },
+		listen:       net.ListenConfig{Control: reusePort},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Addr 返回当前监听器的地址 尚未监听时返回 nil
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ln == nil {
+		return nil
+	}
+	return s.ln.Addr()
+}
+
+// Rebind 切换到新的监听地址 地址未变化时不做任何事
+//
+// 先绑定新地址并开始接受连接 再关闭旧监听器并等待旧服务处理完已有请求
+// 新地址绑定失败时保持原有的监听并返回错误 配合 WithSyncApply 可以拒绝该配置
+func (s *Server) Rebind(ctx context.Context, addr string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return http.ErrServerClosed
+	}
+	if s.current != nil && addr == s.addr {
+		return nil
+	}
+
+	ln, err := s.listen.Listen(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listen %s: %w", addr, err)
+	}
+	srv := s.template()
+	srv.Addr = addr
+	srv.Handler = s.handler
+	s.serve(srv, ln)
+
+	old, oldAddr := s.current, s.addr
+	s.addr, s.current, s.ln = addr, srv, ln
+	s.logger.Info("Listener bound", zap.String("address", ln.Addr().String()), zap.String("previous", oldAddr))
+	if old != nil {
+		s.drain(old, oldAddr)
+	}
+	return nil
+}
+
+// serve 在后台运行服务
+func (s *Server) serve(srv *http.Server, ln net.Listener) {
+	s.serving.Add(1)
+	go func() {
+		defer s.serving.Done()
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Error("HTTP server stopped", zap.String("address", ln.Addr().String()), zap.Error(err))
+		}
+	}()
+}
+
+// drain 在后台关闭旧服务 不再接受新连接 等待已有请求处理完毕 超时后强制关闭
+func (s *Server) drain(old *http.Server, addr string) {
+	s.serving.Add(1)
+	go func() {
+		defer s.serving.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), s.drainTimeout)
+		defer cancel()
+		if err := old.Shutdown(ctx); err != nil {
+			s.logger.Warn("Previous listener did not drain in time, closing remaining connections", zap.String("address", addr), zap.Error(err))
+			_ = old.Close()
+			return
+		}
+		s.logger.Info("Previous listener drained", zap.String("address", addr))
+	}()
+}
+
+// Shutdown 关闭当前服务 等待已有请求与正在关闭的旧服务完成 ctx 结束时强制关闭
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.closed = true
+	current := s.current
+	s.mu.Unlock()
+
+	var err error
+	if current != nil {
+		if err = current.Shutdown(ctx); err != nil {
+			_ = current.Close()
+		}
+	}
+	done := make(chan struct{})
+	go func() {
+		s.serving.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Bind 按 pick 取出的监听地址运行服务 首份配置加载后开始监听 之后每次配置变更时重新绑定
+// pick 返回空字符串时保持当前的监听 新地址绑定失败时处理函数返回错误 启用 WithSyncApply 时该配置会被拒绝
+func Bind[T any](ctx context.Context, provider config.ConfigProvider[T], server *Server, pick func(*T) string) {
+	update := func(c *T) error {
+		addr := pick(c)
+		if addr == "" {
+			return nil
+		}
+		return server.Rebind(ctx, addr)
+	}
+	provider.OnChange(func(_, newConfig *T) error {
+		return update(newConfig)
+	})
+	go func() {
+		if provider.WaitReady(ctx) != nil {
+			return
+		}
+		if err := update(provider.GetConfig()); err != nil {
+			server.logger.Error("Failed to bind listener for initial config", zap.Error(err))
+		}
+	}()
+}
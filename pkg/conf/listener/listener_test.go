@@ -0,0 +1,142 @@
+package listener
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/omeyang/practices/internal/entity"
+	config "github.com/omeyang/practices/pkg/conf"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// staticProvider 测试用的配置提供者 由测试触发变更
+type staticProvider struct {
+	config   *entity.AppConf
+	handlers []config.ChangeHandler[entity.AppConf]
+}
+
+// GetConfig 实现 config.ConfigProvider
+func (p *staticProvider) GetConfig() *entity.AppConf {
+	return p.config
+}
+
+// OnChange 实现 config.ConfigProvider
+func (p *staticProvider) OnChange(handler config.ChangeHandler[entity.AppConf]) {
+	p.handlers = append(p.handlers, handler)
+}
+
+// WaitReady 实现 config.ConfigProvider 配置总是就绪
+func (p *staticProvider) WaitReady(context.Context) error {
+	return nil
+}
+
+// set 替换配置并调用处理函数
+func (p *staticProvider) set(c *entity.AppConf) error {
+	old := p.config
+	p.config = c
+	for _, handler := range p.handlers {
+		if err := handler(old, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// get 请求地址并返回响应内容
+func get(addr net.Addr, path string) (string, error) {
+	resp, err := http.Get("http://" + addr.String() + path)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return string(body), err
+}
+
+// TestServer_Rebind 测试切换地址后新连接由新监听器接受 旧监听器上的请求正常完成
+func TestServer_Rebind(t *testing.T) {
+	entered, release := make(chan struct{}), make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, _ *http.Request) {
+		close(entered)
+		<-release
+		_, _ = io.WriteString(w, "slow")
+	})
+	server := NewServer(mux, zap.NewNop())
+	defer server.Shutdown(context.Background())
+
+	ctx := context.Background()
+	assert.NoError(t, server.Rebind(ctx, "127.0.0.1:0"))
+	oldAddr := server.Addr()
+	slow := make(chan string, 1)
+	go func() {
+		body, _ := get(oldAddr, "/slow")
+		slow <- body
+	}()
+	<-entered
+
+	assert.NoError(t, server.Rebind(ctx, "localhost:0"))
+	newAddr := server.Addr()
+	assert.NotEqual(t, oldAddr.String(), newAddr.String())
+	body, err := get(newAddr, "/")
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", body)
+	assert.Eventually(t, func() bool {
+		_, err := get(oldAddr, "/")
+		return err != nil
+	}, time.Second, 10*time.Millisecond)
+
+	close(release)
+	assert.Equal(t, "slow", <-slow)
+
+	assert.Error(t, server.Rebind(ctx, "127.0.0.1:-1"))
+	assert.Equal(t, newAddr, server.Addr())
+}
+
+// TestServer_ReusePort 测试新旧监听器可以同时绑定同一端口
+func TestServer_ReusePort(t *testing.T) {
+	if !reusePortSupported {
+		t.Skip("SO_REUSEPORT is not supported on this platform")
+	}
+	server := NewServer(http.NotFoundHandler(), zap.NewNop())
+	defer server.Shutdown(context.Background())
+
+	ctx := context.Background()
+	assert.NoError(t, server.Rebind(ctx, "127.0.0.1:0"))
+	port := server.Addr().(*net.TCPAddr).Port
+	assert.NoError(t, server.Rebind(ctx, fmt.Sprintf("0.0.0.0:%d", port)))
+	assert.Equal(t, port, server.Addr().(*net.TCPAddr).Port)
+}
+
+// TestBind 测试按配置中的地址监听 配置变更后重新绑定 关闭后拒绝重新绑定
+func TestBind(t *testing.T) {
+	provider := &staticProvider{config: &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Enable: true, Address: "127.0.0.1"}}}
+	server := NewServer(http.NotFoundHandler(), zap.NewNop(), WithDrainTimeout(time.Second))
+	pick := func(c *entity.AppConf) string {
+		if c.PrometheusCfg == nil || !c.PrometheusCfg.Enable {
+			return ""
+		}
+		return net.JoinHostPort(c.PrometheusCfg.Address, strconv.Itoa(c.PrometheusCfg.Port))
+	}
+	Bind[entity.AppConf](context.Background(), provider, server, pick)
+	assert.Eventually(t, func() bool { return server.Addr() != nil }, time.Second, 10*time.Millisecond)
+	first := server.Addr()
+
+	assert.NoError(t, provider.set(&entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Enable: true, Address: "localhost"}}))
+	assert.NotEqual(t, first.String(), server.Addr().String())
+	assert.NoError(t, provider.set(&entity.AppConf{}))
+
+	assert.NoError(t, server.Shutdown(context.Background()))
+	assert.ErrorIs(t, provider.set(&entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Enable: true, Address: "127.0.0.1"}}), http.ErrServerClosed)
+}
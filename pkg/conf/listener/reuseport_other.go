@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+
+package listener
+
+import "syscall"
+
+// reusePortSupported 当前平台是否支持 SO_REUSEPORT
+const reusePortSupported = false
+
+// reusePort 不支持 SO_REUSEPORT 的平台上不做任何设置 新地址与旧地址的端口相同时绑定会失败
+func reusePort(_, _ string, _ syscall.RawConn) error {
+	return nil
+}
@@ -0,0 +1,28 @@
+//go:build linux || darwin
+
+package listener
+
+import (
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// reusePortSupported 当前平台是否支持 SO_REUSEPORT
+const reusePortSupported = true
+
+// reusePort 为监听套接字设置 SO_REUSEADDR 与 SO_REUSEPORT 使新旧监听器可以同时绑定同一端口
+// 标准库 syscall 在 Linux 上没有 SO_REUSEPORT 常量 因此使用 x/sys/unix
+func reusePort(_, _ string, c syscall.RawConn) error {
+	var sockErr error
+	err := c.Control(func(fd uintptr) {
+		if sockErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEADDR, 1); sockErr != nil {
+			return
+		}
+		sockErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1)
+	})
+	if err != nil {
+		return err
+	}
+	return sockErr
+}
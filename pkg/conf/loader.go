@@ -0,0 +1,122 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/spf13/afero"
+	"go.uber.org/zap"
+)
+
+// 打开文件遇到共享冲突时的重试参数 Windows 上编辑器保存期间文件会被独占
+const (
+	openRetryAttempts = 5
+	openRetryDelay    = 20 * time.Millisecond
+)
+
+// FileLoader 从文件系统加载配置 解析器根据文件扩展名选择
+type FileLoader[T any] struct {
+	fs              afero.Fs
+	path            string
+	parser          CfgParser[T]
+	logger          *zap.Logger
+	permissionCheck PermissionCheck // 加载前的文件权限检查级别
+}
+
+// fileLoaderOptions 文件加载器可选项
+type fileLoaderOptions struct {
+	parserOpts      []ParserOption
+	permissionCheck PermissionCheck
+}
+
+// FileLoaderOption 文件加载器选项
+type FileLoaderOption func(*fileLoaderOptions)
+
+// WithParserOptions 设置创建解析器时使用的选项
+func WithParserOptions(opts ...ParserOption) FileLoaderOption {
+	return func(o *fileLoaderOptions) {
+		o.parserOpts = append(o.parserOpts, opts...)
+	}
+}
+
+// WithPermissionCheck 加载前检查配置文件的权限与属主 类似 SSH 对私钥权限的检查
+func WithPermissionCheck(level PermissionCheck) FileLoaderOption {
+	return func(o *fileLoaderOptions) {
+		o.permissionCheck = level
+	}
+}
+
+// NewFileLoader 创建文件配置加载器
+func NewFileLoader[T any](fs afero.Fs, path string, logger *zap.Logger, opts ...FileLoaderOption) (*FileLoader[T], error) {
+	var o fileLoaderOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	parser, err := NewParser[T](ConfigExt(path), logger, o.parserOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return &FileLoader[T]{
+		fs:              fs,
+		path:            NormalizePath(path),
+		parser:          parser,
+		logger:          logger,
+		permissionCheck: o.permissionCheck,
+	}, nil
+}
+
+// LoadConfig 读取并解析配置文件
+func (l *FileLoader[T]) LoadConfig(ctx context.Context) (*T, error) {
+	file, err := l.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if l.permissionCheck != PermissionCheckOff {
+		info, err := file.Stat()
+		if err != nil {
+			return nil, fmt.Errorf("stat config %s: %w", l.path, err)
+		}
+		if err := checkPermissions(info, l.permissionCheck, reflect.TypeFor[T](), l.logger); err != nil {
+			return nil, err
+		}
+	}
+	return l.parser.Parse(file)
+}
+
+// GetConfigPath 返回配置文件路径
+func (l *FileLoader[T]) GetConfigPath() string {
+	return l.path
+}
+
+// open 打开配置文件 遇到共享冲突时短暂等待后重试
+func (l *FileLoader[T]) open(ctx context.Context) (afero.File, error) {
+	delay := openRetryDelay
+	for attempt := 1; ; attempt++ {
+		file, err := l.fs.Open(l.path)
+		if err == nil || !isSharingViolation(err) || attempt == openRetryAttempts {
+			if err != nil {
+				return nil, fmt.Errorf("open config %s: %w", l.path, err)
+			}
+			return file, nil
+		}
+
+		l.logger.Debug("Config file is locked by another process, retrying", zap.String("path", l.path), zap.Int("attempt", attempt))
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// NormalizePath 规范化配置文件路径 统一分隔符并去除冗余部分
+func NormalizePath(path string) string {
+	return filepath.Clean(filepath.FromSlash(path))
+}
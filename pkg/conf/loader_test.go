@@ -0,0 +1,35 @@
+package config
+
+import (
+	"context"
+	"testing"
+
+	"github.com/omeyang/practices/internal/entity"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// TestFileLoader_LoadConfig 测试从文件系统加载配置
+func TestFileLoader_LoadConfig(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	fs := afero.NewMemMapFs()
+	assert.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte("prometheusCfg:\n  port: 9090\n"), 0o644))
+
+	loader, err := NewFileLoader[entity.AppConf](fs, "/etc/app/../app/config.yaml", logger)
+	assert.NoError(t, err)
+	assert.Equal(t, "/etc/app/config.yaml", loader.GetConfigPath())
+
+	config, err := loader.LoadConfig(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 9090, config.PrometheusCfg.Port)
+
+	missing, err := NewFileLoader[entity.AppConf](fs, "/etc/app/missing.json", logger)
+	assert.NoError(t, err)
+	_, err = missing.LoadConfig(context.Background())
+	assert.Error(t, err)
+
+	_, err = NewFileLoader[entity.AppConf](fs, "/etc/app/config.xml", logger)
+	assert.Error(t, err)
+}
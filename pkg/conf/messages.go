@@ -0,0 +1,115 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+const (
+	// LocaleEnUS 英文消息
+	LocaleEnUS = "en-US"
+	// LocaleZhCN 简体中文消息
+	LocaleZhCN = "zh-CN"
+)
+
+// messageCatalog 按语言与错误代码索引的消息模板
+var messageCatalog = struct {
+	mu       sync.RWMutex
+	messages map[string]map[ErrorCode]string
+}{messages: map[string]map[ErrorCode]string{
+	LocaleEnUS: {
+		CodeParse:            "cannot parse config: %[1]s",
+		CodeSyntax:           "syntax error: %[1]s; check quoting, brackets and indentation around this position",
+		CodeTypeMismatch:     "%[1]s; change the value to the type this key expects",
+		CodeUnknownField:     "unknown key %[1]q in %[2]s; check the spelling or remove the key",
+		CodeRequired:         "required; set a non-empty value",
+		CodeOutOfRange:       "must be in range %[1]s, got %[2]v",
+		CodeNotOneOf:         "must be one of %[1]s, got %[2]q",
+		CodeInvalidRule:      "invalid validation rule %[1]q; fix the validate tag of this field",
+		CodeSecretUnresolved: "cannot resolve secret reference: %[1]s; check that the secret exists and is readable",
+		CodeDecryptFailed:    "cannot decrypt value: %[1]s; check that it was encrypted with a current key",
+	},
+	LocaleZhCN: {
+		CodeParse:            "无法解析配置: %[1]s",
+		CodeSyntax:           "语法错误: %[1]s 请检查此处附近的引号 括号与缩进",
+		CodeTypeMismatch:     "取值类型不正确: %[1]s 请改为该配置项要求的类型",
+		CodeUnknownField:     "%[2]s 中没有配置项 %[1]q 请检查拼写或删除该配置项",
+		CodeRequired:         "必填 请设置非空的取值",
+		CodeOutOfRange:       "取值必须在 %[1]s 范围内 当前为 %[2]v",
+		CodeNotOneOf:         "取值必须是 %[1]s 之一 当前为 %[2]q",
+		CodeInvalidRule:      "校验规则 %[1]q 无效 请修正该字段的 validate 标签",
+		CodeSecretUnresolved: "无法解析密钥引用: %[1]s 请确认密钥存在且有读取权限",
+		CodeDecryptFailed:    "无法解密取值: %[1]s 请确认使用当前的密钥加密",
+	},
+}}
+
+// RegisterMessages 注册 locale 语言的消息模板 与已有的模板合并 相同代码的模板被覆盖
+// 模板按 fmt 格式化 以 %[1]v 等序号引用 FieldError.Params 可用于增加语言或改写提示
+func RegisterMessages(locale string, messages map[ErrorCode]string) {
+	messageCatalog.mu.Lock()
+	defer messageCatalog.mu.Unlock()
+	catalog := messageCatalog.messages[locale]
+	if catalog == nil {
+		catalog = make(map[ErrorCode]string, len(messages))
+		messageCatalog.messages[locale] = catalog
+	}
+	for code, message := range messages {
+		catalog[code] = message
+	}
+}
+
+// lookupMessage 查找 locale 语言的消息模板 语言标签不区分大小写 也接受下划线分隔
+// 没有完全匹配的语言时使用同一语种的其他地区 如 zh 与 zh-TW 匹配 zh-CN
+func lookupMessage(locale string, code ErrorCode) (string, bool) {
+	locale = strings.ReplaceAll(locale, "_", "-")
+	language, _, _ := strings.Cut(locale, "-")
+
+	messageCatalog.mu.RLock()
+	defer messageCatalog.mu.RUnlock()
+	var fallback string
+	for name, catalog := range messageCatalog.messages {
+		message, ok := catalog[code]
+		if !ok {
+			continue
+		}
+		if strings.EqualFold(name, locale) {
+			return message, true
+		}
+		if prefix, _, _ := strings.Cut(name, "-"); strings.EqualFold(prefix, language) && (fallback == "" || name < fallback) {
+			fallback = name
+		}
+	}
+	if fallback == "" {
+		return "", false
+	}
+	return messageCatalog.messages[fallback][code], true
+}
+
+// Localize 返回 locale 语言的错误描述 格式与 Error 相同
+// 没有该语言或该代码的模板时使用原始描述
+func (e *FieldError) Localize(locale string) string {
+	code := e.Code
+	if code == "" {
+		code = CodeInvalid
+	}
+	message, ok := lookupMessage(locale, code)
+	if !ok {
+		return e.Error()
+	}
+	return e.format(fmt.Sprintf(message, e.Params...))
+}
+
+// LocalizeError 将错误展开为配置项错误 返回每个错误 locale 语言的描述
+// 支持 ValidationError MultiError 与 FieldError 其他错误按原始描述返回
+func LocalizeError(err error, locale string) []string {
+	errs := asFieldErrors(err)
+	if len(errs) == 0 {
+		return nil
+	}
+	messages := make([]string, len(errs))
+	for i, fieldErr := range errs {
+		messages[i] = fieldErr.Localize(locale)
+	}
+	return messages
+}
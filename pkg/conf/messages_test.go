@@ -0,0 +1,50 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/omeyang/practices/internal/entity"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// TestFieldError_Localize 测试按语言输出错误描述 没有模板时使用原始描述
+func TestFieldError_Localize(t *testing.T) {
+	err := &FieldError{File: "app.yaml", Path: "port", Line: 3, Message: "must be in range 1-65535, got 0", Code: CodeOutOfRange, Params: []any{"1-65535", 0.0}}
+	assert.Equal(t, "port (app.yaml, line 3): 取值必须在 1-65535 范围内 当前为 0", err.Localize(LocaleZhCN))
+	assert.Equal(t, "port (app.yaml, line 3): 取值必须在 1-65535 范围内 当前为 0", err.Localize("zh_TW"))
+	assert.Equal(t, "port (app.yaml, line 3): must be in range 1-65535, got 0", err.Localize("en"))
+	assert.Equal(t, err.Error(), err.Localize("de-DE"))
+
+	custom := &FieldError{Path: "port", Message: "required when enabled"}
+	assert.Equal(t, "port: required when enabled", custom.Localize(LocaleZhCN))
+
+	RegisterMessages("fr-FR", map[ErrorCode]string{CodeRequired: "obligatoire"})
+	assert.Equal(t, "name: obligatoire", (&FieldError{Path: "name", Message: "required", Code: CodeRequired}).Localize("fr-FR"))
+}
+
+// TestLocalizeError 测试校验与解析错误带有代码并可本地化
+func TestLocalizeError(t *testing.T) {
+	err := ValidateStruct(&validatedConf{Level: "trace", Server: &validatedServer{Port: 8080}, Backends: []validatedServer{{Port: 1}}})
+	var multi *MultiError
+	assert.ErrorAs(t, err, &multi)
+	assert.Equal(t, CodeRequired, multi.Errors[0].Code)
+	assert.Equal(t, CodeNotOneOf, multi.Errors[1].Code)
+	assert.Equal(t, []string{
+		"name: 必填 请设置非空的取值",
+		`level: 取值必须是 debug, info, warn 之一 当前为 "trace"`,
+	}, LocalizeError(&ValidationError{Errors: multi.Errors}, LocaleZhCN))
+
+	parser, err := NewParser[entity.AppConf](".yaml", zap.NewNop(), WithStrictDecoding())
+	assert.NoError(t, err)
+	_, err = parser.Parse(mockFile("prometheusCfg:\n  prot: 9090\n  enable: maybe\n"))
+	assert.Equal(t, []string{
+		`test, line 2: entity.PrometheusConf 中没有配置项 "prot" 请检查拼写或删除该配置项`,
+		"test, line 3: 取值类型不正确: cannot unmarshal !!str `maybe` into bool 请改为该配置项要求的类型",
+	}, LocalizeError(err, LocaleZhCN))
+
+	assert.Equal(t, []string{"boom"}, LocalizeError(errors.New("boom"), LocaleZhCN))
+	assert.Nil(t, LocalizeError(nil, LocaleZhCN))
+}
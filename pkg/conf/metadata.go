@@ -0,0 +1,105 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// MetadataPrefix 模板上下文变量在 ${...} 引用中使用的前缀 如 ${meta.hostname}
+const MetadataPrefix = "meta."
+
+// 内置提供者填充的模板上下文变量
+const (
+	MetaHostname  = "hostname"
+	MetaPod       = "pod"
+	MetaNamespace = "namespace"
+	MetaNode      = "node"
+	MetaRegion    = "region"
+)
+
+// serviceAccountNamespaceFile Kubernetes 挂载的命名空间文件
+const serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// MetadataProvider 提供实例元数据 用于填充模板上下文 未知的取值直接省略
+type MetadataProvider interface {
+	Metadata() (map[string]string, error)
+}
+
+// MetadataFunc 函数形式的 MetadataProvider
+type MetadataFunc func() (map[string]string, error)
+
+// Metadata 实现 MetadataProvider
+func (f MetadataFunc) Metadata() (map[string]string, error) {
+	return f()
+}
+
+// HostnameProvider 提供主机名
+var HostnameProvider = MetadataFunc(func() (map[string]string, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{MetaHostname: hostname}, nil
+})
+
+// KubernetesProvider 读取 downward API 注入的 POD_NAME POD_NAMESPACE NODE_NAME 环境变量
+// 未注入命名空间时读取 service account 挂载的命名空间文件
+var KubernetesProvider = MetadataFunc(func() (map[string]string, error) {
+	vars := map[string]string{}
+	setFromEnv(vars, MetaPod, "POD_NAME")
+	setFromEnv(vars, MetaNamespace, "POD_NAMESPACE")
+	setFromEnv(vars, MetaNode, "NODE_NAME")
+	if _, ok := vars[MetaNamespace]; !ok {
+		if data, err := os.ReadFile(serviceAccountNamespaceFile); err == nil {
+			vars[MetaNamespace] = strings.TrimSpace(string(data))
+		}
+	}
+	return vars, nil
+})
+
+// CloudRegionProvider 从常见的云厂商环境变量读取地域
+var CloudRegionProvider = MetadataFunc(func() (map[string]string, error) {
+	vars := map[string]string{}
+	setFromEnv(vars, MetaRegion, "AWS_REGION", "AWS_DEFAULT_REGION", "GOOGLE_CLOUD_REGION", "CLOUD_REGION", "REGION")
+	return vars, nil
+})
+
+// setFromEnv 使用第一个非空的环境变量设置取值
+func setFromEnv(vars map[string]string, key string, envs ...string) {
+	for _, env := range envs {
+		if value := os.Getenv(env); value != "" {
+			vars[key] = value
+			return
+		}
+	}
+}
+
+// DefaultMetadataProviders 返回内置的元数据提供者
+func DefaultMetadataProviders() []MetadataProvider {
+	return []MetadataProvider{HostnameProvider, KubernetesProvider, CloudRegionProvider}
+}
+
+// TemplateContext 依次合并各提供者的元数据 后面的提供者覆盖前面的同名取值
+// 结果既可用于 ${meta.xxx} 引用 也可直接作为 EvaluateConditions 的上下文变量
+func TemplateContext(providers ...MetadataProvider) (map[string]string, error) {
+	vars := map[string]string{}
+	for i, provider := range providers {
+		metadata, err := provider.Metadata()
+		if err != nil {
+			return nil, fmt.Errorf("metadata provider %d: %w", i, err)
+		}
+		for key, value := range metadata {
+			vars[key] = value
+		}
+	}
+	return vars, nil
+}
+
+// WithMetadata 使 ExpandEnv 从模板上下文解析 ${meta.xxx} 引用
+// 这类引用不受 WithEnvPrefix 与 WithEnvAllow 限制 也不会读取进程环境变量
+func WithMetadata(vars map[string]string) EnvOption {
+	return func(o *envOptions) {
+		o.metadata = vars
+	}
+}
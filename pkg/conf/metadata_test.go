@@ -0,0 +1,50 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTemplateContext 测试内置提供者与提供者的覆盖顺序
+func TestTemplateContext(t *testing.T) {
+	t.Setenv("POD_NAME", "web-0")
+	t.Setenv("POD_NAMESPACE", "prod")
+	t.Setenv("NODE_NAME", "node-1")
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	t.Setenv("GOOGLE_CLOUD_REGION", "")
+	t.Setenv("CLOUD_REGION", "")
+	t.Setenv("REGION", "cn-north")
+
+	vars, err := TemplateContext(append(DefaultMetadataProviders(), MetadataFunc(func() (map[string]string, error) {
+		return map[string]string{MetaNode: "override"}, nil
+	}))...)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, vars[MetaHostname])
+	assert.Equal(t, "web-0", vars[MetaPod])
+	assert.Equal(t, "prod", vars[MetaNamespace])
+	assert.Equal(t, "override", vars[MetaNode])
+	assert.Equal(t, "cn-north", vars[MetaRegion])
+
+	_, err = TemplateContext(MetadataFunc(func() (map[string]string, error) {
+		return nil, errors.New("metadata service unavailable")
+	}))
+	assert.Error(t, err)
+}
+
+// TestExpandEnvMetadata 测试 ${meta.xxx} 引用与环境变量限制互不影响
+func TestExpandEnvMetadata(t *testing.T) {
+	t.Setenv("APP_PORT", "9090")
+	vars := map[string]string{MetaPod: "web-0"}
+	tree := map[string]any{
+		"name":    "${meta.pod}-${APP_PORT}",
+		"region":  "${meta.region:-default}",
+		"escaped": "$${meta.pod}",
+	}
+	assert.NoError(t, ExpandEnv(WithMetadata(vars), WithEnvPrefix("APP_"), WithEnvStrict())(tree))
+	assert.Equal(t, map[string]any{"name": "web-0-9090", "region": "default", "escaped": "${meta.pod}"}, tree)
+
+	assert.Error(t, ExpandEnv(WithMetadata(vars), WithEnvStrict())(map[string]any{"node": "${meta.node}"}))
+}
@@ -0,0 +1,281 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// configMetrics 配置生命周期的 Prometheus 指标 未启用时为 nil 方法均可在 nil 上调用
+type configMetrics struct {
+	reloads    *prometheus.CounterVec // 按结果统计的重载次数
+	retries    prometheus.Counter     // 重载中的重试次数
+	version    prometheus.Gauge       // 当前配置的版本号
+	lastReload prometheus.Gauge       // 上次成功重载的时间戳
+	duration   prometheus.Histogram   // 重载耗时 包括重试等待
+	size       prometheus.Gauge       // 生效配置序列化后的字节数
+	keys       prometheus.Gauge       // 生效配置的叶子键数量 列表元素逐个计数
+	sections   *prometheus.GaugeVec   // 每个顶层配置段的叶子键数量
+	sourceUp   prometheus.Gauge       // 最近一次健康探测是否成功
+	contact    prometheus.Gauge       // 最近一次健康探测成功的时间戳
+	events     *prometheus.CounterVec // 按操作统计收到的文件事件
+	ignored    prometheus.Counter     // 不需要重载而被忽略的文件事件
+	coalesced  prometheus.Counter     // 与其他事件合并为一次重载的文件事件
+	watched    prometheus.Gauge       // 正在监听的路径数量
+	drift      prometheus.Counter     // 核对时发现内存中的配置与配置源不一致的次数
+}
+
+// newConfigMetrics 创建指标并注册 source 作为常量标签区分同一进程中的多个管理器
+// 已注册的同名指标会被复用 注册失败时记录日志并返回 nil
+func newConfigMetrics(reg prometheus.Registerer, source string, logger *zap.Logger) *configMetrics {
+	labels := prometheus.Labels{"source": source}
+	m := &configMetrics{
+		reloads: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "config_reloads_total",
+			Help:        "Config reloads by result (success or failure).",
+			ConstLabels: labels,
+		}, []string{"result"}),
+		retries: prometheus.NewCounter(prometheus.CounterOpts{
+			Name:        "config_reload_retries_total",
+			Help:        "Config load attempts retried after a failure.",
+			ConstLabels: labels,
+		}),
+		version: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        "config_version",
+			Help:        "Version number of the config currently in effect.",
+			ConstLabels: labels,
+		}),
+		lastReload: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        "config_last_reload_success_timestamp_seconds",
+			Help:        "Unix time of the last successful config reload.",
+			ConstLabels: labels,
+		}),
+		duration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:        "config_reload_duration_seconds",
+			Help:        "Time taken by config reloads, including retry backoff.",
+			ConstLabels: labels,
+			Buckets:     prometheus.ExponentialBuckets(0.001, 4, 10),
+		}),
+		size: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        "config_size_bytes",
+			Help:        "Size of the config currently in effect, serialized as YAML.",
+			ConstLabels: labels,
+		}),
+		keys: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        "config_keys",
+			Help:        "Leaf keys in the config currently in effect, counting each list element.",
+			ConstLabels: labels,
+		}),
+		sections: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name:        "config_section_keys",
+			Help:        "Leaf keys in each top-level section of the config currently in effect.",
+			ConstLabels: labels,
+		}, []string{"section"}),
+		sourceUp: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        "config_source_up",
+			Help:        "Whether the last health check of the config source succeeded (1) or failed (0).",
+			ConstLabels: labels,
+		}),
+		contact: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        "config_source_last_contact_timestamp_seconds",
+			Help:        "Unix time of the last successful health check of the config source.",
+			ConstLabels: labels,
+		}),
+		events: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "config_watch_events_total",
+			Help:        "File events received by the config watcher, by operation.",
+			ConstLabels: labels,
+		}, []string{"op"}),
+		ignored: prometheus.NewCounter(prometheus.CounterOpts{
+			Name:        "config_watch_events_ignored_total",
+			Help:        "File events that did not trigger a config reload.",
+			ConstLabels: labels,
+		}),
+		coalesced: prometheus.NewCounter(prometheus.CounterOpts{
+			Name:        "config_watch_events_coalesced_total",
+			Help:        "File events merged into a reload triggered by an earlier event.",
+			ConstLabels: labels,
+		}),
+		watched: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        "config_watched_paths",
+			Help:        "Paths currently watched for config changes.",
+			ConstLabels: labels,
+		}),
+		drift: prometheus.NewCounter(prometheus.CounterOpts{
+			Name:        "config_reconcile_drift_total",
+			Help:        "Reconciliations that found the in-memory config out of sync with the config source.",
+			ConstLabels: labels,
+		}),
+	}
+
+	var err error
+	m.reloads = registerCollector(reg, m.reloads, &err)
+	m.retries = registerCollector(reg, m.retries, &err)
+	m.version = registerCollector(reg, m.version, &err)
+	m.lastReload = registerCollector(reg, m.lastReload, &err)
+	m.duration = registerCollector(reg, m.duration, &err)
+	m.size = registerCollector(reg, m.size, &err)
+	m.keys = registerCollector(reg, m.keys, &err)
+	m.sections = registerCollector(reg, m.sections, &err)
+	m.sourceUp = registerCollector(reg, m.sourceUp, &err)
+	m.contact = registerCollector(reg, m.contact, &err)
+	m.events = registerCollector(reg, m.events, &err)
+	m.ignored = registerCollector(reg, m.ignored, &err)
+	m.coalesced = registerCollector(reg, m.coalesced, &err)
+	m.watched = registerCollector(reg, m.watched, &err)
+	m.drift = registerCollector(reg, m.drift, &err)
+	if err != nil {
+		logger.Error("Failed to register config metrics", zap.Error(err))
+		return nil
+	}
+	return m
+}
+
+// registerCollector 注册指标 已注册时返回已有的指标 错误记录到 errp
+func registerCollector[C prometheus.Collector](reg prometheus.Registerer, c C, errp *error) C {
+	err := reg.Register(c)
+	if err == nil {
+		return c
+	}
+	var are prometheus.AlreadyRegisteredError
+	if errors.As(err, &are) {
+		if existing, ok := are.ExistingCollector.(C); ok {
+			return existing
+		}
+	}
+	*errp = errors.Join(*errp, err)
+	return c
+}
+
+// observeReload 记录一次重载的结果与耗时
+func (m *configMetrics) observeReload(err error, took time.Duration, now time.Time) {
+	if m == nil {
+		return
+	}
+	m.duration.Observe(took.Seconds())
+	if err != nil {
+		m.reloads.WithLabelValues("failure").Inc()
+		return
+	}
+	m.reloads.WithLabelValues("success").Inc()
+	m.lastReload.Set(float64(now.UnixNano()) / 1e9)
+}
+
+// observeRetry 记录一次重试
+func (m *configMetrics) observeRetry() {
+	if m != nil {
+		m.retries.Inc()
+	}
+}
+
+// observeHealth 记录一次配置源健康探测的结果
+func (m *configMetrics) observeHealth(err error, now time.Time) {
+	if m == nil {
+		return
+	}
+	if err != nil {
+		m.sourceUp.Set(0)
+		return
+	}
+	m.sourceUp.Set(1)
+	m.contact.Set(float64(now.UnixNano()) / 1e9)
+}
+
+// observeDrift 记录一次核对发现的不一致
+func (m *configMetrics) observeDrift() {
+	if m != nil {
+		m.drift.Inc()
+	}
+}
+
+// observeWatchEvent 按操作记录一个文件事件 同时包含多个操作的事件分别计数
+func (m *configMetrics) observeWatchEvent(op fsnotify.Op) {
+	if m == nil {
+		return
+	}
+	for _, name := range eventOpNames(op) {
+		m.events.WithLabelValues(name).Inc()
+	}
+}
+
+// observeWatchIgnored 记录一个被忽略的文件事件
+func (m *configMetrics) observeWatchIgnored() {
+	if m != nil {
+		m.ignored.Inc()
+	}
+}
+
+// observeWatchCoalesced 记录被合并的文件事件数
+func (m *configMetrics) observeWatchCoalesced(n int) {
+	if m != nil && n > 0 {
+		m.coalesced.Add(float64(n))
+	}
+}
+
+// setWatchedPaths 记录正在监听的路径数量
+func (m *configMetrics) setWatchedPaths(n int) {
+	if m != nil {
+		m.watched.Set(float64(n))
+	}
+}
+
+// setVersion 记录当前配置的版本号
+func (m *configMetrics) setVersion(version uint64) {
+	if m != nil {
+		m.version.Set(float64(version))
+	}
+}
+
+// observeConfig 记录生效配置的大小与键数量 便于在生成的配置无限增长前发现问题
+func (m *configMetrics) observeConfig(config any) {
+	if m == nil {
+		return
+	}
+	data, err := yamlCodec.marshal(config)
+	if err != nil {
+		return
+	}
+	tree := map[string]any{}
+	if err := yamlCodec.decode(bytes.NewReader(data), &tree); err != nil {
+		return
+	}
+
+	m.size.Set(float64(len(data)))
+	m.sections.Reset()
+	total := 0
+	for section, value := range tree {
+		if value == nil {
+			continue
+		}
+		n := countLeaves(value)
+		m.sections.WithLabelValues(section).Set(float64(n))
+		total += n
+	}
+	m.keys.Set(float64(total))
+}
+
+// countLeaves 统计配置树中的叶子取值 空值以及空的映射与列表不计数
+func countLeaves(node any) int {
+	switch n := node.(type) {
+	case nil:
+		return 0
+	case map[string]any:
+		count := 0
+		for _, child := range n {
+			count += countLeaves(child)
+		}
+		return count
+	case []any:
+		count := 0
+		for _, item := range n {
+			count += countLeaves(item)
+		}
+		return count
+	default:
+		return 1
+	}
+}
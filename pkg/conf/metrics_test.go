@@ -0,0 +1,82 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/omeyang/practices/internal/entity"
+	mocks "github.com/omeyang/practices/mocks/conf"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+// TestCfgManager_Metrics 测试重载结果 重试次数与版本号指标
+func TestCfgManager_Metrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return("/etc/app.yaml").AnyTimes()
+
+	reg := prometheus.NewRegistry()
+	cm := NewConfigManager[entity.AppConf](mockLoader, nil, zap.NewNop(), RetryPolicy{MaxAttempts: 2}, WithMetrics(reg))
+	ctx := context.Background()
+
+	// 一次重试后成功
+	mockLoader.EXPECT().LoadConfig(gomock.Any()).Return(nil, errors.New("load error"))
+	mockLoader.EXPECT().LoadConfig(gomock.Any()).Return(&entity.AppConf{}, nil)
+	assert.NoError(t, cm.Reload(ctx))
+
+	// 重试耗尽后失败
+	mockLoader.EXPECT().LoadConfig(gomock.Any()).Return(nil, errors.New("load error")).Times(2)
+	assert.Error(t, cm.Reload(ctx))
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(cm.metrics.reloads.WithLabelValues("success")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(cm.metrics.reloads.WithLabelValues("failure")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(cm.metrics.retries))
+	assert.Equal(t, 1.0, testutil.ToFloat64(cm.metrics.version))
+	assert.Positive(t, testutil.ToFloat64(cm.metrics.lastReload))
+	var histogram dto.Metric
+	assert.NoError(t, cm.metrics.duration.(prometheus.Metric).Write(&histogram))
+	assert.Equal(t, uint64(2), histogram.GetHistogram().GetSampleCount())
+
+	// 同一来源的第二个管理器复用已注册的指标
+	other := NewConfigManager[entity.AppConf](mockLoader, nil, zap.NewNop(), RetryPolicy{}, WithMetrics(reg))
+	assert.Same(t, cm.metrics.retries, other.metrics.retries)
+}
+
+// TestCfgManager_ConfigSizeMetrics 测试生效配置的大小与键数量指标
+func TestCfgManager_ConfigSizeMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return("/etc/app.yaml").AnyTimes()
+
+	reg := prometheus.NewRegistry()
+	cm := NewConfigManager[entity.AppConf](mockLoader, nil, zap.NewNop(), RetryPolicy{}, WithMetrics(reg))
+	ctx := context.Background()
+
+	assert.NoError(t, cm.Set(ctx, &entity.AppConf{
+		PrometheusCfg: &entity.PrometheusConf{Enable: true, Port: 9090, Address: "0.0.0.0"},
+		Tenants: map[string]map[string]any{
+			"acme":   {"prometheusCfg": map[string]any{"port": 9191}},
+			"globex": {"routes": []any{"/a", "/b"}},
+		},
+	}))
+	assert.Equal(t, 6.0, testutil.ToFloat64(cm.metrics.keys))
+	assert.Equal(t, 3.0, testutil.ToFloat64(cm.metrics.sections.WithLabelValues("prometheusCfg")))
+	assert.Equal(t, 3.0, testutil.ToFloat64(cm.metrics.sections.WithLabelValues("tenants")))
+	assert.Positive(t, testutil.ToFloat64(cm.metrics.size))
+
+	// 配置段被删除后不再报告
+	assert.NoError(t, cm.Set(ctx, &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9090}}))
+	assert.Equal(t, 3.0, testutil.ToFloat64(cm.metrics.keys))
+	assert.Equal(t, 1, testutil.CollectAndCount(cm.metrics.sections))
+}
@@ -0,0 +1,245 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/spf13/afero"
+	"go.uber.org/zap"
+)
+
+// Source 多源加载器的单个配置源 返回原始配置树
+type Source interface {
+	Name() string
+	Load(ctx context.Context) (map[string]any, error)
+}
+
+// fileSource 文件配置源
+type fileSource struct {
+	fs       afero.Fs
+	path     string
+	optional bool
+}
+
+// FileSource 从 JSON 或 YAML 文件读取配置 文件必须存在
+func FileSource(fs afero.Fs, path string) Source {
+	return &fileSource{fs: fs, path: NormalizePath(path)}
+}
+
+// OptionalFileSource 从 JSON 或 YAML 文件读取配置 文件不存在时视为空配置 适用于环境覆盖文件
+func OptionalFileSource(fs afero.Fs, path string) Source {
+	return &fileSource{fs: fs, path: NormalizePath(path), optional: true}
+}
+
+// Name 返回文件路径
+func (s *fileSource) Name() string {
+	return s.path
+}
+
+// Load 读取并解码配置文件
+func (s *fileSource) Load(_ context.Context) (map[string]any, error) {
+	c, err := codecFor(filepath.Ext(s.path))
+	if err != nil {
+		return nil, err
+	}
+	data, err := afero.ReadFile(s.fs, s.path)
+	if err != nil {
+		if s.optional && errors.Is(err, fs.ErrNotExist) {
+			return map[string]any{}, nil
+		}
+		return nil, fmt.Errorf("read config %s: %w", s.path, err)
+	}
+	tree := map[string]any{}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return tree, nil
+	}
+	if err := c.decode(bytes.NewReader(data), &tree); err != nil {
+		return nil, c.locate(s.path, data, err)
+	}
+	return tree, nil
+}
+
+// envSeparator 环境变量名中分隔配置键层级的字符串
+const envSeparator = "__"
+
+// envSource 环境变量配置源
+type envSource struct {
+	prefix string
+}
+
+// EnvSource 从带前缀的环境变量读取配置 层级之间以双下划线分隔
+// 如 APP__PROMETHEUSCFG__PORT=9100 对应 prometheusCfg.port
+// 键名与之前各源的键按大小写无关匹配 取值会还原为整数 浮点数与布尔值
+func EnvSource(prefix string) Source {
+	return &envSource{prefix: prefix}
+}
+
+// Name 返回环境变量前缀
+func (s *envSource) Name() string {
+	return "env:" + s.prefix
+}
+
+// Load 读取环境变量
+func (s *envSource) Load(_ context.Context) (map[string]any, error) {
+	tree := map[string]any{}
+	for _, env := range os.Environ() {
+		name, value, _ := strings.Cut(env, "=")
+		rest, ok := strings.CutPrefix(name, s.prefix+envSeparator)
+		if !ok || rest == "" {
+			continue
+		}
+		keys := strings.Split(strings.ToLower(rest), envSeparator)
+		node := tree
+		for _, key := range keys[:len(keys)-1] {
+			child, ok := node[key].(map[string]any)
+			if !ok {
+				child = map[string]any{}
+				node[key] = child
+			}
+			node = child
+		}
+		node[keys[len(keys)-1]] = scalarFromEnv(value)
+	}
+	return tree, nil
+}
+
+// MultiSourceLoader 按优先级合并多个配置源的加载器 实现 CfgLoader
+// 后面的源覆盖前面的源 映射逐层深度合并 其他取值整体替换
+type MultiSourceLoader[T any] struct {
+	sources []Source
+	logger  *zap.Logger
+
+	mu      sync.Mutex
+	origins map[string]string
+}
+
+// NewMultiSourceLoader 创建多源加载器 sources 按优先级从低到高排列
+func NewMultiSourceLoader[T any](logger *zap.Logger, sources ...Source) *MultiSourceLoader[T] {
+	return &MultiSourceLoader[T]{sources: sources, logger: logger}
+}
+
+// GetConfigPath 返回优先级最低的配置源名称 通常为基础配置文件
+func (l *MultiSourceLoader[T]) GetConfigPath() string {
+	if len(l.sources) == 0 {
+		return ""
+	}
+	return l.sources[0].Name()
+}
+
+// Sources 返回全部配置源的名称 按优先级从低到高排列
+func (l *MultiSourceLoader[T]) Sources() []string {
+	names := make([]string, len(l.sources))
+	for i, source := range l.sources {
+		names[i] = source.Name()
+	}
+	return names
+}
+
+// Paths 返回全部文件配置源的路径 可用于 AddWatcher 监听覆盖文件
+func (l *MultiSourceLoader[T]) Paths() []string {
+	var paths []string
+	for _, source := range l.sources {
+		if s, ok := source.(*fileSource); ok {
+			paths = append(paths, s.path)
+		}
+	}
+	return paths
+}
+
+// LoadConfig 依次加载各配置源 深度合并后解码
+func (l *MultiSourceLoader[T]) LoadConfig(ctx context.Context) (*T, error) {
+	tree := map[string]any{}
+	origins := map[string]string{}
+	for _, source := range l.sources {
+		layer, err := source.Load(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("source %s: %w", source.Name(), err)
+		}
+		mergeSource(tree, layer, "", source.Name(), origins)
+	}
+
+	var config T
+	if err := decodeTree(tree, &config); err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	l.origins = origins
+	l.mu.Unlock()
+	l.logger.Info("Successfully merged config sources", zap.Int("sources", len(l.sources)))
+	return &config, nil
+}
+
+// Origin 返回上次加载中提供该键路径取值的配置源 未知时返回空串
+func (l *MultiSourceLoader[T]) Origin(path string) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.origins[path]
+}
+
+// Origins 返回上次加载中每个叶子键路径的来源 用于排查取值来自哪个配置源
+func (l *MultiSourceLoader[T]) Origins() map[string]string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	origins := make(map[string]string, len(l.origins))
+	for path, source := range l.origins {
+		origins[path] = source
+	}
+	return origins
+}
+
+// mergeSource 将配置源合并到 dst 并记录叶子键的来源
+// dst 中不存在同名键时按大小写无关匹配已有的键 以便环境变量覆盖驼峰键名
+func mergeSource(dst, src map[string]any, path, source string, origins map[string]string) {
+	for key, srcVal := range src {
+		key = matchKey(dst, key)
+		keyPath := joinPath(path, key)
+		srcMap, srcIsMap := srcVal.(map[string]any)
+		dstMap, dstIsMap := dst[key].(map[string]any)
+		if srcIsMap && dstIsMap {
+			mergeSource(dstMap, srcMap, keyPath, source, origins)
+			continue
+		}
+		forgetOrigins(origins, keyPath)
+		if srcIsMap {
+			dstMap = map[string]any{}
+			dst[key] = dstMap
+			mergeSource(dstMap, srcMap, keyPath, source, origins)
+			if len(srcMap) == 0 {
+				origins[keyPath] = source
+			}
+			continue
+		}
+		dst[key] = srcVal
+		origins[keyPath] = source
+	}
+}
+
+// matchKey 返回 dst 中与 key 大小写无关匹配的键 没有则返回 key
+func matchKey(dst map[string]any, key string) string {
+	if _, ok := dst[key]; ok {
+		return key
+	}
+	for existing := range dst {
+		if strings.EqualFold(existing, key) {
+			return existing
+		}
+	}
+	return key
+}
+
+// forgetOrigins 删除被整体替换的子树的来源记录
+func forgetOrigins(origins map[string]string, path string) {
+	for key := range origins {
+		if key == path || strings.HasPrefix(key, path+".") {
+			delete(origins, key)
+		}
+	}
+}
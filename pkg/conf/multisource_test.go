@@ -0,0 +1,61 @@
+package config
+
+import (
+	"context"
+	"testing"
+
+	"github.com/omeyang/practices/internal/entity"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// TestMultiSourceLoader 测试基础配置 环境覆盖文件与环境变量按优先级合并
+func TestMultiSourceLoader(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte("prometheusCfg:\n  enable: false\n  port: 9090\n  address: 127.0.0.1\n"), 0o600))
+	assert.NoError(t, afero.WriteFile(fs, "/etc/app/config.prod.json", []byte(`{"prometheusCfg": {"enable": true}}`), 0o600))
+	t.Setenv("APP__PROMETHEUSCFG__PORT", "9100")
+
+	loader := NewMultiSourceLoader[entity.AppConf](zap.NewNop(),
+		FileSource(fs, "/etc/app/config.yaml"),
+		OptionalFileSource(fs, "/etc/app/config.prod.json"),
+		OptionalFileSource(fs, "/etc/app/config.local.yaml"),
+		EnvSource("APP"),
+	)
+	config, err := loader.LoadConfig(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, &entity.PrometheusConf{Enable: true, Port: 9100, Address: "127.0.0.1"}, config.PrometheusCfg)
+
+	assert.Equal(t, NormalizePath("/etc/app/config.yaml"), loader.GetConfigPath())
+	assert.Len(t, loader.Paths(), 3)
+	assert.Equal(t, map[string]string{
+		"prometheusCfg.enable":  NormalizePath("/etc/app/config.prod.json"),
+		"prometheusCfg.port":    "env:APP",
+		"prometheusCfg.address": NormalizePath("/etc/app/config.yaml"),
+	}, loader.Origins())
+	assert.Equal(t, "env:APP", loader.Origin("prometheusCfg.port"))
+}
+
+// TestMultiSourceLoaderErrors 测试必需的配置源缺失与格式错误
+func TestMultiSourceLoaderErrors(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	loader := NewMultiSourceLoader[entity.AppConf](zap.NewNop(), FileSource(fs, "/etc/app/config.yaml"))
+	_, err := loader.LoadConfig(context.Background())
+	assert.ErrorContains(t, err, "config.yaml")
+
+	assert.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte("prometheusCfg: [\n"), 0o600))
+	_, err = loader.LoadConfig(context.Background())
+	assert.Error(t, err)
+}
+
+// TestMergeSourceReplacesSubtree 测试非映射取值整体替换子树时清除旧的来源
+func TestMergeSourceReplacesSubtree(t *testing.T) {
+	tree := map[string]any{}
+	origins := map[string]string{}
+	mergeSource(tree, map[string]any{"a": map[string]any{"b": 1, "c": 2}}, "", "base", origins)
+	mergeSource(tree, map[string]any{"a": "flat"}, "", "overlay", origins)
+	assert.Equal(t, map[string]any{"a": "flat"}, tree)
+	assert.Equal(t, map[string]string{"a": "overlay"}, origins)
+}
@@ -0,0 +1,163 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/afero"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrReadOnly 只读模式下拒绝修改配置
+	ErrReadOnly = errors.New("config manager is read-only")
+	// ErrNoPreviousConfig 没有可回滚的配置
+	ErrNoPreviousConfig = errors.New("no previous config to roll back to")
+)
+
+// ReadOnlyError 只读模式下被拒绝的修改操作 可用 errors.Is(err, ErrReadOnly) 判断
+type ReadOnlyError struct {
+	Op string // 被拒绝的操作 如 set save rollback
+}
+
+// Error 实现 error 接口
+func (e *ReadOnlyError) Error() string {
+	return fmt.Sprintf("%s rejected: %v", e.Op, ErrReadOnly)
+}
+
+// Unwrap 返回 ErrReadOnly
+func (e *ReadOnlyError) Unwrap() error {
+	return ErrReadOnly
+}
+
+// ReadOnly 返回管理器是否处于只读模式
+func (cm *CfgManager[T]) ReadOnly() bool {
+	return cm.opts.readOnly
+}
+
+// guardMutation 只读模式下拒绝修改操作
+func (cm *CfgManager[T]) guardMutation(op string) error {
+	if !cm.opts.readOnly {
+		return nil
+	}
+	cm.logger.Warn("Rejected config mutation in read-only mode", zap.String("op", op))
+	return &ReadOnlyError{Op: op}
+}
+
+// storeConfig 替换当前配置 分配新的版本号 保留上一份配置用于回滚并通知订阅者 调用方需持有 applyMu
+// 同步模式下先在不持有写锁时执行变更处理函数 失败时保持当前配置不变
+func (cm *CfgManager[T]) storeConfig(config *T) error {
+	current, _ := cm.config.Load().(*T)
+	if cm.opts.syncApply {
+		if err := cm.callChangeHandlers(current, config); err != nil {
+			return err
+		}
+	}
+
+	cm.rwMutex.Lock()
+	if current != nil {
+		cm.previous = current
+	}
+	cm.config.Store(config)
+	cm.snapshot.store(config)
+	cm.versions.record(config, cm.opts.clock.Now())
+	cm.rwMutex.Unlock()
+	cm.metrics.setVersion(cm.Version())
+	cm.metrics.observeConfig(config)
+	cm.syncCertificates(config)
+	cm.readyOnce.Do(func() { close(cm.ready) })
+	cm.subscribers.publish(config)
+	return nil
+}
+
+// Set 直接替换当前配置 生效时间规则与重载一致
+func (cm *CfgManager[T]) Set(ctx context.Context, config *T) error {
+	if err := cm.guardMutation("set"); err != nil {
+		return err
+	}
+	if config == nil {
+		return errors.New("config is nil")
+	}
+
+	if err := cm.applyDefaults(config); err != nil {
+		return err
+	}
+	if err := cm.Validate(config); err != nil {
+		return err
+	}
+	if err := cm.Probe(ctx, config); err != nil {
+		return err
+	}
+
+	cm.applyMu.Lock()
+	defer cm.applyMu.Unlock()
+	applied, err := cm.applyConfig(ctx, config)
+	if err != nil {
+		return err
+	}
+	if applied {
+		cm.logger.Info("Config replaced", zap.String("configPath", cm.loader.GetConfigPath()))
+		cm.reportApply(ctx, nil)
+	}
+	return nil
+}
+
+// Rollback 恢复上一份生效的配置 只能回滚一步
+func (cm *CfgManager[T]) Rollback(ctx context.Context) error {
+	if err := cm.guardMutation("rollback"); err != nil {
+		return err
+	}
+
+	cm.applyMu.Lock()
+	defer cm.applyMu.Unlock()
+	if cm.previous == nil {
+		return ErrNoPreviousConfig
+	}
+	if err := cm.storeConfig(cm.previous); err != nil {
+		return err
+	}
+	cm.previous = nil
+	cm.logger.Info("Config rolled back", zap.String("configPath", cm.loader.GetConfigPath()))
+	cm.reportApply(ctx, nil)
+	return nil
+}
+
+// Save 将当前配置原子地写回配置文件 格式取自文件扩展名 YAML 文件保留原有的注释
+// 文件已存在时通过加载器读取原配置 取值未变化的 enc: 密文 密钥引用与 ${VAR} 占位符保留原文
+// 写入引起的文件事件不会触发重载
+func (cm *CfgManager[T]) Save(fs afero.Fs) error {
+	if err := cm.guardMutation("save"); err != nil {
+		return err
+	}
+
+	cm.rwMutex.RLock()
+	defer cm.rwMutex.RUnlock()
+	config := cm.config.Load()
+	return cm.writeConfigSource(func() error {
+		return writeConfigFile(fs, cm.loader.GetConfigPath(), config, func() (any, error) {
+			return cm.loader.LoadConfig(context.Background())
+		})
+	})
+}
+
+// SaveConfig 与 Set 一样校验并应用新配置 随后通过实现了 CfgWriter 的加载器写回配置源
+// 写入引起的文件事件不会触发重载 加载器未实现 CfgWriter 时返回 ErrNotWritable 且不修改配置
+func (cm *CfgManager[T]) SaveConfig(ctx context.Context, config *T) error {
+	if err := cm.guardMutation("save"); err != nil {
+		return err
+	}
+	writer, ok := cm.loader.(CfgWriter[T])
+	if !ok {
+		return ErrNotWritable
+	}
+	if err := cm.Set(ctx, config); err != nil {
+		return err
+	}
+	if err := cm.writeConfigSource(func() error { return writer.WriteConfig(ctx, config) }); err != nil {
+		cm.logger.Error("Failed to write config back", zap.String("configPath", cm.loader.GetConfigPath()), zap.Error(err))
+		return err
+	}
+	cm.logger.Info("Config saved", zap.String("configPath", cm.loader.GetConfigPath()))
+	return nil
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"context"
+	"testing"
+
+	"github.com/omeyang/practices/internal/entity"
+	mocks "github.com/omeyang/practices/mocks/conf"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+// TestCfgManager_Mutations 测试 Set Rollback Save
+func TestCfgManager_Mutations(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockWatcher := mocks.NewMockWatcherInterface(ctrl)
+	logger, _ := zap.NewDevelopment()
+	mockLoader.EXPECT().GetConfigPath().Return("/etc/app.json").AnyTimes()
+
+	cm := NewConfigManager[entity.AppConf](mockLoader, mockWatcher, logger, RetryPolicy{})
+	ctx := context.Background()
+	first := &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9090}}
+	second := &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9091}}
+
+	assert.NoError(t, cm.Set(ctx, first))
+	assert.ErrorIs(t, cm.Rollback(ctx), ErrNoPreviousConfig)
+	assert.NoError(t, cm.Set(ctx, second))
+	assert.Equal(t, second, cm.GetConfig())
+	assert.NoError(t, cm.Rollback(ctx))
+	assert.Equal(t, first, cm.GetConfig())
+	assert.ErrorIs(t, cm.Rollback(ctx), ErrNoPreviousConfig)
+
+	fs := afero.NewMemMapFs()
+	assert.NoError(t, cm.Save(fs))
+	data, err := afero.ReadFile(fs, "/etc/app.json")
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"effectiveAt": null, "prometheusCfg": {"enable": false, "port": 9090, "address": ""}}`, string(data))
+}
+
+// TestCfgManager_ReadOnly 测试只读模式拒绝修改操作
+func TestCfgManager_ReadOnly(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockWatcher := mocks.NewMockWatcherInterface(ctrl)
+	logger, _ := zap.NewDevelopment()
+
+	cm := NewConfigManager[entity.AppConf](mockLoader, mockWatcher, logger, RetryPolicy{}, WithReadOnly())
+	assert.True(t, cm.ReadOnly())
+
+	ctx := context.Background()
+	for op, err := range map[string]error{
+		"set":      cm.Set(ctx, &entity.AppConf{}),
+		"rollback": cm.Rollback(ctx),
+		"save":     cm.Save(afero.NewMemMapFs()),
+	} {
+		var readOnlyErr *ReadOnlyError
+		assert.ErrorIs(t, err, ErrReadOnly)
+		assert.ErrorAs(t, err, &readOnlyErr)
+		assert.Equal(t, op, readOnlyErr.Op)
+	}
+}
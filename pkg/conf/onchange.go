@@ -0,0 +1,243 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// ChangeHandler 配置变更处理函数 oldConfig 在首次加载时为空
+// 同步模式下处理函数在新配置可见之前执行 期间 GetConfig 与 Snapshot 等读取方法返回替换前的配置
+// 处理函数不能修改配置 也不能在首次加载时等待 WaitReady
+type ChangeHandler[T any] func(oldConfig, newConfig *T) error
+
+// ErrHandlerCycle 具名变更处理函数的依赖关系存在环
+var ErrHandlerCycle = errors.New("change handler dependency cycle")
+
+// HandlerError 单个变更处理函数的错误
+type HandlerError struct {
+	Name string // 处理函数名称 未命名的处理函数为注册序号
+	Err  error
+}
+
+// Error 实现 error 接口
+func (e *HandlerError) Error() string {
+	return "change handler " + e.Name + ": " + e.Err.Error()
+}
+
+// Unwrap 返回处理函数的原始错误
+func (e *HandlerError) Unwrap() error {
+	return e.Err
+}
+
+// changeHandler 已注册的处理函数及其依赖
+type changeHandler[T any] struct {
+	name      string // 为空表示未命名 不能被依赖
+	dependsOn []string
+	fn        ChangeHandler[T]
+}
+
+// changeHandlers 已注册的配置变更处理函数
+type changeHandlers[T any] struct {
+	mu       sync.Mutex
+	handlers []changeHandler[T]
+	order    []int            // 按依赖排序后的执行顺序 元素为 handlers 的下标
+	sub      *Subscription[T] // 异步模式下驱动处理函数的订阅
+	stopped  chan struct{}    // 后台处理协程退出后关闭
+}
+
+// OnChange 注册配置变更处理函数 处理函数按注册顺序执行
+//
+// 默认在新配置生效后由后台协程依次调用 返回的错误只记录日志
+// 启用 WithSyncApply 时在新配置对 GetConfig 可见之前同步调用 任一处理函数返回错误都会拒绝新配置
+func (cm *CfgManager[T]) OnChange(handler ChangeHandler[T]) {
+	_ = cm.addChangeHandler(changeHandler[T]{fn: handler})
+}
+
+// OnChangeNamed 注册具名的配置变更处理函数 在 dependsOn 列出的处理函数成功之后执行
+// 如先重连数据库再重建依赖它的仓储 依赖可以稍后注册 执行时仍未注册的依赖视为失败
+//
+// 依赖失败时该处理函数被跳过 并连带跳过依赖它的处理函数 互不依赖的处理函数仍会执行
+// 启用 WithSyncApply 时遇到第一个错误即停止 名称重复或依赖成环时拒绝注册
+func (cm *CfgManager[T]) OnChangeNamed(name string, handler ChangeHandler[T], dependsOn ...string) error {
+	if name == "" {
+		return errors.New("change handler name is empty")
+	}
+	return cm.addChangeHandler(changeHandler[T]{name: name, dependsOn: dependsOn, fn: handler})
+}
+
+// addChangeHandler 注册处理函数 重新计算执行顺序 并在异步模式下启动后台处理协程
+func (cm *CfgManager[T]) addChangeHandler(handler changeHandler[T]) error {
+	cm.changes.mu.Lock()
+	defer cm.changes.mu.Unlock()
+	if handler.name != "" {
+		for _, h := range cm.changes.handlers {
+			if h.name == handler.name {
+				return fmt.Errorf("change handler %q already registered", handler.name)
+			}
+		}
+	}
+	handlers := append(cm.changes.handlers[:len(cm.changes.handlers):len(cm.changes.handlers)], handler)
+	order, err := orderHandlers(handlers)
+	if err != nil {
+		return err
+	}
+	cm.changes.handlers, cm.changes.order = handlers, order
+
+	if cm.opts.syncApply || cm.changes.sub != nil {
+		return nil
+	}
+	current, _ := cm.config.Load().(*T)
+	cm.changes.sub = cm.Subscribe(WithDelivery(DeliverBlocking))
+	cm.changes.stopped = make(chan struct{})
+	go cm.runChangeHandlers(cm.changes.sub, current, cm.changes.stopped)
+	return nil
+}
+
+// orderHandlers 按依赖关系排序 依赖在前 其余保持注册顺序 未注册的依赖不参与排序
+func orderHandlers[T any](handlers []changeHandler[T]) ([]int, error) {
+	index := make(map[string]int, len(handlers))
+	for i, h := range handlers {
+		if h.name != "" {
+			index[h.name] = i
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(handlers))
+	order := make([]int, 0, len(handlers))
+	var stack []string
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case visited:
+			return nil
+		case visiting:
+			start := slices.Index(stack, handlers[i].name)
+			cycle := append(slices.Clone(stack[start:]), handlers[i].name)
+			return fmt.Errorf("%w: %s", ErrHandlerCycle, strings.Join(cycle, " -> "))
+		}
+		state[i] = visiting
+		stack = append(stack, handlers[i].name)
+		for _, dep := range handlers[i].dependsOn {
+			if j, ok := index[dep]; ok {
+				if err := visit(j); err != nil {
+					return err
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[i] = visited
+		order = append(order, i)
+		return nil
+	}
+	for i := range handlers {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// runChangeHandlers 后台依次处理配置变更
+func (cm *CfgManager[T]) runChangeHandlers(sub *Subscription[T], old *T, stopped chan struct{}) {
+	defer close(stopped)
+	for config := range sub.C() {
+		if err := cm.callChangeHandlers(old, config); err != nil {
+			cm.logger.Error("Config change handler failed", zap.Error(err))
+		}
+		old = config
+	}
+}
+
+// callChangeHandlers 按依赖顺序调用处理函数 返回汇总各处理函数 HandlerError 的错误
+// 同步模式下遇到错误立即停止 异步模式下只跳过依赖失败的处理函数
+func (cm *CfgManager[T]) callChangeHandlers(oldConfig, newConfig *T) error {
+	cm.changes.mu.Lock()
+	handlers, order := cm.changes.handlers, cm.changes.order
+	cm.changes.mu.Unlock()
+
+	registered := make(map[string]bool, len(handlers))
+	for _, h := range handlers {
+		if h.name != "" {
+			registered[h.name] = true
+		}
+	}
+	failed := map[string]bool{}
+	var errs []error
+	for _, i := range order {
+		h := handlers[i]
+		err := h.blocked(registered, failed)
+		if err == nil {
+			err = h.fn(oldConfig, newConfig)
+		}
+		if err == nil {
+			continue
+		}
+		name := h.name
+		if name == "" {
+			name = strconv.Itoa(i)
+		}
+		errs = append(errs, &HandlerError{Name: name, Err: err})
+		if cm.opts.syncApply {
+			break
+		}
+		if h.name != "" {
+			failed[h.name] = true
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// blocked 返回阻止处理函数执行的依赖错误 依赖未注册或已失败
+func (h changeHandler[T]) blocked(registered, failed map[string]bool) error {
+	for _, dep := range h.dependsOn {
+		if !registered[dep] {
+			return fmt.Errorf("depends on unregistered handler %q", dep)
+		}
+		if failed[dep] {
+			return fmt.Errorf("skipped: dependency %q failed", dep)
+		}
+	}
+	return nil
+}
+
+// flushChangeHandlers 等待后台处理协程处理完已排队的配置后退出 ctx 结束时直接停止
+func (cm *CfgManager[T]) flushChangeHandlers(ctx context.Context) error {
+	cm.changes.mu.Lock()
+	sub, stopped := cm.changes.sub, cm.changes.stopped
+	cm.changes.sub = nil
+	cm.changes.mu.Unlock()
+	if sub == nil {
+		return nil
+	}
+
+	sub.drain()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		sub.Close()
+		return ctx.Err()
+	}
+}
+
+// stopChangeHandlers 停止后台处理协程
+func (cm *CfgManager[T]) stopChangeHandlers() {
+	cm.changes.mu.Lock()
+	defer cm.changes.mu.Unlock()
+	if cm.changes.sub != nil {
+		cm.changes.sub.Close()
+		cm.changes.sub = nil
+	}
+}
@@ -0,0 +1,164 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/omeyang/practices/internal/entity"
+	mocks "github.com/omeyang/practices/mocks/conf"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+// TestCfgManager_OnChangeSync 测试同步模式下处理函数按顺序在配置可见前执行
+func TestCfgManager_OnChangeSync(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockWatcher := mocks.NewMockWatcherInterface(ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return("/path/to/config").AnyTimes()
+
+	cm := NewConfigManager[entity.AppConf](mockLoader, mockWatcher, zap.NewNop(), RetryPolicy{}, WithSyncApply())
+	ctx := context.Background()
+	first := &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9090}}
+	assert.NoError(t, cm.Set(ctx, first))
+
+	var calls []string
+	var before uint64 // 每次 Set 之前的版本号
+	cm.OnChange(func(oldConfig, newConfig *entity.AppConf) error {
+		// 处理函数执行时旧配置仍然可见 读取配置不会死锁
+		assert.Same(t, oldConfig, cm.GetConfig())
+		assert.Equal(t, oldConfig, cm.Snapshot().Config)
+		_, version := cm.GetVersioned()
+		assert.Equal(t, before, version)
+		calls = append(calls, "rebind")
+		if newConfig.PrometheusCfg.Port < 1024 {
+			return errors.New("privileged port")
+		}
+		return nil
+	})
+	cm.OnChange(func(_, _ *entity.AppConf) error {
+		calls = append(calls, "notify")
+		return nil
+	})
+
+	second := &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9091}}
+	before = cm.Version()
+	assert.NoError(t, cm.Set(ctx, second))
+	assert.Equal(t, []string{"rebind", "notify"}, calls)
+	assert.Same(t, second, cm.GetConfig())
+
+	// 处理函数拒绝的配置不会生效 后续处理函数也不再执行
+	calls = nil
+	before = cm.Version()
+	assert.ErrorContains(t, cm.Set(ctx, &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 80}}), "privileged port")
+	assert.Equal(t, []string{"rebind"}, calls)
+	assert.Same(t, second, cm.GetConfig())
+}
+
+// TestCfgManager_OnChangeAsync 测试默认模式下处理函数在配置生效后执行
+func TestCfgManager_OnChangeAsync(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockWatcher := mocks.NewMockWatcherInterface(ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return("/path/to/config").AnyTimes()
+
+	cm := NewConfigManager[entity.AppConf](mockLoader, mockWatcher, zap.NewNop(), RetryPolicy{})
+	defer cm.stopChangeHandlers()
+	ctx := context.Background()
+	first := &entity.AppConf{}
+	assert.NoError(t, cm.Set(ctx, first))
+
+	changes := make(chan [2]*entity.AppConf, 2)
+	cm.OnChange(func(oldConfig, newConfig *entity.AppConf) error {
+		changes <- [2]*entity.AppConf{oldConfig, newConfig}
+		return errors.New("ignored")
+	})
+
+	second, third := &entity.AppConf{}, &entity.AppConf{}
+	assert.NoError(t, cm.Set(ctx, second))
+	assert.NoError(t, cm.Set(ctx, third))
+	for _, expected := range [][2]*entity.AppConf{{first, second}, {second, third}} {
+		select {
+		case change := <-changes:
+			assert.Same(t, expected[0], change[0])
+			assert.Same(t, expected[1], change[1])
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for change handler")
+		}
+	}
+}
+
+// TestCfgManager_OnChangeNamed 测试具名处理函数按依赖顺序执行 拒绝重复名称与依赖环
+func TestCfgManager_OnChangeNamed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return("/path/to/config").AnyTimes()
+
+	cm := NewConfigManager[entity.AppConf](mockLoader, nil, zap.NewNop(), RetryPolicy{}, WithSyncApply())
+	var calls []string
+	record := func(name string) ChangeHandler[entity.AppConf] {
+		return func(_, _ *entity.AppConf) error {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+	// 依赖可以在之后注册
+	assert.NoError(t, cm.OnChangeNamed("repos", record("repos"), "db", "cache"))
+	cm.OnChange(record("log"))
+	assert.NoError(t, cm.OnChangeNamed("db", record("db")))
+	err := cm.OnChangeNamed("cache", record("cache"), "repos")
+	assert.ErrorIs(t, err, ErrHandlerCycle)
+	assert.ErrorContains(t, err, "repos -> cache -> repos")
+	assert.NoError(t, cm.OnChangeNamed("cache", record("cache"), "db"))
+	assert.Error(t, cm.OnChangeNamed("db", record("db")))
+
+	// 未注册的依赖视为失败 同步模式下拒绝配置
+	assert.NoError(t, cm.OnChangeNamed("api", record("api"), "repos"))
+	assert.NoError(t, cm.OnChangeNamed("auth", record("auth"), "missing"))
+	err = cm.Set(context.Background(), &entity.AppConf{})
+	var handlerErr *HandlerError
+	assert.ErrorAs(t, err, &handlerErr)
+	assert.Equal(t, "auth", handlerErr.Name)
+	assert.Equal(t, []string{"db", "cache", "repos", "log", "api"}, calls)
+}
+
+// TestCfgManager_OnChangeNamedAsync 测试异步模式下依赖失败只跳过依赖它的处理函数
+func TestCfgManager_OnChangeNamedAsync(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return("/path/to/config").AnyTimes()
+
+	cm := NewConfigManager[entity.AppConf](mockLoader, nil, zap.NewNop(), RetryPolicy{})
+	defer cm.stopChangeHandlers()
+
+	var calls []string
+	assert.NoError(t, cm.OnChangeNamed("db", func(_, _ *entity.AppConf) error {
+		calls = append(calls, "db")
+		return errors.New("connection refused")
+	}))
+	assert.NoError(t, cm.OnChangeNamed("repos", func(_, _ *entity.AppConf) error {
+		calls = append(calls, "repos")
+		return nil
+	}, "db"))
+	assert.NoError(t, cm.OnChangeNamed("metrics", func(_, _ *entity.AppConf) error {
+		calls = append(calls, "metrics")
+		return nil
+	}))
+
+	err := cm.callChangeHandlers(nil, &entity.AppConf{})
+	assert.ErrorContains(t, err, "change handler db: connection refused")
+	assert.ErrorContains(t, err, `change handler repos: skipped: dependency "db" failed`)
+	assert.Equal(t, []string{"db", "metrics"}, calls)
+}
@@ -0,0 +1,249 @@
+package config
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// options 配置管理器的可选项
+type options struct {
+	reloadSchedule   Schedule              // 定时重载计划 为空表示不启用
+	pollingFallback  time.Duration         // inotify 资源耗尽时轮询的间隔 不大于 0 表示不启用
+	batchQuiet       time.Duration         // 事件静默多久后合并重载 不大于 0 表示不合并
+	batchMaxWait     time.Duration         // 合并重载的最长等待时间
+	ackReporter      AckReporter           // 配置应用结果的回报器 为空表示不回报
+	instance         string                // 回报中使用的实例标识
+	readOnly         bool                  // 只读模式 拒绝 Set Save Rollback
+	syncApply        bool                  // 新配置可见前同步执行变更处理函数
+	probeTimeout     time.Duration         // 单个预热探测的超时时间
+	clock            Clock                 // 时间源
+	kubernetesWatch  bool                  // 监听配置目录以感知 ConfigMap 与 Secret 的符号链接替换
+	atomicSave       bool                  // Create Rename Remove 事件同样触发重载
+	startupReport    bool                  // Init 成功后输出一条结构化的启动汇总日志
+	versionHistory   int                   // 保留的配置版本数
+	certificateWatch bool                  // 监听配置中 TLSConf 引用的证书文件
+	metrics          prometheus.Registerer // 注册配置生命周期指标 为空表示不采集
+	snapshotShards   int                   // GetConfig 分片快照的分片数 0 表示不启用
+	redactPaths      []string              // 除 sensitive 标签外额外脱敏的配置键路径
+	redactProfiles   map[string][]string   // 按名称区分的脱敏配置 在 redactPaths 之外额外脱敏的键路径
+	healthInterval   time.Duration         // 配置源健康探测的间隔 不大于 0 表示不探测
+	staggerWindow    time.Duration         // 重载的配置按实例错开生效的时间窗口 不大于 0 表示立即生效
+	copyOnRead       bool                  // GetConfig 返回深拷贝
+	reconcileEvery   time.Duration         // 与配置源核对的间隔 不大于 0 表示不核对
+}
+
+// defaultPollingFallback 默认的轮询降级间隔
+const defaultPollingFallback = 5 * time.Second
+
+// defaultProbeTimeout 默认的预热探测超时时间
+const defaultProbeTimeout = 5 * time.Second
+
+// defaultOptions 返回默认选项
+func defaultOptions() options {
+	return options{
+		pollingFallback: defaultPollingFallback,
+		instance:        HostnameInstanceKey(),
+		probeTimeout:    defaultProbeTimeout,
+		clock:           RealClock,
+		versionHistory:  defaultVersionHistory,
+	}
+}
+
+// Option 配置管理器选项
+type Option func(*options)
+
+// WithReloadSchedule 按给定计划定时强制重载配置 不依赖文件监听事件
+func WithReloadSchedule(schedule Schedule) Option {
+	return func(o *options) {
+		o.reloadSchedule = schedule
+	}
+}
+
+// WithReloadInterval 按固定间隔定时强制重载配置
+func WithReloadInterval(interval time.Duration) Option {
+	return func(o *options) {
+		if interval > 0 {
+			o.reloadSchedule = Every(interval)
+		}
+	}
+}
+
+// WithPollingFallback 设置 inotify 监听数耗尽时降级为轮询的间隔 不大于 0 表示不降级
+func WithPollingFallback(interval time.Duration) Option {
+	return func(o *options) {
+		o.pollingFallback = interval
+	}
+}
+
+// WithEventBatching 合并突发的文件事件: 事件停止 quiet 时长后只重载一次
+// maxWait 限制持续事件下的最长等待时间 不大于 0 表示不限制
+func WithEventBatching(quiet, maxWait time.Duration) Option {
+	return func(o *options) {
+		o.batchQuiet = quiet
+		o.batchMaxWait = maxWait
+	}
+}
+
+// WithAtomicSaveEvents 响应编辑器原子保存产生的事件 适合与 WithEventBatching 一起使用
+// 原子保存先写入临时文件再重命名覆盖配置文件 不产生 Write 事件 且原文件上的监听随之失效
+// 启用后 Create 事件触发重载 Rename 与 Remove 事件后重新监听新文件并触发重载
+func WithAtomicSaveEvents() Option {
+	return func(o *options) {
+		o.atomicSave = true
+	}
+}
+
+// WithStartupReport Init 成功后输出一条结构化的启动汇总日志 包括配置来源 版本 各配置源提供的键与警告
+// 启用后初始化过程中的警告汇总到该日志中 不再单独输出 汇总信息也可通过 StartupReport 获取
+func WithStartupReport() Option {
+	return func(o *options) {
+		o.startupReport = true
+	}
+}
+
+// WithVersionHistory 设置保留的配置版本数 用于 Diff 与 RollbackTo 默认保留 10 个
+func WithVersionHistory(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.versionHistory = n
+		}
+	}
+}
+
+// WithCertificateWatch 监听配置中 TLSConf 字段引用的证书与私钥文件
+// 文件变化时只重新加载证书并发出 CertificatesRotated 事件 不重新加载配置 配合 GetCertificate 实现证书热轮换
+func WithCertificateWatch() Option {
+	return func(o *options) {
+		o.certificateWatch = true
+	}
+}
+
+// WithMetrics 在 reg 上注册配置生命周期指标: 按结果统计的重载次数 重试次数 当前版本号 上次成功重载时间与重载耗时
+// 指标带有 source 常量标签 取值为加载器的配置路径 可用于告警长时间重载失败的服务
+func WithMetrics(reg prometheus.Registerer) Option {
+	return func(o *options) {
+		o.metrics = reg
+	}
+}
+
+// WithShardedSnapshot 为每秒调用数百万次 GetConfig 的服务启用分片快照 shards 不大于 0 时取 GOMAXPROCS
+// 重载时将配置指针写入每个分片 GetConfig 不再获取读锁 只原子读取随机分片 消除跨核心的缓存行争用
+// 写入各分片期间 同一协程先后两次读取可能分别得到新旧配置 需要一致视图时应读取一次后复用
+func WithShardedSnapshot(shards int) Option {
+	return func(o *options) {
+		if shards <= 0 {
+			shards = runtime.GOMAXPROCS(0)
+		}
+		o.snapshotShards = shards
+	}
+}
+
+// WithRedactPaths 声明额外脱敏的配置键路径 如 tenants.*.password 用于无法添加 sensitive 标签的字段
+// 作用于管理端输出 Status 中的变更 RedactedField 日志字段与全部脱敏配置
+func WithRedactPaths(paths ...string) Option {
+	return func(o *options) {
+		o.redactPaths = append(o.redactPaths, paths...)
+	}
+}
+
+// WithSourceHealthCheck 按 interval 探测配置源的连通性 要求加载器实现 HealthChecker 如 HTTPLoader 与 RemoteLoader
+// 探测独立于重载 结果通过 Status 与 SourceHealth 获取 启用 WithMetrics 时同时记录 config_source_up 指标
+func WithSourceHealthCheck(interval time.Duration) Option {
+	return func(o *options) {
+		o.healthInterval = interval
+	}
+}
+
+// WithRedactionProfile 声明名为 name 的脱敏配置 如 ProfileSupportBundle 在 WithRedactPaths 之外额外脱敏 paths
+// 脱敏配置只会增加脱敏的字段 sensitive 字段与 WithRedactPaths 的路径总是脱敏 重复声明同名配置时追加路径
+// 与 Redact 一致 未设置的取值不会被遮盖
+func WithRedactionProfile(name string, paths ...string) Option {
+	return func(o *options) {
+		if o.redactProfiles == nil {
+			o.redactProfiles = map[string][]string{}
+		}
+		o.redactProfiles[name] = append(o.redactProfiles[name], paths...)
+	}
+}
+
+// WithStaggeredReload 按实例标识的哈希将重载配置的生效推迟 [0, window) 中的固定时长
+// 用于集群范围推送远程配置时 避免错误配置在同一时刻影响所有实例 推迟期间的配置可以通过 Status 查看
+// 只作用于重载 首次加载 Set 与回滚立即生效 实例标识由 WithInstance 设置
+func WithStaggeredReload(window time.Duration) Option {
+	return func(o *options) {
+		o.staggerWindow = window
+	}
+}
+
+// WithCopyOnRead GetConfig GetVersioned 与 ForTenant 返回配置的深拷贝 调用方修改配置不会与重载竞争
+// 每次读取都会复制整个配置 高频读取时应改用 Section 只复制需要的部分 开销见 BenchmarkGetConfig
+func WithCopyOnRead() Option {
+	return func(o *options) {
+		o.copyOnRead = true
+	}
+}
+
+// WithAckReporter 在配置校验并应用或被拒绝后回报结果 用于控制面跟踪各实例运行的版本
+func WithAckReporter(reporter AckReporter) Option {
+	return func(o *options) {
+		o.ackReporter = reporter
+	}
+}
+
+// WithInstance 设置回报中使用的实例标识 默认为主机名
+func WithInstance(instance string) Option {
+	return func(o *options) {
+		o.instance = instance
+	}
+}
+
+// WithReadOnly 启用只读模式 Set Save Rollback 均返回 ReadOnlyError
+func WithReadOnly() Option {
+	return func(o *options) {
+		o.readOnly = true
+	}
+}
+
+// WithSyncApply 在新配置对 GetConfig 可见之前按注册顺序同步执行 OnChange 处理函数
+// 任一处理函数失败时拒绝新配置 适用于需要与配置切换原子完成的重配置 如重新绑定监听端口
+func WithSyncApply() Option {
+	return func(o *options) {
+		o.syncApply = true
+	}
+}
+
+// WithProbeTimeout 设置单个预热探测的超时时间
+func WithProbeTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		if timeout > 0 {
+			o.probeTimeout = timeout
+		}
+	}
+}
+
+// WithClock 设置重试等待 事件合并 轮询降级 定时重载与定时生效使用的时间源
+func WithClock(clock Clock) Option {
+	return func(o *options) {
+		if clock != nil {
+			o.clock = clock
+		}
+	}
+}
+
+// WithKubernetesWatch 启用 Kubernetes 感知的监听模式 适用于以 ConfigMap 或 Secret 挂载的配置文件
+// 挂载的文件通过替换 ..data 符号链接更新 不会产生 Write 事件 该模式改为监听所在目录并在链接目标变化时重载
+func WithKubernetesWatch() Option {
+	return func(o *options) {
+		o.kubernetesWatch = true
+	}
+}
+
+// WithReconcile 按 interval 重新读取配置源 与内存中的配置比较指纹 不一致时强制重载
+// 用于弥补长时间运行中丢失的监听事件 通过 Set 修改但未 Save 的配置同样会被配置源覆盖
+func WithReconcile(interval time.Duration) Option {
+	return func(o *options) {
+		o.reconcileEvery = interval
+	}
+}
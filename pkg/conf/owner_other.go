@@ -0,0 +1,13 @@
+//go:build !unix
+
+package config
+
+import "os"
+
+// permissionBitsSupported 当前平台的文件权限位是否有意义
+const permissionBitsSupported = false
+
+// checkOwner 当前平台不检查属主
+func checkOwner(os.FileInfo) error {
+	return nil
+}
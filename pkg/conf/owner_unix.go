@@ -0,0 +1,24 @@
+//go:build unix
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// permissionBitsSupported 当前平台的文件权限位是否有意义
+const permissionBitsSupported = true
+
+// checkOwner 检查文件属主为当前用户或 root
+func checkOwner(info os.FileInfo) error {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	if uid := int(stat.Uid); uid != 0 && uid != os.Geteuid() {
+		return fmt.Errorf("file is owned by uid %d, expected %d or root", uid, os.Geteuid())
+	}
+	return nil
+}
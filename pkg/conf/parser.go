@@ -1,58 +1,125 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"strings"
 
-	"github.com/omeyang/practices/internal/entity"
-
 	"github.com/spf13/afero"
 	"go.uber.org/zap"
 	"gopkg.in/yaml.v3"
 )
 
-// CfgParser 配置解析器
-type CfgParser interface {
-	Parse(file afero.File) (*entity.AppConf, error)
+// CfgParser 配置解析器 T 为应用的配置结构体类型
+type CfgParser[T any] interface {
+	Parse(file afero.File) (*T, error)
 }
 
+// Transform 在解码为结构体之前对原始配置树进行变换
+type Transform func(tree map[string]any) error
+
 // JSONParser JSON配置解析器
-type JSONParser struct {
-	Logger *zap.Logger
+type JSONParser[T any] struct {
+	Logger     *zap.Logger
+	Transforms []Transform // 解码前的配置树变换
+	Strict     bool        // 拒绝 T 中不存在的键 一次报告全部未知键与类型不匹配
 }
 
 // YAMLParser YAML配置解析器
-type YAMLParser struct {
-	Logger *zap.Logger
+type YAMLParser[T any] struct {
+	Logger     *zap.Logger
+	Transforms []Transform // 解码前的配置树变换
+	Strict     bool        // 拒绝 T 中不存在的键 一次报告全部未知键与类型不匹配
+}
+
+// parserOptions 解析器可选项
+type parserOptions struct {
+	transforms []Transform
+	strict     bool
+}
+
+// ParserOption 解析器选项
+type ParserOption func(*parserOptions)
+
+// WithTransforms 为解析器追加配置树变换 按顺序执行
+func WithTransforms(transforms ...Transform) ParserOption {
+	return func(o *parserOptions) {
+		o.transforms = append(o.transforms, transforms...)
+	}
+}
+
+// WithStrictDecoding 启用严格解码 拼写错误的键如 prometheusCfg.prot 不再被静默丢弃
+// 全部未知键与类型不匹配连同行列号汇总为 MultiError 返回 目前只作用于 JSON 与 YAML 解析器
+func WithStrictDecoding() ParserOption {
+	return func(o *parserOptions) {
+		o.strict = true
+	}
 }
 
 // NewParser 创建新的配置解析器
-func NewParser(fileExtension string, logger *zap.Logger) (CfgParser, error) {
+func NewParser[T any](fileExtension string, logger *zap.Logger, opts ...ParserOption) (CfgParser[T], error) {
 	if logger == nil {
 		return nil, errors.New("logger is required")
 	}
 
+	var o parserOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	// Normalize file extension
 	if !strings.HasPrefix(fileExtension, ".") {
 		fileExtension = "." + fileExtension
 	}
 
+	// 压缩文件使用内层格式的解析器
+	if inner, ok := strings.CutSuffix(strings.ToLower(fileExtension), gzipExt); ok && inner != "" {
+		parser, err := NewParser[T](inner, logger, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return &GzipParser[T]{Inner: parser}, nil
+	}
+
+	// 注册的解析器与格式优先于内置实现
+	if factory, ok := lookupParser[T](fileExtension); ok {
+		return factory(logger), nil
+	}
+	if c, ok := lookupFormat(fileExtension); ok {
+		ext := normalizeExt(fileExtension)
+		return &FormatParser[T]{Logger: logger, Transforms: o.transforms, ext: ext, codec: c}, nil
+	}
+
 	switch fileExtension {
 	case ".json":
-		return &JSONParser{Logger: logger}, nil
+		return &JSONParser[T]{Logger: logger, Transforms: o.transforms, Strict: o.strict}, nil
 	case ".yaml", ".yml":
-		return &YAMLParser{Logger: logger}, nil
+		return &YAMLParser[T]{Logger: logger, Transforms: o.transforms, Strict: o.strict}, nil
+	case ".toml":
+		return &TOMLParser[T]{Logger: logger, Transforms: o.transforms}, nil
+	case ".ini":
+		return &INIParser[T]{Logger: logger, Transforms: o.transforms}, nil
+	case ".env":
+		return &DotenvParser[T]{Logger: logger, Transforms: o.transforms}, nil
+	case ".", ".conf", ".cfg":
+		// 扩展名无法确定格式时根据内容识别
+		return &SniffingParser[T]{Logger: logger, Transforms: o.transforms}, nil
 	default:
 		return nil, fmt.Errorf("unsupported file extension: %s", fileExtension)
 	}
 }
 
 // Parse 解析json配置文件
-func (j *JSONParser) Parse(file afero.File) (*entity.AppConf, error) {
-	var config entity.AppConf
-	err := json.NewDecoder(file).Decode(&config)
+func (j *JSONParser[T]) Parse(file afero.File) (*T, error) {
+	c := jsonCodec
+	if j.Strict {
+		c = strictJSONCodec
+	}
+	var config T
+	err := decodeWithTransforms(file, c, j.Transforms, &config)
 	if err != nil {
 		j.Logger.Error("Failed to parse JSON config", zap.Error(err))
 		return nil, fmt.Errorf("json parsing error: %w", err)
@@ -62,9 +129,13 @@ func (j *JSONParser) Parse(file afero.File) (*entity.AppConf, error) {
 }
 
 // Parse 解析yaml配置文件
-func (y *YAMLParser) Parse(file afero.File) (*entity.AppConf, error) {
-	var config entity.AppConf
-	err := yaml.NewDecoder(file).Decode(&config)
+func (y *YAMLParser[T]) Parse(file afero.File) (*T, error) {
+	c := yamlCodec
+	if y.Strict {
+		c = strictYAMLCodec
+	}
+	var config T
+	err := decodeWithTransforms(file, c, y.Transforms, &config)
 	if err != nil {
 		y.Logger.Error("Failed to parse YAML config", zap.Error(err))
 		return nil, fmt.Errorf("yaml parsing error: %w", err)
@@ -72,3 +143,90 @@ func (y *YAMLParser) Parse(file afero.File) (*entity.AppConf, error) {
 	y.Logger.Info("Successfully parsed YAML config")
 	return &config, nil
 }
+
+// codec 配置格式的编解码函数
+type codec struct {
+	decode  func(r io.Reader, v any) error
+	marshal func(v any) ([]byte, error)
+	locate  func(file string, data []byte, err error) error // 为解码错误补充位置信息
+}
+
+var (
+	jsonCodec = codec{
+		decode:  func(r io.Reader, v any) error { return json.NewDecoder(r).Decode(v) },
+		marshal: json.Marshal,
+		locate:  locateJSONError,
+	}
+	yamlCodec = codec{
+		decode:  func(r io.Reader, v any) error { return yaml.NewDecoder(r).Decode(v) },
+		marshal: yaml.Marshal,
+		locate:  locateYAMLError,
+	}
+)
+
+// decodeWithTransforms 解码配置 存在变换时先解码为原始配置树 变换后再解码为结构体
+// 原始内容的解码错误会附带文件名与行列号
+func decodeWithTransforms(file afero.File, c codec, transforms []Transform, out any) error {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+	return decodeDataWithTransforms(file.Name(), data, c, transforms, out)
+}
+
+// decodeDataWithTransforms 解码已读取的配置内容 name 用于错误中的文件名
+func decodeDataWithTransforms(name string, data []byte, c codec, transforms []Transform, out any) error {
+	if len(transforms) == 0 {
+		if err := c.decode(bytes.NewReader(data), out); err != nil {
+			return c.locate(name, data, err)
+		}
+		return nil
+	}
+
+	tree := map[string]any{}
+	if err := c.decode(bytes.NewReader(data), &tree); err != nil {
+		return c.locate(name, data, err)
+	}
+	for _, transform := range transforms {
+		if err := transform(tree); err != nil {
+			return err
+		}
+	}
+
+	// 变换后的内容已与原文件不对应 不再补充位置信息
+	data, err := c.marshal(tree)
+	if err != nil {
+		return err
+	}
+	return c.decode(bytes.NewReader(data), out)
+}
+
+// codecFor 根据扩展名选择编解码函数 注册的格式优先
+func codecFor(ext string) (codec, error) {
+	if c, ok := lookupFormat(ext); ok {
+		return c, nil
+	}
+	switch strings.ToLower(ext) {
+	case ".json":
+		return jsonCodec, nil
+	case ".yaml", ".yml":
+		return yamlCodec, nil
+	case ".toml":
+		return tomlCodec, nil
+	case ".ini":
+		return iniCodec, nil
+	case ".env":
+		return dotenvCodec, nil
+	default:
+		return codec{}, fmt.Errorf("unsupported file extension: %s", ext)
+	}
+}
+
+// decodeTree 将原始配置树解码为结构体
+func decodeTree(tree map[string]any, out any) error {
+	data, err := yamlCodec.marshal(tree)
+	if err != nil {
+		return err
+	}
+	return yamlCodec.decode(bytes.NewReader(data), out)
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/omeyang/practices/internal/entity"
+
 	"github.com/stretchr/testify/assert"
 
 	"github.com/spf13/afero"
@@ -11,7 +13,7 @@ import (
 )
 
 func TestNewParserNoLogger(t *testing.T) {
-	_, err := NewParser(".json", nil)
+	_, err := NewParser[entity.AppConf](".json", nil)
 	if err == nil {
 		t.Errorf("Expected an error when logger is nil, but got nil")
 	}
@@ -52,14 +54,14 @@ func TestNewParser(t *testing.T) {
 		expectedParser string
 		expectError    bool
 	}{
-		{"JSON Parser", ".json", "*config.JSONParser", false},
-		{"YAML Parser", ".yaml", "*config.YAMLParser", false},
+		{"JSON Parser", ".json", "*config.JSONParser[github.com/omeyang/practices/internal/entity.AppConf]", false},
+		{"YAML Parser", ".yaml", "*config.YAMLParser[github.com/omeyang/practices/internal/entity.AppConf]", false},
 		{"Unsupported Extension", ".xml", "", true},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			parser, err := NewParser(tt.fileExtension, logger)
+			parser, err := NewParser[entity.AppConf](tt.fileExtension, logger)
 			if tt.expectError {
 				assert.Error(t, err)
 			} else {
@@ -74,7 +76,7 @@ func TestNewParser(t *testing.T) {
 // TestJSONParser_Parse 测试 JSONParser 的 Parse 方法
 func TestJSONParser_Parse(t *testing.T) {
 	logger, _ := zap.NewDevelopment()
-	parser := &JSONParser{Logger: logger}
+	parser := &JSONParser[entity.AppConf]{Logger: logger}
 
 	validJSON := `{"key": "value"}`
 	invalidJSON := `{key: "value"}`
@@ -104,7 +106,7 @@ func TestJSONParser_Parse(t *testing.T) {
 // TestYAMLParser_Parse 测试 YAMLParser 的 Parse 方法
 func TestYAMLParser_Parse(t *testing.T) {
 	logger, _ := zap.NewDevelopment()
-	parser := &YAMLParser{Logger: logger}
+	parser := &YAMLParser[entity.AppConf]{Logger: logger}
 
 	validYAML := `key: value`
 	invalidYAML := `: invalidYAML`
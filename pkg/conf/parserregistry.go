@@ -0,0 +1,116 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/spf13/afero"
+	"go.uber.org/zap"
+)
+
+// ParserFactory 创建指定配置类型的解析器 注册的解析器自行负责配置树变换
+type ParserFactory[T any] func(logger *zap.Logger) CfgParser[T]
+
+// 已注册的解析器与格式 NewParser 与各配置源先查找注册表 未注册的扩展名使用内置实现
+var parserRegistry struct {
+	mu      sync.RWMutex
+	parsers map[string][]any // 扩展名 -> 各配置类型的 ParserFactory
+	formats map[string]codec // 扩展名 -> 与配置类型无关的格式
+}
+
+// normalizeExt 统一扩展名为带点的小写形式
+func normalizeExt(ext string) string {
+	ext = strings.ToLower(ext)
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
+// RegisterParser 为配置类型 T 注册扩展名对应的解析器 覆盖内置实现与之前的注册
+// 适用于只服务于特定配置类型的专有格式 如整体加密的配置文件
+func RegisterParser[T any](ext string, factory ParserFactory[T]) {
+	ext = normalizeExt(ext)
+	parserRegistry.mu.Lock()
+	defer parserRegistry.mu.Unlock()
+	if parserRegistry.parsers == nil {
+		parserRegistry.parsers = map[string][]any{}
+	}
+	factories := parserRegistry.parsers[ext]
+	for i, f := range factories {
+		if _, ok := f.(ParserFactory[T]); ok {
+			factories[i] = factory
+			return
+		}
+	}
+	parserRegistry.parsers[ext] = append(factories, factory)
+}
+
+// lookupParser 查找为配置类型 T 注册的解析器
+func lookupParser[T any](ext string) (ParserFactory[T], bool) {
+	parserRegistry.mu.RLock()
+	defer parserRegistry.mu.RUnlock()
+	for _, f := range parserRegistry.parsers[normalizeExt(ext)] {
+		if factory, ok := f.(ParserFactory[T]); ok {
+			return factory, true
+		}
+	}
+	return nil, false
+}
+
+// RegisterFormat 注册与配置类型无关的格式 如 HCL 覆盖内置实现与之前的注册
+// decode 将内容解析为配置树 marshal 为空时该格式不支持 Save
+// 注册的格式同样用于多源加载器 远程加载器等按扩展名选择格式的场景
+func RegisterFormat(ext string, decode func(data []byte) (map[string]any, error), marshal func(tree map[string]any) ([]byte, error)) {
+	ext = normalizeExt(ext)
+	c := codec{
+		decode: treeDecoder(decode),
+		marshal: func(v any) ([]byte, error) {
+			if marshal == nil {
+				return nil, fmt.Errorf("format %s does not support marshaling", ext)
+			}
+			tree, err := configTree(v)
+			if err != nil {
+				return nil, err
+			}
+			return marshal(tree)
+		},
+		locate: locateLineError,
+	}
+
+	parserRegistry.mu.Lock()
+	defer parserRegistry.mu.Unlock()
+	if parserRegistry.formats == nil {
+		parserRegistry.formats = map[string]codec{}
+	}
+	parserRegistry.formats[ext] = c
+}
+
+// lookupFormat 查找注册的格式
+func lookupFormat(ext string) (codec, bool) {
+	parserRegistry.mu.RLock()
+	defer parserRegistry.mu.RUnlock()
+	c, ok := parserRegistry.formats[normalizeExt(ext)]
+	return c, ok
+}
+
+// FormatParser 使用 RegisterFormat 注册的格式的解析器
+type FormatParser[T any] struct {
+	Logger     *zap.Logger
+	Transforms []Transform // 解码前的配置树变换
+	ext        string
+	codec      codec
+}
+
+// Parse 按注册的格式解析配置文件
+func (p *FormatParser[T]) Parse(file afero.File) (*T, error) {
+	var config T
+	err := decodeWithTransforms(file, p.codec, p.Transforms, &config)
+	if err != nil {
+		p.Logger.Error("Failed to parse config", zap.String("format", p.ext), zap.Error(err))
+		return nil, fmt.Errorf("%s parsing error: %w", strings.TrimPrefix(p.ext, "."), err)
+	}
+	p.Logger.Info("Successfully parsed config", zap.String("format", p.ext))
+	return &config, nil
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/omeyang/practices/internal/entity"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// blobParser 测试用的专有格式解析器 内容为端口号
+type blobParser struct {
+	prefix string
+}
+
+func (p *blobParser) Parse(file afero.File) (*entity.AppConf, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	var port int
+	if _, err := fmt.Sscanf(string(data), p.prefix+"%d", &port); err != nil {
+		return nil, err
+	}
+	return &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: port}}, nil
+}
+
+// TestRegisterParser 测试按配置类型注册解析器
+func TestRegisterParser(t *testing.T) {
+	RegisterParser[entity.AppConf](".blob", func(*zap.Logger) CfgParser[entity.AppConf] {
+		return &blobParser{prefix: "v1:"}
+	})
+	RegisterParser[entity.AppConf]("BLOB", func(*zap.Logger) CfgParser[entity.AppConf] {
+		return &blobParser{prefix: "v2:"}
+	})
+
+	parser, err := NewParser[entity.AppConf](".blob", zap.NewNop())
+	assert.NoError(t, err)
+	config, err := parser.Parse(mockFile("v2:9090"))
+	assert.NoError(t, err)
+	assert.Equal(t, 9090, config.PrometheusCfg.Port)
+
+	// 其他配置类型不受影响
+	_, err = NewParser[serviceConf](".blob", zap.NewNop())
+	assert.Error(t, err)
+
+	// 压缩文件使用注册的内层解析器
+	parser, err = NewParser[entity.AppConf](".blob.gz", zap.NewNop())
+	assert.NoError(t, err)
+	assert.IsType(t, &GzipParser[entity.AppConf]{}, parser)
+}
+
+// TestRegisterFormat 测试注册与配置类型无关的格式
+func TestRegisterFormat(t *testing.T) {
+	decode := func(data []byte) (map[string]any, error) {
+		tree := map[string]any{}
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			key, value, ok := strings.Cut(line, " -> ")
+			if !ok {
+				return nil, errors.New("expected key -> value")
+			}
+			keys := strings.Split(key, "/")
+			nodeAt(tree, keys[:len(keys)-1])[keys[len(keys)-1]] = scalarFromEnv(value)
+		}
+		return tree, nil
+	}
+	RegisterFormat("arrow", decode, nil)
+
+	parser, err := NewParser[entity.AppConf](".arrow", zap.NewNop())
+	assert.NoError(t, err)
+	config, err := parser.Parse(mockFile("prometheusCfg/port -> 9090\nprometheusCfg/enable -> true\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, &entity.PrometheusConf{Enable: true, Port: 9090}, config.PrometheusCfg)
+
+	service, err := NewParser[serviceConf](".arrow", zap.NewNop())
+	assert.NoError(t, err)
+	svc, err := service.Parse(mockFile("name -> billing\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "billing", svc.Name)
+
+	// 注册的格式同样用于多源加载器
+	fs := afero.NewMemMapFs()
+	assert.NoError(t, afero.WriteFile(fs, "/etc/app/config.arrow", []byte("prometheusCfg/port -> 9100\n"), 0o600))
+	loader := NewMultiSourceLoader[entity.AppConf](zap.NewNop(), FileSource(fs, "/etc/app/config.arrow"))
+	config, err = loader.LoadConfig(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 9100, config.PrometheusCfg.Port)
+
+	// 未提供 marshal 时不支持保存
+	c, err := codecFor(".arrow")
+	assert.NoError(t, err)
+	_, err = c.marshal(config)
+	assert.ErrorContains(t, err, "does not support marshaling")
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+
+	"go.uber.org/zap"
+)
+
+// ErrInsecurePermissions 配置文件权限或属主不安全
+var ErrInsecurePermissions = errors.New("insecure config file permissions")
+
+// PermissionCheck 配置文件权限检查级别
+type PermissionCheck int
+
+const (
+	// PermissionCheckOff 不检查
+	PermissionCheckOff PermissionCheck = iota
+	// PermissionCheckWarn 发现问题时记录警告日志 仍然加载
+	PermissionCheckWarn
+	// PermissionCheckStrict 发现问题时拒绝加载
+	PermissionCheckStrict
+)
+
+// checkPermissions 检查配置文件不可被任意用户写入 属主为当前用户或 root
+// 配置类型含有 sensitive 字段时 还要求文件不可被任意用户读取
+func checkPermissions(info os.FileInfo, level PermissionCheck, configType reflect.Type, logger *zap.Logger) error {
+	if level == PermissionCheckOff || !permissionBitsSupported {
+		return nil
+	}
+
+	var problems []string
+	perm := info.Mode().Perm()
+	if perm&0o002 != 0 {
+		problems = append(problems, fmt.Sprintf("file is world-writable (mode %04o)", perm))
+	}
+	if perm&0o004 != 0 && hasSensitiveFields(configType) {
+		problems = append(problems, fmt.Sprintf("file contains sensitive fields but is world-readable (mode %04o)", perm))
+	}
+	if err := checkOwner(info); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	for _, problem := range problems {
+		if level == PermissionCheckStrict {
+			return fmt.Errorf("%w: %s: %s", ErrInsecurePermissions, info.Name(), problem)
+		}
+		logger.Warn("Insecure config file permissions", zap.String("file", info.Name()), zap.String("problem", problem))
+	}
+	return nil
+}
+
+// hasSensitiveFields 判断类型中是否包含 sensitive:"true" 标记的字段
+func hasSensitiveFields(t reflect.Type) bool {
+	return walkSensitive(t, map[reflect.Type]bool{})
+}
+
+// walkSensitive 递归检查结构体字段
+func walkSensitive(t reflect.Type, seen map[reflect.Type]bool) bool {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || seen[t] {
+		return false
+	}
+	seen[t] = true
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Tag.Get("sensitive") == "true" || walkSensitive(f.Type, seen) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/omeyang/practices/internal/entity"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// TestWithPermissionCheck 测试配置文件权限检查
+func TestWithPermissionCheck(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permission bits are not meaningful on windows")
+	}
+	logger, _ := zap.NewDevelopment()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte("prometheusCfg:\n  port: 9090\n"), 0o644))
+	assert.NoError(t, os.Chmod(path, 0o666))
+
+	tests := []struct {
+		name        string
+		level       PermissionCheck
+		expectError bool
+	}{
+		{"Off", PermissionCheckOff, false},
+		{"Warn", PermissionCheckWarn, false},
+		{"Strict", PermissionCheckStrict, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader, err := NewFileLoader[entity.AppConf](afero.NewOsFs(), path, logger, WithPermissionCheck(tt.level))
+			assert.NoError(t, err)
+			_, err = loader.LoadConfig(context.Background())
+			if tt.expectError {
+				assert.True(t, errors.Is(err, ErrInsecurePermissions))
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	assert.NoError(t, os.Chmod(path, 0o644))
+	loader, err := NewFileLoader[entity.AppConf](afero.NewOsFs(), path, logger, WithPermissionCheck(PermissionCheckStrict))
+	assert.NoError(t, err)
+	_, err = loader.LoadConfig(context.Background())
+	assert.NoError(t, err)
+}
+
+// TestHasSensitiveFields 测试敏感字段检测
+func TestHasSensitiveFields(t *testing.T) {
+	type inner struct {
+		Password string `sensitive:"true"`
+	}
+	type withSecret struct {
+		DB *inner
+	}
+	type plain struct {
+		Name  string
+		Items []plain
+	}
+
+	assert.True(t, hasSensitiveFields(reflect.TypeOf(withSecret{})))
+	assert.False(t, hasSensitiveFields(reflect.TypeOf(plain{})))
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package config
+
+import "github.com/fsnotify/fsnotify"
+
+// reloadOps 触发重载的事件类型
+const reloadOps = fsnotify.Write
+
+// isSharingViolation 非 Windows 平台不存在文件共享冲突
+func isSharingViolation(error) bool {
+	return false
+}
@@ -0,0 +1,25 @@
+//go:build windows
+
+package config
+
+import (
+	"errors"
+	"syscall"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Windows 文件共享冲突错误码
+const (
+	errorSharingViolation syscall.Errno = 32
+	errorLockViolation    syscall.Errno = 33
+)
+
+// reloadOps 触发重载的事件类型
+// ReadDirectoryChangesW 下编辑器保存常表现为删除后重新创建 因此 Create 也需要触发重载
+const reloadOps = fsnotify.Write | fsnotify.Create
+
+// isSharingViolation 判断是否为文件被其他进程占用导致的错误
+func isSharingViolation(err error) bool {
+	return errors.Is(err, errorSharingViolation) || errors.Is(err, errorLockViolation)
+}
@@ -0,0 +1,254 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+// PluginPrefix 外部配置源插件可执行文件的名称前缀 如 confplugin-vault
+const PluginPrefix = "confplugin-"
+
+// ErrPluginWatcherClosed 插件监听器已关闭
+var ErrPluginWatcherClosed = errors.New("plugin watcher closed")
+
+// pluginLoadResponse 插件 load 命令的输出
+type pluginLoadResponse struct {
+	Version string         `json:"version"`
+	Config  map[string]any `json:"config"`
+	Error   string         `json:"error"`
+}
+
+// pluginEvent 插件 watch 命令输出的事件
+type pluginEvent struct {
+	Event string `json:"event"` // changed 或 error
+	Name  string `json:"name"`
+	Error string `json:"error"`
+}
+
+// ExecPlugin 通过 exec-JSON 协议驱动的外部配置源插件 实现 CfgLoader
+//
+// 协议:
+//   - "<plugin> load [args...]" 在标准输出打印一个 JSON 对象
+//     {"version": "...", "config": {...}} 或 {"error": "..."}
+//   - "<plugin> watch <name> [args...]" 常驻运行 每次变化打印一行
+//     {"event": "changed", "name": "..."} 出错时打印 {"event": "error", "error": "..."}
+type ExecPlugin[T any] struct {
+	path   string
+	args   []string
+	logger *zap.Logger
+	mu     sync.RWMutex
+	rev    string // 最近一次加载的版本
+}
+
+// NewExecPlugin 创建外部插件加载器 args 会追加在每个命令之后
+func NewExecPlugin[T any](path string, logger *zap.Logger, args ...string) *ExecPlugin[T] {
+	return &ExecPlugin[T]{path: path, args: args, logger: logger}
+}
+
+// DiscoverPlugins 在目录中查找插件 返回插件名到可执行文件路径的映射
+func DiscoverPlugins(dir string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	plugins := map[string]string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, PluginPrefix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || (info.Mode().Perm()&0o111 == 0 && filepath.Ext(name) != ".exe") {
+			continue
+		}
+		plugins[strings.TrimSuffix(strings.TrimPrefix(name, PluginPrefix), ".exe")] = filepath.Join(dir, name)
+	}
+	return plugins, nil
+}
+
+// GetConfigPath 返回插件标识
+func (p *ExecPlugin[T]) GetConfigPath() string {
+	return "plugin:" + strings.TrimPrefix(filepath.Base(p.path), PluginPrefix)
+}
+
+// Version 返回最近一次加载时插件报告的版本
+func (p *ExecPlugin[T]) Version() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.rev
+}
+
+// LoadConfig 运行插件的 load 命令并解码其输出
+func (p *ExecPlugin[T]) LoadConfig(ctx context.Context) (*T, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.path, append([]string{"load"}, p.args...)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("plugin %s load: %w: %s", p.path, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var resp pluginLoadResponse
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return nil, fmt.Errorf("plugin %s load: invalid response: %w", p.path, err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("plugin %s load: %s", p.path, resp.Error)
+	}
+
+	var config T
+	if err := decodeTree(resp.Config, &config); err != nil {
+		return nil, fmt.Errorf("plugin %s load: %w", p.path, err)
+	}
+
+	p.mu.Lock()
+	p.rev = resp.Version
+	p.mu.Unlock()
+	return &config, nil
+}
+
+// Watcher 创建由插件 watch 命令驱动的监听器
+func (p *ExecPlugin[T]) Watcher() *PluginWatcher {
+	return &PluginWatcher{
+		path:   p.path,
+		args:   p.args,
+		logger: p.logger,
+		procs:  map[string]context.CancelFunc{},
+		events: make(chan fsnotify.Event, 16),
+		errors: make(chan error, 1),
+		done:   make(chan struct{}),
+	}
+}
+
+// PluginWatcher 将插件 watch 命令的输出转换为文件事件 实现 WatcherInterface
+type PluginWatcher struct {
+	path      string
+	args      []string
+	logger    *zap.Logger
+	mu        sync.Mutex
+	procs     map[string]context.CancelFunc // 监听名 -> 停止对应的 watch 进程
+	wg        sync.WaitGroup
+	events    chan fsnotify.Event
+	errors    chan error
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Add 为 name 启动一个 watch 进程
+func (w *PluginWatcher) Add(name string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	select {
+	case <-w.done:
+		return ErrPluginWatcherClosed
+	default:
+	}
+	if _, ok := w.procs[name]; ok {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd := exec.CommandContext(ctx, w.path, append([]string{"watch", name}, w.args...)...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		cancel()
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		cancel()
+		return fmt.Errorf("plugin %s watch: %w", w.path, err)
+	}
+	w.procs[name] = cancel
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		w.readEvents(name, bufio.NewScanner(stdout))
+		if err := cmd.Wait(); err != nil && ctx.Err() == nil {
+			w.sendError(fmt.Errorf("plugin %s watch %s exited: %w", w.path, name, err))
+		}
+	}()
+	return nil
+}
+
+// readEvents 逐行读取 watch 进程输出
+func (w *PluginWatcher) readEvents(name string, scanner *bufio.Scanner) {
+	for scanner.Scan() {
+		var ev pluginEvent
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			w.logger.Warn("Ignoring malformed plugin event", zap.String("plugin", w.path), zap.Error(err))
+			continue
+		}
+		switch ev.Event {
+		case "changed":
+			if ev.Name == "" {
+				ev.Name = name
+			}
+			select {
+			case w.events <- fsnotify.Event{Name: ev.Name, Op: fsnotify.Write}:
+			case <-w.done:
+				return
+			}
+		case "error":
+			w.sendError(fmt.Errorf("plugin %s: %s", w.path, ev.Error))
+		}
+	}
+}
+
+// Remove 停止 name 对应的 watch 进程
+func (w *PluginWatcher) Remove(name string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	cancel, ok := w.procs[name]
+	if !ok {
+		return fmt.Errorf("%s is not watched", name)
+	}
+	cancel()
+	delete(w.procs, name)
+	return nil
+}
+
+// Close 停止全部 watch 进程 可重复调用
+func (w *PluginWatcher) Close() error {
+	w.closeOnce.Do(func() {
+		w.mu.Lock()
+		close(w.done)
+		for name, cancel := range w.procs {
+			cancel()
+			delete(w.procs, name)
+		}
+		w.mu.Unlock()
+		w.wg.Wait()
+	})
+	return nil
+}
+
+// Events 返回事件通道
+func (w *PluginWatcher) Events() <-chan fsnotify.Event {
+	return w.events
+}
+
+// Errors 返回错误通道
+func (w *PluginWatcher) Errors() <-chan error {
+	return w.errors
+}
+
+// sendError 发送错误 通道已满时丢弃
+func (w *PluginWatcher) sendError(err error) {
+	select {
+	case w.errors <- err:
+	default:
+	}
+}
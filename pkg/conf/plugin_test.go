@@ -0,0 +1,63 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/omeyang/practices/internal/entity"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// testPluginScript 测试用插件 load 输出固定配置 watch 输出一次变化后常驻
+const testPluginScript = `#!/bin/sh
+case "$1" in
+load)
+  echo '{"version": "r7", "config": {"prometheusCfg": {"port": 9300}}}'
+  ;;
+watch)
+  echo '{"event": "changed"}'
+  exec sleep 60
+  ;;
+esac
+`
+
+// TestExecPlugin 测试 exec-JSON 插件的发现 加载与监听
+func TestExecPlugin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell plugins are not supported on windows")
+	}
+	logger, _ := zap.NewDevelopment()
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, PluginPrefix+"test"), []byte(testPluginScript), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, PluginPrefix+"noexec"), []byte(testPluginScript), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "other"), []byte(testPluginScript), 0o755))
+
+	plugins, err := DiscoverPlugins(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"test": filepath.Join(dir, PluginPrefix+"test")}, plugins)
+
+	plugin := NewExecPlugin[entity.AppConf](plugins["test"], logger)
+	assert.Equal(t, "plugin:test", plugin.GetConfigPath())
+	config, err := plugin.LoadConfig(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 9300, config.PrometheusCfg.Port)
+	assert.Equal(t, "r7", plugin.Version())
+
+	watcher := plugin.Watcher()
+	assert.NoError(t, watcher.Add("prod"))
+	select {
+	case event := <-watcher.Events():
+		assert.Equal(t, fsnotify.Event{Name: "prod", Op: fsnotify.Write}, event)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for plugin event")
+	}
+	assert.NoError(t, watcher.Close())
+	assert.ErrorIs(t, watcher.Add("prod"), ErrPluginWatcherClosed)
+}
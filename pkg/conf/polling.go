@@ -0,0 +1,195 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/afero"
+)
+
+// ErrPollingWatcherClosed 轮询监听器已关闭
+var ErrPollingWatcherClosed = errors.New("polling watcher closed")
+
+// Fingerprint 计算被监听对象的指纹 指纹变化即视为内容变化 对象不存在时返回 os.ErrNotExist
+type Fingerprint func(name string) (string, error)
+
+// FileFingerprint 基于文件修改时间与大小的指纹
+func FileFingerprint(fs afero.Fs) Fingerprint {
+	return func(name string) (string, error) {
+		info, err := fs.Stat(name)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size()), nil
+	}
+}
+
+// PollingWatcher 按固定间隔比较指纹的监听器 实现 WatcherInterface
+// 用于无法使用 fsnotify 的场景 如 inotify 监听数耗尽或远程配置源
+type PollingWatcher struct {
+	interval    time.Duration
+	clock       Clock
+	fingerprint Fingerprint
+	mu          sync.Mutex
+	paths       map[string]string // 路径 -> 上一次的指纹 空字符串表示不存在
+	events      chan fsnotify.Event
+	errors      chan error
+	done        chan struct{}
+	closeOnce   sync.Once
+}
+
+// PollingOption 轮询监听器选项
+type PollingOption func(*PollingWatcher)
+
+// WithPollingClock 设置轮询使用的时间源
+func WithPollingClock(clock Clock) PollingOption {
+	return func(w *PollingWatcher) {
+		if clock != nil {
+			w.clock = clock
+		}
+	}
+}
+
+// NewPollingWatcher 创建轮询监听器 fingerprint 为空时使用本地文件系统
+func NewPollingWatcher(interval time.Duration, fingerprint Fingerprint, opts ...PollingOption) *PollingWatcher {
+	if fingerprint == nil {
+		fingerprint = FileFingerprint(afero.NewOsFs())
+	}
+	w := &PollingWatcher{
+		interval:    interval,
+		clock:       RealClock,
+		fingerprint: fingerprint,
+		paths:       make(map[string]string),
+		events:      make(chan fsnotify.Event, 16),
+		errors:      make(chan error, 1),
+		done:        make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	go w.run()
+	return w
+}
+
+// Add 添加监听路径
+func (w *PollingWatcher) Add(name string) error {
+	select {
+	case <-w.done:
+		return ErrPollingWatcherClosed
+	default:
+	}
+
+	fp, err := w.fingerprint(name)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.paths[name] = fp
+	return nil
+}
+
+// Remove 移除监听路径
+func (w *PollingWatcher) Remove(name string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.paths[name]; !ok {
+		return fmt.Errorf("%s is not watched", name)
+	}
+	delete(w.paths, name)
+	return nil
+}
+
+// Has 判断路径是否正在被监听
+func (w *PollingWatcher) Has(name string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, ok := w.paths[name]
+	return ok
+}
+
+// Close 停止轮询 可重复调用
+func (w *PollingWatcher) Close() error {
+	w.closeOnce.Do(func() {
+		close(w.done)
+	})
+	return nil
+}
+
+// Events 返回事件通道
+func (w *PollingWatcher) Events() <-chan fsnotify.Event {
+	return w.events
+}
+
+// Errors 返回错误通道
+func (w *PollingWatcher) Errors() <-chan error {
+	return w.errors
+}
+
+// run 轮询循环
+func (w *PollingWatcher) run() {
+	ticker := w.clock.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C():
+			w.poll()
+		}
+	}
+}
+
+// poll 比较所有路径的指纹 为发生变化的路径生成合成事件
+func (w *PollingWatcher) poll() {
+	w.mu.Lock()
+	names := make([]string, 0, len(w.paths))
+	for name := range w.paths {
+		names = append(names, name)
+	}
+	w.mu.Unlock()
+
+	for _, name := range names {
+		fp, err := w.fingerprint(name)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			w.sendError(err)
+			continue
+		}
+
+		w.mu.Lock()
+		prev, ok := w.paths[name]
+		if ok {
+			w.paths[name] = fp
+		}
+		w.mu.Unlock()
+		if !ok || prev == fp {
+			continue
+		}
+
+		op := fsnotify.Write
+		switch {
+		case prev == "":
+			op = fsnotify.Create
+		case fp == "":
+			op = fsnotify.Remove
+		}
+		select {
+		case w.events <- fsnotify.Event{Name: name, Op: op}:
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// sendError 发送错误 通道已满时丢弃
+func (w *PollingWatcher) sendError(err error) {
+	select {
+	case w.errors <- err:
+	default:
+	}
+}
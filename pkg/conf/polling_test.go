@@ -0,0 +1,48 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPollingWatcher 测试轮询监听器生成合成事件
+func TestPollingWatcher(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NoError(t, afero.WriteFile(fs, "/config.yaml", []byte("a: 1"), 0o644))
+
+	w := NewPollingWatcher(10*time.Millisecond, FileFingerprint(fs))
+	defer w.Close()
+	assert.NoError(t, w.Add("/config.yaml"))
+	assert.True(t, w.Has("/config.yaml"))
+
+	assert.NoError(t, afero.WriteFile(fs, "/config.yaml", []byte("a: 22"), 0o644))
+	assertEvent(t, w, fsnotify.Event{Name: "/config.yaml", Op: fsnotify.Write})
+
+	assert.NoError(t, fs.Remove("/config.yaml"))
+	assertEvent(t, w, fsnotify.Event{Name: "/config.yaml", Op: fsnotify.Remove})
+
+	assert.NoError(t, afero.WriteFile(fs, "/config.yaml", []byte("a: 1"), 0o644))
+	assertEvent(t, w, fsnotify.Event{Name: "/config.yaml", Op: fsnotify.Create})
+
+	assert.NoError(t, w.Remove("/config.yaml"))
+	assert.Error(t, w.Remove("/config.yaml"))
+
+	assert.NoError(t, w.Close())
+	assert.NoError(t, w.Close())
+	assert.ErrorIs(t, w.Add("/config.yaml"), ErrPollingWatcherClosed)
+}
+
+// assertEvent 等待并检查下一个事件
+func assertEvent(t *testing.T, w *PollingWatcher, expected fsnotify.Event) {
+	t.Helper()
+	select {
+	case event := <-w.Events():
+		assert.Equal(t, expected, event)
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for %v", expected)
+	}
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlLinePattern 匹配 yaml.v3 错误信息中的行号
+var yamlLinePattern = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// yamlUnknownFieldPattern 匹配 yaml.v3 严格解码时未知字段的错误信息
+var yamlUnknownFieldPattern = regexp.MustCompile(`^field (\S+) not found in type (\S+)$`)
+
+// locateJSONError 将 json 解码错误的字节偏移转换为行列号
+func locateJSONError(file string, data []byte, err error) error {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.As(err, &syntaxErr):
+		line, column := offsetToPosition(data, syntaxErr.Offset)
+		return &FieldError{File: file, Line: line, Column: column, Message: syntaxErr.Error(), Code: CodeSyntax, Params: []any{syntaxErr.Error()}, Err: err}
+	case errors.As(err, &typeErr):
+		line, column := offsetToPosition(data, typeErr.Offset)
+		message := "cannot unmarshal " + typeErr.Value + " into " + typeErr.Type.String()
+		return &FieldError{File: file, Path: typeErr.Field, Line: line, Column: column, Message: message, Code: CodeTypeMismatch, Params: []any{message}, Err: err}
+	default:
+		return &FieldError{File: file, Message: err.Error(), Code: CodeParse, Params: []any{err.Error()}, Err: err}
+	}
+}
+
+// locateYAMLError 从 yaml 解码错误中提取行号 多个类型错误汇总为 MultiError
+func locateYAMLError(file string, _ []byte, err error) error {
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		var errs MultiError
+		for _, msg := range typeErr.Errors {
+			errs.Append(yamlFieldError(file, CodeTypeMismatch, msg, err))
+		}
+		return errs.ErrorOrNil()
+	}
+	return yamlFieldError(file, CodeSyntax, err.Error(), err)
+}
+
+// yamlFieldError 解析单条 yaml 错误信息
+func yamlFieldError(file string, code ErrorCode, msg string, err error) *FieldError {
+	fieldErr := &FieldError{File: file, Message: strings.TrimPrefix(msg, "yaml: "), Code: code, Err: err}
+	if m := yamlLinePattern.FindStringSubmatch(msg); m != nil {
+		fieldErr.Line, _ = strconv.Atoi(m[1])
+		fieldErr.Message = m[2]
+	}
+	fieldErr.Params = []any{fieldErr.Message}
+	if m := yamlUnknownFieldPattern.FindStringSubmatch(fieldErr.Message); m != nil {
+		fieldErr.Code, fieldErr.Params = CodeUnknownField, []any{m[1], m[2]}
+	}
+	return fieldErr
+}
+
+// offsetToPosition 将 json 报告的已读取字节数转换为出错字符从 1 开始的行列号
+func offsetToPosition(data []byte, offset int64) (line, column int) {
+	idx := int(offset) - 1
+	if idx > len(data) {
+		idx = len(data)
+	}
+	if idx < 0 {
+		idx = 0
+	}
+	prefix := data[:idx]
+	line = bytes.Count(prefix, []byte("\n")) + 1
+	column = idx - bytes.LastIndexByte(prefix, '\n')
+	return line, column
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/omeyang/practices/internal/entity"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// TestParseErrorPosition 测试解析错误携带文件名与行列号
+func TestParseErrorPosition(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+
+	tests := []struct {
+		name           string
+		parser         CfgParser[entity.AppConf]
+		content        string
+		expectedPath   string
+		expectedLine   int
+		expectedColumn int
+	}{
+		{"JSON Syntax", &JSONParser[entity.AppConf]{Logger: logger}, "{\n  \"prometheusCfg\": {\n    \"port\": 90,,\n  }\n}", "", 3, 16},
+		{"JSON Type", &JSONParser[entity.AppConf]{Logger: logger}, "{\n  \"prometheusCfg\": {\"port\": \"abc\"}\n}", "prometheusCfg.port", 2, 33},
+		{"YAML Syntax", &YAMLParser[entity.AppConf]{Logger: logger}, "prometheusCfg:\n  port: a: b\n", "", 2, 0},
+		{"YAML Type", &YAMLParser[entity.AppConf]{Logger: logger}, "prometheusCfg:\n  port: abc\n", "", 2, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.parser.Parse(mockFile(tt.content))
+			var fieldErr *FieldError
+			assert.ErrorAs(t, err, &fieldErr)
+			assert.Equal(t, "test", fieldErr.File)
+			assert.Equal(t, tt.expectedPath, fieldErr.Path)
+			assert.Equal(t, tt.expectedLine, fieldErr.Line)
+			assert.Equal(t, tt.expectedColumn, fieldErr.Column)
+		})
+	}
+}
+
+// TestOffsetToPosition 测试字节偏移到行列号的转换
+func TestOffsetToPosition(t *testing.T) {
+	data := []byte("ab\ncd\nef")
+	line, column := offsetToPosition(data, 1)
+	assert.Equal(t, [2]int{1, 1}, [2]int{line, column})
+	line, column = offsetToPosition(data, 5)
+	assert.Equal(t, [2]int{2, 2}, [2]int{line, column})
+	line, column = offsetToPosition(data, 100)
+	assert.Equal(t, [2]int{3, 3}, [2]int{line, column})
+}
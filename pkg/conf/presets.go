@@ -0,0 +1,105 @@
+package config
+
+import (
+	"maps"
+	"sort"
+	"sync"
+)
+
+// PresetKey 配置树中选择预设使用的键名
+const PresetKey = "preset"
+
+// presetRegistry 全局注册的预设
+var presetRegistry struct {
+	mu      sync.RWMutex
+	presets map[string]map[string]any
+}
+
+// RegisterPreset 注册名为 name 的预设 覆盖之前的同名注册 通常由库在 init 中调用
+// values 为配置树片段 选中后作为配置文件之下的一层 配置文件中的取值总是优先:
+//
+//	config.RegisterPreset("high-throughput", map[string]any{
+//		"workerCfg": map[string]any{"poolSize": 64},
+//	})
+func RegisterPreset(name string, values map[string]any) {
+	presetRegistry.mu.Lock()
+	defer presetRegistry.mu.Unlock()
+	if presetRegistry.presets == nil {
+		presetRegistry.presets = map[string]map[string]any{}
+	}
+	presetRegistry.presets[name] = *DeepCopy(&values)
+}
+
+// PresetNames 返回已注册的预设名称 按名称排序
+func PresetNames() []string {
+	presetRegistry.mu.RLock()
+	defer presetRegistry.mu.RUnlock()
+	names := make([]string, 0, len(presetRegistry.presets))
+	for name := range presetRegistry.presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookupPreset 返回预设取值的副本 变换可以自由修改
+func lookupPreset(name string) (map[string]any, bool) {
+	presetRegistry.mu.RLock()
+	defer presetRegistry.mu.RUnlock()
+	values, ok := presetRegistry.presets[name]
+	if !ok {
+		return nil, false
+	}
+	return *DeepCopy(&values), true
+}
+
+// ApplyPresets 返回展开预设的配置树变换 顶层的 preset 键选择一个或多个已注册的预设
+//
+//	preset: high-throughput
+//	workerCfg:
+//	  poolSize: 16 # 覆盖预设中的取值
+//
+// 选择多个预设时靠后的预设优先 preset 键在展开后删除 应放在 OverrideEnv 等变换之前
+func ApplyPresets() Transform {
+	return func(tree map[string]any) error {
+		raw, ok := tree[PresetKey]
+		if !ok {
+			return nil
+		}
+		delete(tree, PresetKey)
+
+		var errs MultiError
+		names, paths := []any{raw}, []string{PresetKey}
+		switch v := raw.(type) {
+		case nil:
+			names = nil
+		case []any:
+			names, paths = v, make([]string, len(v))
+			for i := range v {
+				paths[i] = indexPath(PresetKey, i)
+			}
+		}
+
+		base := map[string]any{}
+		for i, item := range names {
+			name, ok := item.(string)
+			if !ok {
+				errs.Add(paths[i], "must be a preset name or a list of names")
+				continue
+			}
+			values, ok := lookupPreset(name)
+			if !ok {
+				errs.Addf(paths[i], "unknown preset %q, registered: %v", name, PresetNames())
+				continue
+			}
+			mergeTree(base, values)
+		}
+		if err := errs.ErrorOrNil(); err != nil {
+			return err
+		}
+		mergeTree(base, tree)
+		clear(tree)
+		maps.Copy(tree, base)
+		return nil
+	}
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/omeyang/practices/internal/entity"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// TestApplyPresets 测试预设作为配置文件之下的一层 配置文件中的取值优先
+func TestApplyPresets(t *testing.T) {
+	RegisterPreset("test-high-throughput", map[string]any{
+		"workerCfg":     map[string]any{"poolSize": 64},
+		"prometheusCfg": map[string]any{"enable": true, "port": 9090},
+	})
+	RegisterPreset("test-low-latency", map[string]any{
+		"prometheusCfg": map[string]any{"port": 9091},
+	})
+	parser := &YAMLParser[entity.AppConf]{Logger: zap.NewNop(), Transforms: []Transform{ApplyPresets()}}
+
+	config, err := parser.Parse(mockFile("preset: test-high-throughput\nworkerCfg:\n  poolSize: 16\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, 16, config.WorkerCfg.PoolSize)
+	assert.Equal(t, &entity.PrometheusConf{Enable: true, Port: 9090}, config.PrometheusCfg)
+
+	// 靠后的预设优先
+	config, err = parser.Parse(mockFile("preset: [test-high-throughput, test-low-latency]\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, &entity.PrometheusConf{Enable: true, Port: 9091}, config.PrometheusCfg)
+
+	// 展开后修改配置树不影响注册的预设
+	tree := map[string]any{PresetKey: "test-low-latency"}
+	assert.NoError(t, ApplyPresets()(tree))
+	tree["prometheusCfg"].(map[string]any)["port"] = 1
+	values, _ := lookupPreset("test-low-latency")
+	assert.Equal(t, 9091, values["prometheusCfg"].(map[string]any)["port"])
+
+	_, err = parser.Parse(mockFile("preset: test-missing\n"))
+	assert.ErrorContains(t, err, `unknown preset "test-missing"`)
+	assert.Subset(t, PresetNames(), []string{"test-high-throughput", "test-low-latency"})
+}
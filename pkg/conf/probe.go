@@ -0,0 +1,94 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// Probe 针对候选配置的预热探测 如能否连接配置中的数据库 返回错误表示该配置不可用
+// 探测不应修改候选配置
+type Probe[T any] func(ctx context.Context, candidate *T) error
+
+// ProbeError 探测失败
+type ProbeError struct {
+	Name string // 探测名称
+	Err  error  // 探测返回的错误
+}
+
+// Error 实现 error 接口
+func (e *ProbeError) Error() string {
+	return fmt.Sprintf("probe %s: %v", e.Name, e.Err)
+}
+
+// Unwrap 返回探测返回的错误
+func (e *ProbeError) Unwrap() error {
+	return e.Err
+}
+
+// namedProbe 已注册的探测
+type namedProbe[T any] struct {
+	name  string
+	probe Probe[T]
+}
+
+// probeSet 已注册的探测集合
+type probeSet[T any] struct {
+	mu   sync.Mutex
+	list []namedProbe[T]
+}
+
+// AddProbe 注册预热探测 重载与 Set 在替换配置前对候选配置执行全部探测
+// 任一探测失败时候选配置被拒绝 当前配置保持不变
+func (cm *CfgManager[T]) AddProbe(name string, probe Probe[T]) {
+	cm.probes.mu.Lock()
+	defer cm.probes.mu.Unlock()
+	cm.probes.list = append(cm.probes.list, namedProbe[T]{name: name, probe: probe})
+}
+
+// Probe 并发执行全部探测 每个探测受 WithProbeTimeout 限制 返回全部失败的探测
+func (cm *CfgManager[T]) Probe(ctx context.Context, candidate *T) error {
+	cm.probes.mu.Lock()
+	probes := append([]namedProbe[T](nil), cm.probes.list...)
+	cm.probes.mu.Unlock()
+
+	errs := make([]error, len(probes))
+	var wg sync.WaitGroup
+	for i, p := range probes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			probeCtx, cancel := context.WithTimeout(ctx, cm.opts.probeTimeout)
+			defer cancel()
+			if err := p.probe(probeCtx, candidate); err != nil {
+				errs[i] = &ProbeError{Name: p.name, Err: err}
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// DryRun 加载候选配置并执行校验与全部探测 不应用配置 返回通过校验与探测的候选配置
+func (cm *CfgManager[T]) DryRun(ctx context.Context) (*T, error) {
+	candidate, err := cm.loader.LoadConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := cm.applyDefaults(candidate); err != nil {
+		cm.logger.Warn("Failed to apply defaults to candidate config", zap.Error(err), zap.String("configPath", cm.loader.GetConfigPath()))
+		return nil, err
+	}
+	if err := cm.Validate(candidate); err != nil {
+		cm.logger.Warn("Candidate config failed validation", zap.Error(err), zap.String("configPath", cm.loader.GetConfigPath()))
+		return nil, err
+	}
+	if err := cm.Probe(ctx, candidate); err != nil {
+		cm.logger.Warn("Candidate config failed probes", zap.Error(err), zap.String("configPath", cm.loader.GetConfigPath()))
+		return nil, err
+	}
+	return candidate, nil
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/omeyang/practices/internal/entity"
+	mocks "github.com/omeyang/practices/mocks/conf"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+// portProbe 拒绝指定端口的探测
+func portProbe(bad int) Probe[entity.AppConf] {
+	return func(_ context.Context, candidate *entity.AppConf) error {
+		if candidate.PrometheusCfg != nil && candidate.PrometheusCfg.Port == bad {
+			return errors.New("connection refused")
+		}
+		return nil
+	}
+}
+
+// TestCfgManager_Probe 测试探测失败时拒绝候选配置
+func TestCfgManager_Probe(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return("/etc/app.yaml").AnyTimes()
+	cm := NewConfigManager[entity.AppConf](mockLoader, nil, zap.NewNop(), RetryPolicy{MaxAttempts: 3})
+	cm.AddProbe("metrics", portProbe(1))
+
+	ctx := context.Background()
+	good := &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9090}}
+	bad := &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 1}}
+	assert.NoError(t, cm.Set(ctx, good))
+
+	var probeErr *ProbeError
+	assert.ErrorAs(t, cm.Set(ctx, bad), &probeErr)
+	assert.Equal(t, "metrics", probeErr.Name)
+	assert.Equal(t, good, cm.GetConfig())
+
+	// 探测失败不重试
+	mockLoader.EXPECT().LoadConfig(ctx).Return(bad, nil).Times(1)
+	assert.ErrorAs(t, cm.Reload(ctx), &probeErr)
+	assert.Equal(t, good, cm.GetConfig())
+
+	// DryRun 只探测不应用
+	next := &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9100}}
+	mockLoader.EXPECT().LoadConfig(ctx).Return(next, nil).Times(1)
+	candidate, err := cm.DryRun(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, next, candidate)
+	assert.Equal(t, good, cm.GetConfig())
+}
+
+// TestCfgManager_ProbeTimeout 测试探测超时与多个探测失败
+func TestCfgManager_ProbeTimeout(t *testing.T) {
+	cm := NewConfigManager[entity.AppConf](nil, nil, zap.NewNop(), RetryPolicy{}, WithProbeTimeout(10*time.Millisecond))
+	cm.AddProbe("slow", func(ctx context.Context, _ *entity.AppConf) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	cm.AddProbe("metrics", portProbe(1))
+
+	err := cm.Probe(context.Background(), &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 1}})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "probe slow")
+	assert.ErrorContains(t, err, "probe metrics: connection refused")
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"context"
+)
+
+// ConfigProvider 读取配置的最小能力 供只需要读取配置的组件依赖
+type ConfigProvider[T any] interface {
+	GetConfig() *T
+	OnChange(handler ChangeHandler[T])
+	WaitReady(ctx context.Context) error
+}
+
+// ConfigAdmin 管理配置的能力 供管理端等需要干预配置的组件依赖
+type ConfigAdmin interface {
+	Reload(ctx context.Context) error
+	Rollback(ctx context.Context) error
+	Status() Status
+}
+
+var (
+	_ ConfigProvider[any] = (*CfgManager[any])(nil)
+	_ ConfigAdmin         = (*CfgManager[any])(nil)
+)
+
+// WaitReady 等待首份配置加载完成 ctx 结束时返回其错误
+func (cm *CfgManager[T]) WaitReady(ctx context.Context) error {
+	select {
+	case <-cm.ready:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Reload 立即重新加载配置 按重试策略重试 返回最终的错误
+func (cm *CfgManager[T]) Reload(ctx context.Context) error {
+	return cm.reload(ctx)
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/omeyang/practices/internal/entity"
+	mocks "github.com/omeyang/practices/mocks/conf"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+// TestCfgManager_WaitReady 测试等待首份配置
+func TestCfgManager_WaitReady(t *testing.T) {
+	cm := NewConfigManager[entity.AppConf](nil, nil, zap.NewNop(), RetryPolicy{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, cm.WaitReady(ctx), context.DeadlineExceeded)
+
+	assert.NoError(t, cm.storeConfig(&entity.AppConf{}))
+	assert.NoError(t, cm.storeConfig(&entity.AppConf{}))
+	assert.NoError(t, cm.WaitReady(context.Background()))
+}
+
+// TestCfgManager_Reload 测试手动重载返回错误而不是写入错误通道
+func TestCfgManager_Reload(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockWatcher := mocks.NewMockWatcherInterface(ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return("/path/to/config").AnyTimes()
+
+	var admin ConfigAdmin = NewConfigManager[entity.AppConf](mockLoader, mockWatcher, zap.NewNop(), RetryPolicy{})
+	ctx := context.Background()
+
+	mockLoader.EXPECT().LoadConfig(ctx).Return(&entity.AppConf{}, nil).Times(1)
+	assert.NoError(t, admin.Reload(ctx))
+
+	loadErr := errors.New("load error")
+	mockLoader.EXPECT().LoadConfig(ctx).Return(nil, loadErr).Times(1)
+	assert.ErrorIs(t, admin.Reload(ctx), loadErr)
+	assert.Empty(t, admin.(*CfgManager[entity.AppConf]).ListenForConfigErrors())
+}
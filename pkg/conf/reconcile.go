@@ -0,0 +1,161 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ReconcileStatus 与配置源核对的结果 用于发现长时间运行中因丢失监听事件而过期的配置
+type ReconcileStatus struct {
+	InSync     bool       `json:"inSync"`               // 最近一次核对时内存中的配置是否与配置源一致
+	LastCheck  time.Time  `json:"lastCheck"`            // 最近一次核对的时间
+	LastDrift  *time.Time `json:"lastDrift,omitempty"`  // 最近一次发现不一致的时间 从未发现时为空
+	Drifts     uint64     `json:"drifts"`               // 发现不一致的次数
+	SourceHash string     `json:"sourceHash,omitempty"` // 最近一次读取的配置源内容的指纹
+	Error      string     `json:"error,omitempty"`      // 最近一次核对或强制重载失败的原因
+}
+
+// reconcileState 最近一次核对的结果
+type reconcileState struct {
+	mu       sync.Mutex
+	state    *ReconcileStatus // 尚未核对时为空
+	rejected string           // 最近一次强制重载被拒绝的配置源指纹 配置源不变时不再重试
+}
+
+// snapshot 返回核对结果的副本 尚未核对时返回 nil
+func (r *reconcileState) snapshot() *ReconcileStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.state == nil {
+		return nil
+	}
+	state := *r.state
+	return &state
+}
+
+// record 记录一次核对的结果 drift 表示发现了不一致 发现不一致且重载失败时记住配置源的指纹
+func (r *reconcileState) record(now time.Time, hash string, drift bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.state == nil {
+		r.state = &ReconcileStatus{}
+	}
+	r.state.LastCheck = now
+	r.state.SourceHash = hash
+	r.state.InSync = !drift && err == nil
+	r.state.Error = ""
+	if err != nil {
+		r.state.Error = err.Error()
+	}
+	if drift {
+		r.state.Drifts++
+		r.state.LastDrift = &now
+	}
+	switch {
+	case drift && err != nil:
+		r.rejected = hash
+	case !drift && err == nil:
+		r.rejected = ""
+	}
+}
+
+// skip 配置源与上次被拒绝的内容相同时记录本次核对并返回 true 保留上次拒绝的原因
+func (r *reconcileState) skip(now time.Time, hash string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.rejected == "" || r.rejected != hash || r.state == nil {
+		return false
+	}
+	r.state.LastCheck = now
+	r.state.InSync = false
+	return true
+}
+
+// Reconcile 重新读取配置源 与内存中的配置比较指纹 不一致时强制应用读取到的配置 返回是否发现了不一致
+// 等待生效 等待批准与等待重启的配置视为一致 避免重复触发同一次重载
+// 被校验 探测或钩子拒绝的配置源在内容变化前不再重试
+func (cm *CfgManager[T]) Reconcile(ctx context.Context) (bool, error) {
+	cm.reloadMu.Lock()
+	defer cm.reloadMu.Unlock()
+
+	source, hash, drift, err := cm.checkDrift(ctx)
+	if err == nil && drift {
+		start := cm.opts.clock.Now()
+		if cm.reconciled.skip(start, hash) {
+			return true, nil
+		}
+		cm.logger.Warn("Config drifted from source, forcing reload", zap.String("configPath", cm.loader.GetConfigPath()), zap.String("sourceHash", hash))
+		cm.metrics.observeDrift()
+		err = cm.applyLoaded(ctx, source)
+		now := cm.opts.clock.Now()
+		cm.metrics.observeReload(err, now.Sub(start), now)
+		if err != nil {
+			cm.reportApply(ctx, err)
+		}
+	}
+	cm.reconciled.record(cm.opts.clock.Now(), hash, drift, err)
+	return drift, err
+}
+
+// checkDrift 读取配置源并比较指纹 返回填充默认值后的配置源 其指纹与是否不一致
+func (cm *CfgManager[T]) checkDrift(ctx context.Context) (*T, string, bool, error) {
+	source, err := cm.loader.LoadConfig(ctx)
+	if err != nil {
+		return nil, "", false, err
+	}
+	if err := cm.applyDefaults(source); err != nil {
+		return nil, "", false, err
+	}
+	hash, err := configFingerprint(source)
+	if err != nil {
+		return nil, "", false, err
+	}
+	pending, _ := cm.PendingConfig()
+	candidates := []*T{cm.sharedConfig(), pending}
+	if approval := cm.ApprovalPending(); approval != nil {
+		candidates = append(candidates, approval.Config)
+	}
+	if restart := cm.RestartPending(); restart != nil {
+		candidates = append(candidates, restart.Config)
+	}
+	for _, candidate := range candidates {
+		if candidate == nil {
+			continue
+		}
+		if current, err := configFingerprint(candidate); err == nil && current == hash {
+			return source, hash, false, nil
+		}
+	}
+	return source, hash, true, nil
+}
+
+// runReconcile 按间隔与配置源核对 直到 ctx 结束
+func (cm *CfgManager[T]) runReconcile(ctx context.Context) {
+	ticker := cm.opts.clock.NewTicker(cm.opts.reconcileEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			if _, err := cm.Reconcile(ctx); err != nil && ctx.Err() == nil {
+				cm.logger.Warn("Failed to reconcile config with source", zap.Error(err), zap.String("configPath", cm.loader.GetConfigPath()))
+			}
+		}
+	}
+}
+
+// configFingerprint 以配置编码后的 sha256 作为指纹 映射的键按顺序编码 相同的配置总是得到相同的指纹
+func configFingerprint(config any) (string, error) {
+	data, err := yamlCodec.marshal(config)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/omeyang/practices/internal/entity"
+	mocks "github.com/omeyang/practices/mocks/conf"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+// TestCfgManager_Reconcile 测试配置源与内存中的配置一致时不重载 不一致时强制重载
+func TestCfgManager_Reconcile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return("/path/to/config").AnyTimes()
+
+	clock := NewFakeClock(time.Unix(100, 0))
+	cm := NewConfigManager[entity.AppConf](mockLoader, nil, zap.NewNop(), RetryPolicy{}, WithClock(clock))
+	assert.NoError(t, cm.storeConfig(&entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9090}}))
+	ctx := context.Background()
+
+	mockLoader.EXPECT().LoadConfig(gomock.Any()).Return(&entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9090}}, nil)
+	drift, err := cm.Reconcile(ctx)
+	assert.NoError(t, err)
+	assert.False(t, drift)
+	assert.True(t, cm.Status().Reconcile.InSync)
+
+	// 丢失监听事件后配置源已经变化
+	updated := &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9091}}
+	mockLoader.EXPECT().LoadConfig(gomock.Any()).Return(updated, nil)
+	drift, err = cm.Reconcile(ctx)
+	assert.NoError(t, err)
+	assert.True(t, drift)
+	assert.Equal(t, 9091, cm.GetConfig().PrometheusCfg.Port)
+
+	status := cm.Status().Reconcile
+	at := time.Unix(100, 0)
+	assert.Equal(t, uint64(1), status.Drifts)
+	assert.Equal(t, &at, status.LastDrift)
+	assert.NotEmpty(t, status.SourceHash)
+
+	mockLoader.EXPECT().LoadConfig(gomock.Any()).Return(nil, errors.New("connection refused"))
+	_, err = cm.Reconcile(ctx)
+	assert.Error(t, err)
+	assert.False(t, cm.Status().Reconcile.InSync)
+	assert.Equal(t, "connection refused", cm.Status().Reconcile.Error)
+}
+
+// TestCfgManager_ReconcileRestartPending 测试等待重启的配置视为一致 重启钩子只触发一次
+func TestCfgManager_ReconcileRestartPending(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return("/path/to/config").AnyTimes()
+	loaded := make(chan struct{})
+	mockLoader.EXPECT().LoadConfig(gomock.Any()).DoAndReturn(func(context.Context) (*entity.AppConf, error) {
+		loaded <- struct{}{}
+		return &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9091}}, nil
+	}).Times(4)
+
+	clock := NewFakeClock(time.Unix(100, 0))
+	cm := NewConfigManager[entity.AppConf](mockLoader, nil, zap.NewNop(), RetryPolicy{}, WithClock(clock), WithReconcile(time.Minute))
+	cm.RequireRestart("prometheusCfg.port")
+	var restarts atomic.Int32
+	cm.OnRestartRequired(func(RestartRequired[entity.AppConf]) { restarts.Add(1) })
+	assert.NoError(t, cm.storeConfig(&entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9090}}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		cm.runReconcile(ctx)
+	}()
+	clock.BlockUntil(1)
+	for i := 0; i < 4; i++ {
+		clock.Advance(time.Minute)
+		<-loaded
+		// 等待本次核对完成后再推进时钟
+		assert.Eventually(t, func() bool {
+			status := cm.Status().Reconcile
+			return status != nil && status.LastCheck.Equal(clock.Now())
+		}, time.Second, time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	assert.Eventually(t, func() bool { return restarts.Load() == 1 }, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, int32(1), restarts.Load())
+	assert.Equal(t, 9090, cm.GetConfig().PrometheusCfg.Port)
+	assert.Equal(t, uint64(1), cm.Status().Reconcile.Drifts)
+}
+
+// TestCfgManager_ReconcileRejected 测试被拒绝的配置源在内容变化前不再重试
+func TestCfgManager_ReconcileRejected(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return("/path/to/config").AnyTimes()
+
+	cm := NewConfigManager[entity.AppConf](mockLoader, nil, zap.NewNop(), RetryPolicy{})
+	validations := 0
+	cm.AddValidator(ValidatorFunc[entity.AppConf](func(c *entity.AppConf) error {
+		validations++
+		if c.PrometheusCfg.Port == 0 {
+			return errors.New("port is required")
+		}
+		return nil
+	}))
+	assert.NoError(t, cm.storeConfig(&entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9090}}))
+	ctx := context.Background()
+
+	mockLoader.EXPECT().LoadConfig(gomock.Any()).Return(&entity.AppConf{PrometheusCfg: &entity.PrometheusConf{}}, nil).Times(3)
+	for i := 0; i < 3; i++ {
+		drift, err := cm.Reconcile(ctx)
+		assert.True(t, drift)
+		if i == 0 {
+			assert.ErrorContains(t, err, "port is required")
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+	assert.Equal(t, 1, validations)
+	status := cm.Status().Reconcile
+	assert.False(t, status.InSync)
+	assert.Equal(t, uint64(1), status.Drifts)
+	assert.Contains(t, status.Error, "port is required")
+
+	// 配置源变化后重新应用
+	mockLoader.EXPECT().LoadConfig(gomock.Any()).Return(&entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9091}}, nil)
+	drift, err := cm.Reconcile(ctx)
+	assert.True(t, drift)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, validations)
+	assert.Equal(t, 9091, cm.GetConfig().PrometheusCfg.Port)
+}
@@ -0,0 +1,392 @@
+package config
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// ErrReplayWatcherClosed 回放监听器已关闭
+var ErrReplayWatcherClosed = errors.New("replay watcher closed")
+
+// 录制记录的类型
+const (
+	RecordEvent  = "event"  // 监听器产生的事件
+	RecordError  = "error"  // 监听器产生的错误
+	RecordAdd    = "add"    // 调用 Add
+	RecordRemove = "remove" // 调用 Remove
+)
+
+// WatchRecord 录制的单条监听记录 以 JSON Lines 格式保存
+type WatchRecord struct {
+	Offset time.Duration `json:"offset"`          // 距离录制开始的时间
+	Kind   string        `json:"kind"`            // 记录类型 如 event
+	Name   string        `json:"name,omitempty"`  // 事件或调用的路径
+	Op     string        `json:"op,omitempty"`    // 事件类型 如 CREATE|WRITE
+	Error  string        `json:"error,omitempty"` // 错误信息 包括 Add 与 Remove 返回的错误
+}
+
+// opNames 事件类型与录制中名称的对应关系 不依赖 fsnotify.Op.String 的格式
+var opNames = []struct {
+	op   fsnotify.Op
+	name string
+}{
+	{fsnotify.Create, "CREATE"},
+	{fsnotify.Write, "WRITE"},
+	{fsnotify.Remove, "REMOVE"},
+	{fsnotify.Rename, "RENAME"},
+	{fsnotify.Chmod, "CHMOD"},
+}
+
+// formatOp 将事件类型编码为以 | 分隔的名称
+func formatOp(op fsnotify.Op) string {
+	var names []string
+	for _, o := range opNames {
+		if op&o.op != 0 {
+			names = append(names, o.name)
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+// parseOp 解析 formatOp 编码的事件类型
+func parseOp(s string) (fsnotify.Op, error) {
+	var op fsnotify.Op
+	if s == "" {
+		return op, nil
+	}
+	for _, name := range strings.Split(s, "|") {
+		found := false
+		for _, o := range opNames {
+			if o.name == name {
+				op |= o.op
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("unknown event op %q", name)
+		}
+	}
+	return op, nil
+}
+
+// RecordOption 录制与回放监听器的选项
+type RecordOption func(*recordOptions)
+
+// recordOptions 录制与回放监听器的可选项
+type recordOptions struct {
+	clock   Clock
+	speed   float64
+	rewrite func(name string) string
+}
+
+// WithRecordClock 设置录制与回放使用的时间源 默认为 RealClock
+func WithRecordClock(clock Clock) RecordOption {
+	return func(o *recordOptions) {
+		if clock != nil {
+			o.clock = clock
+		}
+	}
+}
+
+// WithReplaySpeed 设置回放速度的倍数 如 10 表示以十倍速回放 不大于 0 时不等待 依次立即投递
+func WithReplaySpeed(speed float64) RecordOption {
+	return func(o *recordOptions) {
+		o.speed = speed
+	}
+}
+
+// WithReplayRewrite 回放时改写事件路径 如将生产环境的配置目录替换为测试的临时目录
+func WithReplayRewrite(rewrite func(name string) string) RecordOption {
+	return func(o *recordOptions) {
+		o.rewrite = rewrite
+	}
+}
+
+// newRecordOptions 返回应用选项后的可选项
+func newRecordOptions(opts []RecordOption) recordOptions {
+	o := recordOptions{clock: RealClock, speed: 1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// RecordingWatcher 将被包装监听器的事件 错误与 Add Remove 调用逐条写入 w 的监听器 实现 WatcherInterface
+// 用于在生产环境捕获难以复现的事件序列 如重命名风暴与重复事件 之后由 ReplayWatcher 在测试中回放
+type RecordingWatcher struct {
+	inner  WatcherInterface
+	clock  Clock
+	start  time.Time
+	events chan fsnotify.Event
+	errors chan error
+	done   chan struct{}
+
+	mu        sync.Mutex
+	enc       *json.Encoder
+	writeErr  error // 第一次写入录制失败的错误 之后不再录制
+	closeOnce sync.Once
+}
+
+// NewRecordingWatcher 包装监听器并开始录制 w 需要自行关闭
+func NewRecordingWatcher(inner WatcherInterface, w io.Writer, opts ...RecordOption) *RecordingWatcher {
+	o := newRecordOptions(opts)
+	r := &RecordingWatcher{
+		inner:  inner,
+		clock:  o.clock,
+		start:  o.clock.Now(),
+		events: make(chan fsnotify.Event),
+		errors: make(chan error),
+		done:   make(chan struct{}),
+		enc:    json.NewEncoder(w),
+	}
+	go r.run()
+	return r
+}
+
+// Add 添加监听路径并记录调用
+func (r *RecordingWatcher) Add(name string) error {
+	err := r.inner.Add(name)
+	r.record(WatchRecord{Kind: RecordAdd, Name: name, Error: errorString(err)})
+	return err
+}
+
+// Remove 移除监听路径并记录调用
+func (r *RecordingWatcher) Remove(name string) error {
+	err := r.inner.Remove(name)
+	r.record(WatchRecord{Kind: RecordRemove, Name: name, Error: errorString(err)})
+	return err
+}
+
+// Close 关闭被包装的监听器并停止录制 可重复调用
+func (r *RecordingWatcher) Close() error {
+	var err error
+	r.closeOnce.Do(func() {
+		close(r.done)
+		err = r.inner.Close()
+	})
+	return err
+}
+
+// Events 返回事件通道
+func (r *RecordingWatcher) Events() <-chan fsnotify.Event {
+	return r.events
+}
+
+// Errors 返回错误通道
+func (r *RecordingWatcher) Errors() <-chan error {
+	return r.errors
+}
+
+// Err 返回写入录制失败的错误 录制失败不影响事件的转发
+func (r *RecordingWatcher) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.writeErr
+}
+
+// run 转发被包装监听器的事件与错误 转发前先写入录制
+func (r *RecordingWatcher) run() {
+	events, errs := r.inner.Events(), r.inner.Errors()
+	for events != nil || errs != nil {
+		select {
+		case <-r.done:
+			return
+		case event, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			r.record(WatchRecord{Kind: RecordEvent, Name: event.Name, Op: formatOp(event.Op)})
+			select {
+			case r.events <- event:
+			case <-r.done:
+				return
+			}
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			r.record(WatchRecord{Kind: RecordError, Error: err.Error()})
+			select {
+			case r.errors <- err:
+			case <-r.done:
+				return
+			}
+		}
+	}
+}
+
+// record 写入一条记录
+func (r *RecordingWatcher) record(rec WatchRecord) {
+	rec.Offset = r.clock.Now().Sub(r.start)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.writeErr != nil {
+		return
+	}
+	if err := r.enc.Encode(rec); err != nil {
+		r.writeErr = fmt.Errorf("write watch record: %w", err)
+	}
+}
+
+// errorString 返回错误信息 err 为空时返回空串
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// ReadWatchRecords 读取 RecordingWatcher 写入的全部记录
+func ReadWatchRecords(r io.Reader) ([]WatchRecord, error) {
+	var records []WatchRecord
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var rec WatchRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("watch record line %d: %w", line, err)
+		}
+		if _, err := parseOp(rec.Op); err != nil {
+			return nil, fmt.Errorf("watch record line %d: %w", line, err)
+		}
+		records = append(records, rec)
+	}
+	return records, scanner.Err()
+}
+
+// ReplayWatcher 按录制时的时间间隔回放事件与错误的监听器 实现 WatcherInterface
+// 第一次调用 Add 时开始回放 即 CfgManager 完成首次加载并开始监听之后 录制中的 Add 与 Remove 记录不会回放
+type ReplayWatcher struct {
+	records []WatchRecord
+	opts    recordOptions
+	events  chan fsnotify.Event
+	errors  chan error
+	done    chan struct{}
+	played  chan struct{}
+
+	mu        sync.Mutex
+	added     []string
+	started   bool
+	closeOnce sync.Once
+}
+
+// NewReplayWatcher 读取录制并创建回放监听器
+func NewReplayWatcher(r io.Reader, opts ...RecordOption) (*ReplayWatcher, error) {
+	records, err := ReadWatchRecords(r)
+	if err != nil {
+		return nil, err
+	}
+	return &ReplayWatcher{
+		records: records,
+		opts:    newRecordOptions(opts),
+		events:  make(chan fsnotify.Event),
+		errors:  make(chan error),
+		done:    make(chan struct{}),
+		played:  make(chan struct{}),
+	}, nil
+}
+
+// Add 记录监听路径 第一次调用时开始回放
+func (w *ReplayWatcher) Add(name string) error {
+	select {
+	case <-w.done:
+		return ErrReplayWatcherClosed
+	default:
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.added = append(w.added, name)
+	if !w.started {
+		w.started = true
+		go w.replay()
+	}
+	return nil
+}
+
+// Remove 不做任何事 回放的事件与监听的路径无关
+func (w *ReplayWatcher) Remove(string) error {
+	return nil
+}
+
+// Close 停止回放 可重复调用
+func (w *ReplayWatcher) Close() error {
+	w.closeOnce.Do(func() {
+		close(w.done)
+	})
+	return nil
+}
+
+// Events 返回事件通道
+func (w *ReplayWatcher) Events() <-chan fsnotify.Event {
+	return w.events
+}
+
+// Errors 返回错误通道
+func (w *ReplayWatcher) Errors() <-chan error {
+	return w.errors
+}
+
+// Added 返回调用 Add 添加过的路径 用于断言被测代码的监听行为
+func (w *ReplayWatcher) Added() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string(nil), w.added...)
+}
+
+// Played 返回全部记录回放完成后关闭的通道
+func (w *ReplayWatcher) Played() <-chan struct{} {
+	return w.played
+}
+
+// replay 依次投递事件与错误 按回放速度等待记录之间的间隔
+func (w *ReplayWatcher) replay() {
+	var last time.Duration
+	for _, rec := range w.records {
+		if rec.Kind != RecordEvent && rec.Kind != RecordError {
+			continue
+		}
+		if w.opts.speed > 0 && rec.Offset > last {
+			timer := w.opts.clock.NewTimer(time.Duration(float64(rec.Offset-last) / w.opts.speed))
+			select {
+			case <-timer.C():
+			case <-w.done:
+				timer.Stop()
+				return
+			}
+		}
+		last = rec.Offset
+
+		if rec.Kind == RecordError {
+			select {
+			case w.errors <- errors.New(rec.Error):
+			case <-w.done:
+				return
+			}
+			continue
+		}
+		op, _ := parseOp(rec.Op)
+		name := rec.Name
+		if w.opts.rewrite != nil {
+			name = w.opts.rewrite(name)
+		}
+		select {
+		case w.events <- fsnotify.Event{Name: name, Op: op}:
+		case <-w.done:
+			return
+		}
+	}
+	close(w.played)
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	mocks "github.com/omeyang/practices/mocks/conf"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+// nextEvent 等待监听器的下一个事件
+func nextEvent(t *testing.T, w WatcherInterface) fsnotify.Event {
+	t.Helper()
+	select {
+	case event := <-w.Events():
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event")
+		return fsnotify.Event{}
+	}
+}
+
+// TestRecordingWatcher 测试事件 错误与 Add 调用按顺序录制并原样转发
+func TestRecordingWatcher(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	events, errs := make(chan fsnotify.Event), make(chan error)
+	inner := mocks.NewMockWatcherInterface(ctrl)
+	inner.EXPECT().Events().Return(events)
+	inner.EXPECT().Errors().Return(errs)
+	inner.EXPECT().Add("/etc/app/config.yaml").Return(nil)
+	inner.EXPECT().Close().Return(nil)
+
+	clock := NewFakeClock(time.Unix(0, 0))
+	var buf bytes.Buffer
+	w := NewRecordingWatcher(inner, &buf, WithRecordClock(clock))
+	assert.NoError(t, w.Add("/etc/app/config.yaml"))
+
+	clock.Advance(time.Second)
+	events <- fsnotify.Event{Name: "/etc/app/config.yaml", Op: fsnotify.Create | fsnotify.Write}
+	assert.Equal(t, fsnotify.Event{Name: "/etc/app/config.yaml", Op: fsnotify.Create | fsnotify.Write}, nextEvent(t, w))
+	clock.Advance(time.Second)
+	errs <- errors.New("queue overflow")
+	assert.EqualError(t, <-w.Errors(), "queue overflow")
+	assert.NoError(t, w.Close())
+	assert.NoError(t, w.Close())
+
+	records, err := ReadWatchRecords(&buf)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Err())
+	assert.Equal(t, []WatchRecord{
+		{Offset: 0, Kind: RecordAdd, Name: "/etc/app/config.yaml"},
+		{Offset: time.Second, Kind: RecordEvent, Name: "/etc/app/config.yaml", Op: "CREATE|WRITE"},
+		{Offset: 2 * time.Second, Kind: RecordError, Error: "queue overflow"},
+	}, records)
+}
+
+// TestReplayWatcher 测试从第一次 Add 开始按录制的间隔回放 并改写路径
+func TestReplayWatcher(t *testing.T) {
+	recording := `{"offset":0,"kind":"add","name":"/prod/config.yaml"}
+{"offset":1000000000,"kind":"event","name":"/prod/config.yaml","op":"RENAME"}
+{"offset":1000000000,"kind":"event","name":"/prod/config.yaml","op":"CREATE"}
+{"offset":3000000000,"kind":"error","error":"queue overflow"}
+`
+	clock := NewFakeClock(time.Unix(0, 0))
+	w, err := NewReplayWatcher(strings.NewReader(recording), WithRecordClock(clock),
+		WithReplayRewrite(func(name string) string { return strings.Replace(name, "/prod", "/test", 1) }))
+	assert.NoError(t, err)
+	defer w.Close()
+
+	assert.NoError(t, w.Add("/test/config.yaml"))
+	assert.Equal(t, []string{"/test/config.yaml"}, w.Added())
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	assert.Equal(t, fsnotify.Event{Name: "/test/config.yaml", Op: fsnotify.Rename}, nextEvent(t, w))
+	assert.Equal(t, fsnotify.Event{Name: "/test/config.yaml", Op: fsnotify.Create}, nextEvent(t, w))
+
+	clock.BlockUntil(1)
+	clock.Advance(2 * time.Second)
+	assert.EqualError(t, <-w.Errors(), "queue overflow")
+	<-w.Played()
+
+	assert.NoError(t, w.Close())
+	assert.ErrorIs(t, w.Add("/test/config.yaml"), ErrReplayWatcherClosed)
+
+	_, err = NewReplayWatcher(strings.NewReader(`{"offset":0,"kind":"event","op":"TOUCH"}`))
+	assert.ErrorContains(t, err, `unknown event op "TOUCH"`)
+}
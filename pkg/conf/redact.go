@@ -0,0 +1,226 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// RedactedValue 脱敏后的取值 与 confctl compare 的 -redacted 默认值一致
+const RedactedValue = "******"
+
+// 常用的脱敏配置名称 需要通过 WithRedactionProfile 声明各自额外脱敏的键路径
+const (
+	ProfileOperator      = "operator"       // 运维人员 排查线上问题
+	ProfileDeveloper     = "developer"      // 开发人员 通常还需遮盖用户数据等字段
+	ProfileSupportBundle = "support-bundle" // 导出给外部的诊断包 通常遮盖主机名与内部地址
+)
+
+// ErrUnknownRedactionProfile 脱敏配置未通过 WithRedactionProfile 声明
+var ErrUnknownRedactionProfile = errors.New("unknown redaction profile")
+
+// Redact 返回脱敏后的配置树 sensitive:"true" 字段与 paths 指定键路径的取值替换为 RedactedValue
+//
+// 路径以点分隔 * 匹配任意键 列表中的每个元素按相同的路径处理 如 tenants.*.password 或 replicas.password
+// 空值与空字符串保持原样 便于看出敏感字段未设置
+func Redact(config any, paths ...string) (map[string]any, error) {
+	tree, err := configTree(config)
+	if err != nil {
+		return nil, err
+	}
+	redactNode(tree, reflect.TypeOf(config))
+	for _, path := range paths {
+		redactPath(tree, strings.Split(path, "."))
+	}
+	return tree, nil
+}
+
+// redactNode 按配置类型递归替换配置树中敏感字段的取值
+func redactNode(node any, t reflect.Type) {
+	if t == nil {
+		return
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	t = lazyElem(t)
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := node.(map[string]any)
+		if !ok {
+			return
+		}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			if name == "-" {
+				continue
+			}
+			if opts == "inline" {
+				redactNode(m, field.Type)
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+			value, ok := m[name]
+			if !ok || unset(value) {
+				continue
+			}
+			if field.Tag.Get("sensitive") == "true" {
+				m[name] = RedactedValue
+				continue
+			}
+			redactNode(value, field.Type)
+		}
+	case reflect.Map:
+		if m, ok := node.(map[string]any); ok {
+			for _, value := range m {
+				redactNode(value, t.Elem())
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if items, ok := node.([]any); ok {
+			for _, item := range items {
+				redactNode(item, t.Elem())
+			}
+		}
+	}
+}
+
+// redactPath 替换配置树中匹配键路径的取值
+func redactPath(node any, keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	switch n := node.(type) {
+	case map[string]any:
+		for key, value := range n {
+			if keys[0] != "*" && keys[0] != key {
+				continue
+			}
+			if len(keys) == 1 {
+				if !unset(value) {
+					n[key] = RedactedValue
+				}
+				continue
+			}
+			redactPath(value, keys[1:])
+		}
+	case []any:
+		for _, item := range n {
+			redactPath(item, keys)
+		}
+	}
+}
+
+// unset 判断配置树中的取值是否未设置 未设置的取值不脱敏
+func unset(value any) bool {
+	return value == nil || value == ""
+}
+
+// redactChanges 返回按新旧配置的脱敏配置树替换取值后的变更
+func redactChanges(changes []Change, oldTree, newTree map[string]any) []Change {
+	if changes == nil {
+		return nil
+	}
+	redacted := make([]Change, len(changes))
+	for i, change := range changes {
+		change.Old = redactedValue(oldTree, change.Path, change.Old)
+		change.New = redactedValue(newTree, change.Path, change.New)
+		redacted[i] = change
+	}
+	return redacted
+}
+
+// redactedValue 返回变更取值在脱敏配置树中对应的取值 所在的子树整体脱敏时返回 RedactedValue
+func redactedValue(tree map[string]any, path string, value any) any {
+	if unset(value) {
+		return value
+	}
+	if redacted, ok := lookupPath(tree, path); ok {
+		return redacted
+	}
+	return RedactedValue
+}
+
+// redact 按 sensitive 标签与 WithRedactPaths 脱敏配置
+func (cm *CfgManager[T]) redact(config *T) (map[string]any, error) {
+	return Redact(config, cm.opts.redactPaths...)
+}
+
+// redactionPaths 返回脱敏配置对应的全部键路径 profile 为空时只包括 WithRedactPaths 的路径
+func (cm *CfgManager[T]) redactionPaths(profile string) ([]string, error) {
+	if profile == "" {
+		return cm.opts.redactPaths, nil
+	}
+	extra, ok := cm.opts.redactProfiles[profile]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownRedactionProfile, profile)
+	}
+	return append(slices.Clip(cm.opts.redactPaths), extra...), nil
+}
+
+// RedactWithProfile 按脱敏配置脱敏 用于导出快照或诊断包 profile 为空时与管理端的默认输出一致
+func (cm *CfgManager[T]) RedactWithProfile(config *T, profile string) (map[string]any, error) {
+	paths, err := cm.redactionPaths(profile)
+	if err != nil {
+		return nil, err
+	}
+	return Redact(config, paths...)
+}
+
+// RedactedField 返回输出脱敏后配置的日志字段 paths 为额外脱敏的键路径
+// 配置只在日志实际输出时才转换 被级别过滤的日志没有额外开销
+func RedactedField(key string, config any, paths ...string) zap.Field {
+	return zap.Object(key, redactedConfig{config: config, paths: paths})
+}
+
+// RedactedField 返回按管理器脱敏规则输出配置的日志字段
+func (cm *CfgManager[T]) RedactedField(key string, config *T) zap.Field {
+	return RedactedField(key, config, cm.opts.redactPaths...)
+}
+
+// RedactedFieldWithProfile 返回按脱敏配置输出配置的日志字段 如审计日志使用 ProfileSupportBundle
+// 脱敏配置未声明时整个配置输出为 RedactedValue
+func (cm *CfgManager[T]) RedactedFieldWithProfile(key string, config *T, profile string) zap.Field {
+	paths, err := cm.redactionPaths(profile)
+	if err != nil {
+		return zap.String(key, RedactedValue)
+	}
+	return RedactedField(key, config, paths...)
+}
+
+// redactedConfig 以脱敏配置树编码的日志对象
+type redactedConfig struct {
+	config any
+	paths  []string
+}
+
+// MarshalLogObject 实现 zapcore.ObjectMarshaler 按键名顺序输出
+func (r redactedConfig) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	tree, err := Redact(r.config, r.paths...)
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(tree))
+	for key := range tree {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := enc.AddReflected(key, tree[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mocks "github.com/omeyang/practices/mocks/conf"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestRedact 测试敏感字段在结构体 列表与映射中都被脱敏
+func TestRedact(t *testing.T) {
+	tree, err := Redact(&adminConf{
+		Name:      "app",
+		Database:  adminDatabaseConf{User: "root", Password: "secret"},
+		Replicas:  []adminDatabaseConf{{User: "ro", Password: "secret"}},
+		Upstreams: map[string]adminDatabaseConf{"billing": {User: "svc", Password: "secret"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"name":      "app",
+		"database":  map[string]any{"user": "root", "password": RedactedValue},
+		"replicas":  []any{map[string]any{"user": "ro", "password": RedactedValue}},
+		"upstreams": map[string]any{"billing": map[string]any{"user": "svc", "password": RedactedValue}},
+	}, tree)
+}
+
+// TestRedact_Paths 测试按键路径脱敏 * 匹配任意键 列表元素按相同路径处理 未设置的敏感字段保持为空
+func TestRedact_Paths(t *testing.T) {
+	tree, err := Redact(&adminConf{
+		Name:      "app",
+		Database:  adminDatabaseConf{User: "root"},
+		Replicas:  []adminDatabaseConf{{User: "ro"}},
+		Upstreams: map[string]adminDatabaseConf{"billing": {User: "svc"}},
+	}, "database.user", "replicas.user", "upstreams.*.user", "missing.key")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"name":      "app",
+		"database":  map[string]any{"user": RedactedValue, "password": ""},
+		"replicas":  []any{map[string]any{"user": RedactedValue, "password": ""}},
+		"upstreams": map[string]any{"billing": map[string]any{"user": RedactedValue, "password": ""}},
+	}, tree)
+}
+
+// TestRedactedField 测试日志字段输出脱敏后的配置
+func TestRedactedField(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	logger := zap.New(core)
+	config := &adminConf{Name: "app", Database: adminDatabaseConf{User: "root", Password: "secret"}}
+	logger.Info("Config loaded", RedactedField("config", config, "name"))
+	logger.Debug("Filtered", RedactedField("config", config))
+
+	entries := logs.AllUntimed()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, map[string]any{
+		"name":     RedactedValue,
+		"database": map[string]any{"user": "root", "password": RedactedValue},
+	}, entries[0].ContextMap()["config"])
+}
+
+// TestCfgManager_StatusRedacted 测试 Status 中等待重启的变更按管理器规则脱敏
+func TestCfgManager_StatusRedacted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[adminConf](ctrl)
+	mockWatcher := mocks.NewMockWatcherInterface(ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return("/path/to/config").AnyTimes()
+
+	cm := NewConfigManager[adminConf](mockLoader, mockWatcher, zap.NewNop(), RetryPolicy{}, WithRedactPaths("database.user"))
+	cm.RequireRestart("database")
+	assert.NoError(t, cm.storeConfig(&adminConf{Database: adminDatabaseConf{User: "root", Password: "old"}}))
+	assert.True(t, cm.checkRestart(&adminConf{Name: "app", Database: adminDatabaseConf{User: "admin", Password: "new"}}))
+
+	status := cm.Status()
+	assert.Equal(t, []Change{{Path: "name", Old: "", New: "app"}}, status.RestartRequired.Hot)
+	assert.Equal(t, []Change{
+		{Path: "database.password", Old: RedactedValue, New: RedactedValue, Restart: true},
+		{Path: "database.user", Old: RedactedValue, New: RedactedValue, Restart: true},
+	}, status.RestartRequired.Restart)
+}
+
+// TestCfgManager_RedactionProfiles 测试脱敏配置在默认规则之外额外脱敏 未设置的取值保持为空 并可在管理端按头部或查询参数选择
+func TestCfgManager_RedactionProfiles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[adminConf](ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return("/path/to/config").AnyTimes()
+
+	cm := NewConfigManager[adminConf](mockLoader, nil, zap.NewNop(), RetryPolicy{},
+		WithRedactPaths("upstreams.*.user"),
+		WithRedactionProfile(ProfileOperator),
+		WithRedactionProfile(ProfileSupportBundle, "name"),
+		WithRedactionProfile(ProfileSupportBundle, "database.user"))
+	config := &adminConf{
+		Name:      "app",
+		Database:  adminDatabaseConf{User: "root", Password: "secret"},
+		Upstreams: map[string]adminDatabaseConf{"billing": {User: "svc"}},
+	}
+	assert.NoError(t, cm.Set(context.Background(), config))
+
+	operator, err := cm.RedactWithProfile(config, ProfileOperator)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"name":      "app",
+		"database":  map[string]any{"user": "root", "password": RedactedValue},
+		"upstreams": map[string]any{"billing": map[string]any{"user": RedactedValue, "password": ""}},
+	}, operator)
+	bundle, err := cm.RedactWithProfile(config, ProfileSupportBundle)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"name":      RedactedValue,
+		"database":  map[string]any{"user": RedactedValue, "password": RedactedValue},
+		"upstreams": map[string]any{"billing": map[string]any{"user": RedactedValue, "password": ""}},
+	}, bundle)
+
+	// 脱敏配置的路径同样不遮盖未设置的取值
+	unset, err := cm.RedactWithProfile(&adminConf{Database: adminDatabaseConf{Password: "secret"}}, ProfileSupportBundle)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"name":     "",
+		"database": map[string]any{"user": "", "password": RedactedValue},
+	}, unset)
+	_, err = cm.RedactWithProfile(config, ProfileDeveloper)
+	assert.ErrorIs(t, err, ErrUnknownRedactionProfile)
+
+	core, logs := observer.New(zap.InfoLevel)
+	logger := zap.New(core)
+	logger.Info("Config audited", cm.RedactedFieldWithProfile("config", config, ProfileSupportBundle),
+		cm.RedactedFieldWithProfile("unknown", config, ProfileDeveloper))
+	fields := logs.AllUntimed()[0].ContextMap()
+	assert.Equal(t, RedactedValue, fields["config"].(map[string]any)["name"])
+	assert.Equal(t, RedactedValue, fields["unknown"])
+
+	handler := cm.AdminHandler()
+	serve := func(target, profile string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if profile != "" {
+			req.Header.Set(RedactionProfileHeader, profile)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	assert.Contains(t, serve("/config", "").Body.String(), `"user":"root"`)
+	assert.NotContains(t, serve("/config", ProfileSupportBundle).Body.String(), "root")
+	assert.NotContains(t, serve("/config?profile="+ProfileSupportBundle, "").Body.String(), "root")
+	assert.Contains(t, serve("/config?profile="+ProfileSupportBundle, ProfileOperator).Body.String(), `"user":"root"`)
+	assert.Equal(t, http.StatusBadRequest, serve("/config", ProfileDeveloper).Code)
+	assert.Equal(t, http.StatusBadRequest, serve("/config/history?profile=unknown", "").Code)
+}
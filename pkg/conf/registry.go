@@ -0,0 +1,238 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+// ErrWatcherClosed 监听器已关闭或未配置
+var ErrWatcherClosed = errors.New("config watcher closed")
+
+// watcherRegistry 管理器持有的监听器集合 包括主监听器与 inotify 资源耗尽时的轮询监听器
+// 全部状态由内部锁保护 关闭后的添加与移除返回 ErrWatcherClosed
+type watcherRegistry struct {
+	mu       sync.Mutex
+	primary  WatcherInterface
+	poller   *PollingWatcher
+	fallback time.Duration
+	clock    Clock
+	logger   *zap.Logger
+	closed   bool
+	paths    map[string]bool // 已添加的监听路径 -> 是否由轮询监听器监听
+	metrics  *configMetrics
+
+	kubernetes bool              // 监听配置文件所在目录以感知 ConfigMap 符号链接替换
+	targets    kubernetesTargets // Kubernetes 模式下监听的配置文件
+
+	changed chan struct{} // 轮询监听器创建时唤醒事件循环
+	done    chan struct{} // 关闭后关闭
+}
+
+// newWatcherRegistry 创建监听器集合 primary 为空时视为已关闭
+func newWatcherRegistry(primary WatcherInterface, fallback time.Duration, clock Clock, logger *zap.Logger) *watcherRegistry {
+	r := &watcherRegistry{
+		primary:  primary,
+		fallback: fallback,
+		clock:    clock,
+		logger:   logger,
+		changed:  make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	if primary == nil {
+		r.closed = true
+		close(r.done)
+	}
+	return r
+}
+
+// add 添加监听路径 inotify 资源耗尽时自动改用轮询监听器
+func (r *watcherRegistry) add(path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return ErrWatcherClosed
+	}
+	return r.addLocked(path)
+}
+
+// addAll 添加全部监听路径 任一路径失败时移除本次新添加的路径 返回汇总全部失败路径的错误
+func (r *watcherRegistry) addAll(paths []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return ErrWatcherClosed
+	}
+	var (
+		added []string
+		errs  []error
+	)
+	seen := make(map[string]struct{}, len(paths))
+	for _, path := range paths {
+		if _, ok := seen[path]; ok {
+			continue
+		}
+		seen[path] = struct{}{}
+		_, watched := r.paths[path]
+		if err := r.addLocked(path); err != nil {
+			errs = append(errs, fmt.Errorf("watch %s: %w", path, err))
+			continue
+		}
+		if !watched {
+			added = append(added, path)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	for _, path := range added {
+		if err := r.removeLocked(path); err != nil {
+			r.logger.Warn("Failed to roll back watch path", zap.String("path", path), zap.Error(err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// addLocked 添加监听路径 调用方需持有锁
+func (r *watcherRegistry) addLocked(path string) error {
+	var err error
+	if r.kubernetes {
+		err = r.addKubernetes(path)
+	} else {
+		err = r.addPrimary(path, path)
+	}
+	if err == nil {
+		if r.paths == nil {
+			r.paths = map[string]bool{}
+		}
+		r.paths[path] = r.poller != nil && r.poller.Has(path)
+		r.metrics.setWatchedPaths(len(r.paths))
+	}
+	return err
+}
+
+// addPrimary 使用主监听器监听 watchPath inotify 资源耗尽时改用轮询监听器监听 pollPath 调用方需持有锁
+func (r *watcherRegistry) addPrimary(watchPath, pollPath string) error {
+	err := r.primary.Add(watchPath)
+	if err == nil || !isWatchLimitError(err) || r.fallback <= 0 {
+		return err
+	}
+
+	r.logger.Warn("Watch limit exhausted, falling back to polling",
+		zap.String("path", pollPath), zap.Duration("interval", r.fallback),
+		zap.String("hint", watchLimitHint), zap.Error(err))
+
+	if r.poller == nil {
+		r.poller = NewPollingWatcher(r.fallback, nil, WithPollingClock(r.clock))
+		select {
+		case r.changed <- struct{}{}:
+		default:
+		}
+	}
+	return r.poller.Add(pollPath)
+}
+
+// remove 移除监听路径 路径可能位于轮询监听器中
+func (r *watcherRegistry) remove(path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return ErrWatcherClosed
+	}
+	return r.removeLocked(path)
+}
+
+// removeLocked 移除监听路径 调用方需持有锁
+func (r *watcherRegistry) removeLocked(path string) error {
+	var err error
+	switch {
+	case r.poller != nil && r.poller.Has(path):
+		delete(r.targets, path)
+		err = r.poller.Remove(path)
+	case r.kubernetes:
+		err = r.removeKubernetes(path)
+	default:
+		err = r.primary.Remove(path)
+	}
+	if err == nil {
+		delete(r.paths, path)
+		r.metrics.setWatchedPaths(len(r.paths))
+	}
+	return err
+}
+
+// watched 返回已添加的监听路径 按路径排序 关闭后为空
+func (r *watcherRegistry) watched() []WatchedPath {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	paths := make([]WatchedPath, 0, len(r.paths))
+	for path, polling := range r.paths {
+		paths = append(paths, WatchedPath{Path: path, Polling: polling})
+	}
+	sort.Slice(paths, func(i, j int) bool { return paths[i].Path < paths[j].Path })
+	return paths
+}
+
+// rewatch 配置文件被重命名或删除后重新添加主监听 新文件已存在并成功监听时返回 true
+func (r *watcherRegistry) rewatch(path string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return false
+	}
+	if r.poller != nil && r.poller.Has(path) {
+		return false
+	}
+	if err := r.primary.Add(path); err != nil {
+		r.logger.Debug("Config file not yet replaced after rename", zap.String("path", path), zap.Error(err))
+		return false
+	}
+	return true
+}
+
+// close 关闭全部监听器 可重复调用
+func (r *watcherRegistry) close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	close(r.done)
+	clear(r.paths)
+	r.metrics.setWatchedPaths(0)
+
+	err := r.primary.Close()
+	if r.poller != nil {
+		err = errors.Join(err, r.poller.Close())
+		r.poller = nil
+	}
+	return err
+}
+
+// watcherChannels 事件循环监听的通道 未启用轮询时轮询通道为 nil
+type watcherChannels struct {
+	events     <-chan fsnotify.Event
+	errors     <-chan error
+	pollEvents <-chan fsnotify.Event
+	pollErrors <-chan error
+}
+
+// channels 返回当前的监听通道 关闭后全部为 nil
+func (r *watcherRegistry) channels() watcherChannels {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return watcherChannels{}
+	}
+	c := watcherChannels{events: r.primary.Events(), errors: r.primary.Errors()}
+	if r.poller != nil {
+		c.pollEvents, c.pollErrors = r.poller.Events(), r.poller.Errors()
+	}
+	return c
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"errors"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/omeyang/practices/internal/entity"
+
+	mocks "github.com/omeyang/practices/mocks/conf"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+// TestWatcherRegistry_Closed 测试关闭后的添加与移除返回 ErrWatcherClosed
+func TestWatcherRegistry_Closed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockWatcher := mocks.NewMockWatcherInterface(ctrl)
+	r := newWatcherRegistry(mockWatcher, time.Hour, RealClock, zap.NewNop())
+
+	mockWatcher.EXPECT().Add("/a").Return(syscall.ENOSPC).Times(1)
+	assert.NoError(t, r.add("/a"))
+	poller := r.poller
+	assert.True(t, poller.Has("/a"))
+
+	mockWatcher.EXPECT().Close().Return(nil).Times(1)
+	assert.NoError(t, r.close())
+	assert.NoError(t, r.close())
+	assert.ErrorIs(t, r.add("/b"), ErrWatcherClosed)
+	assert.ErrorIs(t, r.remove("/a"), ErrWatcherClosed)
+	assert.Equal(t, watcherChannels{}, r.channels())
+	assert.ErrorIs(t, poller.Add("/c"), ErrPollingWatcherClosed)
+
+	// 未配置监听器时同样视为已关闭
+	cm := NewConfigManager[entity.AppConf](nil, nil, zap.NewNop(), RetryPolicy{})
+	assert.ErrorIs(t, cm.AddWatcher("/a"), ErrWatcherClosed)
+	assert.ErrorIs(t, cm.RemoveWatcher("/a"), ErrWatcherClosed)
+}
+
+// TestWatcherRegistry_Concurrent 测试并发添加 移除与关闭
+func TestWatcherRegistry_Concurrent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockWatcher := mocks.NewMockWatcherInterface(ctrl)
+	mockWatcher.EXPECT().Add(gomock.Any()).Return(nil).AnyTimes()
+	mockWatcher.EXPECT().Remove(gomock.Any()).Return(nil).AnyTimes()
+	mockWatcher.EXPECT().Close().Return(nil).Times(1)
+	r := newWatcherRegistry(mockWatcher, time.Hour, RealClock, zap.NewNop())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if err := r.add("/a"); err != nil {
+					assert.ErrorIs(t, err, ErrWatcherClosed)
+				}
+				if err := r.remove("/a"); err != nil {
+					assert.ErrorIs(t, err, ErrWatcherClosed)
+				}
+			}
+		}()
+	}
+	assert.NoError(t, r.close())
+	wg.Wait()
+}
+
+// TestCfgManager_AddWatchers 测试批量添加失败时撤销本次添加的路径 已在监听的路径保留
+func TestCfgManager_AddWatchers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockWatcher := mocks.NewMockWatcherInterface(ctrl)
+	cm := NewConfigManager[entity.AppConf](nil, mockWatcher, zap.NewNop(), RetryPolicy{})
+
+	mockWatcher.EXPECT().Add("/etc/app/base.yaml").Return(nil).Times(1)
+	assert.NoError(t, cm.AddWatcher("/etc/app/base.yaml"))
+
+	denied := errors.New("permission denied")
+	gomock.InOrder(
+		mockWatcher.EXPECT().Add("/etc/app/base.yaml").Return(nil),
+		mockWatcher.EXPECT().Add("/etc/app/tls.crt").Return(nil),
+		mockWatcher.EXPECT().Add("/etc/app/tls.key").Return(denied),
+		mockWatcher.EXPECT().Add("/etc/app/rules.md").Return(denied),
+		mockWatcher.EXPECT().Remove("/etc/app/tls.crt").Return(nil),
+	)
+	err := cm.AddWatchers([]string{"/etc/app/base.yaml", "/etc/app/tls.crt", "/etc/app/tls.key", "/etc/app/tls.crt", "/etc/app/rules.md"})
+	assert.ErrorIs(t, err, denied)
+	assert.EqualError(t, err, "watch /etc/app/tls.key: permission denied\nwatch /etc/app/rules.md: permission denied")
+	assert.Equal(t, []WatchedPath{{Path: "/etc/app/base.yaml"}}, cm.WatcherStatus().Paths)
+
+	mockWatcher.EXPECT().Add(gomock.Any()).Return(nil).Times(2)
+	assert.NoError(t, cm.AddWatchers([]string{"/etc/app/tls.crt", "/etc/app/tls.key"}))
+	assert.Len(t, cm.WatcherStatus().Paths, 3)
+}
@@ -0,0 +1,322 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+// ErrRemoteClosed 远程配置加载器已关闭
+var ErrRemoteClosed = errors.New("remote config loader closed")
+
+// KVPair 远程键值存储中的一项
+type KVPair struct {
+	Key   string
+	Value []byte
+}
+
+// KVWatchResponse 远程键值存储的一次变更通知
+type KVWatchResponse struct {
+	Revision int64 // 变更后的版本号
+	Err      error // 监听出错时不为空 之后通道会被关闭
+}
+
+// KVBackend 远程键值存储 如 etcd 或 Consul KV
+type KVBackend interface {
+	// List 返回前缀下的全部键值与当前版本号
+	List(ctx context.Context, prefix string) ([]KVPair, int64, error)
+	// Watch 监听前缀下版本号大于 revision 的变更 revision 为 0 时从当前版本开始
+	// ctx 结束或连接断开时关闭通道
+	Watch(ctx context.Context, prefix string, revision int64) (<-chan KVWatchResponse, error)
+}
+
+// RemoteOption 远程配置加载器选项
+type RemoteOption func(*remoteOptions)
+
+// remoteOptions 远程配置加载器可选项
+type remoteOptions struct {
+	retryPolicy RetryPolicy
+	clock       Clock
+}
+
+// WithRemoteRetry 设置监听断开后的重试策略
+// 连续失败达到 MaxAttempts 次时向错误通道报告一次 之后按 Timeout 间隔继续重连
+func WithRemoteRetry(policy RetryPolicy) RemoteOption {
+	return func(o *remoteOptions) {
+		o.retryPolicy = policy
+	}
+}
+
+// WithRemoteClock 设置重连等待使用的时间源
+func WithRemoteClock(clock Clock) RemoteOption {
+	return func(o *remoteOptions) {
+		if clock != nil {
+			o.clock = clock
+		}
+	}
+}
+
+// RemoteLoader 基于远程键值存储的配置加载器 同时实现 CfgLoader 与 WatcherInterface
+//
+// 前缀下的键以 / 分隔层级映射为配置键路径 如前缀 /app/ 下的 /app/prometheusCfg/port 对应 prometheusCfg.port
+// 取值会还原为整数 浮点数与布尔值 以 .json .yaml 或 .yml 结尾的键按文档解码后合并到其所在层级
+// 后端的变更通知转换为合成 Write 事件 交由 CfgManager 按已有的流程重新加载
+type RemoteLoader[T any] struct {
+	backend KVBackend
+	prefix  string
+	logger  *zap.Logger
+	opts    remoteOptions
+
+	mu       sync.Mutex
+	revision int64 // 最近一次读取或收到通知的版本号
+
+	events    chan fsnotify.Event
+	errors    chan error
+	cancel    context.CancelFunc
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewRemoteLoader 创建远程配置加载器 prefix 用作 GetConfigPath 与事件名
+func NewRemoteLoader[T any](backend KVBackend, prefix string, logger *zap.Logger, opts ...RemoteOption) *RemoteLoader[T] {
+	o := remoteOptions{clock: RealClock}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &RemoteLoader[T]{
+		backend: backend,
+		prefix:  prefix,
+		logger:  logger,
+		opts:    o,
+		events:  make(chan fsnotify.Event, 1),
+		errors:  make(chan error, 1),
+		done:    make(chan struct{}),
+	}
+}
+
+// Start 启动监听循环 监听断开后按重试策略重连 直到 ctx 结束或 Close 重复调用或 Close 之后调用不做任何事
+func (r *RemoteLoader[T]) Start(ctx context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cancel != nil || r.closed() {
+		return
+	}
+	ctx, r.cancel = context.WithCancel(ctx)
+	go r.run(ctx)
+}
+
+// closed 返回监听循环是否已退出或加载器已关闭
+func (r *RemoteLoader[T]) closed() bool {
+	select {
+	case <-r.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// run 监听循环
+func (r *RemoteLoader[T]) run(ctx context.Context) {
+	defer close(r.done)
+	failures := 0
+	for ctx.Err() == nil {
+		err := r.watch(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		failures++
+		wait := r.opts.retryPolicy.delay(failures)
+		r.logger.Warn("Remote config watch failed, reconnecting", zap.String("prefix", r.prefix), zap.Int("attempt", failures), zap.Duration("backoff", wait), zap.Error(err))
+		if failures >= max(r.opts.retryPolicy.MaxAttempts, 1) {
+			r.sendError(fmt.Errorf("watch %s: %w", r.prefix, err))
+			failures = 0
+		}
+		if err := sleepClock(ctx, r.opts.clock, wait); err != nil {
+			return
+		}
+	}
+}
+
+// watch 建立一次监听并转发变更通知 直到出错 通道关闭或 ctx 结束 不依赖后端在取消后关闭通道
+func (r *RemoteLoader[T]) watch(ctx context.Context) error {
+	responses, err := r.backend.Watch(ctx, r.prefix, r.Revision())
+	if err != nil {
+		return err
+	}
+	for {
+		var resp KVWatchResponse
+		var ok bool
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case resp, ok = <-responses:
+		}
+		if !ok {
+			return errors.New("watch channel closed")
+		}
+		if resp.Err != nil {
+			return resp.Err
+		}
+		if !r.advance(resp.Revision) {
+			continue
+		}
+		r.logger.Info("Remote config changed", zap.String("prefix", r.prefix), zap.Int64("revision", resp.Revision))
+		// 事件通道只需保留一个待处理的通知 加载时总是读取最新版本
+		select {
+		case r.events <- fsnotify.Event{Name: r.prefix, Op: fsnotify.Write}:
+		default:
+		}
+	}
+}
+
+// advance 记录更新的版本号 版本号未增加时返回 false
+func (r *RemoteLoader[T]) advance(revision int64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if revision <= r.revision {
+		return false
+	}
+	r.revision = revision
+	return true
+}
+
+// LoadConfig 读取前缀下的全部键值并解码
+func (r *RemoteLoader[T]) LoadConfig(ctx context.Context) (*T, error) {
+	if r.closed() {
+		return nil, ErrRemoteClosed
+	}
+
+	pairs, revision, err := r.backend.List(ctx, r.prefix)
+	if err != nil {
+		return nil, fmt.Errorf("list %s: %w", r.prefix, err)
+	}
+	r.advance(revision)
+
+	tree := map[string]any{}
+	for _, pair := range pairs {
+		if err := r.mergePair(tree, pair); err != nil {
+			return nil, err
+		}
+	}
+	var config T
+	if err := decodeTree(tree, &config); err != nil {
+		return nil, fmt.Errorf("revision %d: %w", revision, err)
+	}
+	return &config, nil
+}
+
+// mergePair 将一个键值合并到配置树
+func (r *RemoteLoader[T]) mergePair(tree map[string]any, pair KVPair) error {
+	rel := strings.Trim(strings.TrimPrefix(pair.Key, r.prefix), "/")
+	var keys []string
+	if rel != "" {
+		keys = strings.Split(rel, "/")
+	}
+
+	if ext := path.Ext(rel); ext != "" {
+		if c, err := codecFor(ext); err == nil {
+			doc := map[string]any{}
+			if len(bytes.TrimSpace(pair.Value)) > 0 {
+				if err := c.decode(bytes.NewReader(pair.Value), &doc); err != nil {
+					return c.locate(pair.Key, pair.Value, err)
+				}
+			}
+			mergeSource(nodeAt(tree, keys[:len(keys)-1]), doc, "", pair.Key, map[string]string{})
+			return nil
+		}
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("key %s: value at prefix root must be a json or yaml document", pair.Key)
+	}
+	nodeAt(tree, keys[:len(keys)-1])[keys[len(keys)-1]] = scalarFromEnv(string(pair.Value))
+	return nil
+}
+
+// nodeAt 返回键路径对应的映射 不存在时创建
+func nodeAt(tree map[string]any, keys []string) map[string]any {
+	node := tree
+	for _, key := range keys {
+		child, ok := node[key].(map[string]any)
+		if !ok {
+			child = map[string]any{}
+			node[key] = child
+		}
+		node = child
+	}
+	return node
+}
+
+// CheckHealth 探测远程键值存储 实现 HealthChecker
+// 后端实现 HealthChecker 时使用后端的探测 如 etcd 的状态查询 否则读取一次前缀下的键值
+func (r *RemoteLoader[T]) CheckHealth(ctx context.Context) error {
+	if checker, ok := r.backend.(HealthChecker); ok {
+		return checker.CheckHealth(ctx)
+	}
+	_, _, err := r.backend.List(ctx, r.prefix)
+	return err
+}
+
+// Revision 返回最近一次读取或收到通知的版本号
+func (r *RemoteLoader[T]) Revision() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.revision
+}
+
+// GetConfigPath 返回键前缀
+func (r *RemoteLoader[T]) GetConfigPath() string {
+	return r.prefix
+}
+
+// Add 远程加载器总是监听整个前缀 无需添加路径
+func (r *RemoteLoader[T]) Add(string) error {
+	return nil
+}
+
+// Remove 远程加载器总是监听整个前缀 无需移除路径
+func (r *RemoteLoader[T]) Remove(string) error {
+	return nil
+}
+
+// Close 停止监听循环 可重复调用
+func (r *RemoteLoader[T]) Close() error {
+	r.closeOnce.Do(func() {
+		// 未启动时在持有锁时关闭 之后的 Start 不再启动监听循环
+		r.mu.Lock()
+		cancel := r.cancel
+		if cancel == nil {
+			close(r.done)
+		}
+		r.mu.Unlock()
+		if cancel != nil {
+			cancel()
+			<-r.done
+		}
+	})
+	return nil
+}
+
+// Events 返回配置变更事件通道
+func (r *RemoteLoader[T]) Events() <-chan fsnotify.Event {
+	return r.events
+}
+
+// Errors 返回监听错误通道
+func (r *RemoteLoader[T]) Errors() <-chan error {
+	return r.errors
+}
+
+// sendError 发送错误 通道已满时丢弃
+func (r *RemoteLoader[T]) sendError(err error) {
+	select {
+	case r.errors <- err:
+	default:
+	}
+}
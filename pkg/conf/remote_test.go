@@ -0,0 +1,200 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/omeyang/practices/internal/entity"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// fakeKV 测试用键值存储
+type fakeKV struct {
+	mu       sync.Mutex
+	pairs    []KVPair
+	revision int64
+	watches  chan chan KVWatchResponse // 每次 Watch 建立的通知通道
+	watchErr error
+}
+
+func newFakeKV(pairs ...KVPair) *fakeKV {
+	return &fakeKV{pairs: pairs, revision: 1, watches: make(chan chan KVWatchResponse, 4)}
+}
+
+func (f *fakeKV) List(_ context.Context, _ string) ([]KVPair, int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]KVPair(nil), f.pairs...), f.revision, nil
+}
+
+// Watch 返回的通道在 ctx 结束时关闭 与 etcd 等后端一致 测试通过 watches 收到的通道发送通知
+func (f *fakeKV) Watch(ctx context.Context, _ string, _ int64) (<-chan KVWatchResponse, error) {
+	f.mu.Lock()
+	err := f.watchErr
+	f.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	in, out := make(chan KVWatchResponse, 1), make(chan KVWatchResponse)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case resp := <-in:
+				select {
+				case out <- resp:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	f.watches <- in
+	return out, nil
+}
+
+// stuckKV 取消后仍不关闭监听通道的键值存储
+type stuckKV struct {
+	*fakeKV
+}
+
+func (stuckKV) Watch(context.Context, string, int64) (<-chan KVWatchResponse, error) {
+	return make(chan KVWatchResponse), nil
+}
+
+func (f *fakeKV) put(key, value string) int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.revision++
+	for i, pair := range f.pairs {
+		if pair.Key == key {
+			f.pairs[i].Value = []byte(value)
+			return f.revision
+		}
+	}
+	f.pairs = append(f.pairs, KVPair{Key: key, Value: []byte(value)})
+	return f.revision
+}
+
+// TestRemoteLoader_LoadConfig 测试键值到配置结构的映射
+func TestRemoteLoader_LoadConfig(t *testing.T) {
+	kv := newFakeKV(
+		KVPair{Key: "/app/config.yaml", Value: []byte("prometheusCfg:\n  address: 0.0.0.0\n  port: 9090\n")},
+		KVPair{Key: "/app/prometheusCfg/port", Value: []byte("9100")},
+		KVPair{Key: "/app/prometheusCfg/enable", Value: []byte("true")},
+	)
+	loader := NewRemoteLoader[entity.AppConf](kv, "/app/", zap.NewNop())
+
+	config, err := loader.LoadConfig(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, &entity.PrometheusConf{Enable: true, Port: 9100, Address: "0.0.0.0"}, config.PrometheusCfg)
+	assert.Equal(t, int64(1), loader.Revision())
+	assert.Equal(t, "/app/", loader.GetConfigPath())
+
+	kv.put("/app/", "port: 1")
+	_, err = loader.LoadConfig(context.Background())
+	assert.ErrorContains(t, err, "must be a json or yaml document")
+}
+
+// TestRemoteLoader_Watch 测试后端变更转换为事件并驱动重新加载
+func TestRemoteLoader_Watch(t *testing.T) {
+	kv := newFakeKV(KVPair{Key: "/app/prometheusCfg/port", Value: []byte("9090")})
+	loader := NewRemoteLoader[entity.AppConf](kv, "/app/", zap.NewNop())
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cm := NewConfigManager[entity.AppConf](loader, loader, zap.NewNop(), RetryPolicy{MaxAttempts: 1})
+	loader.Start(ctx)
+	defer loader.Close()
+	assert.NoError(t, cm.Init(ctx))
+	assert.Equal(t, 9090, cm.GetConfig().PrometheusCfg.Port)
+
+	watch := <-kv.watches
+	watch <- KVWatchResponse{Revision: kv.put("/app/prometheusCfg/port", "9091")}
+	assert.Eventually(t, func() bool {
+		return cm.GetConfig().PrometheusCfg.Port == 9091
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, int64(2), loader.Revision())
+
+	// 旧版本的通知不会产生事件
+	watch <- KVWatchResponse{Revision: 2}
+	select {
+	case <-loader.Events():
+		t.Fatal("stale revision produced an event")
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+// TestRemoteLoader_Reconnect 测试监听断开后按重试策略重连并报告错误
+func TestRemoteLoader_Reconnect(t *testing.T) {
+	kv := newFakeKV()
+	clock := NewFakeClock(time.Unix(0, 0))
+	loader := NewRemoteLoader[entity.AppConf](kv, "/app/", zap.NewNop(),
+		WithRemoteRetry(RetryPolicy{MaxAttempts: 2, Timeout: time.Second}), WithRemoteClock(clock))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	loader.Start(ctx)
+	defer loader.Close()
+
+	backendErr := errors.New("etcd: leader changed")
+	(<-kv.watches) <- KVWatchResponse{Err: backendErr}
+	clock.BlockUntil(1)
+	select {
+	case err := <-loader.Errors():
+		t.Fatalf("error reported before retries exhausted: %v", err)
+	default:
+	}
+
+	clock.Advance(time.Second)
+	(<-kv.watches) <- KVWatchResponse{Err: backendErr}
+	select {
+	case err := <-loader.Errors():
+		assert.ErrorIs(t, err, backendErr)
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for watch error")
+	}
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	watch := <-kv.watches
+	watch <- KVWatchResponse{Revision: 5}
+	select {
+	case event := <-loader.Events():
+		assert.Equal(t, fsnotify.Event{Name: "/app/", Op: fsnotify.Write}, event)
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for change event")
+	}
+}
+
+// TestRemoteLoader_Close 测试关闭后加载返回 ErrRemoteClosed
+func TestRemoteLoader_Close(t *testing.T) {
+	loader := NewRemoteLoader[entity.AppConf](newFakeKV(), "/app/", zap.NewNop())
+	assert.NoError(t, loader.Close())
+	assert.NoError(t, loader.Close())
+	_, err := loader.LoadConfig(context.Background())
+	assert.ErrorIs(t, err, ErrRemoteClosed)
+	loader.Start(context.Background())
+
+	// 后端在取消后不关闭通道时 Close 也能返回 重复 Start 不会再次启动监听循环
+	stuck := NewRemoteLoader[entity.AppConf](stuckKV{newFakeKV()}, "/app/", zap.NewNop())
+	stuck.Start(context.Background())
+	stuck.Start(context.Background())
+	closed := make(chan struct{})
+	go func() {
+		assert.NoError(t, stuck.Close())
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close blocked on a watch channel that is never closed")
+	}
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// RestartRequired 需要重启才能生效的配置变更
+type RestartRequired[T any] struct {
+	Keys    []string  // 发生变化的重启键路径
+	Changes ChangeSet // 新配置的全部变更 按重载方式分类
+	Config  *T        // 未应用的新配置
+}
+
+// RestartHook 收到需要重启的配置变更时调用 通常用于通知进程管理器优雅重启
+type RestartHook[T any] func(event RestartRequired[T])
+
+// restartCoordinator 重启键的变更检测状态
+type restartCoordinator[T any] struct {
+	mu      sync.Mutex
+	keys    []string
+	hook    RestartHook[T]
+	events  chan RestartRequired[T]
+	pending *RestartRequired[T]
+}
+
+// RequireRestart 将配置键标记为需要重启才能生效 如 prometheusCfg.port
+// 这些键发生变化时新配置整体不会热更新 而是发出 RestartRequired 事件 避免只应用一半的变更
+// 结构体中带 reload:"restart" 标签的字段总是需要重启 无需在此声明
+func (cm *CfgManager[T]) RequireRestart(keys ...string) {
+	cm.restart.mu.Lock()
+	defer cm.restart.mu.Unlock()
+	cm.restart.keys = append(cm.restart.keys, keys...)
+}
+
+// OnRestartRequired 设置需要重启时调用的钩子 钩子在后台协程中执行
+func (cm *CfgManager[T]) OnRestartRequired(hook RestartHook[T]) {
+	cm.restart.mu.Lock()
+	defer cm.restart.mu.Unlock()
+	cm.restart.hook = hook
+}
+
+// RestartEvents 返回需要重启的变更事件 通道只保留最新的事件
+func (cm *CfgManager[T]) RestartEvents() <-chan RestartRequired[T] {
+	return cm.restart.events
+}
+
+// RestartPending 返回等待重启生效的变更 没有则返回 nil
+func (cm *CfgManager[T]) RestartPending() *RestartRequired[T] {
+	cm.restart.mu.Lock()
+	defer cm.restart.mu.Unlock()
+	return cm.restart.pending
+}
+
+// checkRestart 判断新配置是否修改了重启键 是则记录并通知 返回 true 表示不能热更新
+func (cm *CfgManager[T]) checkRestart(newConfig *T) bool {
+	cm.restart.mu.Lock()
+	defer cm.restart.mu.Unlock()
+	current, _ := cm.config.Load().(*T)
+	if current == nil {
+		return false
+	}
+
+	changes, err := Diff(current, newConfig)
+	if err != nil {
+		cm.logger.Error("Failed to compare configs for restart keys", zap.Error(err))
+		return false
+	}
+	set := Classify(changes, cm.restartKeys())
+	if len(set.Restart) == 0 {
+		cm.restart.pending = nil
+		return false
+	}
+
+	event := RestartRequired[T]{Keys: set.RestartPaths(), Changes: set, Config: newConfig}
+	cm.restart.pending = &event
+	cm.logger.Warn("Config change requires restart, not hot-applying", zap.Strings("keys", event.Keys), zap.String("configPath", cm.loader.GetConfigPath()))
+
+	// 丢弃未被消费的旧事件 只保留最新的变更
+	select {
+	case <-cm.restart.events:
+	default:
+	}
+	cm.restart.events <- event
+	if hook := cm.restart.hook; hook != nil {
+		go hook(event)
+	}
+	return true
+}
+
+// restartKeys 返回标签声明与 RequireRestart 注册的全部重启键 调用方需持有 restart.mu
+func (cm *CfgManager[T]) restartKeys() []string {
+	return append(RestartKeys((*T)(nil)), cm.restart.keys...)
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/omeyang/practices/internal/entity"
+	mocks "github.com/omeyang/practices/mocks/conf"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+// TestCfgManager_RequireRestart 测试修改重启键的配置不会热更新
+func TestCfgManager_RequireRestart(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockWatcher := mocks.NewMockWatcherInterface(ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return("/path/to/config").AnyTimes()
+
+	cm := NewConfigManager[entity.AppConf](mockLoader, mockWatcher, zap.NewNop(), RetryPolicy{})
+	cm.RequireRestart("prometheusCfg.port")
+	hooked := make(chan RestartRequired[entity.AppConf], 1)
+	cm.OnRestartRequired(func(event RestartRequired[entity.AppConf]) {
+		hooked <- event
+	})
+
+	ctx := context.Background()
+	first := &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9090}}
+	assert.NoError(t, cm.Set(ctx, first))
+	assert.Nil(t, cm.RestartPending())
+
+	// 只修改可热更新的键时正常应用
+	hot := &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9090, Enable: true}}
+	assert.NoError(t, cm.Set(ctx, hot))
+	assert.Same(t, hot, cm.GetConfig())
+
+	restart := &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9091, Enable: true}}
+	assert.NoError(t, cm.Set(ctx, restart))
+	assert.Same(t, hot, cm.GetConfig())
+	expected := RestartRequired[entity.AppConf]{
+		Keys:    []string{"prometheusCfg.port"},
+		Changes: ChangeSet{Restart: []Change{{Path: "prometheusCfg.port", Old: 9090, New: 9091, Restart: true}}},
+		Config:  restart,
+	}
+	assert.Equal(t, &expected, cm.RestartPending())
+	assert.Equal(t, expected, <-cm.RestartEvents())
+	select {
+	case event := <-hooked:
+		assert.Equal(t, expected, event)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for restart hook")
+	}
+
+	// 改回原值后不再等待重启
+	assert.NoError(t, cm.Set(ctx, &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9090}}))
+	assert.Nil(t, cm.RestartPending())
+}
+
+// TestCfgManager_Status 测试运行状态中的待重启变更
+func TestCfgManager_Status(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockWatcher := mocks.NewMockWatcherInterface(ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return("/path/to/config").AnyTimes()
+
+	cm := NewConfigManager[entity.AppConf](mockLoader, mockWatcher, zap.NewNop(), RetryPolicy{}, WithInstance("host-1"), WithReadOnly())
+	assert.Equal(t, Status{Source: "/path/to/config", Instance: "host-1", ReadOnly: true, Watcher: &WatcherStatus{Paths: []WatchedPath{}}}, cm.Status())
+
+	cm.RequireRestart("prometheusCfg")
+	assert.NoError(t, cm.storeConfig(&entity.AppConf{}))
+	assert.True(t, cm.checkRestart(&entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9090}}))
+	status := cm.Status()
+	assert.NotNil(t, status.RestartRequired)
+	assert.Len(t, status.RestartRequired.Restart, 3)
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// RuntimeConf Go 运行时参数 取值含义与同名环境变量一致 为空时保持当前设置
+type RuntimeConf struct {
+	GOMAXPROCS int    `yaml:"gomaxprocs,omitempty"` // 最大并行的 P 数量 0 表示保持不变
+	GOGC       *int   `yaml:"gogc,omitempty"`       // 触发 GC 的堆增长百分比 负数表示关闭 GC
+	GOMEMLIMIT string `yaml:"gomemlimit,omitempty"` // 软内存上限 如 512MiB 2GiB off 表示不限制
+}
+
+// Apply 应用运行时参数 参数非法时不做任何修改
+func (c RuntimeConf) Apply() error {
+	if c.GOMAXPROCS < 0 {
+		return fmt.Errorf("gomaxprocs: must not be negative, got %d", c.GOMAXPROCS)
+	}
+	limit := int64(-1)
+	if c.GOMEMLIMIT != "" {
+		var err error
+		if limit, err = parseMemoryLimit(c.GOMEMLIMIT); err != nil {
+			return fmt.Errorf("gomemlimit: %w", err)
+		}
+	}
+
+	if c.GOMAXPROCS > 0 {
+		runtime.GOMAXPROCS(c.GOMAXPROCS)
+	}
+	if c.GOGC != nil {
+		debug.SetGCPercent(*c.GOGC)
+	}
+	if limit >= 0 {
+		debug.SetMemoryLimit(limit)
+	}
+	return nil
+}
+
+// RuntimeHandler 返回在每次配置变更后应用运行时参数的变更处理函数 pick 返回 nil 时保持不变
+// 在 Init 之前通过 OnChange 注册时 首份配置同样会被应用
+func RuntimeHandler[T any](pick func(config *T) *RuntimeConf) ChangeHandler[T] {
+	return func(_, newConfig *T) error {
+		conf := pick(newConfig)
+		if conf == nil {
+			return nil
+		}
+		return conf.Apply()
+	}
+}
+
+// memoryUnits GOMEMLIMIT 支持的单位
+var memoryUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"TiB", 1 << 40},
+	{"GiB", 1 << 30},
+	{"MiB", 1 << 20},
+	{"KiB", 1 << 10},
+	{"B", 1},
+}
+
+// parseMemoryLimit 按 GOMEMLIMIT 的格式解析内存上限 off 表示不限制
+func parseMemoryLimit(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "off" {
+		return math.MaxInt64, nil
+	}
+	number, size := s, int64(1)
+	for _, unit := range memoryUnits {
+		if n, ok := strings.CutSuffix(s, unit.suffix); ok {
+			number, size = n, unit.size
+			break
+		}
+	}
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid memory limit %q", s)
+	}
+	if n > math.MaxInt64/size {
+		return math.MaxInt64, nil
+	}
+	return n * size, nil
+}
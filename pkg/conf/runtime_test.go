@@ -0,0 +1,56 @@
+package config
+
+import (
+	"math"
+	"runtime"
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseMemoryLimit 测试 GOMEMLIMIT 格式的解析
+func TestParseMemoryLimit(t *testing.T) {
+	for input, want := range map[string]int64{
+		"1024":   1024,
+		"512MiB": 512 << 20,
+		"2GiB":   2 << 30,
+		"10B":    10,
+		"off":    math.MaxInt64,
+	} {
+		got, err := parseMemoryLimit(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, want, got, input)
+	}
+	for _, input := range []string{"", "1.5GiB", "-1", "512MB"} {
+		_, err := parseMemoryLimit(input)
+		assert.Error(t, err, input)
+	}
+}
+
+// TestRuntimeHandler 测试变更处理函数应用运行时参数 非法取值不做任何修改
+func TestRuntimeHandler(t *testing.T) {
+	procs := runtime.GOMAXPROCS(0)
+	gcPercent := debug.SetGCPercent(100)
+	memoryLimit := debug.SetMemoryLimit(-1)
+	t.Cleanup(func() {
+		runtime.GOMAXPROCS(procs)
+		debug.SetGCPercent(gcPercent)
+		debug.SetMemoryLimit(memoryLimit)
+	})
+
+	type appConf struct {
+		Runtime *RuntimeConf `yaml:"runtime"`
+	}
+	handler := RuntimeHandler(func(c *appConf) *RuntimeConf { return c.Runtime })
+
+	gogc := 50
+	assert.NoError(t, handler(nil, &appConf{Runtime: &RuntimeConf{GOMAXPROCS: 1, GOGC: &gogc, GOMEMLIMIT: "256MiB"}}))
+	assert.Equal(t, 1, runtime.GOMAXPROCS(0))
+	assert.Equal(t, 50, debug.SetGCPercent(50))
+	assert.Equal(t, int64(256<<20), debug.SetMemoryLimit(-1))
+
+	assert.Error(t, handler(nil, &appConf{Runtime: &RuntimeConf{GOMAXPROCS: 2, GOMEMLIMIT: "lots"}}))
+	assert.Equal(t, 1, runtime.GOMAXPROCS(0))
+	assert.NoError(t, handler(nil, &appConf{}))
+}
@@ -0,0 +1,224 @@
+// Package sampling 将配置中的日志与链路采样参数绑定到运行中的 zap 与 OpenTelemetry 采样器
+// 配置重载后采样率立即生效 排障时可以临时调高采样 无需重启进程
+package sampling
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	config "github.com/omeyang/practices/pkg/conf"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// LogConf 日志配置
+type LogConf struct {
+	Level      string        `yaml:"level"`      // 日志级别 如 debug info warn 为空时保持不变
+	Initial    int           `yaml:"initial"`    // 每个周期内同一条消息先全部输出的条数 不大于 0 表示不采样
+	Thereafter int           `yaml:"thereafter"` // 超过 Initial 后每 Thereafter 条输出一条 不大于 0 时丢弃其余消息
+	Tick       time.Duration `yaml:"tick"`       // 采样周期 默认 1s
+}
+
+// TracingConf 链路追踪配置
+type TracingConf struct {
+	SampleRate float64 `yaml:"sampleRate"` // 根 span 的采样比例 取值 [0, 1] 子 span 跟随父 span 的采样决定
+}
+
+// defaultTick 默认的日志采样周期
+const defaultTick = time.Second
+
+// LogSampler 可在运行时修改采样参数与日志级别的 zap 采样器
+type LogSampler struct {
+	base  zapcore.Core
+	level zap.AtomicLevel
+	core  atomic.Pointer[zapcore.Core] // 当前的采样 core
+	gen   atomic.Uint64                // 每次更新递增 使派生的 core 重建
+}
+
+// NewLogSampler 基于 base 创建采样器 通过 Core 返回的 core 构造 logger
+func NewLogSampler(base zapcore.Core, conf LogConf) (*LogSampler, error) {
+	s := &LogSampler{base: base, level: zap.NewAtomicLevelAt(zapcore.DebugLevel)}
+	if err := s.Update(conf); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Update 修改日志级别与采样参数 级别非法时保持不变并返回错误
+func (s *LogSampler) Update(conf LogConf) error {
+	if conf.Level != "" {
+		level, err := zapcore.ParseLevel(conf.Level)
+		if err != nil {
+			return fmt.Errorf("log level: %w", err)
+		}
+		s.level.SetLevel(level)
+	}
+
+	core := s.base
+	if conf.Initial > 0 {
+		tick := conf.Tick
+		if tick <= 0 {
+			tick = defaultTick
+		}
+		core = zapcore.NewSamplerWithOptions(s.base, tick, conf.Initial, conf.Thereafter)
+	}
+	s.core.Store(&core)
+	s.gen.Add(1)
+	return nil
+}
+
+// Level 返回采样器使用的日志级别
+func (s *LogSampler) Level() zap.AtomicLevel {
+	return s.level
+}
+
+// Core 返回跟随采样参数变化的 core 如 zap.New(sampler.Core())
+func (s *LogSampler) Core() zapcore.Core {
+	return &dynamicCore{sampler: s}
+}
+
+// derivedCore 按某次更新的采样 core 派生出的 core
+type derivedCore struct {
+	gen  uint64
+	core zapcore.Core
+}
+
+// dynamicCore 转发到当前采样 core 的 core 采样参数更新后重新派生
+type dynamicCore struct {
+	sampler *LogSampler
+	fields  []zapcore.Field
+	derived atomic.Pointer[derivedCore]
+}
+
+// current 返回当前采样 core 附加 With 字段后的 core
+func (c *dynamicCore) current() zapcore.Core {
+	gen := c.sampler.gen.Load()
+	if d := c.derived.Load(); d != nil && d.gen == gen {
+		return d.core
+	}
+	core := *c.sampler.core.Load()
+	if len(c.fields) > 0 {
+		core = core.With(c.fields)
+	}
+	c.derived.Store(&derivedCore{gen: gen, core: core})
+	return core
+}
+
+// Enabled 实现 zapcore.Core
+func (c *dynamicCore) Enabled(level zapcore.Level) bool {
+	return c.sampler.level.Enabled(level) && c.current().Enabled(level)
+}
+
+// With 实现 zapcore.Core
+func (c *dynamicCore) With(fields []zapcore.Field) zapcore.Core {
+	return &dynamicCore{sampler: c.sampler, fields: append(c.fields[:len(c.fields):len(c.fields)], fields...)}
+}
+
+// Check 实现 zapcore.Core
+func (c *dynamicCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.sampler.level.Enabled(entry.Level) {
+		return checked
+	}
+	return c.current().Check(entry, checked)
+}
+
+// Write 实现 zapcore.Core
+func (c *dynamicCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.current().Write(entry, fields)
+}
+
+// Sync 实现 zapcore.Core
+func (c *dynamicCore) Sync() error {
+	return c.sampler.base.Sync()
+}
+
+// TraceSampler 可在运行时修改采样比例的 OpenTelemetry 采样器 用作 sdktrace.WithSampler 的参数
+type TraceSampler struct {
+	current atomic.Pointer[traceSampler]
+}
+
+// traceSampler 包装 sdktrace.Sampler 接口值 便于原子替换
+type traceSampler struct {
+	sdktrace.Sampler
+}
+
+// NewTraceSampler 创建链路采样器
+func NewTraceSampler(conf TracingConf) *TraceSampler {
+	s := &TraceSampler{}
+	s.Update(conf)
+	return s
+}
+
+// Update 修改根 span 的采样比例 超出 [0, 1] 的取值按边界处理
+func (s *TraceSampler) Update(conf TracingConf) {
+	rate := min(max(conf.SampleRate, 0), 1)
+	s.current.Store(&traceSampler{sdktrace.ParentBased(sdktrace.TraceIDRatioBased(rate))})
+}
+
+// ShouldSample 实现 sdktrace.Sampler
+func (s *TraceSampler) ShouldSample(params sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	return s.current.Load().ShouldSample(params)
+}
+
+// Description 实现 sdktrace.Sampler
+func (s *TraceSampler) Description() string {
+	return "Dynamic{" + s.current.Load().Description() + "}"
+}
+
+var _ sdktrace.Sampler = (*TraceSampler)(nil)
+
+// BindLogs 在每次配置变更后用 pick 取出的日志配置更新采样器 pick 返回 nil 时保持不变
+// 首份配置加载后同样会应用一次 可以在 Init 之前或之后调用 ctx 结束前未加载配置时不再等待
+// 日志级别非法时处理函数返回错误 启用 WithSyncApply 时该配置会被拒绝
+func BindLogs[T any](ctx context.Context, provider config.ConfigProvider[T], sampler *LogSampler, pick func(*T) *LogConf) {
+	update := func(c *T) error {
+		if conf := pick(c); conf != nil {
+			return sampler.Update(*conf)
+		}
+		return nil
+	}
+	bind(ctx, provider, update)
+}
+
+// BindTraces 在每次配置变更后用 pick 取出的链路配置更新采样器 pick 返回 nil 时保持不变
+// 首份配置加载后同样会应用一次 可以在 Init 之前或之后调用
+func BindTraces[T any](ctx context.Context, provider config.ConfigProvider[T], sampler *TraceSampler, pick func(*T) *TracingConf) {
+	update := func(c *T) error {
+		if conf := pick(c); conf != nil {
+			sampler.Update(*conf)
+		}
+		return nil
+	}
+	bind(ctx, provider, update)
+}
+
+// bind 注册变更处理函数 并在首份配置就绪后应用当前配置 重复应用同一配置没有副作用
+// 变更处理函数成功应用过配置后不再应用就绪时读取的配置 避免较旧的配置覆盖较新的配置
+func bind[T any](ctx context.Context, provider config.ConfigProvider[T], update func(*T) error) {
+	var mu sync.Mutex
+	changed := false
+	provider.OnChange(func(_, newConfig *T) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if err := update(newConfig); err != nil {
+			return err
+		}
+		changed = true
+		return nil
+	})
+	go func() {
+		if provider.WaitReady(ctx) != nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if !changed {
+			_ = update(provider.GetConfig())
+		}
+	}()
+}
@@ -0,0 +1,154 @@
+package sampling
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	config "github.com/omeyang/practices/pkg/conf"
+
+	"github.com/stretchr/testify/assert"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// appConf 测试用的配置类型
+type appConf struct {
+	Log     *LogConf     `yaml:"log"`
+	Tracing *TracingConf `yaml:"tracing"`
+}
+
+// staticProvider 测试用的配置提供者 由测试触发变更
+// 与 WithSyncApply 一致 处理函数在新配置可见之前执行 任一处理函数返回错误时不替换配置
+type staticProvider struct {
+	mu          sync.Mutex
+	config      *appConf
+	handlers    []config.ChangeHandler[appConf]
+	ready       chan struct{} // 为空时配置总是就绪
+	beforeStore func()        // 处理函数执行后 新配置可见之前调用
+}
+
+// GetConfig 实现 config.ConfigProvider
+func (p *staticProvider) GetConfig() *appConf {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.config
+}
+
+// OnChange 实现 config.ConfigProvider
+func (p *staticProvider) OnChange(handler config.ChangeHandler[appConf]) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.handlers = append(p.handlers, handler)
+}
+
+// WaitReady 实现 config.ConfigProvider
+func (p *staticProvider) WaitReady(ctx context.Context) error {
+	if p.ready == nil {
+		return nil
+	}
+	select {
+	case <-p.ready:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// set 调用处理函数后替换配置
+func (p *staticProvider) set(c *appConf) error {
+	p.mu.Lock()
+	old, handlers := p.config, p.handlers
+	p.mu.Unlock()
+	for _, handler := range handlers {
+		if err := handler(old, c); err != nil {
+			return err
+		}
+	}
+	if p.beforeStore != nil {
+		p.beforeStore()
+	}
+	p.mu.Lock()
+	p.config = c
+	p.mu.Unlock()
+	return nil
+}
+
+// TestLogSampler 测试采样参数与日志级别在更新后立即生效 包括 With 派生的 logger
+func TestLogSampler(t *testing.T) {
+	base, logs := observer.New(zap.DebugLevel)
+	sampler, err := NewLogSampler(base, LogConf{Level: "info", Initial: 1, Thereafter: 0, Tick: time.Hour})
+	assert.NoError(t, err)
+	logger := zap.New(sampler.Core()).With(zap.String("component", "api"))
+
+	for i := 0; i < 5; i++ {
+		logger.Info("request")
+	}
+	logger.Debug("details")
+	assert.Equal(t, 1, logs.Len())
+	assert.Equal(t, "api", logs.All()[0].ContextMap()["component"])
+
+	// 关闭采样并调低级别
+	assert.NoError(t, sampler.Update(LogConf{Level: "debug"}))
+	for i := 0; i < 5; i++ {
+		logger.Info("request")
+	}
+	logger.Debug("details")
+	assert.Equal(t, 7, logs.Len())
+
+	assert.Error(t, sampler.Update(LogConf{Level: "verbose"}))
+	assert.Equal(t, zap.DebugLevel, sampler.Level().Level())
+}
+
+// TestTraceSampler 测试链路采样比例的更新
+func TestTraceSampler(t *testing.T) {
+	sampler := NewTraceSampler(TracingConf{SampleRate: 0})
+	params := sdktrace.SamplingParameters{TraceID: trace.TraceID{1}, Name: "GET /"}
+	assert.Equal(t, sdktrace.Drop, sampler.ShouldSample(params).Decision)
+
+	sampler.Update(TracingConf{SampleRate: 2})
+	assert.Equal(t, sdktrace.RecordAndSample, sampler.ShouldSample(params).Decision)
+	assert.Contains(t, sampler.Description(), "TraceIDRatioBased{1}")
+}
+
+// TestBind 测试配置变更驱动采样器更新
+func TestBind(t *testing.T) {
+	provider := &staticProvider{config: &appConf{Tracing: &TracingConf{SampleRate: 1}}}
+	traces := NewTraceSampler(TracingConf{})
+	logs, err := NewLogSampler(zap.NewNop().Core(), LogConf{Level: "info"})
+	assert.NoError(t, err)
+
+	BindTraces(context.Background(), provider, traces, func(c *appConf) *TracingConf { return c.Tracing })
+	BindLogs(context.Background(), provider, logs, func(c *appConf) *LogConf { return c.Log })
+
+	// 首份配置在后台应用
+	params := sdktrace.SamplingParameters{TraceID: trace.TraceID{1}}
+	assert.Eventually(t, func() bool {
+		return traces.ShouldSample(params).Decision == sdktrace.RecordAndSample
+	}, time.Second, time.Millisecond)
+
+	assert.NoError(t, provider.set(&appConf{Log: &LogConf{Level: "warn"}, Tracing: &TracingConf{SampleRate: 0}}))
+	assert.Equal(t, sdktrace.Drop, traces.ShouldSample(params).Decision)
+	assert.Equal(t, zap.WarnLevel, logs.Level().Level())
+
+	assert.Error(t, provider.set(&appConf{Log: &LogConf{Level: "loud"}}))
+}
+
+// TestBindKeepsNewer 测试就绪时读取的旧配置不会覆盖处理函数已应用的新配置
+func TestBindKeepsNewer(t *testing.T) {
+	provider := &staticProvider{config: &appConf{Tracing: &TracingConf{SampleRate: 1}}, ready: make(chan struct{})}
+	traces := NewTraceSampler(TracingConf{})
+	BindTraces(context.Background(), provider, traces, func(c *appConf) *TracingConf { return c.Tracing })
+
+	// 新配置的处理函数执行后 首份配置才就绪 此时读取到的仍是旧配置
+	provider.beforeStore = func() {
+		close(provider.ready)
+		time.Sleep(20 * time.Millisecond)
+	}
+	assert.NoError(t, provider.set(&appConf{Tracing: &TracingConf{SampleRate: 0}}))
+	params := sdktrace.SamplingParameters{TraceID: trace.TraceID{1}}
+	assert.Equal(t, sdktrace.Drop, traces.ShouldSample(params).Decision)
+}
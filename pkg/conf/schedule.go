@@ -0,0 +1,184 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Schedule 重载计划 返回给定时间之后的下一次触发时间 返回零值表示不再触发
+// 与 robfig/cron 的 Schedule 接口兼容
+type Schedule interface {
+	Next(t time.Time) time.Time
+}
+
+// intervalSchedule 固定间隔计划
+type intervalSchedule struct {
+	interval time.Duration
+}
+
+// Every 创建固定间隔的重载计划
+func Every(interval time.Duration) Schedule {
+	return intervalSchedule{interval: interval}
+}
+
+// Next 返回下一次触发时间
+func (s intervalSchedule) Next(t time.Time) time.Time {
+	if s.interval <= 0 {
+		return time.Time{}
+	}
+	return t.Add(s.interval)
+}
+
+// cronField cron 字段取值集合 按位存储
+type cronField uint64
+
+func (f cronField) has(v int) bool {
+	return f&(1<<uint(v)) != 0
+}
+
+// cronSchedule 标准五段式 cron 计划: 分 时 日 月 周
+type cronSchedule struct {
+	minute, hour, dom, month, dow cronField
+	domAny, dowAny                bool // 日/周字段是否为 *
+}
+
+// cronBounds 各字段的取值范围
+var cronBounds = [5]struct{ min, max int }{
+	{0, 59}, // 分
+	{0, 23}, // 时
+	{1, 31}, // 日
+	{1, 12}, // 月
+	{0, 6},  // 周 0 为周日
+}
+
+// ParseCron 解析五段式 cron 表达式 支持 * , - / 语法
+func ParseCron(expr string) (Schedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q: expected 5 fields, got %d", expr, len(fields))
+	}
+
+	var parsed [5]cronField
+	for i, field := range fields {
+		f, err := parseCronField(field, cronBounds[i].min, cronBounds[i].max)
+		if err != nil {
+			return nil, fmt.Errorf("cron expression %q: %w", expr, err)
+		}
+		parsed[i] = f
+	}
+
+	return &cronSchedule{
+		minute: parsed[0],
+		hour:   parsed[1],
+		dom:    parsed[2],
+		month:  parsed[3],
+		dow:    parsed[4],
+		domAny: fields[2] == "*",
+		dowAny: fields[4] == "*",
+	}, nil
+}
+
+// parseCronField 解析单个 cron 字段
+func parseCronField(field string, min, max int) (cronField, error) {
+	var result cronField
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if idx := strings.Index(part, "/"); idx >= 0 {
+			s, err := strconv.Atoi(part[idx+1:])
+			if err != nil || s <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			rangePart, step = part[:idx], s
+		}
+
+		lo, hi := min, max
+		if rangePart != "*" {
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("invalid value in %q", part)
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("invalid value in %q", part)
+				}
+			} else if step > 1 {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("value out of range [%d-%d] in %q", min, max, part)
+		}
+
+		for v := lo; v <= hi; v += step {
+			result |= 1 << uint(v)
+		}
+	}
+	return result, nil
+}
+
+// Next 返回下一次触发时间 五年内无匹配则返回零值
+func (c *cronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if !c.month.has(int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.hour.has(t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if !c.minute.has(t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches 日与周的匹配规则与标准 cron 一致: 两者都受限时满足其一即可
+func (c *cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := c.dom.has(t.Day())
+	dowMatch := c.dow.has(int(t.Weekday()))
+	if c.domAny || c.dowAny {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// runReloadSchedule 按计划定时重载配置 直到 ctx 结束
+func (cm *CfgManager[T]) runReloadSchedule(ctx context.Context) {
+	for {
+		now := cm.opts.clock.Now()
+		next := cm.opts.reloadSchedule.Next(now)
+		if next.IsZero() {
+			cm.logger.Info("Config reload schedule exhausted")
+			return
+		}
+
+		timer := cm.opts.clock.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C():
+			cm.logger.Debug("Scheduled config reload", zap.String("configPath", cm.loader.GetConfigPath()))
+			cm.reloadConfig(ctx)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestEvery 测试固定间隔计划
+func TestEvery(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, now.Add(time.Minute), Every(time.Minute).Next(now))
+	assert.True(t, Every(0).Next(now).IsZero())
+}
+
+// TestParseCron 测试 cron 表达式解析与下次触发时间计算
+func TestParseCron(t *testing.T) {
+	// 2024-01-01 为周一
+	base := time.Date(2024, 1, 1, 10, 30, 15, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		expr     string
+		expected time.Time
+	}{
+		{"Every Minute", "* * * * *", time.Date(2024, 1, 1, 10, 31, 0, 0, time.UTC)},
+		{"Every 15 Minutes", "*/15 * * * *", time.Date(2024, 1, 1, 10, 45, 0, 0, time.UTC)},
+		{"Daily At 3am", "0 3 * * *", time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)},
+		{"Hour Range", "0 9-17 * * *", time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)},
+		{"Sunday", "0 0 * * 0", time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)},
+		{"First Of Month Or Friday", "0 0 1 * 5", time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{"List", "5,40 * * * *", time.Date(2024, 1, 1, 10, 40, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCron(tt.expr)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, schedule.Next(base))
+		})
+	}
+}
+
+// TestParseCronInvalid 测试非法 cron 表达式
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "*/0 * * * *", "a * * * *", "5-1 * * * *"} {
+		_, err := ParseCron(expr)
+		assert.Error(t, err, expr)
+	}
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// ErrConfigNotFound 候选路径中都没有配置文件
+var ErrConfigNotFound = errors.New("config file not found")
+
+// SearchResult 查找配置文件的结果
+type SearchResult struct {
+	Path    string   // 选中的配置文件路径
+	Skipped []string // 在选中路径之前检查过但不存在的路径
+}
+
+// DefaultSearchPaths 返回常用的配置文件候选路径 按优先级从高到低排列:
+//
+//	./<file>
+//	$XDG_CONFIG_HOME/<app>/<file> 未设置时为 ~/.config/<app>/<file>
+//	/etc/<app>/<file>
+func DefaultSearchPaths(app, file string) []string {
+	paths := []string{file}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		paths = append(paths, filepath.Join(dir, app, file))
+	} else if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".config", app, file))
+	}
+	return append(paths, filepath.Join(string(filepath.Separator), "etc", app, file))
+}
+
+// SearchConfig 按顺序查找第一个存在的配置文件 返回选中的路径与跳过的路径 便于启动时记录配置来源
+// 候选路径存在但无法访问或不是普通文件时返回错误 不会静默使用优先级更低的配置文件
+func SearchConfig(fs afero.Fs, candidates ...string) (SearchResult, error) {
+	var result SearchResult
+	for _, candidate := range candidates {
+		path := NormalizePath(candidate)
+		info, err := fs.Stat(path)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			result.Skipped = append(result.Skipped, path)
+			continue
+		case err != nil:
+			return result, fmt.Errorf("stat config %s: %w", path, err)
+		case !info.Mode().IsRegular():
+			return result, fmt.Errorf("config %s is not a regular file", path)
+		}
+		result.Path = path
+		return result, nil
+	}
+	return result, fmt.Errorf("%w: searched %s", ErrConfigNotFound, strings.Join(result.Skipped, ", "))
+}
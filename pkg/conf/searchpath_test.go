@@ -0,0 +1,39 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDefaultSearchPaths 测试候选路径的顺序与 XDG_CONFIG_HOME
+func TestDefaultSearchPaths(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/home/dev/.cfg")
+	assert.Equal(t, []string{
+		"config.yaml",
+		filepath.Join("/home/dev/.cfg", "app", "config.yaml"),
+		filepath.Join(string(filepath.Separator), "etc", "app", "config.yaml"),
+	}, DefaultSearchPaths("app", "config.yaml"))
+}
+
+// TestSearchConfig 测试选中第一个存在的配置文件并报告跳过的路径
+func TestSearchConfig(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte("prometheusCfg: {}\n"), 0o644))
+	assert.NoError(t, afero.WriteFile(fs, "/home/dev/.config/app/config.yaml", []byte("prometheusCfg: {}\n"), 0o644))
+
+	result, err := SearchConfig(fs, "/srv/config.yaml", "/home/dev/.config/app/config.yaml", "/etc/app/config.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, SearchResult{Path: "/home/dev/.config/app/config.yaml", Skipped: []string{"/srv/config.yaml"}}, result)
+
+	// 优先级更高的路径不是普通文件时不会回退到 /etc
+	assert.NoError(t, fs.MkdirAll("/srv/config.yaml", 0o755))
+	_, err = SearchConfig(fs, "/srv/config.yaml", "/etc/app/config.yaml")
+	assert.ErrorContains(t, err, "not a regular file")
+
+	_, err = SearchConfig(fs, "/missing/a.yaml", "/missing/b.yaml")
+	assert.ErrorIs(t, err, ErrConfigNotFound)
+	assert.ErrorContains(t, err, "/missing/a.yaml, /missing/b.yaml")
+}
@@ -0,0 +1,250 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// ErrUnknownSecret 密钥引用无法解析
+var ErrUnknownSecret = errors.New("cannot resolve secret")
+
+// SecretResolver 解析某种方案的密钥引用 ref 为去掉方案前缀后的部分 如 vault:secret/data/db#password 中的 secret/data/db#password
+type SecretResolver interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// SecretResolverFunc 函数形式的 SecretResolver
+type SecretResolverFunc func(ctx context.Context, ref string) (string, error)
+
+// Resolve 实现 SecretResolver
+func (f SecretResolverFunc) Resolve(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+// cachedSecret 缓存的解析结果
+type cachedSecret struct {
+	value   string
+	expires time.Time
+}
+
+// SecretStore 按方案分发密钥引用的解析器 解析结果按 TTL 缓存
+// 同时实现 Schedule 配合 WithReloadSchedule 在缓存过期时重新加载配置 使轮换后的密钥生效
+type SecretStore struct {
+	ttl   time.Duration
+	clock Clock
+
+	mu        sync.Mutex
+	resolvers map[string]SecretResolver
+	cache     map[string]cachedSecret
+}
+
+// SecretOption 密钥解析选项
+type SecretOption func(*SecretStore)
+
+// WithSecretClock 替换缓存过期使用的时钟 默认为 RealClock
+func WithSecretClock(clock Clock) SecretOption {
+	return func(s *SecretStore) {
+		s.clock = clock
+	}
+}
+
+// NewSecretStore 创建密钥解析器集合 已注册 env 与 file 方案 ttl 不大于 0 时不缓存
+func NewSecretStore(ttl time.Duration, opts ...SecretOption) *SecretStore {
+	s := &SecretStore{
+		ttl:   ttl,
+		clock: RealClock,
+		resolvers: map[string]SecretResolver{
+			"env":  EnvSecretResolver(os.LookupEnv),
+			"file": FileSecretResolver(afero.NewOsFs()),
+		},
+		cache: map[string]cachedSecret{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Register 注册或替换方案的解析器 如 vault
+func (s *SecretStore) Register(scheme string, resolver SecretResolver) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resolvers[scheme] = resolver
+}
+
+// Resolve 解析单个取值 取值不是已注册方案的引用时 ok 为 false
+func (s *SecretStore) Resolve(ctx context.Context, value string) (resolved string, ok bool, err error) {
+	scheme, ref, found := strings.Cut(value, ":")
+	if !found {
+		return value, false, nil
+	}
+	s.mu.Lock()
+	resolver := s.resolvers[scheme]
+	cached, hit := s.cache[value]
+	s.mu.Unlock()
+	if resolver == nil {
+		return value, false, nil
+	}
+
+	now := s.clock.Now()
+	if hit && now.Before(cached.expires) {
+		return cached.value, true, nil
+	}
+	resolved, err = resolver.Resolve(ctx, ref)
+	if err != nil {
+		return value, true, fmt.Errorf("%w %s: %w", ErrUnknownSecret, value, err)
+	}
+	if s.ttl > 0 {
+		s.mu.Lock()
+		s.cache[value] = cachedSecret{value: resolved, expires: now.Add(s.ttl)}
+		s.mu.Unlock()
+	}
+	return resolved, true, nil
+}
+
+// Next 实现 Schedule 返回最早的缓存过期时间 不缓存时返回零值
+func (s *SecretStore) Next(t time.Time) time.Time {
+	if s.ttl <= 0 {
+		return time.Time{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next := t.Add(s.ttl)
+	for _, cached := range s.cache {
+		if cached.expires.After(t) && cached.expires.Before(next) {
+			next = cached.expires
+		}
+	}
+	return next
+}
+
+// ResolveSecrets 返回将配置树中的密钥引用替换为解析结果的配置树变换
+// 只有整个字符串取值为已注册方案的引用时才会解析 如 vault:secret/data/db#password 或 file:/run/secrets/token
+func ResolveSecrets(ctx context.Context, store *SecretStore) Transform {
+	return func(tree map[string]any) error {
+		var errs MultiError
+		for key, value := range tree {
+			tree[key] = resolveSecretValue(ctx, store, value, key, &errs)
+		}
+		return errs.ErrorOrNil()
+	}
+}
+
+// resolveSecretValue 递归解析配置树中的密钥引用
+func resolveSecretValue(ctx context.Context, store *SecretStore, node any, path string, errs *MultiError) any {
+	switch n := node.(type) {
+	case map[string]any:
+		for key, child := range n {
+			n[key] = resolveSecretValue(ctx, store, child, joinPath(path, key), errs)
+		}
+		return n
+	case []any:
+		for i, item := range n {
+			n[i] = resolveSecretValue(ctx, store, item, indexPath(path, i), errs)
+		}
+		return n
+	case string:
+		resolved, _, err := store.Resolve(ctx, n)
+		if err != nil {
+			errs.Append(&FieldError{Path: path, Message: err.Error(), Code: CodeSecretUnresolved, Params: []any{err.Error()}, Err: err})
+			return n
+		}
+		return resolved
+	default:
+		return node
+	}
+}
+
+// EnvSecretResolver 以环境变量解析 env:NAME 形式的引用 lookup 通常为 os.LookupEnv
+func EnvSecretResolver(lookup func(string) (string, bool)) SecretResolver {
+	return SecretResolverFunc(func(_ context.Context, name string) (string, error) {
+		value, ok := lookup(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return value, nil
+	})
+}
+
+// FileSecretResolver 以文件内容解析 file:/run/secrets/token 形式的引用 去掉末尾的换行
+func FileSecretResolver(fs afero.Fs) SecretResolver {
+	return SecretResolverFunc(func(_ context.Context, path string) (string, error) {
+		data, err := afero.ReadFile(fs, path)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	})
+}
+
+// VaultSecretResolver 通过 Vault HTTP API 解析 vault:secret/data/db#password 形式的引用
+// # 之前为 API 路径 之后为字段名 同时支持 KV v2 与 KV v1 的响应格式
+type VaultSecretResolver struct {
+	Address   string // Vault 地址 如 https://vault:8200
+	Token     string // 访问令牌
+	Namespace string // 企业版命名空间 可选
+	Client    *http.Client
+}
+
+// NewVaultSecretResolver 创建 Vault 解析器 client 为空时使用 http.DefaultClient
+func NewVaultSecretResolver(address, token string, client *http.Client) *VaultSecretResolver {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &VaultSecretResolver{Address: strings.TrimRight(address, "/"), Token: token, Client: client}
+}
+
+// Resolve 实现 SecretResolver
+func (v *VaultSecretResolver) Resolve(ctx context.Context, ref string) (string, error) {
+	path, field, ok := strings.Cut(ref, "#")
+	if !ok || field == "" {
+		return "", fmt.Errorf("vault reference %q: missing #field", ref)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.Address+"/v1/"+strings.TrimLeft(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", v.Token)
+	if v.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.Namespace)
+	}
+
+	resp, err := v.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault read %s: unexpected status %s", path, resp.Status)
+	}
+	var body struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("vault read %s: %w", path, err)
+	}
+	// KV v2 的字段位于 data.data 中
+	data := body.Data
+	if nested, ok := data["data"].(map[string]any); ok {
+		if _, isMeta := data["metadata"]; isMeta {
+			data = nested
+		}
+	}
+	value, ok := data[field]
+	if !ok {
+		return "", fmt.Errorf("vault read %s: field %q not found", path, field)
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	return fmt.Sprint(value), nil
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestResolveSecrets 测试配置树中的密钥引用按方案解析 其余取值保持不变
+func TestResolveSecrets(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NoError(t, afero.WriteFile(fs, "/run/secrets/token", []byte("s3cr3t\n"), 0o600))
+
+	store := NewSecretStore(0)
+	store.Register("env", EnvSecretResolver(func(name string) (string, bool) {
+		return "from-" + name, name == "DB_USER"
+	}))
+	store.Register("file", FileSecretResolver(fs))
+
+	tree := map[string]any{
+		"db":    map[string]any{"user": "env:DB_USER", "hosts": []any{"http://db:5432"}},
+		"token": "file:/run/secrets/token",
+		"port":  5432,
+	}
+	assert.NoError(t, ResolveSecrets(context.Background(), store)(tree))
+	assert.Equal(t, map[string]any{
+		"db":    map[string]any{"user": "from-DB_USER", "hosts": []any{"http://db:5432"}},
+		"token": "s3cr3t",
+		"port":  5432,
+	}, tree)
+
+	err := ResolveSecrets(context.Background(), store)(map[string]any{"password": "env:DB_PASSWORD"})
+	var fieldErr *FieldError
+	assert.ErrorAs(t, err, &fieldErr)
+	assert.Equal(t, "password", fieldErr.Path)
+	assert.ErrorIs(t, err, ErrUnknownSecret)
+}
+
+// TestSecretStore_Cache 测试解析结果按 TTL 缓存 过期后重新解析
+func TestSecretStore_Cache(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	store := NewSecretStore(time.Minute, WithSecretClock(clock))
+	calls := 0
+	store.Register("counter", SecretResolverFunc(func(_ context.Context, ref string) (string, error) {
+		calls++
+		return fmt.Sprintf("%s-%d", ref, calls), nil
+	}))
+
+	ctx := context.Background()
+	value, ok, err := store.Resolve(ctx, "counter:x")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "x-1", value)
+	value, _, _ = store.Resolve(ctx, "counter:x")
+	assert.Equal(t, "x-1", value)
+	assert.Equal(t, time.Unix(60, 0), store.Next(clock.Now()))
+
+	clock.Advance(30 * time.Second)
+	_, _, _ = store.Resolve(ctx, "counter:y")
+	assert.Equal(t, time.Unix(60, 0), store.Next(clock.Now()))
+
+	clock.Advance(30 * time.Second)
+	value, _, _ = store.Resolve(ctx, "counter:x")
+	assert.Equal(t, "x-3", value)
+	assert.Equal(t, time.Unix(90, 0), store.Next(clock.Now()))
+
+	_, ok, err = store.Resolve(ctx, "unknown:x")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.True(t, NewSecretStore(0).Next(clock.Now()).IsZero())
+}
+
+// TestVaultSecretResolver 测试读取 Vault KV v2 与 KV v1 的字段
+func TestVaultSecretResolver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			http.Error(w, "permission denied", http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/db":
+			fmt.Fprint(w, `{"data":{"data":{"password":"pw","port":5432},"metadata":{"version":3}}}`)
+		case "/v1/kv/db":
+			fmt.Fprint(w, `{"data":{"password":"v1pw"}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	vault := NewVaultSecretResolver(server.URL+"/", "root", server.Client())
+	value, err := vault.Resolve(ctx, "secret/data/db#password")
+	assert.NoError(t, err)
+	assert.Equal(t, "pw", value)
+	value, err = vault.Resolve(ctx, "secret/data/db#port")
+	assert.NoError(t, err)
+	assert.Equal(t, "5432", value)
+	value, err = vault.Resolve(ctx, "kv/db#password")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1pw", value)
+
+	_, err = vault.Resolve(ctx, "secret/data/db#missing")
+	assert.ErrorContains(t, err, `field "missing" not found`)
+	_, err = vault.Resolve(ctx, "secret/data/db")
+	assert.ErrorContains(t, err, "missing #field")
+	_, err = NewVaultSecretResolver(server.URL, "bad", nil).Resolve(ctx, "secret/data/db#password")
+	assert.ErrorContains(t, err, "403")
+}
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// defaultSectionBackoff 重试策略未设置 Backoff 时配置段处理函数的重试间隔
+var defaultSectionBackoff Backoff = ExponentialBackoff{Initial: time.Second, Max: time.Minute, Jitter: 0.2}
+
+// HandlerFailure 配置段处理函数的失败状态 处理函数成功后清除
+type HandlerFailure struct {
+	Section   string    `json:"section"`   // 配置段
+	Error     string    `json:"error"`     // 最近一次的错误
+	Attempts  int       `json:"attempts"`  // 连续失败的次数
+	Since     time.Time `json:"since"`     // 首次失败的时间
+	NextRetry time.Time `json:"nextRetry"` // 下次重试的时间
+}
+
+// sectionHandler 配置段处理函数
+type sectionHandler[T any] struct {
+	section string
+	fn      ChangeHandler[T]
+}
+
+// sectionHandlers 配置段处理函数的失败状态
+type sectionHandlers[T any] struct {
+	mu       sync.Mutex
+	failures map[*sectionHandler[T]]*HandlerFailure
+}
+
+// OnSectionChange 注册只在配置段变化时调用的处理函数 如 prometheusCfg
+//
+// 每个处理函数在独立的协程中执行 失败时记录到 Status 并按重试策略的 Backoff 只重试该处理函数
+// 不影响配置生效与其他处理函数 重试前收到新配置时改为处理最新的配置
+// oldConfig 为该处理函数上一次成功处理的配置 不受 WithSyncApply 影响
+func (cm *CfgManager[T]) OnSectionChange(section string, handler ChangeHandler[T]) {
+	h := &sectionHandler[T]{section: section, fn: handler}
+	applied, _ := cm.config.Load().(*T)
+	sub := cm.Subscribe(WithSections(section))
+	go cm.runSectionHandler(h, sub, applied)
+}
+
+// runSectionHandler 处理配置段的变化 失败时等待后重试 订阅关闭时退出
+func (cm *CfgManager[T]) runSectionHandler(h *sectionHandler[T], sub *Subscription[T], applied *T) {
+	defer cm.sections.clear(h)
+	backoff := cm.retryPolicy.Backoff
+	if backoff == nil {
+		backoff = defaultSectionBackoff
+	}
+
+	var (
+		pending *T
+		attempt int
+		timer   Timer
+		retry   <-chan time.Time
+	)
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+	for {
+		select {
+		case config, ok := <-sub.C():
+			if !ok {
+				return
+			}
+			if timer != nil {
+				timer.Stop()
+			}
+			pending, attempt, retry = config, 0, nil
+		case <-retry:
+			retry = nil
+		}
+
+		attempt++
+		err := h.fn(applied, pending)
+		if err == nil {
+			applied = pending
+			if cm.sections.clear(h) {
+				cm.logger.Info("Section change handler recovered", zap.String("section", h.section))
+			}
+			continue
+		}
+		wait := backoff.Next(attempt)
+		failure := cm.sections.fail(h, err, cm.opts.clock.Now(), wait)
+		cm.logger.Warn("Section change handler failed, retrying", zap.String("section", h.section), zap.Int("attempts", failure.Attempts), zap.Duration("wait", wait), zap.Error(err))
+		timer = cm.opts.clock.NewTimer(wait)
+		retry = timer.C()
+	}
+}
+
+// fail 记录一次失败 返回更新后的失败状态
+func (s *sectionHandlers[T]) fail(h *sectionHandler[T], err error, now time.Time, wait time.Duration) HandlerFailure {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failures == nil {
+		s.failures = map[*sectionHandler[T]]*HandlerFailure{}
+	}
+	failure := s.failures[h]
+	if failure == nil {
+		failure = &HandlerFailure{Section: h.section, Since: now}
+		s.failures[h] = failure
+	}
+	failure.Error = err.Error()
+	failure.Attempts++
+	failure.NextRetry = now.Add(wait)
+	return *failure
+}
+
+// clear 清除失败状态 返回之前是否处于失败状态
+func (s *sectionHandlers[T]) clear(h *sectionHandler[T]) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, failed := s.failures[h]
+	delete(s.failures, h)
+	return failed
+}
+
+// snapshot 返回当前的失败状态 按配置段与首次失败时间排序
+func (s *sectionHandlers[T]) snapshot() []HandlerFailure {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.failures) == 0 {
+		return nil
+	}
+	failures := make([]HandlerFailure, 0, len(s.failures))
+	for _, failure := range s.failures {
+		failures = append(failures, *failure)
+	}
+	sort.Slice(failures, func(i, j int) bool {
+		if failures[i].Section != failures[j].Section {
+			return failures[i].Section < failures[j].Section
+		}
+		return failures[i].Since.Before(failures[j].Since)
+	})
+	return failures
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/omeyang/practices/internal/entity"
+	mocks "github.com/omeyang/practices/mocks/conf"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+// TestCfgManager_OnSectionChange 测试配置段处理函数失败时单独重试 并在 Status 中报告
+func TestCfgManager_OnSectionChange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return("/path/to/config").AnyTimes()
+
+	clock := NewFakeClock(time.Unix(0, 0))
+	cm := NewConfigManager[entity.AppConf](mockLoader, nil, zap.NewNop(), RetryPolicy{Backoff: ConstantBackoff(time.Second)}, WithClock(clock))
+
+	calls := make(chan [2]*entity.AppConf)
+	failures := 2
+	cm.OnSectionChange("prometheusCfg", func(oldConfig, newConfig *entity.AppConf) error {
+		calls <- [2]*entity.AppConf{oldConfig, newConfig}
+		if failures > 0 {
+			failures--
+			return errors.New("listener busy")
+		}
+		return nil
+	})
+	next := func() [2]*entity.AppConf {
+		select {
+		case call := <-calls:
+			return call
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for section handler")
+			return [2]*entity.AppConf{}
+		}
+	}
+
+	// 处理函数失败不影响配置生效
+	ctx := context.Background()
+	first := &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9090}}
+	assert.NoError(t, cm.Set(ctx, first))
+	assert.Equal(t, [2]*entity.AppConf{nil, first}, next())
+	clock.BlockUntil(1)
+	assert.Same(t, first, cm.GetConfig())
+	assert.Equal(t, []HandlerFailure{{
+		Section:   "prometheusCfg",
+		Error:     "listener busy",
+		Attempts:  1,
+		Since:     time.Unix(0, 0),
+		NextRetry: time.Unix(1, 0),
+	}}, cm.Status().FailedHandlers)
+
+	clock.Advance(time.Second)
+	assert.Equal(t, [2]*entity.AppConf{nil, first}, next())
+	clock.BlockUntil(1)
+	assert.Equal(t, 2, cm.Status().FailedHandlers[0].Attempts)
+
+	// 等待重试期间收到新配置时立即处理最新的配置
+	second := &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9091}}
+	assert.NoError(t, cm.Set(ctx, second))
+	assert.Equal(t, [2]*entity.AppConf{nil, second}, next())
+	assert.Eventually(t, func() bool { return cm.Status().FailedHandlers == nil }, time.Second, time.Millisecond)
+
+	// 配置段未变化时不调用
+	assert.NoError(t, cm.Set(ctx, &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9091}}))
+	third := &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9092}}
+	assert.NoError(t, cm.Set(ctx, third))
+	assert.Equal(t, [2]*entity.AppConf{second, third}, next())
+}
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ErrNoShadowConfig 没有可提升的影子配置
+var ErrNoShadowConfig = errors.New("no shadow config to promote")
+
+// shadowSlot 影子配置 用于在不影响生效配置的情况下评估候选配置
+type shadowSlot[T any] struct {
+	mu          sync.RWMutex
+	config      *T
+	loadedAt    time.Time
+	subscribers subscribers[T]
+}
+
+// LoadShadow 以 loader 加载候选配置并设为影子配置 如指向新版本的加载器
+// 生效的配置 订阅者与变更处理函数都不受影响
+func (cm *CfgManager[T]) LoadShadow(ctx context.Context, loader CfgLoader[T]) error {
+	candidate, err := loader.LoadConfig(ctx)
+	if err != nil {
+		cm.logger.Warn("Failed to load shadow config", zap.Error(err), zap.String("configPath", loader.GetConfigPath()))
+		return err
+	}
+	return cm.SetShadow(ctx, candidate)
+}
+
+// SetShadow 将 config 设为影子配置 取代之前的影子配置 并投递给 SubscribeShadow 的订阅者
+// 与 DryRun 一样设置默认值并执行校验与探测 不执行重载钩子 生效的配置不受影响
+// 服务可以同时按 GetConfig 与 GetShadowConfig 计算 比较新旧配置下的行为 如路由决策
+func (cm *CfgManager[T]) SetShadow(ctx context.Context, config *T) error {
+	if config == nil {
+		return errors.New("config is nil")
+	}
+	if err := cm.applyDefaults(config); err != nil {
+		return err
+	}
+	if err := cm.Validate(config); err != nil {
+		cm.logger.Warn("Shadow config failed validation", zap.Error(err), zap.String("configPath", cm.loader.GetConfigPath()))
+		return err
+	}
+	if err := cm.Probe(ctx, config); err != nil {
+		cm.logger.Warn("Shadow config failed probes", zap.Error(err), zap.String("configPath", cm.loader.GetConfigPath()))
+		return err
+	}
+
+	cm.shadow.mu.Lock()
+	cm.shadow.config, cm.shadow.loadedAt = config, cm.opts.clock.Now()
+	cm.shadow.mu.Unlock()
+	cm.shadow.subscribers.publish(config)
+	cm.logger.Info("Shadow config loaded", zap.String("configPath", cm.loader.GetConfigPath()))
+	return nil
+}
+
+// GetShadowConfig 返回影子配置 没有时返回 nil 与 GetConfig 一样不应修改 启用 WithCopyOnRead 时返回深拷贝
+func (cm *CfgManager[T]) GetShadowConfig() *T {
+	cm.shadow.mu.RLock()
+	config := cm.shadow.config
+	cm.shadow.mu.RUnlock()
+	if cm.opts.copyOnRead {
+		return DeepCopy(config)
+	}
+	return config
+}
+
+// ClearShadow 丢弃影子配置 订阅者不会收到通知
+func (cm *CfgManager[T]) ClearShadow() {
+	cm.shadow.mu.Lock()
+	defer cm.shadow.mu.Unlock()
+	if cm.shadow.config != nil {
+		cm.logger.Info("Shadow config cleared", zap.String("configPath", cm.loader.GetConfigPath()))
+	}
+	cm.shadow.config, cm.shadow.loadedAt = nil, time.Time{}
+}
+
+// SubscribeShadow 订阅影子配置的变化 每次设置影子配置时投递 选项与 Subscribe 相同 使用完毕后需调用 Close
+func (cm *CfgManager[T]) SubscribeShadow(opts ...SubscribeOption) *Subscription[T] {
+	cm.shadow.mu.RLock()
+	current := cm.shadow.config
+	cm.shadow.mu.RUnlock()
+	return cm.shadow.subscribers.subscribe(current, opts)
+}
+
+// shadowLoadedAt 返回影子配置的设置时间 没有影子配置时返回 nil
+func (cm *CfgManager[T]) shadowLoadedAt() *time.Time {
+	cm.shadow.mu.RLock()
+	defer cm.shadow.mu.RUnlock()
+	if cm.shadow.config == nil {
+		return nil
+	}
+	at := cm.shadow.loadedAt
+	return &at
+}
+
+// PromoteShadow 将影子配置提升为生效的配置 并清除影子配置 实现先验证后应用的两步发布
+// 与重载一样重新执行校验 探测与重载前后的钩子 遵循生效时间 错峰与重启键的规则 不执行异常检查
+// 任一步骤失败时影子配置与生效的配置都保持不变 提升期间影子配置被替换时保留新的影子配置
+func (cm *CfgManager[T]) PromoteShadow(ctx context.Context) error {
+	if err := cm.guardMutation("promote"); err != nil {
+		return err
+	}
+	cm.reloadMu.Lock()
+	defer cm.reloadMu.Unlock()
+	cm.shadow.mu.RLock()
+	config := cm.shadow.config
+	cm.shadow.mu.RUnlock()
+	if config == nil {
+		return ErrNoShadowConfig
+	}
+
+	if err := cm.Validate(config); err != nil {
+		return err
+	}
+	if err := cm.Probe(ctx, config); err != nil {
+		return err
+	}
+	if err := cm.runPreReloadHooks(config); err != nil {
+		cm.logger.Error("Shadow config promotion vetoed by hook", zap.Error(err), zap.String("configPath", cm.loader.GetConfigPath()))
+		return err
+	}
+	oldConfig, applied, err := cm.applyReloaded(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	cm.shadow.mu.Lock()
+	if cm.shadow.config == config {
+		cm.shadow.config, cm.shadow.loadedAt = nil, time.Time{}
+	}
+	cm.shadow.mu.Unlock()
+	cm.logger.Info("Shadow config promoted", zap.Bool("applied", applied), zap.String("configPath", cm.loader.GetConfigPath()))
+	if applied {
+		cm.runPostReloadHooks(oldConfig, config)
+	}
+	return nil
+}
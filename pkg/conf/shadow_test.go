@@ -0,0 +1,113 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/omeyang/practices/internal/entity"
+	mocks "github.com/omeyang/practices/mocks/conf"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+// TestCfgManager_Shadow 测试影子配置经过校验后单独生效 不影响当前配置与其订阅者
+func TestCfgManager_Shadow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return("/etc/app/v1.yaml").AnyTimes()
+	candidateLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	candidateLoader.EXPECT().GetConfigPath().Return("/etc/app/v2.yaml").AnyTimes()
+
+	clock := NewFakeClock(time.Unix(100, 0))
+	cm := NewConfigManager[entity.AppConf](mockLoader, nil, zap.NewNop(), RetryPolicy{}, WithClock(clock))
+	primary := &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9090}}
+	assert.NoError(t, cm.storeConfig(primary))
+	cm.AddValidator(ValidatorFunc[entity.AppConf](func(config *entity.AppConf) error {
+		if config.PrometheusCfg == nil {
+			return errors.New("prometheusCfg is missing")
+		}
+		return nil
+	}))
+	assert.Nil(t, cm.GetShadowConfig())
+
+	primarySub := cm.Subscribe()
+	defer primarySub.Close()
+	shadowSub := cm.SubscribeShadow()
+	defer shadowSub.Close()
+
+	ctx := context.Background()
+	candidate := &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9100}}
+	candidateLoader.EXPECT().LoadConfig(ctx).Return(candidate, nil)
+	assert.NoError(t, cm.LoadShadow(ctx, candidateLoader))
+	select {
+	case config := <-shadowSub.C():
+		assert.Same(t, candidate, config)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for shadow config")
+	}
+	assert.Same(t, candidate, cm.GetShadowConfig())
+	assert.Same(t, primary, cm.GetConfig())
+	assert.Equal(t, time.Unix(100, 0), *cm.Status().ShadowLoaded)
+	select {
+	case <-primarySub.C():
+		t.Fatal("shadow config delivered to primary subscriber")
+	default:
+	}
+
+	// 未通过校验的候选配置不取代当前的影子配置
+	assert.ErrorContains(t, cm.SetShadow(ctx, &entity.AppConf{}), "prometheusCfg is missing")
+	assert.Same(t, candidate, cm.GetShadowConfig())
+
+	candidateLoader.EXPECT().LoadConfig(ctx).Return(nil, errors.New("not found"))
+	assert.ErrorContains(t, cm.LoadShadow(ctx, candidateLoader), "not found")
+	assert.Same(t, candidate, cm.GetShadowConfig())
+
+	cm.ClearShadow()
+	assert.Nil(t, cm.GetShadowConfig())
+	assert.Nil(t, cm.Status().ShadowLoaded)
+}
+
+// TestCfgManager_PromoteShadow 测试提升影子配置时执行钩子 否决时两份配置都保持不变
+func TestCfgManager_PromoteShadow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return("/path/to/config").AnyTimes()
+
+	cm := NewConfigManager[entity.AppConf](mockLoader, nil, zap.NewNop(), RetryPolicy{})
+	primary := &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9090}}
+	assert.NoError(t, cm.storeConfig(primary))
+
+	ctx := context.Background()
+	assert.ErrorIs(t, cm.PromoteShadow(ctx), ErrNoShadowConfig)
+
+	veto := true
+	var promoted [2]*entity.AppConf
+	cm.RegisterHook(func(*entity.AppConf) error {
+		if veto {
+			return errors.New("canary still running")
+		}
+		return nil
+	}, func(oldConfig, newConfig *entity.AppConf) {
+		promoted = [2]*entity.AppConf{oldConfig, newConfig}
+	})
+
+	candidate := &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9100}}
+	assert.NoError(t, cm.SetShadow(ctx, candidate))
+	assert.ErrorIs(t, cm.PromoteShadow(ctx), ErrReloadVetoed)
+	assert.Same(t, primary, cm.GetConfig())
+	assert.Same(t, candidate, cm.GetShadowConfig())
+
+	veto = false
+	assert.NoError(t, cm.PromoteShadow(ctx))
+	assert.Same(t, candidate, cm.GetConfig())
+	assert.Nil(t, cm.GetShadowConfig())
+	assert.Equal(t, [2]*entity.AppConf{primary, candidate}, promoted)
+}
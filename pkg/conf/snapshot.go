@@ -0,0 +1,44 @@
+package config
+
+import (
+	"math/bits"
+	"math/rand"
+	"sync/atomic"
+)
+
+// snapshotPad 分片的填充大小 覆盖 128 字节缓存行的 arm64 处理器 并避免相邻缓存行预取造成的伪共享
+const snapshotPad = 128
+
+// snapshotShard 独占缓存行的配置指针
+type snapshotShard[T any] struct {
+	config atomic.Pointer[T]
+	_      [snapshotPad - 8]byte
+}
+
+// shardedSnapshot 按分片复制的配置指针 读取时随机选择分片 各核心不争用同一缓存行
+type shardedSnapshot[T any] struct {
+	shards []snapshotShard[T]
+	mask   uint32
+}
+
+// newShardedSnapshot 创建分片快照 分片数向上取整为 2 的幂
+func newShardedSnapshot[T any](shards int) *shardedSnapshot[T] {
+	n := 1 << bits.Len(uint(max(shards, 1)-1))
+	return &shardedSnapshot[T]{shards: make([]snapshotShard[T], n), mask: uint32(n - 1)}
+}
+
+// load 读取任一分片的配置
+func (s *shardedSnapshot[T]) load() *T {
+	// 未设置种子的 math/rand 全局函数使用运行时的每线程随机数 不会引入共享状态
+	return s.shards[rand.Uint32()&s.mask].config.Load()
+}
+
+// store 将配置写入全部分片 未启用时不做任何事
+func (s *shardedSnapshot[T]) store(config *T) {
+	if s == nil {
+		return
+	}
+	for i := range s.shards {
+		s.shards[i].config.Store(config)
+	}
+}
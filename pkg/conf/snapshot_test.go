@@ -0,0 +1,29 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/omeyang/practices/internal/entity"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// TestShardedSnapshot 测试分片数取整为 2 的幂 写入后每个分片都指向新配置
+func TestShardedSnapshot(t *testing.T) {
+	for shards, want := range map[int]int{0: 1, 1: 1, 3: 4, 8: 8} {
+		assert.Len(t, newShardedSnapshot[entity.AppConf](shards).shards, want, shards)
+	}
+
+	cm := NewConfigManager[entity.AppConf](nil, nil, zap.NewNop(), RetryPolicy{}, WithShardedSnapshot(3))
+	assert.Nil(t, cm.GetConfig())
+
+	config := &entity.AppConf{}
+	assert.NoError(t, cm.storeConfig(config))
+	for i := range cm.snapshot.shards {
+		assert.Same(t, config, cm.snapshot.shards[i].config.Load())
+	}
+	for i := 0; i < 100; i++ {
+		assert.Same(t, config, cm.GetConfig())
+	}
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Layered 按优先级合并多个配置源的加载器 如 MultiSourceLoader
+type Layered interface {
+	Sources() []string          // 配置源名称 按优先级从低到高排列
+	Origins() map[string]string // 上次加载中每个叶子键路径的来源
+}
+
+// LayerReport 单个配置源在生效配置中提供的键
+type LayerReport struct {
+	Source string   `json:"source"`         // 配置源名称
+	Keys   []string `json:"keys,omitempty"` // 生效取值来自该配置源的键路径
+}
+
+// StartupReport 首次加载配置后的汇总信息 便于排查启动问题
+type StartupReport struct {
+	Source   string        `json:"source"`             // 配置来源
+	Version  string        `json:"version,omitempty"`  // 配置版本 加载器未实现 Versioned 时为空
+	Instance string        `json:"instance"`           // 实例标识 变体按其分桶
+	Layers   []LayerReport `json:"layers,omitempty"`   // 各配置源提供的键 加载器未实现 Layered 时为空
+	Watching []string      `json:"watching,omitempty"` // 正在监听的配置文件
+	Polling  bool          `json:"polling"`            // 是否已降级为轮询监听
+	Warnings []string      `json:"warnings,omitempty"` // 不影响启动的问题
+	Duration time.Duration `json:"duration"`           // 首次加载耗时
+}
+
+// StartupReport 返回首次加载配置后的汇总信息 启用 WithStartupReport 且 Init 成功前返回 nil
+func (cm *CfgManager[T]) StartupReport() *StartupReport {
+	cm.rwMutex.RLock()
+	defer cm.rwMutex.RUnlock()
+	return cm.startup
+}
+
+// buildStartupReport 汇总首次加载的结果
+func (cm *CfgManager[T]) buildStartupReport(start time.Time, warnings []string) *StartupReport {
+	report := &StartupReport{
+		Source:   cm.loader.GetConfigPath(),
+		Instance: cm.opts.instance,
+		Watching: []string{cm.loader.GetConfigPath()},
+		Warnings: warnings,
+		Duration: cm.opts.clock.Now().Sub(start),
+	}
+	if v, ok := cm.loader.(Versioned); ok {
+		report.Version = v.Version()
+	}
+	if layered, ok := cm.loader.(Layered); ok {
+		report.Layers = layerReports(layered.Sources(), layered.Origins())
+	}
+	cm.watchers.mu.Lock()
+	report.Polling = cm.watchers.poller != nil
+	cm.watchers.mu.Unlock()
+	return report
+}
+
+// layerReports 按配置源分组键路径 没有提供任何键的配置源同样列出
+func layerReports(sources []string, origins map[string]string) []LayerReport {
+	keys := map[string][]string{}
+	for path, source := range origins {
+		keys[source] = append(keys[source], path)
+	}
+	layers := make([]LayerReport, 0, len(sources))
+	for _, source := range sources {
+		sort.Strings(keys[source])
+		layers = append(layers, LayerReport{Source: source, Keys: keys[source]})
+	}
+	return layers
+}
+
+// logStartupReport 以一条结构化日志输出启动汇总 便于检索
+func (cm *CfgManager[T]) logStartupReport(report *StartupReport) {
+	fields := []zap.Field{
+		zap.String("source", report.Source),
+		zap.String("version", report.Version),
+		zap.String("instance", report.Instance),
+		zap.Strings("watching", report.Watching),
+		zap.Bool("polling", report.Polling),
+		zap.Duration("duration", report.Duration),
+	}
+	if len(report.Layers) > 0 {
+		fields = append(fields, zap.Any("layers", report.Layers))
+	}
+	if len(report.Warnings) > 0 {
+		fields = append(fields, zap.Strings("warnings", report.Warnings))
+	}
+	cm.logger.Info("Config startup report", fields...)
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"context"
+	"testing"
+
+	"github.com/omeyang/practices/internal/entity"
+	mocks "github.com/omeyang/practices/mocks/conf"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+// TestCfgManager_StartupReport 测试 Init 成功后汇总各配置源提供的键
+func TestCfgManager_StartupReport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fs := afero.NewMemMapFs()
+	assert.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte("prometheusCfg:\n  enable: true\n  port: 9090\n"), 0o600))
+	assert.NoError(t, afero.WriteFile(fs, "/etc/app/prod.yaml", []byte("prometheusCfg:\n  port: 9100\n"), 0o600))
+	loader := NewMultiSourceLoader[entity.AppConf](zap.NewNop(),
+		FileSource(fs, "/etc/app/config.yaml"), OptionalFileSource(fs, "/etc/app/local.yaml"), FileSource(fs, "/etc/app/prod.yaml"))
+
+	mockWatcher := mocks.NewMockWatcherInterface(ctrl)
+	mockWatcher.EXPECT().Add("/etc/app/config.yaml").Return(nil)
+	mockWatcher.EXPECT().Events().Return(nil).AnyTimes()
+	mockWatcher.EXPECT().Errors().Return(nil).AnyTimes()
+	mockWatcher.EXPECT().Close().Return(nil).AnyTimes()
+
+	cm := NewConfigManager[entity.AppConf](loader, mockWatcher, zap.NewNop(), RetryPolicy{}, WithInstance("host-1"), WithStartupReport())
+	assert.Nil(t, cm.StartupReport())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.NoError(t, cm.Init(ctx))
+
+	report := cm.StartupReport()
+	assert.NotNil(t, report)
+	assert.Equal(t, "/etc/app/config.yaml", report.Source)
+	assert.Equal(t, "host-1", report.Instance)
+	assert.Equal(t, []string{"/etc/app/config.yaml"}, report.Watching)
+	assert.False(t, report.Polling)
+	assert.Empty(t, report.Warnings)
+	assert.Equal(t, []LayerReport{
+		{Source: "/etc/app/config.yaml", Keys: []string{"prometheusCfg.enable"}},
+		{Source: "/etc/app/local.yaml"},
+		{Source: "/etc/app/prod.yaml", Keys: []string{"prometheusCfg.port"}},
+	}, report.Layers)
+}
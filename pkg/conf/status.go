@@ -0,0 +1,64 @@
+package config
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Status 配置管理器的运行状态 供管理端展示
+type Status struct {
+	Source            string           `json:"source"`                      // 配置来源
+	Version           string           `json:"version,omitempty"`           // 当前配置版本 加载器未实现 Versioned 时为空
+	Instance          string           `json:"instance"`                    // 实例标识
+	ReadOnly          bool             `json:"readOnly"`                    // 是否为只读模式
+	PendingActivation *time.Time       `json:"pendingActivation,omitempty"` // 等待生效配置的生效时间
+	RestartRequired   *ChangeSet       `json:"restartRequired,omitempty"`   // 等待重启生效的变更 敏感取值已脱敏
+	FailedHandlers    []HandlerFailure `json:"failedHandlers,omitempty"`    // 正在重试的配置段处理函数
+	SourceHealth      *SourceHealth    `json:"sourceHealth,omitempty"`      // 配置源的连通状态 启用 WithSourceHealthCheck 时记录
+	ApprovalRequired  []Anomaly        `json:"approvalRequired,omitempty"`  // 等待批准的配置中发现的异常
+	ShadowLoaded      *time.Time       `json:"shadowLoaded,omitempty"`      // 影子配置的设置时间
+	Watcher           *WatcherStatus   `json:"watcher,omitempty"`           // 文件监听的路径与事件统计 未配置监听器时为空
+	Reconcile         *ReconcileStatus `json:"reconcile,omitempty"`         // 与配置源核对的结果 尚未核对时为空
+}
+
+// Status 返回管理器当前的运行状态
+func (cm *CfgManager[T]) Status() Status {
+	status := Status{
+		Source:   cm.loader.GetConfigPath(),
+		Instance: cm.opts.instance,
+		ReadOnly: cm.opts.readOnly,
+	}
+	if v, ok := cm.loader.(Versioned); ok {
+		status.Version = v.Version()
+	}
+	if pending, at := cm.PendingConfig(); pending != nil {
+		status.PendingActivation = &at
+	}
+	if restart := cm.RestartPending(); restart != nil {
+		status.RestartRequired = cm.redactChangeSet(restart.Changes, restart.Config)
+	}
+	status.FailedHandlers = cm.sections.snapshot()
+	status.SourceHealth = cm.health.snapshot()
+	status.ShadowLoaded = cm.shadowLoadedAt()
+	status.Watcher = cm.WatcherStatus()
+	status.Reconcile = cm.reconciled.snapshot()
+	if approval := cm.ApprovalPending(); approval != nil {
+		status.ApprovalRequired = approval.Anomalies
+	}
+	return status
+}
+
+// redactChangeSet 按当前配置与等待生效的配置脱敏变更 脱敏失败时整体替换为 RedactedValue
+func (cm *CfgManager[T]) redactChangeSet(set ChangeSet, newConfig *T) *ChangeSet {
+	current, _ := cm.config.Load().(*T)
+	oldTree, err := cm.redact(current)
+	if err == nil {
+		var newTree map[string]any
+		if newTree, err = cm.redact(newConfig); err == nil {
+			return &ChangeSet{Hot: redactChanges(set.Hot, oldTree, newTree), Restart: redactChanges(set.Restart, oldTree, newTree)}
+		}
+	}
+	cm.logger.Error("Failed to redact pending restart changes", zap.Error(err))
+	return &ChangeSet{Hot: redactChanges(set.Hot, nil, nil), Restart: redactChanges(set.Restart, nil, nil)}
+}
@@ -0,0 +1,257 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+// ErrStreamClosed 推送流加载器已关闭
+var ErrStreamClosed = errors.New("config stream closed")
+
+// 推送流断开后的重连间隔
+const (
+	streamReconnectMin = 500 * time.Millisecond
+	streamReconnectMax = 30 * time.Second
+)
+
+// Revision 服务端推送的一个配置版本
+type Revision struct {
+	Version string // 版本号
+	Format  string // 内容格式 json 或 yaml
+	Data    []byte // 配置内容
+}
+
+// RevisionAck 客户端对配置版本的应用回执
+type RevisionAck struct {
+	Version string // 版本号
+	Applied bool   // 是否已应用
+	Error   string // 拒绝原因
+}
+
+// ConfigStream 配置推送双向流 通常由 gRPC 双向流实现
+type ConfigStream interface {
+	Recv() (*Revision, error)
+	Send(ack *RevisionAck) error
+	CloseSend() error
+}
+
+// StreamDialer 建立配置推送流 ctx 结束时流的 Recv 应当返回
+type StreamDialer func(ctx context.Context) (ConfigStream, error)
+
+// StreamingLoader 基于长连接推送的配置加载器 同时实现 CfgLoader 与 WatcherInterface
+// 服务端推送新版本时生成合成 Write 事件 应用结果通过 Ack 回报给服务端用于集中跟踪发布进度
+type StreamingLoader[T any] struct {
+	name   string
+	dial   StreamDialer
+	logger *zap.Logger
+
+	mu       sync.Mutex
+	stream   ConfigStream
+	latest   *Revision
+	loaded   string        // 最近一次 LoadConfig 解码的版本
+	received chan struct{} // 收到第一个版本后关闭
+
+	events    chan fsnotify.Event
+	errors    chan error
+	cancel    context.CancelFunc
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewStreamingLoader 创建推送流加载器 name 用作 GetConfigPath 与事件名
+func NewStreamingLoader[T any](name string, dial StreamDialer, logger *zap.Logger) *StreamingLoader[T] {
+	return &StreamingLoader[T]{
+		name:     name,
+		dial:     dial,
+		logger:   logger,
+		received: make(chan struct{}),
+		events:   make(chan fsnotify.Event, 1),
+		errors:   make(chan error, 1),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start 启动接收循环 流断开后自动重连 直到 ctx 结束或 Close
+func (s *StreamingLoader[T]) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	s.mu.Lock()
+	s.cancel = cancel
+	s.mu.Unlock()
+	go s.run(ctx)
+}
+
+// run 接收循环
+func (s *StreamingLoader[T]) run(ctx context.Context) {
+	defer close(s.done)
+	delay := streamReconnectMin
+	for ctx.Err() == nil {
+		err := s.receive(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		s.logger.Warn("Config stream disconnected, reconnecting", zap.String("name", s.name), zap.Duration("delay", delay), zap.Error(err))
+		s.sendError(err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > streamReconnectMax {
+			delay = streamReconnectMax
+		}
+	}
+}
+
+// receive 建立一条流并持续接收版本 直到出错
+func (s *StreamingLoader[T]) receive(ctx context.Context) error {
+	stream, err := s.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("dial config stream: %w", err)
+	}
+	s.mu.Lock()
+	s.stream = stream
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.stream = nil
+		s.mu.Unlock()
+		_ = stream.CloseSend()
+	}()
+
+	for {
+		rev, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		first := s.latest == nil
+		s.latest = rev
+		s.mu.Unlock()
+
+		s.logger.Info("Received config revision", zap.String("name", s.name), zap.String("version", rev.Version))
+		if first {
+			close(s.received)
+			continue
+		}
+		// 事件通道只需保留一个待处理的通知 加载时总是读取最新版本
+		select {
+		case s.events <- fsnotify.Event{Name: s.name, Op: fsnotify.Write}:
+		default:
+		}
+	}
+}
+
+// LoadConfig 解码最新的配置版本 尚未收到任何版本时等待
+func (s *StreamingLoader[T]) LoadConfig(ctx context.Context) (*T, error) {
+	select {
+	case <-s.received:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-s.done:
+		return nil, ErrStreamClosed
+	}
+
+	s.mu.Lock()
+	rev := s.latest
+	s.loaded = rev.Version
+	s.mu.Unlock()
+
+	c, err := codecFor("." + rev.Format)
+	if err != nil {
+		return nil, fmt.Errorf("revision %s: %w", rev.Version, err)
+	}
+	var config T
+	if err := c.decode(bytes.NewReader(rev.Data), &config); err != nil {
+		return nil, fmt.Errorf("revision %s: %w", rev.Version, c.locate(s.name, rev.Data, err))
+	}
+	return &config, nil
+}
+
+// LatestVersion 返回最新收到的版本号
+func (s *StreamingLoader[T]) LatestVersion() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.latest == nil {
+		return ""
+	}
+	return s.latest.Version
+}
+
+// Version 返回最近一次 LoadConfig 解码的版本 解码失败时同样更新 便于回报被拒绝的版本
+func (s *StreamingLoader[T]) Version() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loaded
+}
+
+// Ack 向服务端回报版本的应用结果 applyErr 为空表示已应用
+func (s *StreamingLoader[T]) Ack(version string, applyErr error) error {
+	ack := &RevisionAck{Version: version, Applied: applyErr == nil}
+	if applyErr != nil {
+		ack.Error = applyErr.Error()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stream == nil {
+		return ErrStreamClosed
+	}
+	return s.stream.Send(ack)
+}
+
+// GetConfigPath 返回推送流名称
+func (s *StreamingLoader[T]) GetConfigPath() string {
+	return s.name
+}
+
+// Add 推送流总是监听全部配置 无需添加路径
+func (s *StreamingLoader[T]) Add(string) error {
+	return nil
+}
+
+// Remove 推送流总是监听全部配置 无需移除路径
+func (s *StreamingLoader[T]) Remove(string) error {
+	return nil
+}
+
+// Close 停止接收循环 可重复调用
+func (s *StreamingLoader[T]) Close() error {
+	s.closeOnce.Do(func() {
+		s.mu.Lock()
+		cancel := s.cancel
+		s.mu.Unlock()
+		if cancel == nil {
+			close(s.done)
+			return
+		}
+		cancel()
+		<-s.done
+	})
+	return nil
+}
+
+// Events 返回版本更新事件通道
+func (s *StreamingLoader[T]) Events() <-chan fsnotify.Event {
+	return s.events
+}
+
+// Errors 返回流错误通道
+func (s *StreamingLoader[T]) Errors() <-chan error {
+	return s.errors
+}
+
+// sendError 发送错误 通道已满时丢弃
+func (s *StreamingLoader[T]) sendError(err error) {
+	select {
+	case s.errors <- err:
+	default:
+	}
+}
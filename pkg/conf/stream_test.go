@@ -0,0 +1,89 @@
+package config
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/omeyang/practices/internal/entity"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// fakeStream 测试用推送流
+type fakeStream struct {
+	ctx       context.Context
+	revisions chan *Revision
+	mu        sync.Mutex
+	acks      []*RevisionAck
+}
+
+func (f *fakeStream) Recv() (*Revision, error) {
+	select {
+	case rev, ok := <-f.revisions:
+		if !ok {
+			return nil, io.EOF
+		}
+		return rev, nil
+	case <-f.ctx.Done():
+		return nil, f.ctx.Err()
+	}
+}
+
+func (f *fakeStream) Send(ack *RevisionAck) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.acks = append(f.acks, ack)
+	return nil
+}
+
+func (f *fakeStream) CloseSend() error {
+	return nil
+}
+
+// TestStreamingLoader 测试推送版本的加载 通知与回执
+func TestStreamingLoader(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	stream := &fakeStream{revisions: make(chan *Revision, 2)}
+	loader := NewStreamingLoader[entity.AppConf]("stream:test", func(ctx context.Context) (ConfigStream, error) {
+		stream.ctx = ctx
+		return stream, nil
+	}, logger)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	loader.Start(ctx)
+	defer loader.Close()
+
+	stream.revisions <- &Revision{Version: "1", Format: "yaml", Data: []byte("prometheusCfg:\n  port: 9090\n")}
+	config, err := loader.LoadConfig(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 9090, config.PrometheusCfg.Port)
+	assert.NoError(t, loader.Ack("1", nil))
+
+	stream.revisions <- &Revision{Version: "2", Format: "json", Data: []byte(`{"prometheusCfg": {"port": 9091}}`)}
+	select {
+	case event := <-loader.Events():
+		assert.Equal(t, fsnotify.Event{Name: "stream:test", Op: fsnotify.Write}, event)
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for revision event")
+	}
+	assert.Equal(t, "2", loader.LatestVersion())
+	config, err = loader.LoadConfig(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 9091, config.PrometheusCfg.Port)
+
+	stream.mu.Lock()
+	assert.Equal(t, []*RevisionAck{{Version: "1", Applied: true}}, stream.acks)
+	stream.mu.Unlock()
+
+	assert.NoError(t, loader.Close())
+	shortCtx, shortCancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer shortCancel()
+	_, err = NewStreamingLoader[entity.AppConf]("x", nil, logger).LoadConfig(shortCtx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,214 @@
+package config
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 严格模式的编解码函数 拒绝目标类型中不存在的键
+// json 的 DisallowUnknownFields 遇到第一个未知键即停止 因此先对照目标类型检查全部键与取值类型
+// yaml 的 KnownFields 会继续解码 未知键与类型不匹配一起汇总在 yaml.TypeError 中
+var (
+	strictJSONCodec = codec{
+		decode:  decodeStrictJSON,
+		marshal: json.Marshal,
+		locate:  locateStrictJSONError,
+	}
+	strictYAMLCodec = codec{
+		decode: func(r io.Reader, v any) error {
+			decoder := yaml.NewDecoder(r)
+			decoder.KnownFields(true)
+			return decoder.Decode(v)
+		},
+		marshal: yaml.Marshal,
+		locate:  locateYAMLError,
+	}
+)
+
+// decodeStrictJSON 检查全部未知键与类型不匹配 存在问题时返回 MultiError 否则拒绝未知键解码
+func decodeStrictJSON(r io.Reader, v any) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if json.Valid(data) {
+		var errs MultiError
+		errs.Append(strictJSONIssues(data, reflect.TypeOf(v))...)
+		if err := errs.ErrorOrNil(); err != nil {
+			return err
+		}
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// locateStrictJSONError 为严格检查发现的问题补充文件名 其余错误按 locateJSONError 处理
+func locateStrictJSONError(file string, data []byte, err error) error {
+	var multi *MultiError
+	if !errors.As(err, &multi) {
+		return locateJSONError(file, data, err)
+	}
+	for _, fieldErr := range multi.Errors {
+		fieldErr.File = file
+	}
+	return multi
+}
+
+// strictJSONIssues 按行列号逐个检查 JSON 内容中的键与取值类型 JSON 是 YAML 的子集 借助 yaml.Node 获取位置
+func strictJSONIssues(data []byte, t reflect.Type) []*FieldError {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+		return nil
+	}
+	var issues []*FieldError
+	checkJSONNode(doc.Content[0], t, "", &issues)
+	return issues
+}
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// checkJSONNode 按 encoding/json 的规则检查节点能否解码到 t 自定义解码的类型不做检查
+func checkJSONNode(node *yaml.Node, t reflect.Type, path string, issues *[]*FieldError) {
+	if node.Tag == "!!null" {
+		return
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if pt := reflect.PointerTo(t); pt.Implements(jsonUnmarshalerType) || pt.Implements(textUnmarshalerType) {
+		return
+	}
+	mismatch := func() {
+		message := "cannot unmarshal " + jsonKind(node) + " into " + t.String()
+		*issues = append(*issues, &FieldError{
+			Path: path, Line: node.Line, Column: node.Column,
+			Message: message, Code: CodeTypeMismatch, Params: []any{message},
+		})
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			mismatch()
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			field, ok := jsonField(t, key.Value)
+			if !ok {
+				*issues = append(*issues, &FieldError{
+					Path: joinPath(path, key.Value), Line: key.Line, Column: key.Column,
+					Message: fmt.Sprintf("unknown field %q in %s", key.Value, t),
+					Code:    CodeUnknownField, Params: []any{key.Value, t.String()},
+				})
+				continue
+			}
+			checkJSONNode(value, field.Type, joinPath(path, key.Value), issues)
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			mismatch()
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			checkJSONNode(node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value), issues)
+		}
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 && node.Tag == "!!str" {
+			// []byte 以 base64 字符串表示
+			return
+		}
+		if node.Kind != yaml.SequenceNode {
+			mismatch()
+			return
+		}
+		for i, item := range node.Content {
+			checkJSONNode(item, t.Elem(), indexPath(path, i), issues)
+		}
+	case reflect.Bool:
+		if node.Tag != "!!bool" {
+			mismatch()
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if node.Tag != "!!int" {
+			mismatch()
+		}
+	case reflect.Float32, reflect.Float64:
+		if node.Tag != "!!int" && node.Tag != "!!float" {
+			mismatch()
+		}
+	case reflect.String:
+		if node.Tag != "!!str" {
+			mismatch()
+		}
+	}
+}
+
+// jsonField 按 encoding/json 的规则查找键对应的字段 优先完全匹配 其次忽略大小写 包括嵌入结构体提升的字段
+func jsonField(t reflect.Type, key string) (reflect.StructField, bool) {
+	var folded *reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if promoted, ok := jsonField(embedded, key); ok {
+					return promoted, true
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if name == key {
+			return field, true
+		}
+		if folded == nil && strings.EqualFold(name, key) {
+			folded = &field
+		}
+	}
+	if folded != nil {
+		return *folded, true
+	}
+	return reflect.StructField{}, false
+}
+
+// jsonKind 返回节点在 JSON 中的取值类型 与 json.UnmarshalTypeError 的描述一致
+func jsonKind(node *yaml.Node) string {
+	switch {
+	case node.Kind == yaml.MappingNode:
+		return "object"
+	case node.Kind == yaml.SequenceNode:
+		return "array"
+	case node.Tag == "!!bool":
+		return "bool"
+	case node.Tag == "!!int" || node.Tag == "!!float":
+		return "number"
+	default:
+		return "string"
+	}
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/omeyang/practices/internal/entity"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// TestStrictDecoding 测试严格模式一次报告全部未知键与类型不匹配 并附带行号
+func TestStrictDecoding(t *testing.T) {
+	tests := []struct {
+		name     string
+		ext      string
+		content  string
+		expected []FieldError
+	}{
+		{
+			name: "yaml",
+			ext:  ".yaml",
+			content: "prometheusCfg:\n" +
+				"  prot: 9090\n" +
+				"  enable: maybe\n" +
+				"tenant: {}\n",
+			expected: []FieldError{
+				{Line: 2, Message: "field prot not found in type entity.PrometheusConf"},
+				{Line: 3, Message: "cannot unmarshal !!str `maybe` into bool"},
+				{Line: 4, Message: "field tenant not found in type entity.AppConf"},
+			},
+		},
+		{
+			name: "json",
+			ext:  ".json",
+			content: "{\n" +
+				"  \"prometheusCfg\": {\"prot\": 9090, \"enable\": \"yes\"},\n" +
+				"  \"tenants\": {\"acme\": []},\n" +
+				"  \"tenant\": {}\n" +
+				"}\n",
+			expected: []FieldError{
+				{Path: "prometheusCfg.prot", Line: 2, Column: 21, Message: `unknown field "prot" in entity.PrometheusConf`},
+				{Path: "prometheusCfg.enable", Line: 2, Column: 45, Message: "cannot unmarshal string into bool"},
+				{Path: "tenants.acme", Line: 3, Column: 23, Message: "cannot unmarshal array into map[string]interface {}"},
+				{Path: "tenant", Line: 4, Column: 3, Message: `unknown field "tenant" in entity.AppConf`},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewParser[entity.AppConf](tt.ext, zap.NewNop(), WithStrictDecoding())
+			assert.NoError(t, err)
+			_, err = parser.Parse(mockFile(tt.content))
+			var multi *MultiError
+			assert.True(t, errors.As(err, &multi), "%v", err)
+			if multi == nil {
+				return
+			}
+			var actual []FieldError
+			for _, fieldErr := range multi.Errors {
+				assert.Equal(t, "test", fieldErr.File)
+				actual = append(actual, FieldError{Path: fieldErr.Path, Line: fieldErr.Line, Column: fieldErr.Column, Message: fieldErr.Message})
+			}
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+
+	// 非严格模式下未知键被静默忽略
+	lenient, err := NewParser[entity.AppConf](".yaml", zap.NewNop())
+	assert.NoError(t, err)
+	_, err = lenient.Parse(mockFile("prometheusCfg:\n  prot: 9090\n"))
+	assert.NoError(t, err)
+
+	parser, err := NewParser[entity.AppConf](".json", zap.NewNop(), WithStrictDecoding())
+	assert.NoError(t, err)
+	// 与 encoding/json 一致 键名不区分大小写
+	config, err := parser.Parse(mockFile(`{"PrometheusCfg": {"PORT": 9090}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, 9090, config.PrometheusCfg.Port)
+}
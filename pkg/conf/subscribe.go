@@ -0,0 +1,299 @@
+package config
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// DeliveryPolicy 订阅者消费不及时时的投递策略
+type DeliveryPolicy int
+
+const (
+	// DeliverBlocking 不丢弃任何配置 未消费的配置在订阅者队列中排队
+	DeliverBlocking DeliveryPolicy = iota
+	// DeliverDropOldest 队列满时丢弃最早的配置
+	DeliverDropOldest
+	// DeliverLatest 只保留最新的配置
+	DeliverLatest
+)
+
+// defaultSubscriptionBuffer DeliverDropOldest 默认的队列长度
+const defaultSubscriptionBuffer = 8
+
+// subscribeOptions 订阅选项
+type subscribeOptions struct {
+	policy   DeliveryPolicy
+	buffer   int
+	sections []string
+}
+
+// SubscribeOption 订阅选项
+type SubscribeOption func(*subscribeOptions)
+
+// WithDelivery 设置投递策略 默认为 DeliverLatest
+func WithDelivery(policy DeliveryPolicy) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.policy = policy
+	}
+}
+
+// WithBuffer 设置 DeliverDropOldest 的队列长度
+func WithBuffer(size int) SubscribeOption {
+	return func(o *subscribeOptions) {
+		if size > 0 {
+			o.buffer = size
+		}
+	}
+}
+
+// WithSections 只在指定配置段变化时投递 如 prometheusCfg 或 prometheusCfg.port
+func WithSections(paths ...string) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.sections = append(o.sections, paths...)
+	}
+}
+
+// Subscription 配置变更订阅 每个订阅者有独立的队列与投递协程 慢订阅者不会影响其他订阅者
+type Subscription[T any] struct {
+	opts     subscribeOptions
+	owner    *subscribers[T]
+	out      chan *T
+	notify   chan struct{}
+	done     chan struct{}
+	draining chan struct{} // 关闭后投递完队列中的配置即结束
+	dropped  atomic.Uint64
+
+	mu        sync.Mutex
+	queue     []*T
+	last      *T // 最近一次收到的配置 用于判断配置段是否变化
+	closeOnce sync.Once
+	drainOnce sync.Once
+}
+
+// C 返回配置变更通道 订阅关闭后通道关闭
+func (s *Subscription[T]) C() <-chan *T {
+	return s.out
+}
+
+// Dropped 返回因投递策略被丢弃的配置数量
+func (s *Subscription[T]) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close 取消订阅 可重复调用
+func (s *Subscription[T]) Close() {
+	s.closeOnce.Do(func() {
+		s.owner.remove(s)
+		close(s.done)
+	})
+}
+
+// drain 不再接收新配置 投递完队列中的配置后关闭通道 可重复调用
+func (s *Subscription[T]) drain() {
+	s.drainOnce.Do(func() {
+		s.owner.remove(s)
+		close(s.draining)
+	})
+}
+
+// enqueue 按投递策略将配置放入队列 不会阻塞
+func (s *Subscription[T]) enqueue(config *T) {
+	s.mu.Lock()
+	last := s.last
+	s.last = config
+	if !sectionsChanged(last, config, s.opts.sections) {
+		s.mu.Unlock()
+		return
+	}
+	switch s.opts.policy {
+	case DeliverDropOldest:
+		if len(s.queue) >= s.opts.buffer {
+			s.queue = s.queue[1:]
+			s.dropped.Add(1)
+		}
+	case DeliverLatest:
+		s.dropped.Add(uint64(len(s.queue)))
+		s.queue = s.queue[:0]
+	}
+	s.queue = append(s.queue, config)
+	s.mu.Unlock()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// pending 返回队列中等待投递的配置数量
+func (s *Subscription[T]) pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.queue)
+}
+
+// pop 取出队首配置 队列为空时返回 nil
+func (s *Subscription[T]) pop() *T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.queue) == 0 {
+		return nil
+	}
+	next := s.queue[0]
+	s.queue[0] = nil
+	s.queue = s.queue[1:]
+	return next
+}
+
+// deliver 投递协程 按顺序将队列中的配置发送给订阅者
+func (s *Subscription[T]) deliver() {
+	defer close(s.out)
+	for {
+		next := s.pop()
+		if next == nil {
+			select {
+			case <-s.notify:
+				continue
+			case <-s.done:
+				return
+			case <-s.draining:
+				if s.pending() == 0 {
+					return
+				}
+				continue
+			}
+		}
+		for sent := false; !sent; {
+			select {
+			case s.out <- next:
+				sent = true
+			case <-s.notify:
+				// 等待发送期间有新配置 只保留最新配置时替换正在等待的配置
+				if s.opts.policy != DeliverLatest {
+					continue
+				}
+				if newer := s.pop(); newer != nil {
+					next = newer
+					s.dropped.Add(1)
+				}
+			case <-s.done:
+				return
+			}
+		}
+	}
+}
+
+// sectionsChanged 判断配置段是否变化 未指定配置段时总是返回 true
+func sectionsChanged(oldConfig, newConfig any, sections []string) bool {
+	if len(sections) == 0 || oldConfig == nil {
+		return true
+	}
+	oldTree, err := configTree(oldConfig)
+	if err != nil {
+		return true
+	}
+	newTree, err := configTree(newConfig)
+	if err != nil {
+		return true
+	}
+	for _, section := range sections {
+		oldValue, _ := lookupPath(oldTree, section)
+		newValue, _ := lookupPath(newTree, section)
+		if !reflect.DeepEqual(oldValue, newValue) {
+			return true
+		}
+	}
+	return false
+}
+
+// subscribers 管理器的订阅者集合
+type subscribers[T any] struct {
+	mu   sync.Mutex
+	list map[*Subscription[T]]struct{}
+}
+
+// publish 向全部订阅者投递新配置
+func (ss *subscribers[T]) publish(config *T) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	for s := range ss.list {
+		s.enqueue(config)
+	}
+}
+
+// remove 移除订阅者
+func (ss *subscribers[T]) remove(s *Subscription[T]) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	delete(ss.list, s)
+}
+
+// drainAll 让全部订阅者投递完已排队的配置后关闭通道
+func (ss *subscribers[T]) drainAll() {
+	ss.mu.Lock()
+	list := make([]*Subscription[T], 0, len(ss.list))
+	for s := range ss.list {
+		list = append(list, s)
+	}
+	ss.mu.Unlock()
+	for _, s := range list {
+		s.drain()
+	}
+}
+
+// subscribe 添加订阅者 last 为判断配置段是否变化的初始配置
+func (ss *subscribers[T]) subscribe(last *T, opts []SubscribeOption) *Subscription[T] {
+	o := subscribeOptions{policy: DeliverLatest, buffer: defaultSubscriptionBuffer}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	s := &Subscription[T]{
+		opts:     o,
+		owner:    ss,
+		out:      make(chan *T),
+		notify:   make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		draining: make(chan struct{}),
+		last:     last,
+	}
+
+	ss.mu.Lock()
+	if ss.list == nil {
+		ss.list = map[*Subscription[T]]struct{}{}
+	}
+	ss.list[s] = struct{}{}
+	ss.mu.Unlock()
+
+	go s.deliver()
+	return s
+}
+
+// Subscribe 订阅配置变更 每次新配置生效时投递 使用完毕后需调用 Close
+func (cm *CfgManager[T]) Subscribe(opts ...SubscribeOption) *Subscription[T] {
+	current, _ := cm.config.Load().(*T)
+	return cm.subscribers.subscribe(current, opts)
+}
+
+// SubscribeFunc 以回调方式订阅配置变更 回调在独立协程中按顺序执行
+// oldConfig 为该订阅收到的上一份配置 ctx 结束或调用 Close 后停止回调
+func (cm *CfgManager[T]) SubscribeFunc(ctx context.Context, fn func(oldConfig, newConfig *T), opts ...SubscribeOption) *Subscription[T] {
+	s := cm.Subscribe(opts...)
+	old, _ := cm.config.Load().(*T)
+	go func() {
+		defer s.Close()
+		for {
+			select {
+			case config, ok := <-s.C():
+				if !ok {
+					return
+				}
+				fn(old, config)
+				old = config
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return s
+}
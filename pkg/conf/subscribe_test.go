@@ -0,0 +1,120 @@
+package config
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/omeyang/practices/internal/entity"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// receiveConfig 在超时前接收一份配置
+func receiveConfig(t *testing.T, s *Subscription[entity.AppConf]) *entity.AppConf {
+	t.Helper()
+	select {
+	case config := <-s.C():
+		return config
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for config")
+		return nil
+	}
+}
+
+// TestSubscribe 测试各投递策略 以及慢订阅者不影响其他订阅者
+func TestSubscribe(t *testing.T) {
+	cm := NewConfigManager[entity.AppConf](nil, nil, zap.NewNop(), RetryPolicy{})
+	blocking := cm.Subscribe(WithDelivery(DeliverBlocking))
+	defer blocking.Close()
+	dropOldest := cm.Subscribe(WithDelivery(DeliverDropOldest), WithBuffer(2))
+	defer dropOldest.Close()
+	latest := cm.Subscribe()
+	defer latest.Close()
+
+	configs := make([]*entity.AppConf, 5)
+	for i := range configs {
+		configs[i] = &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9000 + i}}
+		assert.NoError(t, cm.storeConfig(configs[i]))
+	}
+
+	// 未消费的订阅者不会阻塞发布 最新配置订阅者只收到最后一份
+	assert.Equal(t, configs[4], receiveConfig(t, latest))
+	assert.Equal(t, uint64(4), latest.Dropped())
+
+	for _, expected := range configs {
+		assert.Equal(t, expected, receiveConfig(t, blocking))
+	}
+	assert.Zero(t, blocking.Dropped())
+
+	// 投递协程可能已取出第一份配置 因此收到的是最后两份或三份
+	var received []*entity.AppConf
+	for len(received) == 0 || received[len(received)-1] != configs[4] {
+		received = append(received, receiveConfig(t, dropOldest))
+	}
+	assert.Equal(t, uint64(5-len(received)), dropOldest.Dropped())
+	assert.Equal(t, configs[5-len(received):], received)
+}
+
+// TestSubscriptionClose 测试取消订阅后通道关闭且不再投递
+func TestSubscriptionClose(t *testing.T) {
+	cm := NewConfigManager[entity.AppConf](nil, nil, zap.NewNop(), RetryPolicy{})
+	s := cm.Subscribe()
+	s.Close()
+	s.Close()
+	assert.NoError(t, cm.storeConfig(&entity.AppConf{}))
+
+	_, ok := <-s.C()
+	assert.False(t, ok)
+	assert.Empty(t, cm.subscribers.list)
+}
+
+// TestSubscribeFunc 测试回调订阅与按配置段订阅
+func TestSubscribeFunc(t *testing.T) {
+	cm := NewConfigManager[entity.AppConf](nil, nil, zap.NewNop(), RetryPolicy{})
+	initial := &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9000}}
+	assert.NoError(t, cm.storeConfig(initial))
+
+	type change struct{ old, new *entity.AppConf }
+	all := make(chan change, 4)
+	ports := make(chan change, 4)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cm.SubscribeFunc(ctx, func(oldConfig, newConfig *entity.AppConf) {
+		all <- change{oldConfig, newConfig}
+	}, WithDelivery(DeliverBlocking))
+	cm.SubscribeFunc(ctx, func(oldConfig, newConfig *entity.AppConf) {
+		ports <- change{oldConfig, newConfig}
+	}, WithDelivery(DeliverBlocking), WithSections("prometheusCfg.port"))
+
+	enabled := &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9000, Enable: true}}
+	moved := &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9100, Enable: true}}
+	assert.NoError(t, cm.storeConfig(enabled))
+	assert.NoError(t, cm.storeConfig(moved))
+
+	assert.Equal(t, change{initial, enabled}, <-all)
+	assert.Equal(t, change{enabled, moved}, <-all)
+	// 只修改 enable 的配置不会通知端口订阅者
+	assert.Equal(t, change{initial, moved}, <-ports)
+	select {
+	case c := <-ports:
+		t.Fatalf("unexpected notification %+v", c)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// TestSubscribeFuncCancel 测试 ctx 结束后取消订阅
+func TestSubscribeFuncCancel(t *testing.T) {
+	cm := NewConfigManager[entity.AppConf](nil, nil, zap.NewNop(), RetryPolicy{})
+	ctx, cancel := context.WithCancel(context.Background())
+	s := cm.SubscribeFunc(ctx, func(_, _ *entity.AppConf) {})
+	cancel()
+
+	select {
+	case _, ok := <-s.C():
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("subscription not closed")
+	}
+}
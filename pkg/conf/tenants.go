@@ -0,0 +1,154 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// TenantsKey 配置树中租户覆盖使用的键名
+	TenantsKey = "tenants"
+	// TenantExtendsKey 租户覆盖中继承其他租户使用的键名
+	TenantExtendsKey = "extends"
+)
+
+// ErrUnknownTenant 配置中没有声明该租户
+var ErrUnknownTenant = errors.New("unknown tenant")
+
+// tenantCache 按配置缓存已解析的租户配置 配置替换后失效
+type tenantCache[T any] struct {
+	mu       sync.Mutex
+	config   *T
+	resolved map[string]*T
+}
+
+// ForTenant 返回租户的有效配置: 基础配置依次合并继承链上各租户的覆盖
+//
+//	prometheusCfg:
+//	  port: 9090
+//	tenants:
+//	  acme:
+//	    prometheusCfg:
+//	      port: 9100
+//	  acme-eu:
+//	    extends: acme
+//	    prometheusCfg:
+//	      address: 10.0.0.1
+//
+// 返回的配置不包含 tenants 调用方不应修改 启用 WithCopyOnRead 时返回深拷贝
+func (cm *CfgManager[T]) ForTenant(name string) (*T, error) {
+	resolved, err := cm.forTenant(name)
+	if err != nil || !cm.opts.copyOnRead {
+		return resolved, err
+	}
+	return DeepCopy(resolved), nil
+}
+
+// forTenant 返回缓存的租户配置 当前配置被替换后清空缓存
+func (cm *CfgManager[T]) forTenant(name string) (*T, error) {
+	config := cm.sharedConfig()
+
+	cm.tenants.mu.Lock()
+	defer cm.tenants.mu.Unlock()
+	if cm.tenants.config != config {
+		cm.tenants.config, cm.tenants.resolved = config, map[string]*T{}
+	}
+	if resolved, ok := cm.tenants.resolved[name]; ok {
+		return resolved, nil
+	}
+
+	resolved, err := ResolveTenant(config, name)
+	if err != nil {
+		return nil, err
+	}
+	cm.tenants.resolved[name] = resolved
+	return resolved, nil
+}
+
+// Tenants 返回当前配置中声明的租户名 按字母序排列
+func (cm *CfgManager[T]) Tenants() []string {
+	tree, err := configTree(cm.sharedConfig())
+	if err != nil {
+		return nil
+	}
+	tenants := tenantTrees(tree)
+	names := make([]string, 0, len(tenants))
+	for name := range tenants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ResolveTenant 解析租户的有效配置 租户覆盖从配置树的 tenants 键读取
+func ResolveTenant[T any](config *T, name string) (*T, error) {
+	tree, err := configTree(config)
+	if err != nil {
+		return nil, err
+	}
+	tenants := tenantTrees(tree)
+	chain, err := tenantChain(tenants, name)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(tree, TenantsKey)
+	for _, tenant := range chain {
+		override := tenants[tenant]
+		delete(override, TenantExtendsKey)
+		mergeTree(tree, override)
+	}
+
+	var resolved T
+	if err := decodeTree(tree, &resolved); err != nil {
+		return nil, fmt.Errorf("tenant %s: %w", name, err)
+	}
+	return &resolved, nil
+}
+
+// tenantTrees 返回配置树中各租户的覆盖子树
+func tenantTrees(tree map[string]any) map[string]map[string]any {
+	raw, _ := tree[TenantsKey].(map[string]any)
+	tenants := make(map[string]map[string]any, len(raw))
+	for name, value := range raw {
+		override, _ := value.(map[string]any)
+		if override == nil {
+			override = map[string]any{}
+		}
+		tenants[name] = override
+	}
+	return tenants
+}
+
+// tenantChain 返回从最上层父租户到 name 的继承链
+func tenantChain(tenants map[string]map[string]any, name string) ([]string, error) {
+	var chain []string
+	seen := map[string]bool{}
+	for current := name; current != ""; {
+		override, ok := tenants[current]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownTenant, current)
+		}
+		if seen[current] {
+			return nil, fmt.Errorf("tenant %s: inheritance cycle %s", name, strings.Join(append(chain, current), " -> "))
+		}
+		seen[current] = true
+		chain = append(chain, current)
+
+		parent, ok := override[TenantExtendsKey]
+		if !ok {
+			break
+		}
+		if current, ok = parent.(string); !ok {
+			return nil, fmt.Errorf("tenant %s: %s must be a string", chain[len(chain)-1], TenantExtendsKey)
+		}
+	}
+
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain, nil
+}
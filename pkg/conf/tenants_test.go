@@ -0,0 +1,60 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/omeyang/practices/internal/entity"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// TestCfgManager_ForTenant 测试租户覆盖的继承链解析
+func TestCfgManager_ForTenant(t *testing.T) {
+	parser := &YAMLParser[entity.AppConf]{Logger: zap.NewNop()}
+	config, err := parser.Parse(mockFile(`
+prometheusCfg:
+  enable: true
+  port: 9090
+  address: 0.0.0.0
+tenants:
+  acme:
+    prometheusCfg:
+      port: 9100
+  acme-eu:
+    extends: acme
+    prometheusCfg:
+      address: 10.0.0.1
+  loop-a:
+    extends: loop-b
+  loop-b:
+    extends: loop-a
+`))
+	assert.NoError(t, err)
+
+	cm := NewConfigManager[entity.AppConf](nil, nil, zap.NewNop(), RetryPolicy{})
+	assert.NoError(t, cm.storeConfig(config))
+	assert.Equal(t, []string{"acme", "acme-eu", "loop-a", "loop-b"}, cm.Tenants())
+
+	acme, err := cm.ForTenant("acme")
+	assert.NoError(t, err)
+	assert.Equal(t, 9100, acme.PrometheusCfg.Port)
+	assert.Equal(t, "0.0.0.0", acme.PrometheusCfg.Address)
+	assert.Nil(t, acme.Tenants)
+
+	eu, err := cm.ForTenant("acme-eu")
+	assert.NoError(t, err)
+	assert.Equal(t, 9100, eu.PrometheusCfg.Port)
+	assert.Equal(t, "10.0.0.1", eu.PrometheusCfg.Address)
+	assert.True(t, eu.PrometheusCfg.Enable)
+
+	cached, err := cm.ForTenant("acme")
+	assert.NoError(t, err)
+	assert.Same(t, acme, cached)
+	assert.Equal(t, 9090, cm.GetConfig().PrometheusCfg.Port)
+
+	_, err = cm.ForTenant("globex")
+	assert.ErrorIs(t, err, ErrUnknownTenant)
+	_, err = cm.ForTenant("loop-a")
+	assert.ErrorContains(t, err, "inheritance cycle loop-a -> loop-b -> loop-a")
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// mergeTree 将 src 深度合并到 dst 中 同名的子树递归合并 其余取值以 src 为准
+func mergeTree(dst, src map[string]any) {
+	for key, srcVal := range src {
+		srcMap, srcIsMap := srcVal.(map[string]any)
+		dstMap, dstIsMap := dst[key].(map[string]any)
+		if srcIsMap && dstIsMap {
+			mergeTree(dstMap, srcMap)
+			continue
+		}
+		dst[key] = srcVal
+	}
+}
+
+// joinPath 拼接配置键路径
+func joinPath(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+// indexPath 拼接列表元素路径
+func indexPath(parent string, index int) string {
+	return fmt.Sprintf("%s[%d]", parent, index)
+}
+
+// toFloat 将配置树中的数值统一转换为 float64
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, true
+	default:
+		return 0, false
+	}
+}
+
+// configTree 将配置结构体转换为配置树 键名取自 yaml 标签
+func configTree(config any) (map[string]any, error) {
+	data, err := yamlCodec.marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	tree := map[string]any{}
+	if err := yamlCodec.decode(bytes.NewReader(data), &tree); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// lookupPath 按点分隔的键路径查找配置树中的取值
+func lookupPath(tree map[string]any, path string) (any, bool) {
+	var node any = tree
+	for _, key := range strings.Split(path, ".") {
+		m, ok := node.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if node, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return node, true
+}
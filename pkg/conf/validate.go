@@ -0,0 +1,214 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// ValidateTag 声明配置项校验规则的结构体标签 多条规则以逗号分隔
+//
+// 支持的规则:
+//
+//	required          取值不能为零值 指针不能为空
+//	range=1-65535     数值必须在闭区间内 字符串 列表与映射按长度比较
+//	oneof=a|b|c       取值必须是列出的值之一
+const ValidateTag = "validate"
+
+// Validator 配置校验器 每次加载与重载后 在探测之前执行
+type Validator[T any] interface {
+	Validate(config *T) error
+}
+
+// ValidatorFunc 以函数实现 Validator 返回 MultiError 或 FieldError 时保留其中的配置键路径
+type ValidatorFunc[T any] func(config *T) error
+
+// Validate 调用校验函数
+func (f ValidatorFunc[T]) Validate(config *T) error {
+	return f(config)
+}
+
+// ValidationError 配置未通过校验 列出全部失败的规则
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+// Error 实现 error 接口
+func (e *ValidationError) Error() string {
+	return "config validation failed: " + (&MultiError{Errors: e.Errors}).Error()
+}
+
+// Unwrap 支持 errors.Is/As 逐个检查
+func (e *ValidationError) Unwrap() []error {
+	return (&MultiError{Errors: e.Errors}).Unwrap()
+}
+
+// validatorSet 已注册的校验器
+type validatorSet[T any] struct {
+	mu   sync.Mutex
+	list []Validator[T]
+}
+
+// AddValidator 注册自定义校验器 按注册顺序在标签规则之后执行
+func (cm *CfgManager[T]) AddValidator(validator Validator[T]) {
+	cm.validators.mu.Lock()
+	defer cm.validators.mu.Unlock()
+	cm.validators.list = append(cm.validators.list, validator)
+}
+
+// Validate 按标签规则与已注册的校验器校验候选配置 返回汇总全部失败规则的 ValidationError
+func (cm *CfgManager[T]) Validate(config *T) error {
+	cm.validators.mu.Lock()
+	validators := append([]Validator[T](nil), cm.validators.list...)
+	cm.validators.mu.Unlock()
+
+	errs := asFieldErrors(ValidateStruct(config))
+	for _, validator := range validators {
+		errs = append(errs, asFieldErrors(validator.Validate(config))...)
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	for _, err := range errs {
+		cm.logger.Warn("Config validation rule failed", zap.String("path", err.Path), zap.String("rule", err.Message))
+	}
+	return &ValidationError{Errors: errs}
+}
+
+// ValidateStruct 按 validate 标签校验配置结构体 返回汇总全部失败规则的 MultiError
+func ValidateStruct(config any) error {
+	var errs MultiError
+	validateValue(reflect.ValueOf(config), "", &errs)
+	return errs.ErrorOrNil()
+}
+
+// validateValue 递归校验结构体字段 空指针的子字段不再校验
+func validateValue(v reflect.Value, path string, errs *MultiError) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+			fieldPath := joinPath(path, name)
+			if rules := field.Tag.Get(ValidateTag); rules != "" {
+				for _, rule := range strings.Split(rules, ",") {
+					if err := checkRule(v.Field(i), strings.TrimSpace(rule)); err != nil {
+						err.Path = fieldPath
+						errs.Append(err)
+					}
+				}
+			}
+			validateValue(v.Field(i), fieldPath, errs)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			validateValue(v.Index(i), indexPath(path, i), errs)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			validateValue(iter.Value(), joinPath(path, fmt.Sprint(iter.Key().Interface())), errs)
+		}
+	}
+}
+
+// checkRule 检查单条规则 返回的错误未设置路径
+func checkRule(v reflect.Value, rule string) *FieldError {
+	name, param, _ := strings.Cut(rule, "=")
+	switch name {
+	case "":
+		return nil
+	case "required":
+		if v.IsZero() {
+			return codedError(CodeRequired, "required")
+		}
+		return nil
+	case "range":
+		return checkRange(v, param)
+	case "oneof":
+		return checkOneOf(v, param)
+	default:
+		return codedError(CodeInvalidRule, fmt.Sprintf("unknown validation rule %q", name), rule)
+	}
+}
+
+// checkRange 检查取值或长度是否在闭区间内 未设置的可选值不检查
+func checkRange(v reflect.Value, param string) *FieldError {
+	// 下限可能为负数 从第二个字符开始查找分隔符
+	sep := -1
+	if param != "" {
+		sep = strings.Index(param[1:], "-")
+	}
+	if sep < 0 {
+		return codedError(CodeInvalidRule, fmt.Sprintf("invalid range %q", param), "range="+param)
+	}
+	sep++
+	lo, errLo := strconv.ParseFloat(param[:sep], 64)
+	hi, errHi := strconv.ParseFloat(param[sep+1:], 64)
+	if errLo != nil || errHi != nil {
+		return codedError(CodeInvalidRule, fmt.Sprintf("invalid range %q", param), "range="+param)
+	}
+
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	var n float64
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		n = v.Float()
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		n = float64(v.Len())
+	default:
+		return codedError(CodeInvalidRule, fmt.Sprintf("range does not apply to %s", v.Kind()), "range="+param)
+	}
+	if n < lo || n > hi {
+		return codedError(CodeOutOfRange, fmt.Sprintf("must be in range %s, got %v", param, n), param, n)
+	}
+	return nil
+}
+
+// checkOneOf 检查取值是否为列出的值之一 未设置的可选值不检查
+func checkOneOf(v reflect.Value, param string) *FieldError {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	value := fmt.Sprint(v.Interface())
+	for _, allowed := range strings.Split(param, "|") {
+		if value == allowed {
+			return nil
+		}
+	}
+	allowed := strings.ReplaceAll(param, "|", ", ")
+	return codedError(CodeNotOneOf, fmt.Sprintf("must be one of %s, got %q", allowed, value), allowed, value)
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/omeyang/practices/internal/entity"
+	mocks "github.com/omeyang/practices/mocks/conf"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+// validatedConf 带校验标签的配置类型
+type validatedConf struct {
+	Name     string            `yaml:"name" validate:"required"`
+	Level    string            `yaml:"level" validate:"oneof=debug|info|warn"`
+	Server   *validatedServer  `yaml:"server" validate:"required"`
+	Backends []validatedServer `yaml:"backends" validate:"range=1-3"`
+	Offset   *int              `yaml:"offset" validate:"range=-10-10"`
+}
+
+// validatedServer 带校验标签的子配置
+type validatedServer struct {
+	Port int `yaml:"port" validate:"range=1-65535"`
+}
+
+// TestValidateStruct 测试标签规则
+func TestValidateStruct(t *testing.T) {
+	offset := -5
+	valid := &validatedConf{
+		Name:     "billing",
+		Level:    "info",
+		Server:   &validatedServer{Port: 8080},
+		Backends: []validatedServer{{Port: 9000}},
+		Offset:   &offset,
+	}
+	assert.NoError(t, ValidateStruct(valid))
+
+	outOfRange := 20
+	err := ValidateStruct(&validatedConf{
+		Level:    "trace",
+		Backends: []validatedServer{{Port: 0}},
+		Offset:   &outOfRange,
+	})
+	var multi *MultiError
+	assert.ErrorAs(t, err, &multi)
+	var paths []string
+	for _, fieldErr := range multi.Errors {
+		paths = append(paths, fieldErr.Path)
+	}
+	assert.Equal(t, []string{"name", "level", "server", "backends[0].port", "offset"}, paths)
+	assert.Contains(t, err.Error(), "must be one of debug, info, warn")
+
+	assert.ErrorContains(t, ValidateStruct(&struct {
+		Port int `validate:"between=1-2"`
+	}{}), "unknown validation rule")
+}
+
+// TestCfgManager_Validate 测试校验失败时保留当前配置并通过错误通道报告
+func TestCfgManager_Validate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return("/etc/app.yaml").AnyTimes()
+	cm := NewConfigManager[entity.AppConf](mockLoader, nil, zap.NewNop(), RetryPolicy{MaxAttempts: 3})
+	cm.AddValidator(ValidatorFunc[entity.AppConf](func(config *entity.AppConf) error {
+		var errs MultiError
+		if config.PrometheusCfg != nil && config.PrometheusCfg.Enable && config.PrometheusCfg.Port == 0 {
+			errs.Add("prometheusCfg.port", "required when prometheus is enabled")
+		}
+		return errs.ErrorOrNil()
+	}))
+	cm.AddValidator(ValidatorFunc[entity.AppConf](func(config *entity.AppConf) error {
+		if config.PrometheusCfg == nil {
+			return errors.New("prometheusCfg is missing")
+		}
+		return nil
+	}))
+
+	ctx := context.Background()
+	good := &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Enable: true, Port: 9090}}
+	assert.NoError(t, cm.Set(ctx, good))
+
+	var validationErr *ValidationError
+	assert.ErrorAs(t, cm.Set(ctx, &entity.AppConf{}), &validationErr)
+	assert.Equal(t, "prometheusCfg is missing", validationErr.Errors[0].Message)
+
+	// 校验失败不重试 错误通过错误通道报告
+	bad := &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Enable: true}}
+	mockLoader.EXPECT().LoadConfig(ctx).Return(bad, nil).Times(1)
+	cm.reloadConfig(ctx)
+	err := <-cm.ListenForConfigErrors()
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "prometheusCfg.port", validationErr.Errors[0].Path)
+	assert.Equal(t, good, cm.GetConfig())
+
+	mockLoader.EXPECT().LoadConfig(ctx).Return(bad, nil).Times(1)
+	_, err = cm.DryRun(ctx)
+	assert.ErrorAs(t, err, &validationErr)
+}
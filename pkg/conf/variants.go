@@ -0,0 +1,129 @@
+package config
+
+import (
+	"hash/fnv"
+	"os"
+)
+
+// VariantsKey 配置树中变体块使用的键名
+const VariantsKey = "variants"
+
+// variantBuckets 分桶总数 百分比精度为 0.01%
+const variantBuckets = 10000
+
+// SelectVariants 返回按实例标识确定性选择变体的配置树变换
+//
+// 任意层级的映射都可以声明变体块 命中的变体 values 会覆盖同层取值:
+//
+//	prometheusCfg:
+//	  port: 9090
+//	  variants:
+//	    - name: candidate
+//	      percent: 10
+//	      values:
+//	        port: 9091
+//
+// 变体按声明顺序累计百分比 同一实例对同一变体块总会落入同一分桶
+func SelectVariants(instanceKey string) Transform {
+	return func(tree map[string]any) error {
+		var errs MultiError
+		selectVariants(tree, "", instanceKey, &errs)
+		return errs.ErrorOrNil()
+	}
+}
+
+// HostnameInstanceKey 使用主机名作为实例标识
+func HostnameInstanceKey() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+	return hostname
+}
+
+// VariantBucket 计算实例在指定变体块中的分桶 取值范围 [0, 10000)
+func VariantBucket(instanceKey, salt string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(salt))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(instanceKey))
+	return int(h.Sum32() % variantBuckets)
+}
+
+// selectVariants 递归处理配置树中的变体块 错误汇总到 errs 中
+func selectVariants(node map[string]any, path, instanceKey string, errs *MultiError) {
+	if raw, ok := node[VariantsKey]; ok {
+		delete(node, VariantsKey)
+		if values := pickVariant(raw, path, instanceKey, errs); values != nil {
+			mergeTree(node, values)
+		}
+	}
+
+	for key, child := range node {
+		walkVariants(child, joinPath(path, key), instanceKey, errs)
+	}
+}
+
+// walkVariants 遍历子节点
+func walkVariants(node any, path, instanceKey string, errs *MultiError) {
+	switch n := node.(type) {
+	case map[string]any:
+		selectVariants(n, path, instanceKey, errs)
+	case []any:
+		for i, item := range n {
+			walkVariants(item, indexPath(path, i), instanceKey, errs)
+		}
+	}
+}
+
+// pickVariant 根据实例分桶选出命中的变体 未命中或变体块非法时返回 nil
+func pickVariant(raw any, path, instanceKey string, errs *MultiError) map[string]any {
+	blockPath := joinPath(path, VariantsKey)
+	variants, ok := raw.([]any)
+	if !ok {
+		errs.Add(blockPath, "must be a list")
+		return nil
+	}
+
+	percents := make([]float64, len(variants))
+	values := make([]map[string]any, len(variants))
+	var total float64
+	valid := true
+	for i, item := range variants {
+		itemPath := indexPath(blockPath, i)
+		variant, ok := item.(map[string]any)
+		if !ok {
+			errs.Add(itemPath, "variant must be a mapping")
+			valid = false
+			continue
+		}
+		percent, ok := toFloat(variant["percent"])
+		if !ok || percent < 0 || percent > 100 {
+			errs.Add(joinPath(itemPath, "percent"), "must be a number between 0 and 100")
+			valid = false
+		}
+		if values[i], ok = variant["values"].(map[string]any); !ok {
+			errs.Add(joinPath(itemPath, "values"), "must be a mapping")
+			valid = false
+		}
+		percents[i] = percent
+		total += percent
+	}
+	if total > 100 {
+		errs.Addf(blockPath, "total percent %.2f exceeds 100", total)
+		valid = false
+	}
+	if !valid {
+		return nil
+	}
+
+	bucket := float64(VariantBucket(instanceKey, path))
+	var cumulative float64
+	for i, percent := range percents {
+		cumulative += percent
+		if bucket < cumulative*variantBuckets/100 {
+			return values[i]
+		}
+	}
+	return nil
+}
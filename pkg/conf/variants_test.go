@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/omeyang/practices/internal/entity"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// TestSelectVariants 测试变体选择
+func TestSelectVariants(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+
+	tests := []struct {
+		name         string
+		percent      int
+		expectedPort int
+	}{
+		{"Full Rollout", 100, 9091},
+		{"No Rollout", 0, 9090},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := fmt.Sprintf(`
+prometheusCfg:
+  port: 9090
+  variants:
+    - name: candidate
+      percent: %d
+      values:
+        port: 9091
+`, tt.percent)
+			parser := &YAMLParser[entity.AppConf]{Logger: logger, Transforms: []Transform{SelectVariants("host-1")}}
+			config, err := parser.Parse(mockFile(content))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedPort, config.PrometheusCfg.Port)
+		})
+	}
+}
+
+// TestSelectVariantsDeterministic 测试同一实例的选择结果稳定且按比例分布
+func TestSelectVariantsDeterministic(t *testing.T) {
+	newTree := func() map[string]any {
+		return map[string]any{
+			"prometheusCfg": map[string]any{
+				"port": 9090,
+				"variants": []any{
+					map[string]any{"percent": 25, "values": map[string]any{"port": 9091}},
+				},
+			},
+		}
+	}
+
+	selected := 0
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("host-%d", i)
+		first, second := newTree(), newTree()
+		assert.NoError(t, SelectVariants(key)(first))
+		assert.NoError(t, SelectVariants(key)(second))
+		assert.Equal(t, first, second)
+		if first["prometheusCfg"].(map[string]any)["port"] == 9091 {
+			selected++
+		}
+	}
+	assert.InDelta(t, 250, selected, 60)
+}
+
+// TestSelectVariantsInvalid 测试非法变体块
+func TestSelectVariantsInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		variants any
+	}{
+		{"Not A List", "oops"},
+		{"Missing Percent", []any{map[string]any{"values": map[string]any{}}}},
+		{"Missing Values", []any{map[string]any{"percent": 10}}},
+		{"Exceeds Total", []any{
+			map[string]any{"percent": 60, "values": map[string]any{}},
+			map[string]any{"percent": 60, "values": map[string]any{}},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := map[string]any{"section": map[string]any{"variants": tt.variants}}
+			assert.Error(t, SelectVariants("host-1")(tree))
+		})
+	}
+}
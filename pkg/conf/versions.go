@@ -0,0 +1,113 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ErrUnknownVersion 版本不存在或已超出保留的历史
+var ErrUnknownVersion = errors.New("unknown config version")
+
+// defaultVersionHistory 默认保留的配置版本数
+const defaultVersionHistory = 10
+
+// Snapshot 一份生效过的配置
+type Snapshot[T any] struct {
+	Version uint64    // 管理器分配的版本号 每次生效递增 从 1 开始
+	Time    time.Time // 生效时间
+	Config  *T        // 生效的配置
+}
+
+// versionHistory 最近生效的配置版本 按版本号从旧到新排列
+type versionHistory[T any] struct {
+	mu        sync.RWMutex
+	limit     int
+	current   uint64
+	snapshots []Snapshot[T]
+}
+
+// record 为新生效的配置分配版本号 超出上限时丢弃最旧的版本
+func (h *versionHistory[T]) record(config *T, at time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.current++
+	h.snapshots = append(h.snapshots, Snapshot[T]{Version: h.current, Time: at, Config: config})
+	if limit := max(h.limit, 1); len(h.snapshots) > limit {
+		h.snapshots = append(h.snapshots[:0:0], h.snapshots[len(h.snapshots)-limit:]...)
+	}
+}
+
+// lookup 查找指定版本
+func (h *versionHistory[T]) lookup(version uint64) (Snapshot[T], error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, s := range h.snapshots {
+		if s.Version == version {
+			return s, nil
+		}
+	}
+	return Snapshot[T]{}, fmt.Errorf("version %d: %w", version, ErrUnknownVersion)
+}
+
+// Version 返回当前配置的版本号 尚未加载配置时为 0
+func (cm *CfgManager[T]) Version() uint64 {
+	cm.versions.mu.RLock()
+	defer cm.versions.mu.RUnlock()
+	return cm.versions.current
+}
+
+// GetVersioned 获取当前的配置及其版本号 启用 WithCopyOnRead 时返回深拷贝
+func (cm *CfgManager[T]) GetVersioned() (*T, uint64) {
+	cm.rwMutex.RLock()
+	config, version := cm.config.Load().(*T), cm.Version()
+	cm.rwMutex.RUnlock()
+	if cm.opts.copyOnRead {
+		config = DeepCopy(config)
+	}
+	return config, version
+}
+
+// Versions 返回保留的配置版本 按版本号从旧到新排列 保留数量由 WithVersionHistory 设置
+func (cm *CfgManager[T]) Versions() []Snapshot[T] {
+	cm.versions.mu.RLock()
+	defer cm.versions.mu.RUnlock()
+	return append([]Snapshot[T](nil), cm.versions.snapshots...)
+}
+
+// Diff 比较两个版本的配置 返回从 from 到 to 的叶子变更
+func (cm *CfgManager[T]) Diff(from, to uint64) ([]Change, error) {
+	oldSnapshot, err := cm.versions.lookup(from)
+	if err != nil {
+		return nil, err
+	}
+	newSnapshot, err := cm.versions.lookup(to)
+	if err != nil {
+		return nil, err
+	}
+	return Diff(oldSnapshot.Config, newSnapshot.Config)
+}
+
+// RollbackTo 恢复指定版本的配置并通知订阅者 恢复的配置获得新的版本号
+func (cm *CfgManager[T]) RollbackTo(ctx context.Context, version uint64) error {
+	if err := cm.guardMutation("rollback"); err != nil {
+		return err
+	}
+	snapshot, err := cm.versions.lookup(version)
+	if err != nil {
+		return err
+	}
+
+	cm.applyMu.Lock()
+	defer cm.applyMu.Unlock()
+	if err := cm.storeConfig(snapshot.Config); err != nil {
+		return err
+	}
+	cm.logger.Info("Config rolled back", zap.Uint64("toVersion", version), zap.Uint64("version", cm.Version()), zap.String("configPath", cm.loader.GetConfigPath()))
+	cm.reportApply(ctx, nil)
+	return nil
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"context"
+	"testing"
+
+	"github.com/omeyang/practices/internal/entity"
+	mocks "github.com/omeyang/practices/mocks/conf"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+// TestCfgManager_Versions 测试版本号分配 版本比较与回滚到指定版本
+func TestCfgManager_Versions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return("/etc/app.yaml").AnyTimes()
+
+	cm := NewConfigManager[entity.AppConf](mockLoader, nil, zap.NewNop(), RetryPolicy{}, WithVersionHistory(3))
+	assert.Equal(t, uint64(0), cm.Version())
+	sub := cm.Subscribe()
+	defer sub.Close()
+
+	ctx := context.Background()
+	configs := make([]*entity.AppConf, 4)
+	for i := range configs {
+		configs[i] = &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Port: 9090 + i}}
+		assert.NoError(t, cm.Set(ctx, configs[i]))
+	}
+	config, version := cm.GetVersioned()
+	assert.Equal(t, configs[3], config)
+	assert.Equal(t, uint64(4), version)
+
+	// 只保留最近的 3 个版本
+	versions := cm.Versions()
+	assert.Len(t, versions, 3)
+	assert.Equal(t, uint64(2), versions[0].Version)
+	assert.Equal(t, configs[1], versions[0].Config)
+
+	changes, err := cm.Diff(2, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, []Change{{Path: "prometheusCfg.port", Old: 9091, New: 9093}}, changes)
+	_, err = cm.Diff(1, 4)
+	assert.ErrorIs(t, err, ErrUnknownVersion)
+
+	// 回滚生成新版本并通知订阅者
+	assert.NoError(t, cm.RollbackTo(ctx, 2))
+	config, version = cm.GetVersioned()
+	assert.Equal(t, configs[1], config)
+	assert.Equal(t, uint64(5), version)
+	assert.Equal(t, configs[1], receiveConfig(t, sub))
+	assert.ErrorIs(t, cm.RollbackTo(ctx, 1), ErrUnknownVersion)
+
+	readOnly := NewConfigManager[entity.AppConf](mockLoader, nil, zap.NewNop(), RetryPolicy{}, WithReadOnly())
+	assert.ErrorIs(t, readOnly.RollbackTo(ctx, 1), ErrReadOnly)
+}
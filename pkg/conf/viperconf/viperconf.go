@@ -0,0 +1,129 @@
+// Package viperconf 将已有的 viper 实例接入多源加载器 便于服务逐步从 viper 迁移
+package viperconf
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"strings"
+
+	config "github.com/omeyang/practices/pkg/conf"
+
+	"github.com/spf13/viper"
+)
+
+// source 以 viper 实例作为配置源
+type source[T any] struct {
+	name  string
+	viper *viper.Viper
+}
+
+// Source 返回读取 viper 实例全部生效取值的配置源 T 为目标配置结构体
+//
+// 取值包括 viper 中的默认值 配置文件 Set 覆盖与已绑定的环境变量 层级按实例的键分隔符展开
+// viper 会把键名转为小写 加载时按 T 的 yaml 标签还原键名 使取值可以解码到结构体并与其他源的键合并
+// 迁移期间可以把它作为优先级最低的源 之后逐步用 FileSource 与 EnvSource 取代
+func Source[T any](name string, v *viper.Viper) config.Source {
+	return &source[T]{name: name, viper: v}
+}
+
+// Name 返回配置源名称
+func (s *source[T]) Name() string {
+	return "viper:" + s.name
+}
+
+// Load 读取 viper 的全部取值并还原键名
+func (s *source[T]) Load(_ context.Context) (map[string]any, error) {
+	tree := s.viper.AllSettings()
+	restoreKeys(tree, reflect.TypeOf((*T)(nil)).Elem())
+	return tree, nil
+}
+
+// restoreKeys 按结构体的 yaml 标签还原小写的键名 无法对应字段的键保持不变
+func restoreKeys(tree map[string]any, t reflect.Type) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		fields := make(map[string]reflect.StructField, t.NumField())
+		names := make(map[string]string, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+			fields[strings.ToLower(name)] = field
+			names[strings.ToLower(name)] = name
+		}
+		keys := make([]string, 0, len(tree))
+		for key := range tree {
+			keys = append(keys, key)
+		}
+		for _, key := range keys {
+			lower := strings.ToLower(key)
+			field, ok := fields[lower]
+			if !ok {
+				continue
+			}
+			value := tree[key]
+			delete(tree, key)
+			tree[names[lower]] = restoreValue(value, field.Type)
+		}
+	case reflect.Map:
+		for key, value := range tree {
+			tree[key] = restoreValue(value, t.Elem())
+		}
+	}
+}
+
+// restoreValue 还原子树中的键名 环境变量提供的字符串按字段类型转换为布尔值与数值
+func restoreValue(value any, t reflect.Type) any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		restoreKeys(v, t)
+	case []any:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for i, item := range v {
+				v[i] = restoreValue(item, t.Elem())
+			}
+		}
+	case string:
+		return convertString(v, t)
+	}
+	return value
+}
+
+// convertString 按字段类型转换字符串 无法转换时保持原样 由解码报告类型错误
+func convertString(s string, t reflect.Type) any {
+	var (
+		converted any
+		err       error
+	)
+	switch t.Kind() {
+	case reflect.Bool:
+		converted, err = strconv.ParseBool(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		converted, err = strconv.ParseInt(s, 10, 64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		converted, err = strconv.ParseUint(s, 10, 64)
+	case reflect.Float32, reflect.Float64:
+		converted, err = strconv.ParseFloat(s, 64)
+	default:
+		return s
+	}
+	if err != nil {
+		return s
+	}
+	return converted
+}
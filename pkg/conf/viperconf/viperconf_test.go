@@ -0,0 +1,57 @@
+package viperconf
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/omeyang/practices/internal/entity"
+	config "github.com/omeyang/practices/pkg/conf"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// TestSource 测试 viper 的默认值 配置文件与环境变量一并作为配置源 并被后续的源覆盖
+func TestSource(t *testing.T) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	v.SetDefault("prometheusCfg.address", "0.0.0.0")
+	assert.NoError(t, v.ReadConfig(bytes.NewBufferString("prometheusCfg:\n  enable: true\n  port: 9090\n")))
+	t.Setenv("LEGACY_PROMETHEUSCFG_ENABLE", "false")
+	v.SetEnvPrefix("legacy")
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	assert.NoError(t, v.BindEnv("prometheusCfg.enable"))
+
+	fs := afero.NewMemMapFs()
+	assert.NoError(t, afero.WriteFile(fs, "/etc/app/override.yaml", []byte("prometheusCfg:\n  port: 9100\n"), 0o600))
+
+	loader := config.NewMultiSourceLoader[entity.AppConf](zap.NewNop(),
+		Source[entity.AppConf]("legacy", v),
+		config.FileSource(fs, "/etc/app/override.yaml"),
+	)
+	conf, err := loader.LoadConfig(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, &entity.PrometheusConf{Enable: false, Port: 9100, Address: "0.0.0.0"}, conf.PrometheusCfg)
+	assert.Equal(t, "viper:legacy", loader.Origin("prometheusCfg.address"))
+	assert.Equal(t, "/etc/app/override.yaml", loader.Origin("prometheusCfg.port"))
+}
+
+// TestRestoreKeys 测试按结构体标签还原键名
+func TestRestoreKeys(t *testing.T) {
+	tree := map[string]any{
+		"prometheuscfg": map[string]any{"port": "9090", "enable": "yes"},
+		"tenants":       map[string]any{"acme": map[string]any{"prometheuscfg": map[string]any{"port": 1}}},
+		"unknown":       map[string]any{"somekey": 1},
+	}
+	restoreKeys(tree, reflect.TypeOf(entity.AppConf{}))
+	assert.Equal(t, map[string]any{
+		"prometheusCfg": map[string]any{"port": int64(9090), "enable": "yes"},
+		"tenants":       map[string]any{"acme": map[string]any{"prometheuscfg": map[string]any{"port": 1}}},
+		"unknown":       map[string]any{"somekey": 1},
+	}, tree)
+}
@@ -0,0 +1,15 @@
+package config
+
+import (
+	"errors"
+	"syscall"
+)
+
+// watchLimitHint inotify 资源耗尽时的处理建议
+const watchLimitHint = "raise fs.inotify.max_user_watches / fs.inotify.max_user_instances " +
+	"(e.g. sysctl -w fs.inotify.max_user_watches=524288) or the open file limit (ulimit -n)"
+
+// isWatchLimitError 判断是否为监听数或文件描述符耗尽导致的错误
+func isWatchLimitError(err error) bool {
+	return errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.EMFILE) || errors.Is(err, syscall.ENFILE)
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// WatchedPath 正在监听的路径
+type WatchedPath struct {
+	Path    string `json:"path"`    // 路径
+	Polling bool   `json:"polling"` // 是否因 inotify 资源耗尽改用轮询
+}
+
+// WatcherStatus 文件监听的统计 用于回答文件修改后为什么没有重载
+type WatcherStatus struct {
+	Paths     []WatchedPath     `json:"paths"`               // 正在监听的路径
+	Events    map[string]uint64 `json:"events,omitempty"`    // 按操作统计收到的事件 如 write create
+	Ignored   uint64            `json:"ignored"`             // 不需要重载而被忽略的事件 如 chmod 证书文件与自身写入
+	Coalesced uint64            `json:"coalesced"`           // 与其他事件合并为一次重载的事件
+	Reloads   uint64            `json:"reloads"`             // 由文件事件触发的重载
+	LastEvent *time.Time        `json:"lastEvent,omitempty"` // 最近一次收到事件的时间
+}
+
+// watchStats 文件事件的统计
+type watchStats struct {
+	mu        sync.Mutex
+	events    map[string]uint64
+	ignored   uint64
+	coalesced uint64
+	reloads   uint64
+	lastEvent time.Time
+}
+
+// watchOps 统计的文件操作及其名称
+var watchOps = []struct {
+	op   fsnotify.Op
+	name string
+}{
+	{fsnotify.Create, "create"},
+	{fsnotify.Write, "write"},
+	{fsnotify.Remove, "remove"},
+	{fsnotify.Rename, "rename"},
+	{fsnotify.Chmod, "chmod"},
+}
+
+// eventOpNames 返回事件包含的操作名称
+func eventOpNames(op fsnotify.Op) []string {
+	var names []string
+	for _, o := range watchOps {
+		if op&o.op != 0 {
+			names = append(names, o.name)
+		}
+	}
+	return names
+}
+
+// received 记录收到的事件
+func (s *watchStats) received(op fsnotify.Op, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.events == nil {
+		s.events = map[string]uint64{}
+	}
+	for _, name := range eventOpNames(op) {
+		s.events[name]++
+	}
+	s.lastEvent = now
+}
+
+// ignore 记录一个被忽略的事件
+func (s *watchStats) ignore() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ignored++
+}
+
+// reload 记录由 events 个事件触发的一次重载
+func (s *watchStats) reload(events int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reloads++
+	if events > 1 {
+		s.coalesced += uint64(events - 1)
+	}
+}
+
+// WatcherStatus 返回文件监听的统计 未配置监听器时返回 nil
+func (cm *CfgManager[T]) WatcherStatus() *WatcherStatus {
+	if cm.watchers.primary == nil {
+		return nil
+	}
+	status := &WatcherStatus{Paths: cm.watchers.watched()}
+	cm.watchStats.mu.Lock()
+	defer cm.watchStats.mu.Unlock()
+	status.Events = maps.Clone(cm.watchStats.events)
+	status.Ignored, status.Coalesced, status.Reloads = cm.watchStats.ignored, cm.watchStats.coalesced, cm.watchStats.reloads
+	if !cm.watchStats.lastEvent.IsZero() {
+		at := cm.watchStats.lastEvent
+		status.LastEvent = &at
+	}
+	return status
+}
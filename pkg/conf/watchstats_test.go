@@ -0,0 +1,59 @@
+package config
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/omeyang/practices/internal/entity"
+	mocks "github.com/omeyang/practices/mocks/conf"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+// TestCfgManager_WatcherStatus 测试监听路径与文件事件的统计
+func TestCfgManager_WatcherStatus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	mockWatcher := mocks.NewMockWatcherInterface(ctrl)
+	mockLoader.EXPECT().GetConfigPath().Return("/etc/app.yaml").AnyTimes()
+	mockWatcher.EXPECT().Add("/etc/app.yaml").Return(nil)
+	mockWatcher.EXPECT().Remove("/etc/app.yaml").Return(nil)
+
+	reg := prometheus.NewRegistry()
+	clock := NewFakeClock(time.Unix(100, 0))
+	cm := NewConfigManager[entity.AppConf](mockLoader, mockWatcher, zap.NewNop(), RetryPolicy{}, WithMetrics(reg), WithClock(clock))
+	assert.NoError(t, cm.AddWatcher("/etc/app.yaml"))
+	assert.Equal(t, 1.0, testutil.ToFloat64(cm.metrics.watched))
+
+	ctx := context.Background()
+	mockLoader.EXPECT().LoadConfig(gomock.Any()).Return(&entity.AppConf{}, nil)
+	cm.processFSNotifyEvent(ctx, fsnotify.Event{Name: "/etc/app.yaml", Op: fsnotify.Write | fsnotify.Chmod}, nil)
+	cm.processFSNotifyEvent(ctx, fsnotify.Event{Name: "/etc/app.yaml", Op: fsnotify.Chmod}, nil)
+
+	at := time.Unix(100, 0)
+	assert.Equal(t, &WatcherStatus{
+		Paths:     []WatchedPath{{Path: "/etc/app.yaml"}},
+		Events:    map[string]uint64{"write": 1, "chmod": 2},
+		Ignored:   1,
+		Reloads:   1,
+		LastEvent: &at,
+	}, cm.Status().Watcher)
+	assert.Equal(t, 2.0, testutil.ToFloat64(cm.metrics.events.WithLabelValues("chmod")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(cm.metrics.ignored))
+
+	// 合并的事件只触发一次重载
+	cm.watchStats.reload(3)
+	assert.Equal(t, uint64(2), cm.WatcherStatus().Coalesced)
+
+	assert.NoError(t, cm.RemoveWatcher("/etc/app.yaml"))
+	assert.Empty(t, cm.WatcherStatus().Paths)
+	assert.Equal(t, 0.0, testutil.ToFloat64(cm.metrics.watched))
+}
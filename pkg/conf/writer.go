@@ -0,0 +1,326 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/afero"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrNotWritable 加载器不支持写回配置
+var ErrNotWritable = errors.New("config loader does not support writing")
+
+// CfgWriter 将配置写回配置源 与 CfgLoader 相反 FileLoader 实现了该接口
+type CfgWriter[T any] interface {
+	WriteConfig(ctx context.Context, config *T) error
+}
+
+// fingerprinter 可以计算配置源内容指纹的加载器 用于识别管理器自身写入引起的文件事件
+type fingerprinter interface {
+	Fingerprint(name string) (string, error)
+}
+
+// selfWrites 管理器自身写入配置文件后的内容指纹 文件路径 -> 指纹
+type selfWrites struct {
+	mu           sync.Mutex
+	fingerprints map[string]string
+}
+
+// WriteConfig 实现 CfgWriter 按扩展名编码后原子地写回配置文件 沿用原文件的权限
+// YAML 文件合并到原有的文档中 保留注释 键的顺序与引号风格
+// 取值未变化的 enc: 密文 密钥引用与 ${VAR} 占位符保留原文 不会以明文写回
+func (l *FileLoader[T]) WriteConfig(ctx context.Context, config *T) error {
+	return writeConfigFile(l.fs, l.path, config, func() (any, error) { return l.LoadConfig(ctx) })
+}
+
+// Fingerprint 以文件内容的摘要作为指纹
+func (l *FileLoader[T]) Fingerprint(string) (string, error) {
+	data, err := afero.ReadFile(l.fs, l.path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// writeConfigFile 按扩展名编码配置并原子地写入文件 文件已存在时沿用其权限
+// resolve 返回按原文件加载的配置 用于识别经过变换的占位符 原文件不存在或为空时不调用
+func writeConfigFile(fs afero.Fs, path string, config any, resolve func() (any, error)) error {
+	ext := ConfigExt(path)
+	c, err := codecFor(ext)
+	if err != nil {
+		return err
+	}
+	// 先转为配置树 使 json 输出沿用 yaml 标签中的键名
+	tree, err := configTree(config)
+	if err != nil {
+		return err
+	}
+
+	perm := os.FileMode(0o644)
+	var existing []byte
+	if info, err := fs.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+		if existing, err = afero.ReadFile(fs, path); err != nil {
+			return fmt.Errorf("read config %s: %w", path, err)
+		}
+	}
+	if len(bytes.TrimSpace(existing)) > 0 && resolve != nil {
+		if err := keepPlaceholders(c, existing, tree, resolve); err != nil {
+			return fmt.Errorf("resolve placeholders in %s: %w", path, err)
+		}
+	}
+
+	var data []byte
+	switch strings.ToLower(ext) {
+	case ".yaml", ".yml":
+		data, err = marshalYAMLPreserving(existing, tree)
+	default:
+		data, err = c.marshal(tree)
+	}
+	if err != nil {
+		return err
+	}
+	return WriteAtomic(fs, path, data, perm)
+}
+
+// keepPlaceholders 将取值未变化的占位符恢复为原文件中的原文 原文件无法解析时没有可保留的占位符
+func keepPlaceholders(c codec, existing []byte, tree map[string]any, resolve func() (any, error)) error {
+	raw := map[string]any{}
+	if err := c.decode(bytes.NewReader(existing), &raw); err != nil {
+		return nil
+	}
+	previous, err := resolve()
+	if err != nil {
+		return err
+	}
+	resolved, err := configTree(previous)
+	if err != nil {
+		return err
+	}
+	restorePlaceholders(tree, raw, resolved)
+	return nil
+}
+
+// restorePlaceholders 原文与加载结果不同说明取值经过了变换 如 enc: 密文 密钥引用 ${VAR} 与 ${meta.xxx}
+// 这类叶子的新取值与加载结果相同时恢复为原文 修改过的取值照常写入
+func restorePlaceholders(tree, raw, resolved map[string]any) {
+	for key, value := range tree {
+		tree[key] = restoreValue(value, raw[key], resolved[key])
+	}
+}
+
+// restoreValue 递归处理单个取值 返回应写入的取值
+func restoreValue(value, raw, resolved any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		rawMap, _ := raw.(map[string]any)
+		resolvedMap, _ := resolved.(map[string]any)
+		if rawMap != nil && resolvedMap != nil {
+			restorePlaceholders(v, rawMap, resolvedMap)
+		}
+		return v
+	case []any:
+		rawList, _ := raw.([]any)
+		resolvedList, _ := resolved.([]any)
+		for i := range v {
+			if i < len(rawList) && i < len(resolvedList) {
+				v[i] = restoreValue(v[i], rawList[i], resolvedList[i])
+			}
+		}
+		return v
+	}
+	if raw != nil && !reflect.DeepEqual(raw, resolved) && reflect.DeepEqual(value, resolved) {
+		return raw
+	}
+	return value
+}
+
+// marshalYAMLPreserving 将配置树合并到原有 YAML 文档的节点中再编码 原文件为空或无法解析时直接编码
+func marshalYAMLPreserving(existing []byte, tree map[string]any) ([]byte, error) {
+	var doc yaml.Node
+	if len(bytes.TrimSpace(existing)) == 0 || yaml.Unmarshal(existing, &doc) != nil || len(doc.Content) == 0 {
+		return yaml.Marshal(tree)
+	}
+	var src yaml.Node
+	if err := src.Encode(tree); err != nil {
+		return nil, err
+	}
+	mergeYAMLNode(doc.Content[0], &src)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(yamlIndent(existing))
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// mergeYAMLNode 用 src 的取值更新 dst 保留 dst 中的注释 已有键的顺序与未变化标量的风格
+// 映射中 src 没有的键被删除 新增的键追加在末尾
+func mergeYAMLNode(dst, src *yaml.Node) {
+	switch {
+	case dst.Kind == yaml.MappingNode && src.Kind == yaml.MappingNode:
+		content := make([]*yaml.Node, 0, len(src.Content))
+		for i := 0; i+1 < len(dst.Content); i += 2 {
+			if value := mappingValue(src, dst.Content[i].Value); value != nil {
+				mergeYAMLNode(dst.Content[i+1], value)
+				content = append(content, dst.Content[i], dst.Content[i+1])
+			}
+		}
+		for i := 0; i+1 < len(src.Content); i += 2 {
+			if mappingValue(dst, src.Content[i].Value) == nil {
+				content = append(content, src.Content[i], src.Content[i+1])
+			}
+		}
+		dst.Content = content
+	case dst.Kind == yaml.SequenceNode && src.Kind == yaml.SequenceNode:
+		for i, item := range src.Content {
+			if i < len(dst.Content) {
+				mergeYAMLNode(dst.Content[i], item)
+			} else {
+				dst.Content = append(dst.Content, item)
+			}
+		}
+		dst.Content = dst.Content[:len(src.Content)]
+	case dst.Kind == yaml.ScalarNode && src.Kind == yaml.ScalarNode:
+		if dst.Tag != src.Tag {
+			dst.Style = src.Style
+		}
+		dst.Tag, dst.Value = src.Tag, src.Value
+	default:
+		head, line, foot := dst.HeadComment, dst.LineComment, dst.FootComment
+		*dst = *src
+		dst.HeadComment, dst.LineComment, dst.FootComment = head, line, foot
+	}
+}
+
+// mappingValue 返回映射节点中键对应的取值节点 不存在时返回 nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// yamlIndent 返回 YAML 内容使用的缩进宽度 无法判断时使用 yaml.v3 默认的 4
+func yamlIndent(data []byte) int {
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if indent := len(line) - len(trimmed); indent > 0 && trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			return indent
+		}
+	}
+	return 4
+}
+
+// WriteAtomic 原子地写入文件: 先写入同目录下的临时文件并刷盘 再重命名覆盖目标文件
+// 写入过程中崩溃不会留下被截断的配置文件
+func WriteAtomic(fs afero.Fs, path string, data []byte, perm os.FileMode) (err error) {
+	dir, name := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+
+	tmp, err := afero.TempFile(fs, dir, "."+name+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create temp file for %s: %w", path, err)
+	}
+	tmpName := tmp.Name()
+	defer func() {
+		if err != nil {
+			_ = fs.Remove(tmpName)
+		}
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write temp file for %s: %w", path, err)
+	}
+	if err = tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("sync temp file for %s: %w", path, err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("close temp file for %s: %w", path, err)
+	}
+	if err = fs.Chmod(tmpName, perm); err != nil {
+		return fmt.Errorf("chmod temp file for %s: %w", path, err)
+	}
+	if err = fs.Rename(tmpName, path); err != nil {
+		return fmt.Errorf("rename temp file to %s: %w", path, err)
+	}
+
+	syncDir(fs, dir)
+	return nil
+}
+
+// syncDir 刷新目录项 确保重命名在断电后依然生效 部分平台不支持时忽略
+func syncDir(fs afero.Fs, dir string) {
+	d, err := fs.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	_ = d.Close()
+}
+
+// writeConfigSource 执行对配置源的写入 并记录写入后的内容指纹
+// 写入期间持有 selfWrites 的锁 写入引起的文件事件在指纹记录之后才会被检查
+func (cm *CfgManager[T]) writeConfigSource(write func() error) error {
+	path := NormalizePath(cm.loader.GetConfigPath())
+	cm.writes.mu.Lock()
+	defer cm.writes.mu.Unlock()
+	if err := write(); err != nil {
+		return err
+	}
+	if fp, ok := cm.loader.(fingerprinter); ok {
+		if sum, err := fp.Fingerprint(path); err == nil {
+			if cm.writes.fingerprints == nil {
+				cm.writes.fingerprints = map[string]string{}
+			}
+			cm.writes.fingerprints[path] = sum
+		}
+	}
+	// 重命名替换了文件 重新监听新的文件 Kubernetes 模式监听的是目录 无需处理
+	if cm.life.watching() && !cm.opts.kubernetesWatch {
+		cm.watchers.rewatch(path)
+	}
+	return nil
+}
+
+// ownWrite 判断文件事件是否由管理器自身的写入引起 文件内容与写入后的指纹一致时忽略
+// 内容已被其他进程修改时清除记录的指纹 正常重载
+func (cm *CfgManager[T]) ownWrite(event fsnotify.Event) bool {
+	path := NormalizePath(event.Name)
+	cm.writes.mu.Lock()
+	defer cm.writes.mu.Unlock()
+	expected, ok := cm.writes.fingerprints[path]
+	if !ok {
+		return false
+	}
+	if current, err := cm.loader.(fingerprinter).Fingerprint(path); err == nil && current == expected {
+		cm.logger.Debug("Ignoring event caused by own config write", zap.String("path", path), zap.Stringer("op", event.Op))
+		return true
+	}
+	delete(cm.writes.fingerprints, path)
+	return false
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/omeyang/practices/internal/entity"
+	mocks "github.com/omeyang/practices/mocks/conf"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+// TestWriteAtomic 测试原子写入
+func TestWriteAtomic(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NoError(t, fs.MkdirAll("/etc/app", 0o755))
+	assert.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte("old"), 0o644))
+
+	assert.NoError(t, WriteAtomic(fs, "/etc/app/config.yaml", []byte("new"), 0o600))
+
+	data, err := afero.ReadFile(fs, "/etc/app/config.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "new", string(data))
+
+	info, err := fs.Stat("/etc/app/config.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	// 不应残留临时文件
+	entries, err := afero.ReadDir(fs, "/etc/app")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+// TestWriteAtomicOsFs 测试在真实文件系统上的原子写入
+func TestWriteAtomicOsFs(t *testing.T) {
+	dir := t.TempDir()
+	path := dir + "/config.json"
+
+	assert.NoError(t, WriteAtomic(afero.NewOsFs(), path, []byte(`{}`), 0o644))
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, `{}`, string(data))
+
+	assert.Error(t, WriteAtomic(afero.NewOsFs(), dir+"/missing/config.json", []byte(`{}`), 0o644))
+}
+
+// TestCfgManager_SaveConfig 测试写回 YAML 时保留注释与键的顺序 并忽略写入引起的文件事件
+func TestCfgManager_SaveConfig(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	original := "# 应用配置\nprometheusCfg:\n  enable: true # 是否启用\n  port: 9090\n  address: \"0.0.0.0\"\n"
+	assert.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte(original), 0o600))
+	loader, err := NewFileLoader[entity.AppConf](fs, "/etc/app/config.yaml", zap.NewNop())
+	assert.NoError(t, err)
+
+	cm := NewConfigManager[entity.AppConf](loader, nil, zap.NewNop(), RetryPolicy{})
+	ctx := context.Background()
+	config := &entity.AppConf{PrometheusCfg: &entity.PrometheusConf{Enable: true, Port: 9091, Address: "0.0.0.0"}}
+	assert.NoError(t, cm.SaveConfig(ctx, config))
+	assert.Same(t, config, cm.GetConfig())
+
+	data, err := afero.ReadFile(fs, "/etc/app/config.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "# 应用配置\nprometheusCfg:\n  enable: true # 是否启用\n  port: 9091\n  address: \"0.0.0.0\"\neffectiveAt: null\n", string(data))
+	info, err := fs.Stat("/etc/app/config.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	path := loader.GetConfigPath()
+	assert.True(t, cm.ownWrite(fsnotify.Event{Name: path, Op: fsnotify.Write}))
+	assert.True(t, cm.ownWrite(fsnotify.Event{Name: path, Op: fsnotify.Create}))
+	assert.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte("prometheusCfg:\n  port: 9092\n"), 0o600))
+	assert.False(t, cm.ownWrite(fsnotify.Event{Name: path, Op: fsnotify.Write}))
+
+	readOnly := NewConfigManager[entity.AppConf](loader, nil, zap.NewNop(), RetryPolicy{}, WithReadOnly())
+	assert.ErrorIs(t, readOnly.SaveConfig(ctx, config), ErrReadOnly)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockLoader := mocks.NewMockCfgLoader[entity.AppConf](ctrl)
+	unwritable := NewConfigManager[entity.AppConf](mockLoader, nil, zap.NewNop(), RetryPolicy{})
+	assert.ErrorIs(t, unwritable.SaveConfig(ctx, config), ErrNotWritable)
+}
+
+// TestCfgManager_SaveConfigKeepsEncrypted 测试写回时取值未变化的 enc: 密文保留原文 不会以明文写入
+func TestCfgManager_SaveConfigKeepsEncrypted(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 32)
+	encrypted, err := EncryptValue(key, "10.0.0.1")
+	assert.NoError(t, err)
+	fs := afero.NewMemMapFs()
+	assert.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte("prometheusCfg:\n  port: 9090\n  address: "+encrypted+"\n"), 0o600))
+
+	ctx := context.Background()
+	loader, err := NewFileLoader[entity.AppConf](fs, "/etc/app/config.yaml", zap.NewNop(), WithParserOptions(WithTransforms(DecryptValues(StaticKeySource(key)))))
+	assert.NoError(t, err)
+	cm := NewConfigManager[entity.AppConf](loader, nil, zap.NewNop(), RetryPolicy{})
+	loaded, err := loader.LoadConfig(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.1", loaded.PrometheusCfg.Address)
+
+	loaded.PrometheusCfg.Port = 9091
+	assert.NoError(t, cm.SaveConfig(ctx, loaded))
+	data, err := afero.ReadFile(fs, "/etc/app/config.yaml")
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "address: "+encrypted)
+	assert.Contains(t, string(data), "port: 9091")
+	assert.NotContains(t, string(data), "10.0.0.1")
+
+	// 写回后的文件仍能解密加载
+	reloaded, err := loader.LoadConfig(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, &entity.PrometheusConf{Port: 9091, Address: "10.0.0.1"}, reloaded.PrometheusCfg)
+
+	// Save 同样保留密文
+	assert.NoError(t, cm.Save(fs))
+	data, err = afero.ReadFile(fs, "/etc/app/config.yaml")
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "10.0.0.1")
+}
+
+// TestMergeYAMLNode 测试合并时删除多余的键 更新列表并保留未变化标量的风格
+func TestMergeYAMLNode(t *testing.T) {
+	existing := []byte("name: 'app'\nhosts:\n    - a\n    - b\n    - c\nremoved: 1\n")
+	data, err := marshalYAMLPreserving(existing, map[string]any{"name": "app", "hosts": []any{"a", "x"}, "tags": map[string]any{"env": "prod"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "name: 'app'\nhosts:\n    - a\n    - x\ntags:\n    env: prod\n", string(data))
+
+	data, err = marshalYAMLPreserving(nil, map[string]any{"name": "app"})
+	assert.NoError(t, err)
+	assert.Equal(t, "name: app\n", string(data))
+}